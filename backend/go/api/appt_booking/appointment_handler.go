@@ -1,8 +1,10 @@
 package appt_booking
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -26,28 +28,42 @@ func NewAppointmentHandler(service *appt_booking_service.ApptBookingService) *Ap
 // AppointmentResponse represents the response for an appointment (without price)
 type AppointmentResponse struct {
 	ID                 int    `json:"id"`
+	CustomerID         int    `json:"customer_id"`
 	CustomerName       string `json:"customer_name"`
 	CustomerEmail      string `json:"customer_email"`
 	CustomerPhone      string `json:"customer_phone"`
 	StaffID            int    `json:"staff_id"`
 	ServiceID          int    `json:"service_id"`
-	AppointmentDatetime string `json:"appointment_datetime"`
+	AppointmentDatetime string `json:"appointment_datetime"` // RFC 3339 in the staff member's timezone
+	Timezone           string `json:"timezone"`
 	DurationMinutes    int    `json:"duration_minutes"`
 	Status             string `json:"status"`
 	Notes              string    `json:"notes"`
 	CreatedAt          string    `json:"created_at"`
 	UpdatedAt          string    `json:"updated_at"`
+	RescheduleHistory  []RescheduleResponse `json:"reschedule_history,omitempty"` // most recent first
+}
+
+// RescheduleResponse represents one entry in an appointment's reschedule history
+type RescheduleResponse struct {
+	PreviousStaffID             int    `json:"previous_staff_id"`
+	PreviousAppointmentDatetime string `json:"previous_appointment_datetime"`
+	NewStaffID                  int    `json:"new_staff_id"`
+	NewAppointmentDatetime      string `json:"new_appointment_datetime"`
+	RescheduledAt               string `json:"rescheduled_at"`
 }
 
 // AppointmentWithDetailsResponse represents an appointment with service price
 type AppointmentWithDetailsResponse struct {
 	ID                 int    `json:"id"`
+	CustomerID         int    `json:"customer_id"`
 	CustomerName       string `json:"customer_name"`
 	CustomerEmail      string `json:"customer_email"`
 	CustomerPhone      string `json:"customer_phone"`
 	StaffID            int    `json:"staff_id"`
 	ServiceID          int    `json:"service_id"`
-	AppointmentDatetime string `json:"appointment_datetime"`
+	AppointmentDatetime string `json:"appointment_datetime"` // RFC 3339 in the staff member's timezone
+	Timezone           string `json:"timezone"`
 	DurationMinutes    int    `json:"duration_minutes"`
 	Status             string `json:"status"`
 	Notes              string    `json:"notes"`
@@ -57,40 +73,96 @@ type AppointmentWithDetailsResponse struct {
 }
 
 // GetAll handles GET /api/appt_booking/appointments
+// Filters: staff_id, service_id, customer_id, email, status (comma-separated) and from/to
+// (YYYY-MM-DD or ISO 8601); all given filters must match. Paged with limit/offset, ordered
+// by sort (default -appointment_datetime); the total is returned in X-Total-Count.
 func (ah *AppointmentHandler) GetAll(c echo.Context) error {
-	// Optional query params for filtering
-	staffIDStr := c.QueryParam("staff_id")
-	email := c.QueryParam("email")
-
-	var appointments []appt_booking_db.AppointmentWithService
+	ctx := c.Request().Context()
+	var filter appt_booking_db.AppointmentFilter
 	var err error
 
-	if staffIDStr != "" {
-		staffID, _ := strconv.Atoi(staffIDStr)
-		appointments, err = ah.service.GetAppointmentsByStaffWithDetails(staffID)
-	} else if email != "" {
-		appointments, err = ah.service.GetAppointmentsByCustomerWithDetails(email)
-	} else {
-		// Default: get all appointments with service details for admin dashboard
-		appointments, err = ah.service.GetAppointmentsWithDetails()
+	for param, dst := range map[string]*int{
+		"staff_id":    &filter.StaffID,
+		"service_id":  &filter.ServiceID,
+		"customer_id": &filter.CustomerID,
+	} {
+		if value := c.QueryParam(param); value != "" {
+			*dst, err = strconv.Atoi(value)
+			if err != nil || *dst <= 0 {
+				return appt_booking_service.Invalid(param, "Invalid "+strings.ReplaceAll(param, "_", " "))
+			}
+		}
+	}
+	filter.CustomerEmail = c.QueryParam("email")
+	if statusStr := c.QueryParam("status"); statusStr != "" {
+		for _, status := range strings.Split(statusStr, ",") {
+			filter.Statuses = append(filter.Statuses, strings.TrimSpace(status))
+		}
+	}
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err := parseRangeBound(fromStr, false)
+		if err != nil {
+			return appt_booking_service.Invalid("from", "Invalid from date. Use YYYY-MM-DD or ISO 8601")
+		}
+		filter.From = &from
+	}
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err := parseRangeBound(toStr, true)
+		if err != nil {
+			return appt_booking_service.Invalid("to", "Invalid to date. Use YYYY-MM-DD or ISO 8601")
+		}
+		filter.To = &to
 	}
 
+	// Providers and customers only ever see their own appointments
+	principal := appt_booking_service.PrincipalFrom(ctx)
+	switch {
+	case principal.HasRole(appt_booking_db.RoleProvider):
+		if filter.StaffID != 0 && !principal.IsStaff(filter.StaffID) {
+			return appt_booking_service.Forbidden("providers can only list their own appointments")
+		}
+		filter.StaffID = *principal.StaffID
+	case principal.HasRole(appt_booking_db.RoleCustomer):
+		if filter.StaffID != 0 || filter.CustomerID != 0 ||
+			(filter.CustomerEmail != "" && !strings.EqualFold(filter.CustomerEmail, principal.Email)) {
+			return appt_booking_service.Forbidden("customers can only list their own appointments")
+		}
+		if !principal.EmailVerified {
+			return appt_booking_service.Forbidden("verify your email address to see your appointments")
+		}
+		filter.CustomerEmail = principal.Email
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+	appointments, total, err := ah.service.ListAppointments(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+
+	locations, err := ah.service.StaffLocations(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch appointments",
-		})
+		return err
 	}
 
 	response := make([]AppointmentWithDetailsResponse, len(appointments))
 	for i, a := range appointments {
+		loc, ok := locations[a.StaffID]
+		if !ok {
+			loc = ah.service.DefaultLocation()
+		}
 		response[i] = AppointmentWithDetailsResponse{
 			ID:                   a.ID,
+			CustomerID:           a.CustomerID,
 			CustomerName:         a.CustomerName,
 			CustomerEmail:        a.CustomerEmail,
 			CustomerPhone:        a.CustomerPhone,
 			StaffID:              a.StaffID,
 			ServiceID:            a.ServiceID,
-			AppointmentDatetime:  a.AppointmentDatetime.Format("2006-01-02T15:04:05Z07:00"),
+			AppointmentDatetime:  a.AppointmentDatetime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+			Timezone:             loc.String(),
 			DurationMinutes:      a.DurationMinutes,
 			Status:               a.Status,
 			Notes:                a.Notes,
@@ -100,44 +172,61 @@ func (ah *AppointmentHandler) GetAll(c echo.Context) error {
 		}
 	}
 
+	setTotalCount(c, total)
 	return c.JSON(http.StatusOK, response)
 }
 
 // GetByID handles GET /api/appt_booking/appointments/:id
 func (ah *AppointmentHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid appointment ID",
-		})
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
 	}
 
-	appointment, err := ah.service.GetAppointment(id)
+	appointment, err := ah.accessibleAppointment(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Appointment not found",
-		})
+		return err
 	}
 
-	response := AppointmentResponse{
-		ID:                   appointment.ID,
-		CustomerName:         appointment.CustomerName,
-		CustomerEmail:        appointment.CustomerEmail,
-		CustomerPhone:        appointment.CustomerPhone,
-		StaffID:              appointment.StaffID,
-		ServiceID:            appointment.ServiceID,
-		AppointmentDatetime:  appointment.AppointmentDatetime.Format("2006-01-02T15:04:05Z07:00"),
-		DurationMinutes:      appointment.DurationMinutes,
-		Status:               appointment.Status,
-		Notes:                appointment.Notes,
-		CreatedAt:            appointment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:            appointment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	loc, err := ah.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
 	}
 
+	reschedules, err := ah.service.GetAppointmentReschedules(ctx, appointment.ID)
+	if err != nil {
+		return err
+	}
+
+	response := newAppointmentResponse(appointment, loc)
+	response.RescheduleHistory = newRescheduleResponses(reschedules, loc)
+
 	return c.JSON(http.StatusOK, response)
 }
 
+// Calendar handles GET /api/appt_booking/appointments/:id/calendar.ics, the appointment
+// as an iCalendar file for the customer's calendar
+func (ah *AppointmentHandler) Calendar(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	appointment, err := ah.accessibleAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+	calendar, err := ah.service.AppointmentCalendar(ctx, appointment)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="appointment-`+strconv.Itoa(appointment.ID)+`.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8; method="+calendar.Method, calendar.Encode())
+}
+
 // BookRequest represents the request for booking an appointment
 type BookRequest struct {
 	CustomerName       string    `json:"customer_name"`
@@ -147,133 +236,444 @@ type BookRequest struct {
 	ServiceID          int       `json:"service_id"`
 	AppointmentDatetime string   `json:"appointment_datetime"` // Expected format: "2006-01-02T15:04:05"
 	Notes              string    `json:"notes"`
+	HoldToken          string    `json:"hold_token"` // Optional: books the slot held with POST /holds
 }
 
 // Book handles POST /api/appt_booking/appointments
+// With a hold_token the held slot is booked and the hold released.
 func (ah *AppointmentHandler) Book(c echo.Context) error {
+	ctx := c.Request().Context()
 	var req BookRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+
+	apptTime, err := validateBookRequest(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	var appointment *appt_booking_db.Appointment
+	if req.HoldToken != "" {
+		appointment, err = ah.service.BookHeldAppointment(ctx,
+			req.HoldToken,
+			req.CustomerName,
+			req.CustomerEmail,
+			req.CustomerPhone,
+			req.StaffID,
+			req.ServiceID,
+			apptTime,
+			req.Notes,
+		)
+	} else {
+		appointment, err = ah.service.BookAppointment(ctx,
+			req.CustomerName,
+			req.CustomerEmail,
+			req.CustomerPhone,
+			req.StaffID,
+			req.ServiceID,
+			apptTime,
+			req.Notes,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	loc, err := ah.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
 	}
 
+	response := newAppointmentResponse(appointment, loc)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// validateBookRequest checks the required fields of a booking and returns its start time.
+// Customers book for themselves: their email is filled in and may not be someone else's.
+func validateBookRequest(ctx context.Context, req *BookRequest) (time.Time, error) {
 	// Validate required fields
 	if req.CustomerName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Customer name is required",
-		})
+		return time.Time{}, appt_booking_service.Invalid("customer_name", "Customer name is required")
+	}
+	if principal := appt_booking_service.PrincipalFrom(ctx); principal.HasRole(appt_booking_db.RoleCustomer) {
+		if req.CustomerEmail == "" {
+			req.CustomerEmail = principal.Email
+		} else if !strings.EqualFold(req.CustomerEmail, principal.Email) {
+			return time.Time{}, appt_booking_service.Forbidden("customers can only book appointments for themselves")
+		}
 	}
 	if req.CustomerEmail == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Customer email is required",
-		})
+		return time.Time{}, appt_booking_service.Invalid("customer_email", "Customer email is required")
 	}
 	if req.StaffID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid staff ID is required",
-		})
+		return time.Time{}, appt_booking_service.Invalid("staff_id", "Valid staff ID is required")
 	}
 	if req.ServiceID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid service ID is required",
-		})
+		return time.Time{}, appt_booking_service.Invalid("service_id", "Valid service ID is required")
 	}
 
-	// Parse appointment datetime (expected format: "2006-01-02T15:04:05Z" or "2006-01-02T15:04:05")
-	// Use time.ParseInLocation with UTC to handle timezone-aware timestamps
-	apptTime, err := time.Parse(time.RFC3339, req.AppointmentDatetime)
+	apptTime, err := parseAppointmentDatetime(req.AppointmentDatetime)
 	if err != nil {
-		// Try without timezone (assume UTC)
-		apptTime, err = time.Parse("2006-01-02T15:04:05", req.AppointmentDatetime)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid appointment datetime format. Use YYYY-MM-DDTHH:MM:SS or ISO 8601",
-			})
-		}
-		// Treat naive time as UTC
-		apptTime = time.Date(apptTime.Year(), apptTime.Month(), apptTime.Day(), 
-			apptTime.Hour(), apptTime.Minute(), apptTime.Second(), 0, time.UTC)
-	}
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid appointment datetime format. Use YYYY-MM-DDTHH:MM:SS",
-		})
-	}
-
-	appointment, err := ah.service.BookAppointment(
-		req.CustomerName,
-		req.CustomerEmail,
-		req.CustomerPhone,
-		req.StaffID,
-		req.ServiceID,
-		apptTime,
-		req.Notes,
-	)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
-	}
-
-	response := AppointmentResponse{
-		ID:                   appointment.ID,
-		CustomerName:         appointment.CustomerName,
-		CustomerEmail:        appointment.CustomerEmail,
-		CustomerPhone:        appointment.CustomerPhone,
-		StaffID:              appointment.StaffID,
-		ServiceID:            appointment.ServiceID,
-		AppointmentDatetime:  appointment.AppointmentDatetime.Format("2006-01-02T15:04:05Z07:00"),
-		DurationMinutes:      appointment.DurationMinutes,
-		Status:               appointment.Status,
-		Notes:                appointment.Notes,
-		CreatedAt:            appointment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:            appointment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		return time.Time{}, appt_booking_service.Invalid("appointment_datetime", "Invalid appointment datetime format. Use YYYY-MM-DDTHH:MM:SS or ISO 8601")
 	}
+	return apptTime, nil
+}
 
-	return c.JSON(http.StatusCreated, response)
+// CancelRequest represents the optional body of a cancellation
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancellationResponse describes who cancelled an appointment and whether it was late
+type CancellationResponse struct {
+	CancelledBy string `json:"cancelled_by"`
+	Reason      string `json:"reason"`
+	Late        bool   `json:"late"`
+	CancelledAt string `json:"cancelled_at"`
 }
 
 // Cancel handles PUT /api/appt_booking/appointments/:id/cancel
+// With ?scope=following an appointment of a series is cancelled with every later occurrence.
 func (ah *AppointmentHandler) Cancel(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid appointment ID",
-		})
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	scope, err := parseSeriesScope(c.QueryParam("scope"))
+	if err != nil {
+		return err
+	}
+
+	var req CancelRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+
+	appointment, err := ah.accessibleAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if scope == appt_booking_service.SeriesScopeFollowing {
+		results, err := ah.service.CancelFollowingOccurrences(ctx, id, req.Reason)
+		if err != nil {
+			return err
+		}
+		return ah.seriesChange(c, appointment, results)
 	}
 
-	err = ah.service.CancelAppointment(id)
+	cancellation, err := ah.service.CancelAppointment(ctx, id, req.Reason)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":      "Appointment cancelled successfully",
+		"cancellation": newCancellationResponse(cancellation),
+	})
+}
+
+// NoShow handles PUT /api/appt_booking/appointments/:id/no-show
+func (ah *AppointmentHandler) NoShow(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	var req CancelRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+
+	if _, err := ah.accessibleAppointment(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := ah.service.MarkNoShow(ctx, id, req.Reason); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Appointment cancelled successfully",
+		"message": "Appointment marked as no-show",
 	})
 }
 
 // Complete handles PUT /api/appt_booking/appointments/:id/complete
 func (ah *AppointmentHandler) Complete(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid appointment ID",
-		})
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
 	}
 
-	err = ah.service.CompleteAppointment(id)
+	if _, err := ah.accessibleAppointment(ctx, id); err != nil {
+		return err
+	}
+
+	err = ah.service.CompleteAppointment(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Appointment marked as completed",
 	})
 }
+
+// RescheduleRequest represents the request for rescheduling an appointment
+type RescheduleRequest struct {
+	StaffID             int    `json:"staff_id"`             // Optional: omit or 0 to keep the current staff member
+	AppointmentDatetime string `json:"appointment_datetime"` // Expected format: "2006-01-02T15:04:05" or ISO 8601
+	Scope               string `json:"scope"`                // Optional: "this" (default) or "following" for appointments in a series
+}
+
+// Reschedule handles PUT /api/appt_booking/appointments/:id/reschedule
+// With scope "following" an appointment of a series is moved with every later occurrence.
+func (ah *AppointmentHandler) Reschedule(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	var req RescheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+	if req.StaffID < 0 {
+		return appt_booking_service.Invalid("staff_id", "Invalid staff ID")
+	}
+
+	apptTime, err := parseAppointmentDatetime(req.AppointmentDatetime)
+	if err != nil {
+		return appt_booking_service.Invalid("appointment_datetime", "Invalid appointment datetime format. Use YYYY-MM-DDTHH:MM:SS or ISO 8601")
+	}
+
+	scope, err := parseSeriesScope(req.Scope)
+	if err != nil {
+		return err
+	}
+
+	current, err := ah.accessibleAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if scope == appt_booking_service.SeriesScopeFollowing {
+		results, err := ah.service.RescheduleFollowingOccurrences(ctx, id, req.StaffID, apptTime)
+		if err != nil {
+			return err
+		}
+		return ah.seriesChange(c, current, results)
+	}
+
+	appointment, err := ah.service.RescheduleAppointment(ctx, id, req.StaffID, apptTime)
+	if err != nil {
+		return err
+	}
+
+	loc, err := ah.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
+	}
+
+	reschedules, err := ah.service.GetAppointmentReschedules(ctx, appointment.ID)
+	if err != nil {
+		return err
+	}
+
+	response := newAppointmentResponse(appointment, loc)
+	response.RescheduleHistory = newRescheduleResponses(reschedules, loc)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// TransitionRequest represents the request for changing an appointment's status. The
+// change is recorded as made by the authenticated caller.
+type TransitionRequest struct {
+	Status string `json:"status"` // Target status, e.g. "checked_in" or "no_show"
+	Reason string `json:"reason"` // Optional: why the change was made
+}
+
+// StatusChangeResponse represents one entry of an appointment's status history
+type StatusChangeResponse struct {
+	ID         int    `json:"id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ChangedBy  string `json:"changed_by"`
+	Reason     string `json:"reason"`
+	ChangedAt  string `json:"changed_at"`
+}
+
+// Transition handles POST /api/appt_booking/appointments/:id/transitions
+func (ah *AppointmentHandler) Transition(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	var req TransitionRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+	if req.Status == "" {
+		return appt_booking_service.Invalid("status", "Status is required")
+	}
+	if !appt_booking_service.IsValidAppointmentStatus(req.Status) {
+		return appt_booking_service.Invalid("status", "Invalid status: "+req.Status)
+	}
+
+	if _, err := ah.accessibleAppointment(ctx, id); err != nil {
+		return err
+	}
+	changedBy := ""
+	if principal := appt_booking_service.PrincipalFrom(ctx); principal != nil {
+		changedBy = principal.Email
+	}
+
+	appointment, err := ah.service.TransitionAppointment(ctx, id, req.Status, changedBy, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	loc, err := ah.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newAppointmentResponse(appointment, loc))
+}
+
+// History handles GET /api/appt_booking/appointments/:id/history
+func (ah *AppointmentHandler) History(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid appointment ID")
+	}
+
+	if _, err := ah.accessibleAppointment(ctx, id); err != nil {
+		return err
+	}
+
+	history, err := ah.service.GetAppointmentStatusHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	response := make([]StatusChangeResponse, len(history))
+	for i, h := range history {
+		response[i] = StatusChangeResponse{
+			ID:         h.ID,
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ChangedBy:  h.ChangedBy,
+			Reason:     h.Reason,
+			ChangedAt:  h.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// accessibleAppointment loads appointment id for the request's principal. Appointments the
+// caller may not access are reported as not found so their existence is not disclosed.
+func (ah *AppointmentHandler) accessibleAppointment(ctx context.Context, id int) (*appt_booking_db.Appointment, error) {
+	appointment, err := ah.service.GetAppointment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := ah.canAccess(ctx, appointment)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, appt_booking_service.NotFound("appointment")
+	}
+	return appointment, nil
+}
+
+// canAccess reports whether the request's principal may access appointment
+func (ah *AppointmentHandler) canAccess(ctx context.Context, appointment *appt_booking_db.Appointment) (bool, error) {
+	principal := appt_booking_service.PrincipalFrom(ctx)
+	if principal.CanAccessAppointment(appointment) {
+		return true, nil
+	}
+	// Customers also own appointments booked before they changed their email
+	if principal.HasRole(appt_booking_db.RoleCustomer) && principal.EmailVerified {
+		return ah.service.IsCustomerAppointment(ctx, principal.Email, appointment)
+	}
+	return false, nil
+}
+
+// parseAppointmentDatetime parses an ISO 8601 datetime; values without a timezone are treated as UTC
+func parseAppointmentDatetime(value string) (time.Time, error) {
+	apptTime, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return apptTime, nil
+	}
+	// Try without timezone (assume UTC)
+	apptTime, err = time.Parse("2006-01-02T15:04:05", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return apptTime.UTC(), nil
+}
+
+// newAppointmentResponse converts an appointment to its response, presenting the
+// appointment time in loc (the staff member's timezone)
+func newAppointmentResponse(appointment *appt_booking_db.Appointment, loc *time.Location) AppointmentResponse {
+	return AppointmentResponse{
+		ID:                  appointment.ID,
+		CustomerID:          appointment.CustomerID,
+		CustomerName:        appointment.CustomerName,
+		CustomerEmail:       appointment.CustomerEmail,
+		CustomerPhone:       appointment.CustomerPhone,
+		StaffID:             appointment.StaffID,
+		ServiceID:           appointment.ServiceID,
+		AppointmentDatetime: appointment.AppointmentDatetime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Timezone:            loc.String(),
+		DurationMinutes:     appointment.DurationMinutes,
+		Status:              appointment.Status,
+		Notes:               appointment.Notes,
+		CreatedAt:           appointment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:           appointment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// newRescheduleResponses converts reschedule history entries, presenting times in loc
+func newRescheduleResponses(reschedules []appt_booking_db.AppointmentReschedule, loc *time.Location) []RescheduleResponse {
+	response := make([]RescheduleResponse, len(reschedules))
+	for i, r := range reschedules {
+		response[i] = RescheduleResponse{
+			PreviousStaffID:             r.PreviousStaffID,
+			PreviousAppointmentDatetime: r.PreviousDatetime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+			NewStaffID:                  r.NewStaffID,
+			NewAppointmentDatetime:      r.NewDatetime.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+			RescheduledAt:               r.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return response
+}
+
+// newCancellationResponse converts a cancellation into its API representation
+func newCancellationResponse(cancellation *appt_booking_db.AppointmentCancellation) CancellationResponse {
+	return CancellationResponse{
+		CancelledBy: cancellation.CancelledBy,
+		Reason:      cancellation.Reason,
+		Late:        cancellation.Late,
+		CancelledAt: cancellation.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,243 @@
+package appt_booking
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// BookSeriesRequest represents the request for booking a recurring appointment.
+// The embedded booking describes the first occurrence.
+type BookSeriesRequest struct {
+	BookRequest
+	Frequency string `json:"frequency"` // "weekly" or "monthly"
+	Interval  int    `json:"interval"`  // Optional: every N weeks or months, default 1
+	Count     int    `json:"count"`     // Number of occurrences; count and/or until is required
+	Until     string `json:"until"`     // Last date, inclusive: "2006-01-02"
+}
+
+// SeriesResponse represents an appointment series with its occurrences
+type SeriesResponse struct {
+	ID          int                  `json:"id"`
+	CustomerID  int                  `json:"customer_id"`
+	StaffID     int                  `json:"staff_id"`
+	ServiceID   int                  `json:"service_id"`
+	Frequency   string               `json:"frequency"`
+	Interval    int                  `json:"interval"`
+	Count       *int                 `json:"count"`
+	Until       *string              `json:"until"`     // YYYY-MM-DD
+	StartsAt    string               `json:"starts_at"` // RFC 3339 in the staff member's timezone
+	Timezone    string               `json:"timezone"`
+	Notes       string               `json:"notes"`
+	CreatedAt   string               `json:"created_at"`
+	Occurrences []OccurrenceResponse `json:"occurrences"`
+}
+
+// SeriesChangeResponse reports the occurrences affected by a "this and following" change
+type SeriesChangeResponse struct {
+	SeriesID    int                  `json:"series_id"`
+	Occurrences []OccurrenceResponse `json:"occurrences"`
+}
+
+// OccurrenceResponse reports one occurrence of a series: its appointment, or why it could
+// not be booked or changed
+type OccurrenceResponse struct {
+	Index               int                  `json:"index"`
+	AppointmentDatetime string               `json:"appointment_datetime"` // RFC 3339 in the staff member's timezone
+	Appointment         *AppointmentResponse `json:"appointment,omitempty"`
+	Error               *OccurrenceError     `json:"error,omitempty"`
+}
+
+// OccurrenceError explains why an occurrence could not be booked or changed
+type OccurrenceError struct {
+	Kind    string `json:"kind"` // e.g. "conflict" or "precondition_failed"
+	Message string `json:"message"`
+}
+
+// BookSeries handles POST /api/appt_booking/appointment-series
+// Occurrences that cannot be booked are reported with their error; the rest are booked.
+func (ah *AppointmentHandler) BookSeries(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req BookSeriesRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking_service.Invalid("body", "Invalid request payload")
+	}
+
+	apptTime, err := validateBookRequest(ctx, &req.BookRequest)
+	if err != nil {
+		return err
+	}
+	if req.HoldToken != "" {
+		return appt_booking_service.Invalid("hold_token", "Holds cannot be used to book a series")
+	}
+	recurrence := appt_booking_service.Recurrence{
+		Frequency: req.Frequency,
+		Interval:  req.Interval,
+		Count:     req.Count,
+	}
+	if req.Until != "" {
+		until, err := time.Parse("2006-01-02", req.Until)
+		if err != nil {
+			return appt_booking_service.Invalid("until", "Invalid until date. Use YYYY-MM-DD")
+		}
+		recurrence.Until = &until
+	}
+
+	booking, err := ah.service.BookAppointmentSeries(ctx,
+		req.CustomerName,
+		req.CustomerEmail,
+		req.CustomerPhone,
+		req.StaffID,
+		req.ServiceID,
+		apptTime,
+		req.Notes,
+		recurrence,
+	)
+	if err != nil {
+		return err
+	}
+
+	response, err := ah.newSeriesResponse(ctx, booking)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// GetSeries handles GET /api/appt_booking/appointment-series/:id
+func (ah *AppointmentHandler) GetSeries(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking_service.Invalid("id", "Invalid series ID")
+	}
+
+	booking, err := ah.service.GetAppointmentSeries(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Callers see a series when they may access one of its appointments
+	accessible := false
+	for _, o := range booking.Occurrences {
+		if accessible, err = ah.canAccess(ctx, o.Appointment); err != nil {
+			return err
+		}
+		if accessible {
+			break
+		}
+	}
+	if !accessible {
+		return appt_booking_service.NotFound("appointment series")
+	}
+
+	response, err := ah.newSeriesResponse(ctx, booking)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// seriesChange responds with the occurrences changed together with appointment
+func (ah *AppointmentHandler) seriesChange(c echo.Context, appointment *appt_booking_db.Appointment, results []appt_booking_service.OccurrenceResult) error {
+	ctx := c.Request().Context()
+	seriesID, err := ah.service.GetAppointmentSeriesID(ctx, appointment.ID)
+	if err != nil {
+		return err
+	}
+	loc, err := ah.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
+	}
+	occurrences, err := ah.newOccurrenceResponses(ctx, results, loc)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, SeriesChangeResponse{
+		SeriesID:    seriesID,
+		Occurrences: occurrences,
+	})
+}
+
+// parseSeriesScope validates the scope of a cancel or reschedule; empty means "this"
+func parseSeriesScope(scope string) (string, error) {
+	switch scope {
+	case "", appt_booking_service.SeriesScopeThis:
+		return appt_booking_service.SeriesScopeThis, nil
+	case appt_booking_service.SeriesScopeFollowing:
+		return scope, nil
+	}
+	return "", appt_booking_service.Invalid("scope", "scope must be this or following")
+}
+
+// newSeriesResponse converts a series, presenting times in each staff member's timezone
+func (ah *AppointmentHandler) newSeriesResponse(ctx context.Context, booking *appt_booking_service.SeriesBooking) (SeriesResponse, error) {
+	series := booking.Series
+	loc, err := ah.service.LocationForStaff(ctx, series.StaffID)
+	if err != nil {
+		return SeriesResponse{}, err
+	}
+	occurrences, err := ah.newOccurrenceResponses(ctx, booking.Occurrences, loc)
+	if err != nil {
+		return SeriesResponse{}, err
+	}
+
+	response := SeriesResponse{
+		ID:          series.ID,
+		CustomerID:  series.CustomerID,
+		StaffID:     series.StaffID,
+		ServiceID:   series.ServiceID,
+		Frequency:   series.Frequency,
+		Interval:    series.Interval,
+		Count:       series.Count,
+		StartsAt:    series.StartsAt.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Timezone:    loc.String(),
+		Notes:       series.Notes,
+		CreatedAt:   series.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Occurrences: occurrences,
+	}
+	if series.Until != nil {
+		until := series.Until.Format("2006-01-02")
+		response.Until = &until
+	}
+	return response, nil
+}
+
+// newOccurrenceResponses converts occurrence results, presenting times in the timezone of
+// each appointment's staff member, or in loc for occurrences without an appointment
+func (ah *AppointmentHandler) newOccurrenceResponses(ctx context.Context, results []appt_booking_service.OccurrenceResult, loc *time.Location) ([]OccurrenceResponse, error) {
+	locations, err := ah.service.StaffLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]OccurrenceResponse, len(results))
+	for i, r := range results {
+		occurrenceLoc := loc
+		response[i] = OccurrenceResponse{Index: r.Index}
+		if r.Appointment != nil {
+			if staffLoc, ok := locations[r.Appointment.StaffID]; ok {
+				occurrenceLoc = staffLoc
+			} else {
+				occurrenceLoc = ah.service.DefaultLocation()
+			}
+			appointment := newAppointmentResponse(r.Appointment, occurrenceLoc)
+			response[i].Appointment = &appointment
+		}
+		if r.Err != nil {
+			response[i].Error = &OccurrenceError{
+				Kind:    string(appt_booking_service.KindOf(r.Err)),
+				Message: r.Err.Error(),
+			}
+		}
+		response[i].AppointmentDatetime = r.Start.In(occurrenceLoc).Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response, nil
+}
@@ -0,0 +1,214 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// AuthHandler handles login, token refresh and account endpoints
+type AuthHandler struct {
+	auth *appt_booking.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(auth *appt_booking.AuthService) *AuthHandler {
+	return &AuthHandler{
+		auth: auth,
+	}
+}
+
+// CredentialsRequest represents the request for logging in or registering
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest represents the request for refreshing tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// VerifyEmailRequest represents the request for confirming an email address with the
+// token from a verification email
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// StaffAccountRequest represents the request for creating a staff member's login
+type StaffAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// TokenResponse represents an issued token pair
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`         // access token lifetime in seconds
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // refresh token lifetime in seconds
+}
+
+// UserResponse represents a login account
+type UserResponse struct {
+	ID            int    `json:"id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	StaffID       *int   `json:"staff_id"`
+	EmailVerified bool   `json:"email_verified"` // customers see their bookings only once verified
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+// Register handles POST /api/auth/register (customer self-signup)
+func (ah *AuthHandler) Register(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req CredentialsRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	user, err := ah.auth.Register(ctx, req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, newUserResponse(user))
+}
+
+// Login handles POST /api/auth/login
+func (ah *AuthHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req CredentialsRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+	if req.Email == "" || req.Password == "" {
+		return appt_booking.Invalid("email", "Email and password are required")
+	}
+
+	tokens, err := ah.auth.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newTokenResponse(tokens))
+}
+
+// Refresh handles POST /api/auth/refresh
+func (ah *AuthHandler) Refresh(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+	if req.RefreshToken == "" {
+		return appt_booking.Invalid("refresh_token", "Refresh token is required")
+	}
+
+	tokens, err := ah.auth.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newTokenResponse(tokens))
+}
+
+// VerifyEmail handles POST /api/auth/verify-email. Access tokens issued before it still
+// carry the unverified address; clients refresh them afterwards.
+func (ah *AuthHandler) VerifyEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+	if req.Token == "" {
+		return appt_booking.Invalid("token", "Token is required")
+	}
+
+	user, err := ah.auth.VerifyEmail(ctx, req.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ResendVerification handles POST /api/auth/verify-email/resend
+func (ah *AuthHandler) ResendVerification(c echo.Context) error {
+	principal := appt_booking.PrincipalFrom(c.Request().Context())
+	if principal == nil {
+		return appt_booking.Unauthorized("authentication required")
+	}
+
+	if err := ah.auth.SendVerificationEmail(c.Request().Context(), principal.UserID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// Me handles GET /api/auth/me
+func (ah *AuthHandler) Me(c echo.Context) error {
+	principal := appt_booking.PrincipalFrom(c.Request().Context())
+	if principal == nil {
+		return appt_booking.Unauthorized("authentication required")
+	}
+
+	return c.JSON(http.StatusOK, UserResponse{
+		ID:            principal.UserID,
+		Email:         principal.Email,
+		Role:          principal.Role,
+		StaffID:       principal.StaffID,
+		EmailVerified: principal.EmailVerified,
+	})
+}
+
+// CreateStaffAccount handles POST /api/appt_booking/staff/:id/account
+func (ah *AuthHandler) CreateStaffAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid staff ID")
+	}
+
+	var req StaffAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	user, err := ah.auth.CreateStaffAccount(ctx, id, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, newUserResponse(user))
+}
+
+// newTokenResponse converts a token pair to its API representation
+func newTokenResponse(tokens *appt_booking.TokenPair) TokenResponse {
+	return TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(time.Until(tokens.AccessExpiresAt).Round(time.Second).Seconds()),
+		RefreshExpiresIn: int(time.Until(tokens.RefreshExpiresAt).Round(time.Second).Seconds()),
+	}
+}
+
+// newUserResponse converts a user to its API representation
+func newUserResponse(user *appt_booking_db.User) UserResponse {
+	return UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		StaffID:       user.StaffID,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
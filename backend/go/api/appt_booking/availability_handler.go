@@ -0,0 +1,119 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// AvailabilityHandler handles availability endpoints
+type AvailabilityHandler struct {
+	service *appt_booking.ApptBookingService
+}
+
+// NewAvailabilityHandler creates a new availability handler
+func NewAvailabilityHandler(service *appt_booking.ApptBookingService) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		service: service,
+	}
+}
+
+// StaffAvailabilityResponse represents the open start times for one staff member
+type StaffAvailabilityResponse struct {
+	StaffID   int      `json:"staff_id"`
+	StaffName string   `json:"staff_name"`
+	Timezone  string   `json:"timezone"`
+	Slots     []string `json:"slots"` // RFC 3339 start times in the staff member's timezone
+}
+
+// AvailabilityResponse represents the response for an availability query
+type AvailabilityResponse struct {
+	ServiceID       int                         `json:"service_id"`
+	DurationMinutes int                         `json:"duration_minutes"`
+	From            string                      `json:"from"`
+	To              string                      `json:"to"`
+	Staff           []StaffAvailabilityResponse `json:"staff"`
+}
+
+// Get handles GET /api/appt_booking/availability?service_id=&staff_id=&from=&to=
+// staff_id is optional; when omitted, every staff member offering the service is included.
+// from/to accept YYYY-MM-DD or ISO 8601 and default to the next 7 days.
+func (ah *AvailabilityHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+	serviceID, err := strconv.Atoi(c.QueryParam("service_id"))
+	if err != nil || serviceID <= 0 {
+		return appt_booking.Invalid("service_id", "Valid service ID is required")
+	}
+
+	staffID := 0
+	if staffIDStr := c.QueryParam("staff_id"); staffIDStr != "" {
+		staffID, err = strconv.Atoi(staffIDStr)
+		if err != nil || staffID <= 0 {
+			return appt_booking.Invalid("staff_id", "Invalid staff ID")
+		}
+	}
+
+	from := time.Now().UTC()
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = parseRangeBound(fromStr, false)
+		if err != nil {
+			return appt_booking.Invalid("from", "Invalid from date. Use YYYY-MM-DD or ISO 8601")
+		}
+	}
+
+	to := from.AddDate(0, 0, 7)
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = parseRangeBound(toStr, true)
+		if err != nil {
+			return appt_booking.Invalid("to", "Invalid to date. Use YYYY-MM-DD or ISO 8601")
+		}
+	}
+
+	availability, err := ah.service.GetAvailability(ctx, serviceID, staffID, from, to)
+	if err != nil {
+		return err
+	}
+
+	response := AvailabilityResponse{
+		ServiceID:       availability.ServiceID,
+		DurationMinutes: availability.DurationMinutes,
+		From:            from.Format("2006-01-02T15:04:05Z07:00"),
+		To:              to.Format("2006-01-02T15:04:05Z07:00"),
+		Staff:           make([]StaffAvailabilityResponse, len(availability.Staff)),
+	}
+	for i, a := range availability.Staff {
+		slots := make([]string, len(a.Slots))
+		for j, slot := range a.Slots {
+			slots[j] = slot.Start.In(a.Location).Format("2006-01-02T15:04:05Z07:00")
+		}
+		response.Staff[i] = StaffAvailabilityResponse{
+			StaffID:   a.StaffID,
+			StaffName: a.StaffName,
+			Timezone:  a.Location.String(),
+			Slots:     slots,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseRangeBound parses a date range query parameter.
+// Date-only values are treated as UTC midnight; when endOfDay is set the
+// bound is moved to the following midnight so the whole day is included.
+func parseRangeBound(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,93 @@
+package appt_booking
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// calendarContentType is the media type of iCalendar responses
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// CalendarHandler handles staff calendar feeds
+type CalendarHandler struct {
+	service *appt_booking.CalendarService
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(service *appt_booking.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		service: service,
+	}
+}
+
+// CalendarFeedResponse describes how to subscribe to a staff member's calendar
+type CalendarFeedResponse struct {
+	StaffID   int    `json:"staff_id"`
+	URL       string `json:"url"` // for calendar apps; contains the token
+	Token     string `json:"token"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Feed handles GET /api/appt_booking/staff/:id/calendar.ics?token=..., the staff member's
+// appointments for calendar apps to subscribe to. The token is the credential.
+func (ch *CalendarHandler) Feed(c echo.Context) error {
+	ctx := c.Request().Context()
+	staffID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid staff ID")
+	}
+
+	calendar, err := ch.service.StaffFeed(ctx, staffID, c.QueryParam("token"), time.Now())
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, max-age=300")
+	return c.Blob(http.StatusOK, calendarContentType, calendar.Encode())
+}
+
+// GetFeed handles GET /api/appt_booking/staff/:id/calendar-feed, returning the feed URL
+// and creating the feed on first use. Providers may only get their own.
+func (ch *CalendarHandler) GetFeed(c echo.Context) error {
+	return ch.respondFeed(c, ch.service.GetFeed)
+}
+
+// RotateFeed handles POST /api/appt_booking/staff/:id/calendar-feed, replacing the feed's
+// token so that existing subscriptions stop working
+func (ch *CalendarHandler) RotateFeed(c echo.Context) error {
+	return ch.respondFeed(c, ch.service.RotateFeed)
+}
+
+// respondFeed checks access to the staff member's feed, gets it with get and responds
+// with its URL
+func (ch *CalendarHandler) respondFeed(c echo.Context, get func(ctx context.Context, staffID int) (*appt_booking_db.StaffCalendarFeed, error)) error {
+	ctx := c.Request().Context()
+	staffID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid staff ID")
+	}
+	principal := appt_booking.PrincipalFrom(ctx)
+	if principal.HasRole(appt_booking_db.RoleProvider) && !principal.IsStaff(staffID) {
+		return appt_booking.Forbidden("providers can only manage their own calendar feed")
+	}
+
+	feed, err := get(ctx, staffID)
+	if err != nil {
+		return err
+	}
+	feedURL := c.Scheme() + "://" + c.Request().Host + "/api/appt_booking/staff/" +
+		strconv.Itoa(feed.StaffID) + "/calendar.ics?token=" + url.QueryEscape(feed.Token)
+	return c.JSON(http.StatusOK, CalendarFeedResponse{
+		StaffID:   feed.StaffID,
+		URL:       feedURL,
+		Token:     feed.Token,
+		CreatedAt: feed.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
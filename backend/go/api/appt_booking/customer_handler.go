@@ -0,0 +1,172 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// CustomerHandler handles customer endpoints
+type CustomerHandler struct {
+	service *appt_booking.ApptBookingService
+}
+
+// NewCustomerHandler creates a new customer handler
+func NewCustomerHandler(service *appt_booking.ApptBookingService) *CustomerHandler {
+	return &CustomerHandler{
+		service: service,
+	}
+}
+
+// CustomerResponse represents the response for a customer
+type CustomerResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// CustomerDetailResponse represents a customer together with their visit statistics
+type CustomerDetailResponse struct {
+	CustomerResponse
+	AppointmentCount   int     `json:"appointment_count"`
+	VisitCount         int     `json:"visit_count"`          // completed appointments
+	LifetimeSpendCents int     `json:"lifetime_spend_cents"` // sum of completed services' prices
+	LastVisit          *string `json:"last_visit"`           // null before the first visit
+}
+
+// CustomerRequest represents the request for creating/updating a customer
+type CustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// GetAll handles GET /api/appt_booking/customers
+// Paged with limit/offset and ordered by sort (default name); the total is returned in X-Total-Count.
+func (ch *CustomerHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+	customers, total, err := ch.service.ListCustomers(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	response := make([]CustomerResponse, len(customers))
+	for i := range customers {
+		response[i] = newCustomerResponse(&customers[i])
+	}
+
+	setTotalCount(c, total)
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetByID handles GET /api/appt_booking/customers/:id
+func (ch *CustomerHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid customer ID")
+	}
+
+	customer, err := ch.service.GetCustomerByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	stats, err := ch.service.GetCustomerStats(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	response := CustomerDetailResponse{
+		CustomerResponse:   newCustomerResponse(customer),
+		AppointmentCount:   stats.AppointmentCount,
+		VisitCount:         stats.VisitCount,
+		LifetimeSpendCents: stats.LifetimeSpendCents,
+	}
+	if stats.LastVisit != nil {
+		lastVisit := stats.LastVisit.In(ch.service.DefaultLocation()).Format("2006-01-02T15:04:05Z07:00")
+		response.LastVisit = &lastVisit
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Create handles POST /api/appt_booking/customers
+func (ch *CustomerHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req CustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	customer, err := ch.service.CreateCustomer(ctx, req.Name, req.Email, req.Phone)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, newCustomerResponse(customer))
+}
+
+// Update handles PUT /api/appt_booking/customers/:id
+func (ch *CustomerHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid customer ID")
+	}
+
+	var req CustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	customer, err := ch.service.UpdateCustomer(ctx, id, req.Name, req.Email, req.Phone)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newCustomerResponse(customer))
+}
+
+// Delete handles DELETE /api/appt_booking/customers/:id
+func (ch *CustomerHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid customer ID")
+	}
+
+	err = ch.service.DeleteCustomer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Customer deleted successfully",
+	})
+}
+
+// newCustomerResponse converts a customer to its API representation
+func newCustomerResponse(customer *appt_booking_db.Customer) CustomerResponse {
+	return CustomerResponse{
+		ID:        customer.ID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		Phone:     customer.Phone,
+		CreatedAt: customer.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: customer.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,182 @@
+package appt_booking
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// ExternalCalendarHandler handles staff members' external calendars, whose events block
+// their availability
+type ExternalCalendarHandler struct {
+	service *appt_booking.ExternalCalendarService
+}
+
+// NewExternalCalendarHandler creates a new external calendar handler
+func NewExternalCalendarHandler(service *appt_booking.ExternalCalendarService) *ExternalCalendarHandler {
+	return &ExternalCalendarHandler{
+		service: service,
+	}
+}
+
+// ExternalCalendarRequest represents the request for subscribing to a calendar by URL.
+// Files are uploaded as multipart/form-data instead, with "file" and optional "name" fields.
+type ExternalCalendarRequest struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // http, https or webcal
+}
+
+// ExternalCalendarResponse represents an external calendar
+type ExternalCalendarResponse struct {
+	ID           int     `json:"id"`
+	StaffID      int     `json:"staff_id"`
+	Name         string  `json:"name"`
+	Source       string  `json:"source"` // "url" or "upload"
+	URL          string  `json:"url,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at"`
+	LastError    string  `json:"last_error"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+// newExternalCalendarResponse converts an external calendar
+func newExternalCalendarResponse(c *appt_booking_db.ExternalCalendar) ExternalCalendarResponse {
+	response := ExternalCalendarResponse{
+		ID:        c.ID,
+		StaffID:   c.StaffID,
+		Name:      c.Name,
+		Source:    "upload",
+		URL:       c.URL,
+		LastError: c.LastError,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+	}
+	if c.URL != "" {
+		response.Source = "url"
+	}
+	if c.LastSyncedAt != nil {
+		syncedAt := c.LastSyncedAt.Format(time.RFC3339)
+		response.LastSyncedAt = &syncedAt
+	}
+	return response
+}
+
+// parseExternalCalendarPath reads the :id and, if present, :calendarId path parameters and
+// checks that a provider is managing their own calendars
+func parseExternalCalendarPath(c echo.Context) (staffID, calendarID int, err error) {
+	staffID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, appt_booking.Invalid("id", "Invalid staff ID")
+	}
+	if param := c.Param("calendarId"); param != "" {
+		if calendarID, err = strconv.Atoi(param); err != nil {
+			return 0, 0, appt_booking.Invalid("calendarId", "Invalid calendar ID")
+		}
+	}
+	principal := appt_booking.PrincipalFrom(c.Request().Context())
+	if principal.HasRole(appt_booking_db.RoleProvider) && !principal.IsStaff(staffID) {
+		return 0, 0, appt_booking.Forbidden("providers can only manage their own calendars")
+	}
+	return staffID, calendarID, nil
+}
+
+// GetAll handles GET /api/appt_booking/staff/:id/external-calendars
+func (eh *ExternalCalendarHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	staffID, _, err := parseExternalCalendarPath(c)
+	if err != nil {
+		return err
+	}
+
+	calendars, err := eh.service.ListCalendars(ctx, staffID)
+	if err != nil {
+		return err
+	}
+	response := make([]ExternalCalendarResponse, len(calendars))
+	for i := range calendars {
+		response[i] = newExternalCalendarResponse(&calendars[i])
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Create handles POST /api/appt_booking/staff/:id/external-calendars: a JSON body
+// subscribes to a calendar URL, a multipart upload adds an ICS file
+func (eh *ExternalCalendarHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	staffID, _, err := parseExternalCalendarPath(c)
+	if err != nil {
+		return err
+	}
+
+	var calendar *appt_booking_db.ExternalCalendar
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return appt_booking.Invalid("file", "an ICS file is required")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return appt_booking.Invalid("file", "could not read the uploaded file")
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return appt_booking.Invalid("file", "could not read the uploaded file")
+		}
+		name := c.FormValue("name")
+		if name == "" {
+			name = strings.TrimSuffix(header.Filename, ".ics")
+		}
+		calendar, err = eh.service.AddUploadedCalendar(ctx, staffID, name, data)
+		if err != nil {
+			return err
+		}
+	} else {
+		var req ExternalCalendarRequest
+		if err := c.Bind(&req); err != nil {
+			return appt_booking.Invalid("body", "Invalid request payload")
+		}
+		calendar, err = eh.service.AddURLCalendar(ctx, staffID, req.Name, req.URL)
+		if err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusCreated, newExternalCalendarResponse(calendar))
+}
+
+// Delete handles DELETE /api/appt_booking/staff/:id/external-calendars/:calendarId
+func (eh *ExternalCalendarHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	staffID, calendarID, err := parseExternalCalendarPath(c)
+	if err != nil {
+		return err
+	}
+
+	if err := eh.service.DeleteCalendar(ctx, staffID, calendarID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Sync handles POST /api/appt_booking/staff/:id/external-calendars/:calendarId/sync,
+// refreshing the calendar now. A failed refresh is reported in last_error.
+func (eh *ExternalCalendarHandler) Sync(c echo.Context) error {
+	ctx := c.Request().Context()
+	staffID, calendarID, err := parseExternalCalendarPath(c)
+	if err != nil {
+		return err
+	}
+
+	calendar, err := eh.service.SyncCalendar(ctx, staffID, calendarID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, newExternalCalendarResponse(calendar))
+}
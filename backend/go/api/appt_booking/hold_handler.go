@@ -0,0 +1,83 @@
+package appt_booking
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// HoldHandler handles slot hold endpoints
+type HoldHandler struct {
+	service *appt_booking.ApptBookingService
+}
+
+// NewHoldHandler creates a new hold handler
+func NewHoldHandler(service *appt_booking.ApptBookingService) *HoldHandler {
+	return &HoldHandler{
+		service: service,
+	}
+}
+
+// HoldRequest represents the request for holding a slot
+type HoldRequest struct {
+	StaffID             int    `json:"staff_id"`
+	ServiceID           int    `json:"service_id"`
+	AppointmentDatetime string `json:"appointment_datetime"` // ISO 8601
+}
+
+// HoldResponse represents a held slot. The token is passed as hold_token when booking.
+type HoldResponse struct {
+	Token               string `json:"token"`
+	StaffID             int    `json:"staff_id"`
+	ServiceID           int    `json:"service_id"`
+	AppointmentDatetime string `json:"appointment_datetime"` // RFC 3339 in the staff member's timezone
+	Timezone            string `json:"timezone"`
+	DurationMinutes     int    `json:"duration_minutes"`
+	ExpiresAt           string `json:"expires_at"`
+}
+
+// Create handles POST /api/appt_booking/holds
+// The hold belongs to the caller; callers already holding the maximum number of slots get 409.
+func (hh *HoldHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req HoldRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+	apptTime, err := parseAppointmentDatetime(req.AppointmentDatetime)
+	if err != nil {
+		return appt_booking.Invalid("appointment_datetime", "Invalid appointment datetime format. Use YYYY-MM-DDTHH:MM:SS or ISO 8601")
+	}
+
+	hold, err := hh.service.CreateHold(ctx, req.StaffID, req.ServiceID, apptTime)
+	if err != nil {
+		return err
+	}
+
+	loc, err := hh.service.LocationForStaff(ctx, hold.StaffID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, HoldResponse{
+		Token:               hold.Token,
+		StaffID:             hold.StaffID,
+		ServiceID:           hold.ServiceID,
+		AppointmentDatetime: hold.StartsAt.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Timezone:            loc.String(),
+		DurationMinutes:     hold.DurationMinutes,
+		ExpiresAt:           hold.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// Release handles DELETE /api/appt_booking/holds/:token
+func (hh *HoldHandler) Release(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := hh.service.ReleaseHold(ctx, c.Param("token")); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Hold released successfully",
+	})
+}
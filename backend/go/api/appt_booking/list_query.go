@@ -0,0 +1,52 @@
+package appt_booking
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// TotalCountHeader carries the number of items matching a list query across all pages
+const TotalCountHeader = "X-Total-Count"
+
+// parseListOptions reads the limit, offset and sort query parameters shared by list endpoints.
+// sort is a comma-separated list of fields; a leading "-" sorts that field descending.
+// Sort field names and page bounds are validated by the service.
+func parseListOptions(c echo.Context) (appt_booking_db.ListOptions, error) {
+	var opts appt_booking_db.ListOptions
+	var err error
+
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		opts.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || opts.Limit <= 0 {
+			return opts, appt_booking.Invalid("limit", "limit must be a positive number")
+		}
+	}
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		opts.Offset, err = strconv.Atoi(offsetStr)
+		if err != nil || opts.Offset < 0 {
+			return opts, appt_booking.Invalid("offset", "offset must be zero or a positive number")
+		}
+	}
+	if sortStr := c.QueryParam("sort"); sortStr != "" {
+		for _, field := range strings.Split(sortStr, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return opts, appt_booking.Invalid("sort", "Invalid sort parameter")
+			}
+			opts.Sort = append(opts.Sort, appt_booking_db.SortField{Field: field, Desc: desc})
+		}
+	}
+	return opts, nil
+}
+
+// setTotalCount reports the number of items matching a list query
+func setTotalCount(c echo.Context, total int) {
+	c.Response().Header().Set(TotalCountHeader, strconv.Itoa(total))
+}
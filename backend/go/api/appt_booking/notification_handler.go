@@ -0,0 +1,53 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// NotificationHandler handles the admin endpoint for the notification log
+type NotificationHandler struct {
+	service *appt_booking.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(service *appt_booking.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+	}
+}
+
+// GetAll handles GET /api/admin/notifications, the latest notifications first. Optional
+// query parameters: appointment_id, status (pending, sent or failed) and limit.
+func (nh *NotificationHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	filter := appt_booking_db.NotificationFilter{Status: c.QueryParam("status")}
+	var err error
+	if value := c.QueryParam("appointment_id"); value != "" {
+		filter.AppointmentID, err = strconv.Atoi(value)
+		if err != nil || filter.AppointmentID <= 0 {
+			return appt_booking.Invalid("appointment_id", "Invalid appointment ID")
+		}
+	}
+	limit := 0
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return appt_booking.Invalid("limit", "limit must be a positive number")
+		}
+	}
+
+	notifications, err := nh.service.ListNotifications(ctx, filter, limit)
+	if err != nil {
+		return err
+	}
+	if notifications == nil {
+		notifications = []appt_booking_db.Notification{}
+	}
+	return c.JSON(http.StatusOK, notifications)
+}
@@ -0,0 +1,184 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// ScheduleExceptionHandler handles schedule exception endpoints
+type ScheduleExceptionHandler struct {
+	service *appt_booking.ApptBookingService
+}
+
+// NewScheduleExceptionHandler creates a new schedule exception handler
+func NewScheduleExceptionHandler(service *appt_booking.ApptBookingService) *ScheduleExceptionHandler {
+	return &ScheduleExceptionHandler{
+		service: service,
+	}
+}
+
+// ScheduleExceptionResponse represents the response for a schedule exception
+type ScheduleExceptionResponse struct {
+	ID        int     `json:"id"`
+	StaffID   *int    `json:"staff_id"` // null for business-wide exceptions
+	StartDate string  `json:"start_date"`
+	EndDate   string  `json:"end_date"`
+	IsClosed  bool    `json:"is_closed"`
+	StartTime *string `json:"start_time"` // null for closed exceptions
+	EndTime   *string `json:"end_time"`   // null for closed exceptions
+	Reason    string  `json:"reason"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// GetAll handles GET /api/appt_booking/schedule-exceptions
+// Optional staff_id narrows the result to exceptions that apply to that staff member,
+// including business-wide ones.
+func (sh *ScheduleExceptionHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	var exceptions []appt_booking_db.ScheduleException
+	var err error
+
+	if staffIDStr := c.QueryParam("staff_id"); staffIDStr != "" {
+		staffID, convErr := strconv.Atoi(staffIDStr)
+		if convErr != nil {
+			return appt_booking.Invalid("staff_id", "Invalid staff ID")
+		}
+		exceptions, err = sh.service.GetScheduleExceptionsByStaff(ctx, staffID)
+	} else {
+		exceptions, err = sh.service.GetAllScheduleExceptions(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	response := make([]ScheduleExceptionResponse, len(exceptions))
+	for i := range exceptions {
+		response[i] = newScheduleExceptionResponse(&exceptions[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetByID handles GET /api/appt_booking/schedule-exceptions/:id
+func (sh *ScheduleExceptionHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid schedule exception ID")
+	}
+
+	exception, err := sh.service.GetScheduleExceptionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newScheduleExceptionResponse(exception))
+}
+
+// ScheduleExceptionRequest represents the request for creating/updating a schedule exception
+type ScheduleExceptionRequest struct {
+	StaffID   *int   `json:"staff_id"`   // Omit or null for a business-wide exception
+	StartDate string `json:"start_date"` // YYYY-MM-DD, inclusive
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD, inclusive
+	IsClosed  bool   `json:"is_closed"`
+	StartTime string `json:"start_time"` // HH:MM, required unless is_closed
+	EndTime   string `json:"end_time"`   // HH:MM, required unless is_closed
+	Reason    string `json:"reason"`
+}
+
+// Create handles POST /api/appt_booking/schedule-exceptions
+func (sh *ScheduleExceptionHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req ScheduleExceptionRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	// Validate required fields
+	if req.StartDate == "" || req.EndDate == "" {
+		return appt_booking.Invalid("start_date", "Start date and end date are required")
+	}
+
+	exception, err := sh.service.CreateScheduleException(ctx, req.StaffID, req.StartDate, req.EndDate, req.IsClosed, req.StartTime, req.EndTime, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, newScheduleExceptionResponse(exception))
+}
+
+// Update handles PUT /api/appt_booking/schedule-exceptions/:id
+func (sh *ScheduleExceptionHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid schedule exception ID")
+	}
+
+	var req ScheduleExceptionRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	// Validate required fields
+	if req.StartDate == "" || req.EndDate == "" {
+		return appt_booking.Invalid("start_date", "Start date and end date are required")
+	}
+
+	exception, err := sh.service.UpdateScheduleException(ctx, id, req.StaffID, req.StartDate, req.EndDate, req.IsClosed, req.StartTime, req.EndTime, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newScheduleExceptionResponse(exception))
+}
+
+// Delete handles DELETE /api/appt_booking/schedule-exceptions/:id
+func (sh *ScheduleExceptionHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return appt_booking.Invalid("id", "Invalid schedule exception ID")
+	}
+
+	err = sh.service.DeleteScheduleException(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Schedule exception deleted successfully",
+	})
+}
+
+// newScheduleExceptionResponse converts a schedule exception to its response
+func newScheduleExceptionResponse(e *appt_booking_db.ScheduleException) ScheduleExceptionResponse {
+	response := ScheduleExceptionResponse{
+		ID:        e.ID,
+		StaffID:   e.StaffID,
+		StartDate: e.StartDate.Format("2006-01-02"),
+		EndDate:   e.EndDate.Format("2006-01-02"),
+		IsClosed:  e.IsClosed,
+		Reason:    e.Reason,
+		CreatedAt: e.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: e.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if e.StartTime != nil {
+		startTime := e.StartTime.Format("15:04")
+		response.StartTime = &startTime
+	}
+	if e.EndTime != nil {
+		endTime := e.EndTime.Format("15:04")
+		response.EndTime = &endTime
+	}
+	return response
+}
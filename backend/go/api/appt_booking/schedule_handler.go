@@ -34,11 +34,10 @@ type ScheduleResponse struct {
 
 // GetAll handles GET /api/appt_booking/schedules
 func (sh *ScheduleHandler) GetAll(c echo.Context) error {
-	schedules, err := sh.service.GetAllSchedules()
+	ctx := c.Request().Context()
+	schedules, err := sh.service.GetAllSchedules(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch schedules",
-		})
+		return err
 	}
 
 	response := make([]ScheduleResponse, len(schedules))
@@ -59,19 +58,16 @@ func (sh *ScheduleHandler) GetAll(c echo.Context) error {
 
 // GetByID handles GET /api/appt_booking/schedules/:id
 func (sh *ScheduleHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid schedule ID",
-		})
+		return appt_booking.Invalid("id", "Invalid schedule ID")
 	}
 
-	schedule, err := sh.service.GetScheduleByID(id)
+	schedule, err := sh.service.GetScheduleByID(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Schedule not found",
-		})
+		return err
 	}
 
 	response := ScheduleResponse{
@@ -89,19 +85,16 @@ func (sh *ScheduleHandler) GetByID(c echo.Context) error {
 
 // GetByStaff handles GET /api/appt_booking/schedules/staff/:staffId
 func (sh *ScheduleHandler) GetByStaff(c echo.Context) error {
+	ctx := c.Request().Context()
 	staffIDStr := c.Param("staffId")
 	staffID, err := strconv.Atoi(staffIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid staff ID",
-		})
+		return appt_booking.Invalid("staffId", "Invalid staff ID")
 	}
 
-	schedules, err := sh.service.GetSchedulesByStaff(staffID)
+	schedules, err := sh.service.GetSchedulesByStaff(ctx, staffID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch schedules for staff",
-		})
+		return err
 	}
 
 	response := make([]ScheduleResponse, len(schedules))
@@ -130,30 +123,23 @@ type ScheduleRequest struct {
 
 // Create handles POST /api/appt_booking/schedules
 func (sh *ScheduleHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
 	var req ScheduleRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.StaffID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid staff ID is required",
-		})
+		return appt_booking.Invalid("staff_id", "Valid staff ID is required")
 	}
 	if req.StartTime == "" || req.EndTime == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Start time and end time are required",
-		})
+		return appt_booking.Invalid("start_time", "Start time and end time are required")
 	}
 
-	schedule, err := sh.service.CreateSchedule(req.StaffID, req.DayOfWeek, req.StartTime, req.EndTime)
+	schedule, err := sh.service.CreateSchedule(ctx, req.StaffID, req.DayOfWeek, req.StartTime, req.EndTime)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := ScheduleResponse{
@@ -171,38 +157,29 @@ func (sh *ScheduleHandler) Create(c echo.Context) error {
 
 // Update handles PUT /api/appt_booking/schedules/:id
 func (sh *ScheduleHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid schedule ID",
-		})
+		return appt_booking.Invalid("id", "Invalid schedule ID")
 	}
 
 	var req ScheduleRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.StaffID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid staff ID is required",
-		})
+		return appt_booking.Invalid("staff_id", "Valid staff ID is required")
 	}
 	if req.StartTime == "" || req.EndTime == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Start time and end time are required",
-		})
+		return appt_booking.Invalid("start_time", "Start time and end time are required")
 	}
 
-	schedule, err := sh.service.UpdateSchedule(id, req.DayOfWeek, req.StartTime, req.EndTime)
+	schedule, err := sh.service.UpdateSchedule(ctx, id, req.DayOfWeek, req.StartTime, req.EndTime)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := ScheduleResponse{
@@ -220,19 +197,16 @@ func (sh *ScheduleHandler) Update(c echo.Context) error {
 
 // Delete handles DELETE /api/appt_booking/schedules/:id
 func (sh *ScheduleHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid schedule ID",
-		})
+		return appt_booking.Invalid("id", "Invalid schedule ID")
 	}
 
-	err = sh.service.DeleteSchedule(id)
+	err = sh.service.DeleteSchedule(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
 	"k8s-fullstack-blueprint-backend/service/appt_booking"
 )
 
@@ -23,31 +24,47 @@ func NewServiceHandler(service *appt_booking.ApptBookingService) *ServiceHandler
 
 // ServiceResponse represents the response for a service
 type ServiceResponse struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	DurationMin int    `json:"duration_min"`
-	PriceCents  int    `json:"price_cents"`
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	DurationMin        int    `json:"duration_min"`
+	PriceCents         int    `json:"price_cents"`
+	BufferBeforeMin    int    `json:"buffer_before_min"`
+	BufferAfterMin     int    `json:"buffer_after_min"`
+	MinNoticeMin       int    `json:"min_notice_min"`
+	MaxAdvanceDays     int    `json:"max_advance_days"`
+	SlotGranularityMin int    `json:"slot_granularity_min"`
+	CancelNoticeMin    int    `json:"cancel_notice_min"`
+}
+
+// newServiceResponse converts a service, including its booking policy
+func newServiceResponse(s *appt_booking_db.Service) ServiceResponse {
+	return ServiceResponse{
+		ID:                 s.ID,
+		Name:               s.Name,
+		Description:        s.Description,
+		DurationMin:        s.DurationMin,
+		PriceCents:         s.PriceCents,
+		BufferBeforeMin:    s.BufferBeforeMin,
+		BufferAfterMin:     s.BufferAfterMin,
+		MinNoticeMin:       s.MinNoticeMin,
+		MaxAdvanceDays:     s.MaxAdvanceDays,
+		SlotGranularityMin: s.SlotGranularityMin,
+		CancelNoticeMin:    s.CancelNoticeMin,
+	}
 }
 
 // GetAll handles GET /api/appt_booking/services
 func (sh *ServiceHandler) GetAll(c echo.Context) error {
-	services, err := sh.service.GetAllServices()
+	ctx := c.Request().Context()
+	services, err := sh.service.GetAllServices(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch services",
-		})
+		return err
 	}
 
 	response := make([]ServiceResponse, len(services))
-	for i, s := range services {
-		response[i] = ServiceResponse{
-			ID:          s.ID,
-			Name:        s.Name,
-			Description: s.Description,
-			DurationMin: s.DurationMin,
-			PriceCents:  s.PriceCents,
-		}
+	for i := range services {
+		response[i] = newServiceResponse(&services[i])
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -55,141 +72,119 @@ func (sh *ServiceHandler) GetAll(c echo.Context) error {
 
 // GetByID handles GET /api/appt_booking/services/:id
 func (sh *ServiceHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid service ID",
-		})
+		return appt_booking.Invalid("id", "Invalid service ID")
 	}
 
-	service, err := sh.service.GetServiceByID(id)
+	service, err := sh.service.GetServiceByID(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Service not found",
-		})
+		return err
 	}
 
-	response := ServiceResponse{
-		ID:          service.ID,
-		Name:        service.Name,
-		Description: service.Description,
-		DurationMin: service.DurationMin,
-		PriceCents:  service.PriceCents,
-	}
+	response := newServiceResponse(service)
 
 	return c.JSON(http.StatusOK, response)
 }
 
 // ServiceRequest represents the request for creating/updating a service
 type ServiceRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	DurationMin int    `json:"duration_min"`
-	PriceCents  int    `json:"price_cents"`
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	DurationMin        int    `json:"duration_min"`
+	PriceCents         int    `json:"price_cents"`
+	BufferBeforeMin    int    `json:"buffer_before_min"`    // preparation time blocked before each appointment
+	BufferAfterMin     int    `json:"buffer_after_min"`     // cleanup time blocked after each appointment
+	MinNoticeMin       int    `json:"min_notice_min"`       // bookings must start at least this far from now
+	MaxAdvanceDays     int    `json:"max_advance_days"`     // bookings must start within this many days; 0 = no limit
+	SlotGranularityMin int    `json:"slot_granularity_min"` // bookings start on multiples of this; 0 = 15 minutes
+	CancelNoticeMin    int    `json:"cancel_notice_min"`    // customers cancelling later than this before the start cancel late; 0 = never late
+}
+
+// policy returns the booking policy set by the request
+func (req *ServiceRequest) policy() appt_booking_db.BookingPolicy {
+	return appt_booking_db.BookingPolicy{
+		BufferBeforeMin:    req.BufferBeforeMin,
+		BufferAfterMin:     req.BufferAfterMin,
+		MinNoticeMin:       req.MinNoticeMin,
+		MaxAdvanceDays:     req.MaxAdvanceDays,
+		SlotGranularityMin: req.SlotGranularityMin,
+		CancelNoticeMin:    req.CancelNoticeMin,
+	}
 }
 
 // Create handles POST /api/appt_booking/services
 func (sh *ServiceHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
 	var req ServiceRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Service name is required",
-		})
+		return appt_booking.Invalid("name", "Service name is required")
 	}
 	if req.DurationMin <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Duration must be positive",
-		})
+		return appt_booking.Invalid("duration_min", "Duration must be positive")
 	}
 
-	service, err := sh.service.CreateService(req.Name, req.Description, req.DurationMin, req.PriceCents)
+	service, err := sh.service.CreateService(ctx, req.Name, req.Description, req.DurationMin, req.PriceCents, req.policy())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create service",
-		})
+		return err
 	}
 
-	response := ServiceResponse{
-		ID:          service.ID,
-		Name:        service.Name,
-		Description: service.Description,
-		DurationMin: service.DurationMin,
-		PriceCents:  service.PriceCents,
-	}
+	response := newServiceResponse(service)
 
 	return c.JSON(http.StatusCreated, response)
 }
 
 // Update handles PUT /api/appt_booking/services/:id
 func (sh *ServiceHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid service ID",
-		})
+		return appt_booking.Invalid("id", "Invalid service ID")
 	}
 
 	var req ServiceRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Service name is required",
-		})
+		return appt_booking.Invalid("name", "Service name is required")
 	}
 	if req.DurationMin <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Duration must be positive",
-		})
+		return appt_booking.Invalid("duration_min", "Duration must be positive")
 	}
 
-	service, err := sh.service.UpdateService(id, req.Name, req.Description, req.DurationMin, req.PriceCents)
+	service, err := sh.service.UpdateService(ctx, id, req.Name, req.Description, req.DurationMin, req.PriceCents, req.policy())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update service",
-		})
+		return err
 	}
 
-	response := ServiceResponse{
-		ID:          service.ID,
-		Name:        service.Name,
-		Description: service.Description,
-		DurationMin: service.DurationMin,
-		PriceCents:  service.PriceCents,
-	}
+	response := newServiceResponse(service)
 
 	return c.JSON(http.StatusOK, response)
 }
 
 // Delete handles DELETE /api/appt_booking/services/:id
 func (sh *ServiceHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid service ID",
-		})
+		return appt_booking.Invalid("id", "Invalid service ID")
 	}
 
-	err = sh.service.DeleteService(id)
+	err = sh.service.DeleteService(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete service",
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
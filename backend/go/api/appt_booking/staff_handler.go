@@ -28,17 +28,17 @@ type StaffResponse struct {
 	Email     string `json:"email"`
 	Phone     string `json:"phone"`
 	Role      string `json:"role"`
+	Timezone  string `json:"timezone"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
 
 // GetAll handles GET /api/appt_booking/staff
 func (sh *StaffHandler) GetAll(c echo.Context) error {
-	staffList, err := sh.service.GetAllStaff()
+	ctx := c.Request().Context()
+	staffList, err := sh.service.GetAllStaff(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch staff",
-		})
+		return err
 	}
 
 	response := make([]StaffResponse, len(staffList))
@@ -49,6 +49,7 @@ func (sh *StaffHandler) GetAll(c echo.Context) error {
 			Email:     s.Email,
 			Phone:     s.Phone,
 			Role:      s.Role,
+			Timezone:  s.Timezone,
 			CreatedAt: s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt: s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
@@ -59,19 +60,16 @@ func (sh *StaffHandler) GetAll(c echo.Context) error {
 
 // GetByID handles GET /api/appt_booking/staff/:id
 func (sh *StaffHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid staff ID",
-		})
+		return appt_booking.Invalid("id", "Invalid staff ID")
 	}
 
-	staff, err := sh.service.GetStaffByID(id)
+	staff, err := sh.service.GetStaffByID(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Staff not found",
-		})
+		return err
 	}
 
 	response := StaffResponse{
@@ -80,6 +78,7 @@ func (sh *StaffHandler) GetByID(c echo.Context) error {
 		Email:     staff.Email,
 		Phone:     staff.Phone,
 		Role:      staff.Role,
+		Timezone:  staff.Timezone,
 		CreatedAt: staff.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: staff.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -93,39 +92,32 @@ type StaffRequest struct {
 	Email string `json:"email"`
 	Phone string `json:"phone"`
 	Role  string `json:"role"`
+	// Timezone is an IANA name (e.g. "America/New_York"); empty uses the business default
+	Timezone string `json:"timezone"`
 }
 
 // Create handles POST /api/appt_booking/staff
 func (sh *StaffHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
 	var req StaffRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff name is required",
-		})
+		return appt_booking.Invalid("name", "Staff name is required")
 	}
 	if req.Email == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff email is required",
-		})
+		return appt_booking.Invalid("email", "Staff email is required")
 	}
 	if req.Role == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff role is required",
-		})
+		return appt_booking.Invalid("role", "Staff role is required")
 	}
 
-	staff, err := sh.service.CreateStaff(req.Name, req.Email, req.Phone, req.Role)
+	staff, err := sh.service.CreateStaff(ctx, req.Name, req.Email, req.Phone, req.Role, req.Timezone)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := StaffResponse{
@@ -134,6 +126,7 @@ func (sh *StaffHandler) Create(c echo.Context) error {
 		Email:     staff.Email,
 		Phone:     staff.Phone,
 		Role:      staff.Role,
+		Timezone:  staff.Timezone,
 		CreatedAt: staff.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: staff.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -143,43 +136,32 @@ func (sh *StaffHandler) Create(c echo.Context) error {
 
 // Update handles PUT /api/appt_booking/staff/:id
 func (sh *StaffHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid staff ID",
-		})
+		return appt_booking.Invalid("id", "Invalid staff ID")
 	}
 
 	var req StaffRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return appt_booking.Invalid("body", "Invalid request payload")
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff name is required",
-		})
+		return appt_booking.Invalid("name", "Staff name is required")
 	}
 	if req.Email == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff email is required",
-		})
+		return appt_booking.Invalid("email", "Staff email is required")
 	}
 	if req.Role == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Staff role is required",
-		})
+		return appt_booking.Invalid("role", "Staff role is required")
 	}
 
-	staff, err := sh.service.UpdateStaff(id, req.Name, req.Email, req.Phone, req.Role)
+	staff, err := sh.service.UpdateStaff(ctx, id, req.Name, req.Email, req.Phone, req.Role, req.Timezone)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := StaffResponse{
@@ -188,6 +170,7 @@ func (sh *StaffHandler) Update(c echo.Context) error {
 		Email:     staff.Email,
 		Phone:     staff.Phone,
 		Role:      staff.Role,
+		Timezone:  staff.Timezone,
 		CreatedAt: staff.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: staff.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
@@ -197,19 +180,16 @@ func (sh *StaffHandler) Update(c echo.Context) error {
 
 // Delete handles DELETE /api/appt_booking/staff/:id
 func (sh *StaffHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid staff ID",
-		})
+		return appt_booking.Invalid("id", "Invalid staff ID")
 	}
 
-	err = sh.service.DeleteStaff(id)
+	err = sh.service.DeleteStaff(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -219,19 +199,16 @@ func (sh *StaffHandler) Delete(c echo.Context) error {
 
 // GetByService retrieves all staff members who offer a specific service
 func (sh *StaffHandler) GetByService(c echo.Context) error {
+	ctx := c.Request().Context()
 	serviceIDStr := c.Param("serviceId")
 	serviceID, err := strconv.Atoi(serviceIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid service ID",
-		})
+		return appt_booking.Invalid("serviceId", "Invalid service ID")
 	}
 
-	staffList, err := sh.service.GetStaffForService(serviceID)
+	staffList, err := sh.service.GetStaffForService(ctx, serviceID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch staff for service",
-		})
+		return err
 	}
 
 	response := make([]StaffResponse, len(staffList))
@@ -242,6 +219,7 @@ func (sh *StaffHandler) GetByService(c echo.Context) error {
 			Email:     s.Email,
 			Phone:     s.Phone,
 			Role:      s.Role,
+			Timezone:  s.Timezone,
 			CreatedAt: s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt: s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
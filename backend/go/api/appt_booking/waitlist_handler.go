@@ -0,0 +1,317 @@
+package appt_booking
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// WaitlistHandler handles waitlist endpoints
+type WaitlistHandler struct {
+	service *appt_booking.ApptBookingService
+}
+
+// NewWaitlistHandler creates a new waitlist handler
+func NewWaitlistHandler(service *appt_booking.ApptBookingService) *WaitlistHandler {
+	return &WaitlistHandler{
+		service: service,
+	}
+}
+
+// JoinWaitlistRequest represents the request for joining the waitlist
+type JoinWaitlistRequest struct {
+	CustomerName  string `json:"customer_name"`
+	CustomerEmail string `json:"customer_email"` // Optional for customers: defaults to their own
+	CustomerPhone string `json:"customer_phone"`
+	ServiceID     int    `json:"service_id"`
+	StaffID       int    `json:"staff_id"`     // Optional: 0 accepts any staff member
+	WindowStart   string `json:"window_start"` // ISO 8601; offered slots start in [window_start, window_end)
+	WindowEnd     string `json:"window_end"`
+	Priority      int    `json:"priority"` // Staff only: higher is offered first
+	Notes         string `json:"notes"`
+}
+
+// AcceptOfferRequest represents the request for accepting a waitlist offer
+type AcceptOfferRequest struct {
+	Token string `json:"token"`
+}
+
+// WaitlistEntryResponse represents the response for a waitlist entry
+type WaitlistEntryResponse struct {
+	ID          int                    `json:"id"`
+	CustomerID  int                    `json:"customer_id"`
+	ServiceID   int                    `json:"service_id"`
+	StaffID     *int                   `json:"staff_id"` // null = any staff member
+	WindowStart string                 `json:"window_start"`
+	WindowEnd   string                 `json:"window_end"`
+	Priority    int                    `json:"priority"`
+	Status      string                 `json:"status"`
+	Notes       string                 `json:"notes"`
+	CreatedAt   string                 `json:"created_at"`
+	UpdatedAt   string                 `json:"updated_at"`
+	Offer       *WaitlistOfferResponse `json:"offer,omitempty"` // set while the entry is offered
+}
+
+// WaitlistOfferResponse represents the slot held for an offered waitlist entry
+type WaitlistOfferResponse struct {
+	StaffID             int    `json:"staff_id"`
+	AppointmentDatetime string `json:"appointment_datetime"` // RFC 3339 in the staff member's timezone
+	Timezone            string `json:"timezone"`
+	ExpiresAt           string `json:"expires_at"`
+	Token               string `json:"token"`
+}
+
+// GetAll handles GET /api/appt_booking/waitlist
+// Filters: service_id, staff_id, customer_id and status (comma-separated). Entries are listed
+// in the order they are offered slots. Providers see entries they could serve; customers
+// see their own.
+func (wh *WaitlistHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	var filter appt_booking_db.WaitlistFilter
+	var err error
+
+	for param, dst := range map[string]*int{
+		"service_id":  &filter.ServiceID,
+		"staff_id":    &filter.StaffID,
+		"customer_id": &filter.CustomerID,
+	} {
+		if value := c.QueryParam(param); value != "" {
+			*dst, err = strconv.Atoi(value)
+			if err != nil || *dst <= 0 {
+				return appt_booking.Invalid(param, "Invalid "+strings.ReplaceAll(param, "_", " "))
+			}
+		}
+	}
+	if statusStr := c.QueryParam("status"); statusStr != "" {
+		for _, status := range strings.Split(statusStr, ",") {
+			filter.Statuses = append(filter.Statuses, strings.TrimSpace(status))
+		}
+	}
+
+	principal := appt_booking.PrincipalFrom(ctx)
+	switch {
+	case principal.HasRole(appt_booking_db.RoleProvider):
+		if filter.StaffID != 0 && !principal.IsStaff(filter.StaffID) {
+			return appt_booking.Forbidden("providers can only list their own waitlist")
+		}
+		filter.StaffID = *principal.StaffID
+	case principal.HasRole(appt_booking_db.RoleCustomer):
+		if filter.StaffID != 0 || filter.CustomerID != 0 {
+			return appt_booking.Forbidden("customers can only list their own waitlist entries")
+		}
+		if !principal.EmailVerified {
+			return appt_booking.Forbidden("verify your email address to see your waitlist entries")
+		}
+		customerID, err := wh.service.CustomerIDForEmail(ctx, principal.Email)
+		if err != nil {
+			return err
+		}
+		if customerID == 0 {
+			return c.JSON(http.StatusOK, []WaitlistEntryResponse{})
+		}
+		filter.CustomerID = customerID
+	}
+
+	entries, err := wh.service.ListWaitlist(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	response := make([]WaitlistEntryResponse, len(entries))
+	for i := range entries {
+		if response[i], err = wh.newWaitlistEntryResponse(ctx, &entries[i]); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetByID handles GET /api/appt_booking/waitlist/:id
+func (wh *WaitlistHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	entry, err := wh.accessibleEntry(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := wh.newWaitlistEntryResponse(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Join handles POST /api/appt_booking/waitlist
+// If a matching slot is already free it is offered straight away.
+func (wh *WaitlistHandler) Join(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req JoinWaitlistRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	// Customers join for themselves, at the default priority
+	if principal := appt_booking.PrincipalFrom(ctx); principal.HasRole(appt_booking_db.RoleCustomer) {
+		if req.CustomerEmail == "" {
+			req.CustomerEmail = principal.Email
+		} else if !strings.EqualFold(req.CustomerEmail, principal.Email) {
+			return appt_booking.Forbidden("customers can only join the waitlist for themselves")
+		}
+		if req.Priority != 0 {
+			return appt_booking.Forbidden("customers cannot set a waitlist priority")
+		}
+	}
+
+	windowStart, err := parseAppointmentDatetime(req.WindowStart)
+	if err != nil {
+		return appt_booking.Invalid("window_start", "Invalid window start. Use YYYY-MM-DDTHH:MM:SS or ISO 8601")
+	}
+	windowEnd, err := parseAppointmentDatetime(req.WindowEnd)
+	if err != nil {
+		return appt_booking.Invalid("window_end", "Invalid window end. Use YYYY-MM-DDTHH:MM:SS or ISO 8601")
+	}
+
+	entry, err := wh.service.JoinWaitlist(ctx,
+		req.CustomerName,
+		req.CustomerEmail,
+		req.CustomerPhone,
+		req.ServiceID,
+		req.StaffID,
+		windowStart,
+		windowEnd,
+		req.Priority,
+		req.Notes,
+	)
+	if err != nil {
+		return err
+	}
+
+	response, err := wh.newWaitlistEntryResponse(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// Leave handles DELETE /api/appt_booking/waitlist/:id
+// The entry is kept as cancelled; a slot held for it is offered to the next entry.
+func (wh *WaitlistHandler) Leave(c echo.Context) error {
+	ctx := c.Request().Context()
+	entry, err := wh.accessibleEntry(c)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := wh.service.LeaveWaitlist(ctx, entry.ID)
+	if err != nil {
+		return err
+	}
+
+	response, err := wh.newWaitlistEntryResponse(ctx, cancelled)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Accept handles POST /api/appt_booking/waitlist/:id/accept
+// Books the offered slot with the token sent in the offer.
+func (wh *WaitlistHandler) Accept(c echo.Context) error {
+	ctx := c.Request().Context()
+	entry, err := wh.accessibleEntry(c)
+	if err != nil {
+		return err
+	}
+	var req AcceptOfferRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	appointment, err := wh.service.AcceptWaitlistOffer(ctx, entry.ID, req.Token)
+	if err != nil {
+		return err
+	}
+
+	loc, err := wh.service.LocationForStaff(ctx, appointment.StaffID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, newAppointmentResponse(appointment, loc))
+}
+
+// accessibleEntry loads the entry named by the :id parameter for the request's principal.
+// Customers may only access their own entries; others are reported as not found.
+func (wh *WaitlistHandler) accessibleEntry(c echo.Context) (*appt_booking_db.WaitlistEntry, error) {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, appt_booking.Invalid("id", "Invalid waitlist entry ID")
+	}
+
+	entry, err := wh.service.GetWaitlistEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	principal := appt_booking.PrincipalFrom(ctx)
+	switch {
+	case principal.HasRole(appt_booking_db.RoleAdmin):
+		return entry, nil
+	case principal.HasRole(appt_booking_db.RoleProvider):
+		if entry.StaffID == nil || principal.IsStaff(*entry.StaffID) {
+			return entry, nil
+		}
+	case principal.HasRole(appt_booking_db.RoleCustomer) && principal.EmailVerified:
+		customerID, err := wh.service.CustomerIDForEmail(ctx, principal.Email)
+		if err != nil {
+			return nil, err
+		}
+		if customerID == entry.CustomerID {
+			return entry, nil
+		}
+	}
+	return nil, appt_booking.NotFound("waitlist entry")
+}
+
+// newWaitlistEntryResponse converts an entry, including the held slot while it is offered
+func (wh *WaitlistHandler) newWaitlistEntryResponse(ctx context.Context, entry *appt_booking_db.WaitlistEntry) (WaitlistEntryResponse, error) {
+	response := WaitlistEntryResponse{
+		ID:          entry.ID,
+		CustomerID:  entry.CustomerID,
+		ServiceID:   entry.ServiceID,
+		StaffID:     entry.StaffID,
+		WindowStart: entry.WindowStart.Format("2006-01-02T15:04:05Z07:00"),
+		WindowEnd:   entry.WindowEnd.Format("2006-01-02T15:04:05Z07:00"),
+		Priority:    entry.Priority,
+		Status:      entry.Status,
+		Notes:       entry.Notes,
+		CreatedAt:   entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   entry.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if entry.Status != appt_booking_db.WaitlistStatusOffered {
+		return response, nil
+	}
+
+	hold, err := wh.service.GetWaitlistOffer(ctx, entry.ID)
+	if err != nil || hold == nil {
+		return response, err
+	}
+	loc, err := wh.service.LocationForStaff(ctx, hold.StaffID)
+	if err != nil {
+		return WaitlistEntryResponse{}, err
+	}
+	response.Offer = &WaitlistOfferResponse{
+		StaffID:             hold.StaffID,
+		AppointmentDatetime: hold.StartsAt.In(loc).Format("2006-01-02T15:04:05Z07:00"),
+		Timezone:            loc.String(),
+		ExpiresAt:           hold.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		Token:               hold.Token,
+	}
+	return response, nil
+}
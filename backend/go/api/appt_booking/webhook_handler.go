@@ -0,0 +1,206 @@
+package appt_booking
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// WebhookHandler handles the admin endpoints for webhook subscriptions and their delivery log
+type WebhookHandler struct {
+	service *appt_booking.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service *appt_booking.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+	}
+}
+
+// WebhookRequest represents the request for creating/updating a webhook subscription
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"` // empty = every event
+	Secret      string   `json:"secret"`      // optional; generated on create and kept on update when empty
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // defaults to true
+}
+
+// active returns whether the request enables the subscription
+func (req *WebhookRequest) active() bool {
+	return req.Active == nil || *req.Active
+}
+
+// WebhookResponse represents a webhook subscription. The secret is only included when it
+// was just set, i.e. in responses to creating a subscription or changing its secret.
+type WebhookResponse struct {
+	ID          int      `json:"id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Secret      string   `json:"secret,omitempty"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// newWebhookResponse converts a subscription, including its secret if showSecret is set
+func newWebhookResponse(s *appt_booking_db.WebhookSubscription, showSecret bool) WebhookResponse {
+	response := WebhookResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		EventTypes:  s.EventTypes,
+		Description: s.Description,
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   s.UpdatedAt.Format(time.RFC3339),
+	}
+	if response.EventTypes == nil {
+		response.EventTypes = []string{}
+	}
+	if showSecret {
+		response.Secret = s.Secret
+	}
+	return response
+}
+
+// parseWebhookID reads the :id path parameter
+func parseWebhookID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, appt_booking.Invalid("id", "Invalid webhook ID")
+	}
+	return id, nil
+}
+
+// GetAll handles GET /api/admin/webhooks
+func (wh *WebhookHandler) GetAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	subscriptions, err := wh.service.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	response := make([]WebhookResponse, len(subscriptions))
+	for i := range subscriptions {
+		response[i] = newWebhookResponse(&subscriptions[i], false)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetByID handles GET /api/admin/webhooks/:id
+func (wh *WebhookHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := wh.service.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, newWebhookResponse(subscription, false))
+}
+
+// Create handles POST /api/admin/webhooks
+func (wh *WebhookHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	subscription, err := wh.service.CreateSubscription(ctx, req.URL, req.EventTypes, req.Secret, req.Description, req.active())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, newWebhookResponse(subscription, true))
+}
+
+// Update handles PUT /api/admin/webhooks/:id
+func (wh *WebhookHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return appt_booking.Invalid("body", "Invalid request payload")
+	}
+
+	subscription, err := wh.service.UpdateSubscription(ctx, id, req.URL, req.EventTypes, req.Secret, req.Description, req.active())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, newWebhookResponse(subscription, req.Secret != ""))
+}
+
+// Delete handles DELETE /api/admin/webhooks/:id
+func (wh *WebhookHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := wh.service.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// Deliveries handles GET /api/admin/webhooks/:id/deliveries, the latest deliveries first.
+// The optional limit query parameter caps how many are returned.
+func (wh *WebhookHandler) Deliveries(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return appt_booking.Invalid("limit", "limit must be a positive number")
+		}
+	}
+
+	deliveries, err := wh.service.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		return err
+	}
+	if deliveries == nil {
+		deliveries = []appt_booking_db.WebhookDelivery{}
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver handles POST /api/admin/webhooks/:id/deliveries/:deliveryId/redeliver
+func (wh *WebhookHandler) Redeliver(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		return appt_booking.Invalid("deliveryId", "Invalid delivery ID")
+	}
+
+	delivery, err := wh.service.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusAccepted, delivery)
+}
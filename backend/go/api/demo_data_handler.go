@@ -36,11 +36,10 @@ type DemoDataResponse struct {
 
 // GetAll handles GET /api/demo-data
 func (dh *DemoDataHandler) GetAll(c echo.Context) error {
-	records, err := dh.demoDataService.GetAllDemoData()
+	ctx := c.Request().Context()
+	records, err := dh.demoDataService.GetAllDemoData(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch demo data",
-		})
+		return err
 	}
 
 	// Convert to response format
@@ -59,6 +58,7 @@ func (dh *DemoDataHandler) GetAll(c echo.Context) error {
 
 // Upsert handles POST /api/demo-data (upsert operation)
 func (dh *DemoDataHandler) Upsert(c echo.Context) error {
+	ctx := c.Request().Context()
 	var req DemoDataRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -75,11 +75,9 @@ func (dh *DemoDataHandler) Upsert(c echo.Context) error {
 
 	// For simplicity, we'll always create a new record (id = 0)
 	// In a real app, you'd pass the ID from the request for updates
-	record, err := dh.demoDataService.UpsertDemoData(0, req.Content)
+	record, err := dh.demoDataService.UpsertDemoData(ctx, 0, req.Content)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to save demo data",
-		})
+		return err
 	}
 
 	response := DemoDataResponse{
@@ -94,6 +92,7 @@ func (dh *DemoDataHandler) Upsert(c echo.Context) error {
 
 // GetByID handles GET /api/demo-data/:id
 func (dh *DemoDataHandler) GetByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -102,9 +101,11 @@ func (dh *DemoDataHandler) GetByID(c echo.Context) error {
 		})
 	}
 
-	record, err := dh.demoDataService.GetDemoDataByID(id)
+	record, err := dh.demoDataService.GetDemoDataByID(ctx, id)
 	if err != nil {
-		// For simplicity, if not found, return 404
+		return err
+	}
+	if record == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
 			"error": "Demo data not found",
 		})
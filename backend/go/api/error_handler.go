@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// MIMEApplicationProblemJSON is the media type for RFC 7807 problem details
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type     string                    `json:"type"`
+	Title    string                    `json:"title"`
+	Status   int                       `json:"status"`
+	Detail   string                    `json:"detail,omitempty"`
+	Instance string                    `json:"instance,omitempty"`
+	Errors   []appt_booking.FieldError `json:"errors,omitempty"` // field-level validation details
+}
+
+// problemStatus maps domain error kinds to HTTP status codes
+var problemStatus = map[appt_booking.ErrorKind]int{
+	appt_booking.KindNotFound:           http.StatusNotFound,
+	appt_booking.KindValidation:         http.StatusBadRequest,
+	appt_booking.KindConflict:           http.StatusConflict,
+	appt_booking.KindPreconditionFailed: http.StatusPreconditionFailed,
+	appt_booking.KindUnauthorized:       http.StatusUnauthorized,
+	appt_booking.KindForbidden:          http.StatusForbidden,
+}
+
+// HTTPErrorHandler renders every error returned by a handler as application/problem+json.
+// Domain errors map to 404/400/409/412/401/403; echo.HTTPErrors keep their status; deadlines map
+// to 504 and database unavailability to 503; anything else is logged and reported as a
+// 500 without exposing its message.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	problem := newProblem(err)
+	problem.Instance = c.Request().URL.Path
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("[%s] %s: %v", c.Request().Method, c.Path(), err)
+	}
+
+	if problem.Status == http.StatusUnauthorized {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	}
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		err = c.JSON(problem.Status, problem)
+	}
+	if err != nil {
+		log.Printf("failed to write error response: %v", err)
+	}
+}
+
+// newProblem converts an error into problem details
+func newProblem(err error) Problem {
+	var domainErr *appt_booking.Error
+	if errors.As(err, &domainErr) {
+		status, ok := problemStatus[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		problem := problemFor(status)
+		problem.Type = "/problems/" + string(domainErr.Kind)
+		problem.Detail = domainErr.Message
+		problem.Errors = domainErr.Fields
+		return problem
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if internal, ok := httpErr.Internal.(*echo.HTTPError); ok {
+			httpErr = internal
+		}
+		problem := problemFor(httpErr.Code)
+		if httpErr.Message != nil {
+			problem.Detail = fmt.Sprint(httpErr.Message)
+		}
+		return problem
+	}
+
+	switch {
+	case isTimeout(err):
+		problem := problemFor(http.StatusGatewayTimeout)
+		problem.Detail = "the request did not complete in time"
+		return problem
+	case isUnavailable(err):
+		problem := problemFor(http.StatusServiceUnavailable)
+		problem.Detail = "the service is temporarily unavailable, please retry"
+		return problem
+	}
+
+	return problemFor(http.StatusInternalServerError)
+}
+
+// isTimeout reports whether err comes from a request or query deadline
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// query_canceled: raised when a statement is cancelled by its context or statement_timeout
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
+
+// isUnavailable reports whether err means the database could not serve the request,
+// or the client went away before it completed
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	// Class 08: connection exception, 53: insufficient resources (e.g. too_many_connections),
+	// 57P01-57P03: server shutting down or not yet accepting connections
+	switch pqErr.Code.Class() {
+	case "08", "53":
+		return true
+	}
+	switch pqErr.Code {
+	case "57P01", "57P02", "57P03":
+		return true
+	}
+	return false
+}
+
+// problemFor returns a problem with the standard title for status
+func problemFor(status int) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+
+	"k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantDetail string
+		wantFields int
+	}{
+		{
+			name:       "not found",
+			err:        appt_booking.NotFound("staff"),
+			wantStatus: http.StatusNotFound,
+			wantType:   "/problems/not_found",
+			wantDetail: "staff not found",
+		},
+		{
+			name:       "validation with field",
+			err:        appt_booking.Invalid("customer_email", "invalid email format"),
+			wantStatus: http.StatusBadRequest,
+			wantType:   "/problems/validation",
+			wantDetail: "invalid email format",
+			wantFields: 1,
+		},
+		{
+			name:       "conflict",
+			err:        appt_booking.Conflict("appointment time conflicts with an existing appointment", nil),
+			wantStatus: http.StatusConflict,
+			wantType:   "/problems/conflict",
+			wantDetail: "appointment time conflicts with an existing appointment",
+		},
+		{
+			name:       "precondition failed",
+			err:        appt_booking.PreconditionFailed("cannot move appointment from completed to cancelled", nil),
+			wantStatus: http.StatusPreconditionFailed,
+			wantType:   "/problems/precondition_failed",
+			wantDetail: "cannot move appointment from completed to cancelled",
+		},
+		{
+			name:       "unauthorized",
+			err:        appt_booking.Unauthorized("invalid email or password"),
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "/problems/unauthorized",
+			wantDetail: "invalid email or password",
+		},
+		{
+			name:       "forbidden",
+			err:        appt_booking.Forbidden("admin role required"),
+			wantStatus: http.StatusForbidden,
+			wantType:   "/problems/forbidden",
+			wantDetail: "admin role required",
+		},
+		{
+			name:       "echo http error",
+			err:        echo.NewHTTPError(http.StatusMethodNotAllowed, "Method Not Allowed"),
+			wantStatus: http.StatusMethodNotAllowed,
+			wantType:   "about:blank",
+			wantDetail: "Method Not Allowed",
+		},
+		{
+			name:       "request deadline",
+			err:        fmt.Errorf("list staff: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantType:   "about:blank",
+			wantDetail: "the request did not complete in time",
+		},
+		{
+			name:       "query canceled by statement timeout",
+			err:        &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+			wantStatus: http.StatusGatewayTimeout,
+			wantType:   "about:blank",
+			wantDetail: "the request did not complete in time",
+		},
+		{
+			name:       "connection failure",
+			err:        &pq.Error{Code: "08006", Message: "connection failure"},
+			wantStatus: http.StatusServiceUnavailable,
+			wantType:   "about:blank",
+			wantDetail: "the service is temporarily unavailable, please retry",
+		},
+		{
+			name:       "too many connections",
+			err:        &pq.Error{Code: "53300", Message: "too many connections"},
+			wantStatus: http.StatusServiceUnavailable,
+			wantType:   "about:blank",
+			wantDetail: "the service is temporarily unavailable, please retry",
+		},
+		{
+			name:       "bad driver connection",
+			err:        driver.ErrBadConn,
+			wantStatus: http.StatusServiceUnavailable,
+			wantType:   "about:blank",
+			wantDetail: "the service is temporarily unavailable, please retry",
+		},
+		{
+			name:       "unexpected error hides message",
+			err:        errors.New("pq: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "about:blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = HTTPErrorHandler
+			e.GET("/test", func(c echo.Context) error { return tt.err })
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); ct != MIMEApplicationProblemJSON {
+				t.Errorf("expected content type %s, got %s", MIMEApplicationProblemJSON, ct)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Status != tt.wantStatus {
+				t.Errorf("expected problem status %d, got %d", tt.wantStatus, problem.Status)
+			}
+			if problem.Type != tt.wantType {
+				t.Errorf("expected type %s, got %s", tt.wantType, problem.Type)
+			}
+			if problem.Detail != tt.wantDetail {
+				t.Errorf("expected detail %q, got %q", tt.wantDetail, problem.Detail)
+			}
+			if len(problem.Errors) != tt.wantFields {
+				t.Errorf("expected %d field errors, got %d", tt.wantFields, len(problem.Errors))
+			}
+			if problem.Instance != "/test" {
+				t.Errorf("expected instance /test, got %s", problem.Instance)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorHandler_WrappedDomainError(t *testing.T) {
+	cause := errors.New("appointment status was changed by another request")
+	err := appt_booking.Conflict(cause.Error(), cause)
+
+	if !errors.Is(err, cause) {
+		t.Error("expected domain error to unwrap to its cause")
+	}
+	if got := newProblem(err).Status; got != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, got)
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// TokenVerifier validates access tokens
+type TokenVerifier interface {
+	VerifyAccessToken(token string) (*appt_booking_service.Principal, error)
+}
+
+// Authenticate returns a middleware that reads a bearer token from the Authorization
+// header and stores the caller's principal in the request context. Requests without the
+// header stay anonymous so public routes keep working; RequireRole rejects them where a
+// login is needed. A malformed header or invalid token is rejected with 401.
+func Authenticate(verifier TokenVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				return appt_booking_service.Unauthorized("authorization header must be \"Bearer <token>\"")
+			}
+			principal, err := verifier.VerifyAccessToken(strings.TrimSpace(token))
+			if err != nil {
+				return err
+			}
+
+			ctx := appt_booking_service.WithPrincipal(c.Request().Context(), principal)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// RequireRole returns a middleware that only lets through callers with one of roles.
+// Anonymous callers get 401, callers with another role 403.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := appt_booking_service.PrincipalFrom(c.Request().Context())
+			if principal == nil {
+				return appt_booking_service.Unauthorized("authentication required")
+			}
+			if !principal.HasRole(roles...) {
+				return appt_booking_service.Forbidden("insufficient role for this operation")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// fakeVerifier accepts the token "good" as a customer and rejects everything else
+type fakeVerifier struct{}
+
+func (fakeVerifier) VerifyAccessToken(token string) (*appt_booking_service.Principal, error) {
+	if token != "good" {
+		return nil, appt_booking_service.Unauthorized("invalid token")
+	}
+	return &appt_booking_service.Principal{UserID: 1, Email: "bob@example.com", Role: "customer"}, nil
+}
+
+func TestAuthenticateAndRequireRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		roles    []string // nil: no RequireRole
+		wantKind appt_booking_service.ErrorKind
+	}{
+		{"anonymous on public route", "", nil, ""},
+		{"anonymous on protected route", "", []string{"customer"}, appt_booking_service.KindUnauthorized},
+		{"valid token", "Bearer good", []string{"customer"}, ""},
+		{"scheme is case-insensitive", "bearer good", []string{"customer"}, ""},
+		{"invalid token", "Bearer bad", nil, appt_booking_service.KindUnauthorized},
+		{"wrong scheme", "Basic Ym9iOnB3", nil, appt_booking_service.KindUnauthorized},
+		{"wrong role", "Bearer good", []string{"admin"}, appt_booking_service.KindForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var principal *appt_booking_service.Principal
+			handler := func(c echo.Context) error {
+				principal = appt_booking_service.PrincipalFrom(c.Request().Context())
+				return nil
+			}
+			if tt.roles != nil {
+				handler = RequireRole(tt.roles...)(handler)
+			}
+			err := Authenticate(fakeVerifier{})(handler)(c)
+
+			if got := appt_booking_service.KindOf(err); got != tt.wantKind {
+				t.Fatalf("expected %q error, got %v", tt.wantKind, err)
+			}
+			var domainErr *appt_booking_service.Error
+			if err != nil && !errors.As(err, &domainErr) {
+				t.Fatalf("expected a domain error, got %T", err)
+			}
+			if err == nil && tt.header != "" && (principal == nil || principal.UserID != 1) {
+				t.Errorf("expected principal in request context, got %+v", principal)
+			}
+		})
+	}
+}
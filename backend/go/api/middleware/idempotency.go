@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"k8s-fullstack-blueprint-backend/db"
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed" // "true" on responses replayed for a retry
+)
+
+// Idempotency defaults and limits
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+	maxIdempotencyKeyLength       = 255
+	idempotencyRetryAfterSeconds  = 1
+)
+
+// IdempotencyStore keeps idempotency keys and the responses to their requests
+type IdempotencyStore interface {
+	Begin(ctx context.Context, scope, key, requestHash string, now, lockUntil, expiresAt time.Time) (*db.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, contentType, location string, body []byte) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyConfig configures the Idempotency middleware
+type IdempotencyConfig struct {
+	TTL         time.Duration // how long a key's response is replayed
+	LockTimeout time.Duration // how long a request holds its key before a retry may take over; longer than any request
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c IdempotencyConfig) withDefaults() IdempotencyConfig {
+	if c.TTL <= 0 {
+		c.TTL = defaultIdempotencyTTL
+	}
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = defaultIdempotencyLockTimeout
+	}
+	return c
+}
+
+// Idempotency returns a middleware that makes requests with an Idempotency-Key header
+// safe to retry. The first request with a key runs and its response is stored; a retry
+// with the same key and request gets that response again, marked Idempotent-Replayed,
+// instead of running again. Reusing a key for a different request is rejected with 422,
+// and a retry that arrives while the first request is still running with 409. Keys are
+// scoped to the method, path and caller. Anonymous callers cannot be told apart, so their
+// keys are scoped to the request itself instead: a stored response is only replayed for
+// an identical request, and reusing a key for a different one simply runs it. Server
+// errors are not stored, so the request can be retried with the same key. Requests
+// without the header are not affected.
+//
+// Use it after the role checks of a route, so that the caller is known.
+func Idempotency(store IdempotencyStore, config IdempotencyConfig) echo.MiddlewareFunc {
+	config = config.withDefaults()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return appt_booking_service.Invalid(HeaderIdempotencyKey, "idempotency key must not exceed 255 characters")
+			}
+
+			req := c.Request()
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return appt_booking_service.Invalid("body", "could not read request body")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			hash := idempotencyRequestHash(req, body)
+			scope := idempotencyScope(c, hash)
+
+			now := time.Now()
+			record, claimed, err := store.Begin(req.Context(), scope, key, hash, now, now.Add(config.LockTimeout), now.Add(config.TTL))
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				return replayIdempotent(c, record, hash)
+			}
+			return runIdempotent(c, next, store, scope, key)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key is held by an earlier request
+func replayIdempotent(c echo.Context, record *db.IdempotencyRecord, hash string) error {
+	if record != nil && record.RequestHash != hash {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "idempotency key was already used for a different request")
+	}
+	if record == nil || !record.Completed() {
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(idempotencyRetryAfterSeconds))
+		return appt_booking_service.Conflict("a request with this idempotency key is still in progress", nil)
+	}
+
+	header := c.Response().Header()
+	if record.ContentType != "" {
+		header.Set(echo.HeaderContentType, record.ContentType)
+	}
+	if record.Location != "" {
+		header.Set(echo.HeaderLocation, record.Location)
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+	c.Response().WriteHeader(record.StatusCode)
+	_, err := c.Response().Write(record.Body)
+	return err
+}
+
+// runIdempotent runs the request that claimed key and stores its response, or releases
+// the key if it failed with a server error or did not finish
+func runIdempotent(c echo.Context, next echo.HandlerFunc, store IdempotencyStore, scope, key string) error {
+	recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+	c.Response().Writer = recorder
+
+	finished := false
+	defer func() {
+		if !finished {
+			// Panicked; let a retry run the request again
+			releaseIdempotencyKey(store, scope, key)
+		}
+	}()
+
+	err := next(c)
+	if err != nil {
+		// Render the error now so its response can be stored
+		c.Error(err)
+	}
+	finished = true
+
+	response := c.Response()
+	if response.Status >= http.StatusInternalServerError || !response.Committed {
+		releaseIdempotencyKey(store, scope, key)
+		return err
+	}
+	// The response has been sent; the handler's context may have ended with it
+	ctx := context.WithoutCancel(c.Request().Context())
+	if storeErr := store.Complete(ctx, scope, key, response.Status, response.Header().Get(echo.HeaderContentType),
+		response.Header().Get(echo.HeaderLocation), recorder.body.Bytes()); storeErr != nil {
+		log.Printf("store response for idempotency key %q: %v", key, storeErr)
+		releaseIdempotencyKey(store, scope, key)
+	}
+	return err
+}
+
+// releaseIdempotencyKey frees key, logging a failure; the key's claim then lapses by itself
+func releaseIdempotencyKey(store IdempotencyStore, scope, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Release(ctx, scope, key); err != nil {
+		log.Printf("release idempotency key %q: %v", key, err)
+	}
+}
+
+// idempotencyScope is what a key is unique within: the method, path and caller, so that
+// callers cannot see each other's responses. For anonymous callers it is the request's
+// hash, so only a client that sends the very same request gets its response.
+func idempotencyScope(c echo.Context, requestHash string) string {
+	caller := "anonymous:" + requestHash
+	if principal := appt_booking_service.PrincipalFrom(c.Request().Context()); principal != nil {
+		caller = "user:" + strconv.Itoa(principal.UserID)
+	}
+	return c.Request().Method + " " + c.Request().URL.Path + " " + caller
+}
+
+// idempotencyRequestHash identifies a request's content, to tell a retry from a different
+// request reusing its key
+func idempotencyRequestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, req.Method+"\n"+req.URL.RequestURI()+"\n"+req.Header.Get(echo.HeaderContentType)+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body as it is written
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
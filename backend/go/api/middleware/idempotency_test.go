@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"k8s-fullstack-blueprint-backend/db"
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+// memoryIdempotencyStore claims keys the way db.IdempotencyRepository does
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]db.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]db.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, scope, key, requestHash string, now, lockUntil, expiresAt time.Time) (*db.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.records[scope+"\x00"+key]
+	if ok && existing.ExpiresAt.After(now) &&
+		(existing.Completed() || existing.LockedUntil.After(now) || existing.RequestHash != requestHash) {
+		return &existing, false, nil
+	}
+	record := db.IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash, LockedUntil: lockUntil, CreatedAt: now, ExpiresAt: expiresAt}
+	s.records[scope+"\x00"+key] = record
+	return &record, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, scope, key string, statusCode int, contentType, location string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"\x00"+key]; ok && !record.Completed() {
+		record.StatusCode, record.ContentType, record.Location, record.Body = statusCode, contentType, location, body
+		s.records[scope+"\x00"+key] = record
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"\x00"+key]; ok && !record.Completed() {
+		delete(s.records, scope+"\x00"+key)
+	}
+	return nil
+}
+
+// idempotencyRequest sends a POST with body and key, if any, through mw to handler as user 1
+func idempotencyRequest(mw echo.MiddlewareFunc, handler echo.HandlerFunc, key, body string) (*httptest.ResponseRecorder, error) {
+	return idempotencyRequestAs(&appt_booking_service.Principal{UserID: 1}, "/api/appt_booking/appointments", mw, handler, key, body)
+}
+
+// idempotencyRequestAs sends a POST to path with body and key, if any, through mw to
+// handler as principal; nil sends it anonymously
+func idempotencyRequestAs(principal *appt_booking_service.Principal, path string, mw echo.MiddlewareFunc, handler echo.HandlerFunc, key, body string) (*httptest.ResponseRecorder, error) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if principal != nil {
+		req = req.WithContext(appt_booking_service.WithPrincipal(req.Context(), principal))
+	}
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	err := mw(handler)(e.NewContext(req, rec))
+	return rec, err
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		c.Response().Header().Set(echo.HeaderLocation, "/api/appt_booking/appointments/7")
+		return c.JSON(http.StatusCreated, map[string]int{"id": 6 + calls})
+	}
+
+	first, err := idempotencyRequest(mw, handler, "key-1", `{"staff_id":1}`)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	retry, err := idempotencyRequest(mw, handler, "key-1", `{"staff_id":1}`)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the first response %d %q, got %d %q", first.Code, first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get(HeaderIdempotentReplayed) != "true" || retry.Header().Get(echo.HeaderLocation) != "/api/appt_booking/appointments/7" {
+		t.Errorf("unexpected replay headers %v", retry.Header())
+	}
+
+	// Another key, or no key, runs the handler again
+	if _, err := idempotencyRequest(mw, handler, "key-2", `{"staff_id":1}`); err != nil {
+		t.Fatalf("other key: %v", err)
+	}
+	if _, err := idempotencyRequest(mw, handler, "", `{"staff_id":1}`); err != nil {
+		t.Fatalf("no key: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 handler runs, got %d", calls)
+	}
+}
+
+func TestIdempotency_ScopedToCaller(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"id": calls})
+	}
+
+	// Another caller with the same key gets a response of its own
+	for _, userID := range []int{1, 2} {
+		rec, err := idempotencyRequestAs(&appt_booking_service.Principal{UserID: userID}, "/api/appt_booking/appointments", mw, handler, "key-1", `{}`)
+		if err != nil || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+			t.Fatalf("user %d: expected a fresh response, got %v, %v", userID, rec.Header(), err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected every caller's request to run, got %d runs", calls)
+	}
+}
+
+func TestIdempotency_AnonymousDemoDataUpsert(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"id": calls})
+	}
+
+	first, err := idempotencyRequestAs(nil, "/api/demo-data", mw, handler, "key-1", `{"content":"hello"}`)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	retry, err := idempotencyRequestAs(nil, "/api/demo-data", mw, handler, "key-1", `{"content":"hello"}`)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if calls != 1 || retry.Header().Get(HeaderIdempotentReplayed) != "true" || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the retry to replay %q, got %q after %d runs", first.Body.String(), retry.Body.String(), calls)
+	}
+
+	// Another client picking the same key for its own request does not get that response
+	other, err := idempotencyRequestAs(nil, "/api/demo-data", mw, handler, "key-1", `{"content":"bye"}`)
+	if err != nil || other.Code != http.StatusCreated || other.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Fatalf("expected a fresh response, got %d %v, %v", other.Code, other.Header(), err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the other request to run, got %d runs", calls)
+	}
+}
+
+func TestIdempotency_RejectsReusedKey(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	handler := func(c echo.Context) error { return c.NoContent(http.StatusCreated) }
+
+	if _, err := idempotencyRequest(mw, handler, "key-1", `{"staff_id":1}`); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	_, err := idempotencyRequest(mw, handler, "key-1", `{"staff_id":2}`)
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %v", err)
+	}
+}
+
+func TestIdempotency_ConcurrentDuplicate(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.JSON(http.StatusCreated, map[string]int{"id": 1})
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := idempotencyRequest(mw, handler, "key-1", `{}`)
+		done <- err
+	}()
+	<-started
+	rec, err := idempotencyRequest(mw, handler, "key-1", `{}`)
+	if got := appt_booking_service.KindOf(err); got != appt_booking_service.KindConflict {
+		t.Fatalf("expected a conflict while the first request runs, got %v", err)
+	}
+	if rec.Header().Get(echo.HeaderRetryAfter) == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	rec, err = idempotencyRequest(mw, handler, "key-1", `{}`)
+	if err != nil || rec.Code != http.StatusCreated {
+		t.Errorf("expected the stored response once the first request finished, got %d, %v", rec.Code, err)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	mw := Idempotency(newMemoryIdempotencyStore(), IdempotencyConfig{})
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("database unavailable")
+		}
+		return c.NoContent(http.StatusCreated)
+	}
+
+	rec, err := idempotencyRequest(mw, handler, "key-1", `{}`)
+	if err == nil || rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the error to be rendered as a 500, got %d, %v", rec.Code, err)
+	}
+	rec, err = idempotencyRequest(mw, handler, "key-1", `{}`)
+	if err != nil || rec.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected the retry to run, got %d after %d calls, %v", rec.Code, calls, err)
+	}
+}
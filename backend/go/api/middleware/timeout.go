@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTimeout returns a middleware that gives each request's context a deadline.
+// Handlers pass the context down to the database, so queries are cancelled once the
+// deadline passes or the client disconnects. Handlers are not interrupted; the
+// resulting context error is rendered by the HTTP error handler as 503/504.
+func RequestTimeout(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
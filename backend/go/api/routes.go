@@ -4,6 +4,8 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"k8s-fullstack-blueprint-backend/api/appt_booking"
+	"k8s-fullstack-blueprint-backend/api/middleware"
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
 )
 
 // SetupRoutes configures all API routes
@@ -14,46 +16,137 @@ func SetupRoutes(
 	serviceHandler *appt_booking.ServiceHandler,
 	staffHandler *appt_booking.StaffHandler,
 	scheduleHandler *appt_booking.ScheduleHandler,
+	scheduleExceptionHandler *appt_booking.ScheduleExceptionHandler,
 	appointmentHandler *appt_booking.AppointmentHandler,
+	availabilityHandler *appt_booking.AvailabilityHandler,
+	authHandler *appt_booking.AuthHandler,
+	customerHandler *appt_booking.CustomerHandler,
+	waitlistHandler *appt_booking.WaitlistHandler,
+	holdHandler *appt_booking.HoldHandler,
+	webhookHandler *appt_booking.WebhookHandler,
+	notificationHandler *appt_booking.NotificationHandler,
+	calendarHandler *appt_booking.CalendarHandler,
+	externalCalendarHandler *appt_booking.ExternalCalendarHandler,
+	idempotent echo.MiddlewareFunc,
 ) {
+	// Role checks; the principal itself is set by middleware.Authenticate.
+	// Routes without one of these are public.
+	anyUser := middleware.RequireRole(appt_booking_db.RoleCustomer, appt_booking_db.RoleProvider, appt_booking_db.RoleAdmin)
+	staffOnly := middleware.RequireRole(appt_booking_db.RoleProvider, appt_booking_db.RoleAdmin)
+	adminOnly := middleware.RequireRole(appt_booking_db.RoleAdmin)
+
 	// Health check endpoints
 	e.GET("/", healthHandler.Root)
 	e.GET("/health", healthHandler.Check)
 	e.GET("/info", healthHandler.Info)
 
+	// Creating endpoints take an Idempotency-Key header (applied after the role checks
+	// so keys are scoped to the caller; anonymous keys are scoped to the request itself)
+
 	// Demo data endpoints
 	e.GET("/api/demo-data", demoDataHandler.GetAll)
 	e.GET("/api/demo-data/:id", demoDataHandler.GetByID)
-	e.POST("/api/demo-data", demoDataHandler.Upsert)
+	e.POST("/api/demo-data", demoDataHandler.Upsert, idempotent)
+
+	// Authentication
+	e.POST("/api/auth/register", authHandler.Register)
+	e.POST("/api/auth/login", authHandler.Login)
+	e.POST("/api/auth/refresh", authHandler.Refresh)
+	e.POST("/api/auth/verify-email", authHandler.VerifyEmail)
+	e.POST("/api/auth/verify-email/resend", authHandler.ResendVerification, anyUser)
+	e.GET("/api/auth/me", authHandler.Me, anyUser)
 
 	// Appointment Booking endpoints
 	// Services
 	e.GET("/api/appt_booking/services", serviceHandler.GetAll)
 	e.GET("/api/appt_booking/services/:id", serviceHandler.GetByID)
-	e.POST("/api/appt_booking/services", serviceHandler.Create)
-	e.PUT("/api/appt_booking/services/:id", serviceHandler.Update)
-	e.DELETE("/api/appt_booking/services/:id", serviceHandler.Delete)
+	e.POST("/api/appt_booking/services", serviceHandler.Create, adminOnly, idempotent)
+	e.PUT("/api/appt_booking/services/:id", serviceHandler.Update, adminOnly)
+	e.DELETE("/api/appt_booking/services/:id", serviceHandler.Delete, adminOnly)
 
 	// Staff
 	e.GET("/api/appt_booking/staff", staffHandler.GetAll)
 	e.GET("/api/appt_booking/staff/:id", staffHandler.GetByID)
-	e.POST("/api/appt_booking/staff", staffHandler.Create)
-	e.PUT("/api/appt_booking/staff/:id", staffHandler.Update)
-	e.DELETE("/api/appt_booking/staff/:id", staffHandler.Delete)
+	e.POST("/api/appt_booking/staff", staffHandler.Create, adminOnly, idempotent)
+	e.PUT("/api/appt_booking/staff/:id", staffHandler.Update, adminOnly)
+	e.DELETE("/api/appt_booking/staff/:id", staffHandler.Delete, adminOnly)
 	e.GET("/api/appt_booking/staff/by-service/:serviceId", staffHandler.GetByService)
+	e.POST("/api/appt_booking/staff/:id/account", authHandler.CreateStaffAccount, adminOnly)
+
+	// Staff calendar feeds; the token in the feed URL is the credential for calendar apps
+	e.GET("/api/appt_booking/staff/:id/calendar.ics", calendarHandler.Feed)
+	e.GET("/api/appt_booking/staff/:id/calendar-feed", calendarHandler.GetFeed, staffOnly)
+	e.POST("/api/appt_booking/staff/:id/calendar-feed", calendarHandler.RotateFeed, staffOnly)
+
+	// External calendars, whose events block the staff member's availability
+	e.GET("/api/appt_booking/staff/:id/external-calendars", externalCalendarHandler.GetAll, staffOnly)
+	e.POST("/api/appt_booking/staff/:id/external-calendars", externalCalendarHandler.Create, staffOnly)
+	e.DELETE("/api/appt_booking/staff/:id/external-calendars/:calendarId", externalCalendarHandler.Delete, staffOnly)
+	e.POST("/api/appt_booking/staff/:id/external-calendars/:calendarId/sync", externalCalendarHandler.Sync, staffOnly)
 
 	// Schedules
 	e.GET("/api/appt_booking/schedules", scheduleHandler.GetAll)
 	e.GET("/api/appt_booking/schedules/:id", scheduleHandler.GetByID)
 	e.GET("/api/appt_booking/schedules/staff/:staffId", scheduleHandler.GetByStaff)
-	e.POST("/api/appt_booking/schedules", scheduleHandler.Create)
-	e.PUT("/api/appt_booking/schedules/:id", scheduleHandler.Update)
-	e.DELETE("/api/appt_booking/schedules/:id", scheduleHandler.Delete)
-
-	// Appointments
-	e.GET("/api/appt_booking/appointments", appointmentHandler.GetAll)
-	e.GET("/api/appt_booking/appointments/:id", appointmentHandler.GetByID)
-	e.POST("/api/appt_booking/appointments", appointmentHandler.Book)
-	e.PUT("/api/appt_booking/appointments/:id/cancel", appointmentHandler.Cancel)
-	e.PUT("/api/appt_booking/appointments/:id/complete", appointmentHandler.Complete)
+	e.POST("/api/appt_booking/schedules", scheduleHandler.Create, adminOnly)
+	e.PUT("/api/appt_booking/schedules/:id", scheduleHandler.Update, adminOnly)
+	e.DELETE("/api/appt_booking/schedules/:id", scheduleHandler.Delete, adminOnly)
+
+	// Schedule exceptions (time off, holidays, one-off hours)
+	e.GET("/api/appt_booking/schedule-exceptions", scheduleExceptionHandler.GetAll)
+	e.GET("/api/appt_booking/schedule-exceptions/:id", scheduleExceptionHandler.GetByID)
+	e.POST("/api/appt_booking/schedule-exceptions", scheduleExceptionHandler.Create, adminOnly)
+	e.PUT("/api/appt_booking/schedule-exceptions/:id", scheduleExceptionHandler.Update, adminOnly)
+	e.DELETE("/api/appt_booking/schedule-exceptions/:id", scheduleExceptionHandler.Delete, adminOnly)
+
+	// Customers
+	e.GET("/api/appt_booking/customers", customerHandler.GetAll, staffOnly)
+	e.GET("/api/appt_booking/customers/:id", customerHandler.GetByID, staffOnly)
+	e.POST("/api/appt_booking/customers", customerHandler.Create, staffOnly)
+	e.PUT("/api/appt_booking/customers/:id", customerHandler.Update, staffOnly)
+	e.DELETE("/api/appt_booking/customers/:id", customerHandler.Delete, adminOnly)
+
+	// Appointments; handlers further restrict providers and customers to their own
+	e.GET("/api/appt_booking/appointments", appointmentHandler.GetAll, anyUser)
+	e.GET("/api/appt_booking/appointments/:id", appointmentHandler.GetByID, anyUser)
+	e.POST("/api/appt_booking/appointments", appointmentHandler.Book, anyUser, idempotent)
+	e.PUT("/api/appt_booking/appointments/:id/cancel", appointmentHandler.Cancel, anyUser)
+	e.PUT("/api/appt_booking/appointments/:id/complete", appointmentHandler.Complete, staffOnly)
+	e.PUT("/api/appt_booking/appointments/:id/no-show", appointmentHandler.NoShow, staffOnly)
+	e.PUT("/api/appt_booking/appointments/:id/reschedule", appointmentHandler.Reschedule, anyUser)
+	e.POST("/api/appt_booking/appointments/:id/transitions", appointmentHandler.Transition, staffOnly)
+	e.GET("/api/appt_booking/appointments/:id/history", appointmentHandler.History, anyUser)
+	e.GET("/api/appt_booking/appointments/:id/calendar.ics", appointmentHandler.Calendar, anyUser)
+
+	// Recurring appointment series; occurrences are cancelled and rescheduled through the
+	// appointment endpoints with scope "following"
+	e.POST("/api/appt_booking/appointment-series", appointmentHandler.BookSeries, anyUser, idempotent)
+	e.GET("/api/appt_booking/appointment-series/:id", appointmentHandler.GetSeries, anyUser)
+
+	// Slot holds during checkout; a hold can only be booked or released by the account
+	// that placed it, with its token
+	e.POST("/api/appt_booking/holds", holdHandler.Create, anyUser)
+	e.DELETE("/api/appt_booking/holds/:token", holdHandler.Release, anyUser)
+
+	// Waitlist; handlers further restrict providers and customers to their own entries
+	e.GET("/api/appt_booking/waitlist", waitlistHandler.GetAll, anyUser)
+	e.GET("/api/appt_booking/waitlist/:id", waitlistHandler.GetByID, anyUser)
+	e.POST("/api/appt_booking/waitlist", waitlistHandler.Join, anyUser)
+	e.DELETE("/api/appt_booking/waitlist/:id", waitlistHandler.Leave, anyUser)
+	e.POST("/api/appt_booking/waitlist/:id/accept", waitlistHandler.Accept, anyUser)
+
+	// Availability
+	e.GET("/api/appt_booking/availability", availabilityHandler.Get)
+
+	// Webhook subscriptions and their delivery log
+	e.GET("/api/admin/webhooks", webhookHandler.GetAll, adminOnly)
+	e.GET("/api/admin/webhooks/:id", webhookHandler.GetByID, adminOnly)
+	e.POST("/api/admin/webhooks", webhookHandler.Create, adminOnly)
+	e.PUT("/api/admin/webhooks/:id", webhookHandler.Update, adminOnly)
+	e.DELETE("/api/admin/webhooks/:id", webhookHandler.Delete, adminOnly)
+	e.GET("/api/admin/webhooks/:id/deliveries", webhookHandler.Deliveries, adminOnly)
+	e.POST("/api/admin/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver, adminOnly)
+
+	// Log of the notifications sent to customers
+	e.GET("/api/admin/notifications", notificationHandler.GetAll, adminOnly)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	appt_booking_service "k8s-fullstack-blueprint-backend/service/appt_booking"
+)
+
+const createAdminUsage = `Usage: backend create-admin -email EMAIL
+
+Creates an admin login account. The password is read from the first line of
+standard input so it does not end up in shell history or the process list.
+`
+
+// runCreateAdmin implements the create-admin subcommand, which bootstraps the first
+// admin account; further accounts can then be managed through the API
+func runCreateAdmin(args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email address of the new admin")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, createAdminUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return errors.New("-email is required")
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := appt_booking_db.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to appointment booking database: %w", err)
+	}
+	defer conn.Close()
+
+	authService, err := appt_booking_service.NewAuthService(
+		appt_booking_db.NewUserRepository(conn),
+		appt_booking_db.NewStaffRepository(conn),
+		appt_booking_service.LogNotifier{}, // admins are created without a verification email
+		authConfig,
+	)
+	if err != nil {
+		return err
+	}
+	user, err := authService.CreateAdmin(context.Background(), *email, password)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created admin %s (id %d)", user.Email, user.ID)
+	return nil
+}
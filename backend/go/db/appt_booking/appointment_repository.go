@@ -1,10 +1,38 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"slices"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrAppointmentConflict is returned when an appointment would overlap another
+// non-cancelled appointment for the same staff member
+var ErrAppointmentConflict = errors.New("appointment time conflicts with an existing appointment")
+
+// ErrAppointmentStatusChanged is returned when an appointment's status changed
+// concurrently between being read and being transitioned
+var ErrAppointmentStatusChanged = errors.New("appointment status was changed by another request")
+
+// ReschedulableStatuses are the statuses of appointments that can still be moved
+var ReschedulableStatuses = []string{AppointmentStatusPending, AppointmentStatusConfirmed}
+
+// appointmentLockNamespace is the first key of the per-staff advisory lock taken while booking
+const appointmentLockNamespace = 1001
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// serviceBuffersJoin adds the buffer_before_minutes and buffer_after_minutes of each row's
+// service to a query on appointments or slot_holds without making their columns ambiguous
+const serviceBuffersJoin = "JOIN (SELECT id AS buffer_service_id, buffer_before_minutes, buffer_after_minutes FROM services) buffers ON buffers.buffer_service_id = service_id"
+
 // AppointmentRepository handles database operations for appointments
 type AppointmentRepository struct {
 	db *sql.DB
@@ -16,32 +44,148 @@ func NewAppointmentRepository(db *sql.DB) *AppointmentRepository {
 }
 
 // Create inserts a new appointment
-func (ar *AppointmentRepository) Create(customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) (*Appointment, error) {
+func (ar *AppointmentRepository) Create(ctx context.Context, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return insertAppointment(ctx, ar.db, customerID, customerName, customerEmail, customerPhone, staffID, serviceID, durationMinutes, appointmentDatetime, status, notes)
+}
+
+// CreateExclusive inserts a new appointment only if it does not overlap an existing
+// non-cancelled appointment for the same staff member. The conflict check and insert
+// run in one transaction holding a per-staff advisory lock, and the appointments
+// exclusion constraint backs this up; both cases return ErrAppointmentConflict.
+// event is recorded for the new appointment in the same transaction.
+func (ar *AppointmentRepository) CreateExclusive(ctx context.Context, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string, event *OutboxEvent) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Serialize bookings for this staff member until commit
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1::int, $2::int)", appointmentLockNamespace, staffID); err != nil {
+		return nil, err
+	}
+
+	hasConflict, err := checkConflict(ctx, tx, staffID, serviceID, appointmentDatetime, durationMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if hasConflict {
+		return nil, ErrAppointmentConflict
+	}
+
+	appointment, err := insertAppointment(ctx, tx, customerID, customerName, customerEmail, customerPhone, staffID, serviceID, durationMinutes, appointmentDatetime, status, notes)
+	if err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, appointment.ID, appointment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// CreateFromHold books the slot reserved by an unexpired hold, releases the hold and records
+// event for the new appointment. Returns ErrHoldNotFound if the hold does not exist or has expired.
+func (ar *AppointmentRepository) CreateFromHold(ctx context.Context, holdID, customerID int, customerName, customerEmail, customerPhone, status, notes string, event *OutboxEvent) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hold, err := deleteActiveHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize bookings for this staff member until commit
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1::int, $2::int)", appointmentLockNamespace, hold.StaffID); err != nil {
+		return nil, err
+	}
+
+	hasConflict, err := checkConflict(ctx, tx, hold.StaffID, hold.ServiceID, hold.StartsAt, hold.DurationMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if hasConflict {
+		return nil, ErrAppointmentConflict
+	}
+
+	appointment, err := insertAppointment(ctx, tx, customerID, customerName, customerEmail, customerPhone, hold.StaffID, hold.ServiceID, hold.DurationMinutes, hold.StartsAt, status, notes)
+	if err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, appointment.ID, appointment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// insertAppointment inserts an appointment using the given connection or transaction.
+// appointment_datetime is stored as UTC wall-clock time.
+func insertAppointment(ctx context.Context, q queryRower, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) (*Appointment, error) {
 	now := time.Now()
 	appointment := &Appointment{}
-	err := ar.db.QueryRow(
-		`INSERT INTO appointments (customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at) 
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
-		 RETURNING id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
-		customerName, customerEmail, customerPhone, staffID, serviceID, appointmentDatetime, durationMinutes, status, notes, now, now,
-	).Scan(&appointment.ID, &appointment.CustomerName, &appointment.CustomerEmail, &appointment.CustomerPhone, &appointment.StaffID, &appointment.ServiceID, &appointment.AppointmentDatetime, &appointment.DurationMinutes, &appointment.Status, &appointment.Notes, &appointment.CreatedAt, &appointment.UpdatedAt)
+	err := q.QueryRowContext(ctx,
+		`INSERT INTO appointments (customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+		 RETURNING id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
+		customerID, customerName, customerEmail, customerPhone, staffID, serviceID, appointmentDatetime.UTC(), durationMinutes, status, notes, now, now,
+	).Scan(&appointment.ID, &appointment.CustomerID, &appointment.CustomerName, &appointment.CustomerEmail, &appointment.CustomerPhone, &appointment.StaffID, &appointment.ServiceID, &appointment.AppointmentDatetime, &appointment.DurationMinutes, &appointment.Status, &appointment.Notes, &appointment.CreatedAt, &appointment.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return appointment, nil
 }
 
+// isExclusionViolation checks if an error is a PostgreSQL exclusion constraint violation (23P01)
+func isExclusionViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01"
+}
+
 // Update modifies an existing appointment
-func (ar *AppointmentRepository) Update(id int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) (*Appointment, error) {
+func (ar *AppointmentRepository) Update(ctx context.Context, id, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	now := time.Now()
 	appointment := &Appointment{}
-	err := ar.db.QueryRow(
+	err := ar.db.QueryRowContext(ctx,
 		`UPDATE appointments 
-		 SET customer_name = $1, customer_email = $2, customer_phone = $3, staff_id = $4, service_id = $5, appointment_datetime = $6, duration_minutes = $7, status = $8, notes = $9, updated_at = $10 
-		 WHERE id = $11 
-		 RETURNING id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
-		customerName, customerEmail, customerPhone, staffID, serviceID, appointmentDatetime, durationMinutes, status, notes, now, id,
-	).Scan(&appointment.ID, &appointment.CustomerName, &appointment.CustomerEmail, &appointment.CustomerPhone, &appointment.StaffID, &appointment.ServiceID, &appointment.AppointmentDatetime, &appointment.DurationMinutes, &appointment.Status, &appointment.Notes, &appointment.CreatedAt, &appointment.UpdatedAt)
+		 SET customer_id = $1, customer_name = $2, customer_email = $3, customer_phone = $4, staff_id = $5, service_id = $6, appointment_datetime = $7, duration_minutes = $8, status = $9, notes = $10, updated_at = $11 
+		 WHERE id = $12 
+		 RETURNING id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
+		customerID, customerName, customerEmail, customerPhone, staffID, serviceID, appointmentDatetime.UTC(), durationMinutes, status, notes, now, id,
+	).Scan(&appointment.ID, &appointment.CustomerID, &appointment.CustomerName, &appointment.CustomerEmail, &appointment.CustomerPhone, &appointment.StaffID, &appointment.ServiceID, &appointment.AppointmentDatetime, &appointment.DurationMinutes, &appointment.Status, &appointment.Notes, &appointment.CreatedAt, &appointment.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -51,10 +195,157 @@ func (ar *AppointmentRepository) Update(id int, customerName, customerEmail, cus
 	return appointment, nil
 }
 
+// RescheduleExclusive moves an appointment to a new staff member and/or datetime and records
+// the previous values in appointment_reschedules. Like CreateExclusive, the conflict check
+// (excluding the appointment itself) and update run in one transaction holding the
+// per-staff advisory lock; overlaps return ErrAppointmentConflict, and an appointment no
+// longer in a ReschedulableStatuses status returns ErrAppointmentStatusChanged. event is
+// recorded in the same transaction. Returns nil if the appointment does not exist.
+func (ar *AppointmentRepository) RescheduleExclusive(ctx context.Context, id, staffID int, appointmentDatetime time.Time, durationMinutes int, event *OutboxEvent) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so the previous values recorded below are the ones being replaced, and
+	// recheck the status the caller read: it may have been cancelled or completed since
+	var previousStaffID, serviceID int
+	var previousDatetime time.Time
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT staff_id, appointment_datetime, service_id, status FROM appointments WHERE id = $1 FOR UPDATE",
+		id,
+	).Scan(&previousStaffID, &previousDatetime, &serviceID, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !slices.Contains(ReschedulableStatuses, status) {
+		return nil, ErrAppointmentStatusChanged
+	}
+
+	// Serialize bookings for the target staff member until commit
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1::int, $2::int)", appointmentLockNamespace, staffID); err != nil {
+		return nil, err
+	}
+
+	hasConflict, err := checkConflict(ctx, tx, staffID, serviceID, appointmentDatetime, durationMinutes, id)
+	if err != nil {
+		return nil, err
+	}
+	if hasConflict {
+		return nil, ErrAppointmentConflict
+	}
+
+	now := time.Now()
+	a := &Appointment{}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE appointments 
+		 SET staff_id = $1, appointment_datetime = $2, updated_at = $3 
+		 WHERE id = $4 
+		 RETURNING id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
+		staffID, appointmentDatetime.UTC(), now, id,
+	).Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO appointment_reschedules (appointment_id, previous_staff_id, previous_datetime, new_staff_id, new_datetime, created_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, previousStaffID, previousDatetime, staffID, appointmentDatetime.UTC(), now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, a.ID, a); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetReschedules retrieves the reschedule history of an appointment, most recent first
+func (ar *AppointmentRepository) GetReschedules(ctx context.Context, appointmentID int) ([]AppointmentReschedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, appointment_id, previous_staff_id, previous_datetime, new_staff_id, new_datetime, created_at 
+		 FROM appointment_reschedules 
+		 WHERE appointment_id = $1 
+		 ORDER BY created_at DESC, id DESC`,
+		appointmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reschedules []AppointmentReschedule
+	for rows.Next() {
+		var r AppointmentReschedule
+		if err := rows.Scan(&r.ID, &r.AppointmentID, &r.PreviousStaffID, &r.PreviousDatetime, &r.NewStaffID, &r.NewDatetime, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reschedules = append(reschedules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reschedules, nil
+}
+
+// CountReschedules returns how often each of the given appointments has been rescheduled.
+// Appointments never rescheduled are absent from the result.
+func (ar *AppointmentRepository) CountReschedules(ctx context.Context, appointmentIDs []int) (map[int]int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT appointment_id, COUNT(*) FROM appointment_reschedules
+		 WHERE appointment_id = ANY($1)
+		 GROUP BY appointment_id`,
+		pq.Array(appointmentIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var id, count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
 // GetAll retrieves all appointments
-func (ar *AppointmentRepository) GetAll() ([]Appointment, error) {
-	rows, err := ar.db.Query(
-		`SELECT id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+func (ar *AppointmentRepository) GetAll(ctx context.Context) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
 		 FROM appointments 
 		 ORDER BY appointment_datetime DESC`,
 	)
@@ -66,7 +357,7 @@ func (ar *AppointmentRepository) GetAll() ([]Appointment, error) {
 	var appointments []Appointment
 	for rows.Next() {
 		var a Appointment
-		if err := rows.Scan(&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
 			return nil, err
 		}
 		appointments = append(appointments, a)
@@ -78,14 +369,17 @@ func (ar *AppointmentRepository) GetAll() ([]Appointment, error) {
 }
 
 // GetByID retrieves a single appointment by ID
-func (ar *AppointmentRepository) GetByID(id int) (*Appointment, error) {
+func (ar *AppointmentRepository) GetByID(ctx context.Context, id int) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	a := &Appointment{}
-	err := ar.db.QueryRow(
-		`SELECT id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+	err := ar.db.QueryRowContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
 		 FROM appointments 
 		 WHERE id = $1`,
 		id,
-	).Scan(&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt)
+	).Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -96,9 +390,12 @@ func (ar *AppointmentRepository) GetByID(id int) (*Appointment, error) {
 }
 
 // GetByStaff retrieves all appointments for a specific staff member
-func (ar *AppointmentRepository) GetByStaff(staffID int) ([]Appointment, error) {
-	rows, err := ar.db.Query(
-		`SELECT id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+func (ar *AppointmentRepository) GetByStaff(ctx context.Context, staffID int) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
 		 FROM appointments 
 		 WHERE staff_id = $1 
 		 ORDER BY appointment_datetime DESC`,
@@ -112,7 +409,7 @@ func (ar *AppointmentRepository) GetByStaff(staffID int) ([]Appointment, error)
 	var appointments []Appointment
 	for rows.Next() {
 		var a Appointment
-		if err := rows.Scan(&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
 			return nil, err
 		}
 		appointments = append(appointments, a)
@@ -123,12 +420,16 @@ func (ar *AppointmentRepository) GetByStaff(staffID int) ([]Appointment, error)
 	return appointments, nil
 }
 
-// GetByCustomerEmail retrieves all appointments for a customer by email
-func (ar *AppointmentRepository) GetByCustomerEmail(email string) ([]Appointment, error) {
-	rows, err := ar.db.Query(
-		`SELECT id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+// GetByCustomerEmail retrieves all appointments of the customer with the given email,
+// including those booked under a previous email or name
+func (ar *AppointmentRepository) GetByCustomerEmail(ctx context.Context, email string) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
 		 FROM appointments 
-		 WHERE customer_email = $1 
+		 WHERE customer_id = (SELECT id FROM customers WHERE email = LOWER($1)) 
 		 ORDER BY appointment_datetime DESC`,
 		email,
 	)
@@ -140,7 +441,7 @@ func (ar *AppointmentRepository) GetByCustomerEmail(email string) ([]Appointment
 	var appointments []Appointment
 	for rows.Next() {
 		var a Appointment
-		if err := rows.Scan(&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
 			return nil, err
 		}
 		appointments = append(appointments, a)
@@ -152,9 +453,12 @@ func (ar *AppointmentRepository) GetByCustomerEmail(email string) ([]Appointment
 }
 
 // GetUpcoming retrieves upcoming appointments (from now onwards)
-func (ar *AppointmentRepository) GetUpcoming(limit int) ([]Appointment, error) {
-	rows, err := ar.db.Query(
-		`SELECT id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+func (ar *AppointmentRepository) GetUpcoming(ctx context.Context, limit int) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
 		 FROM appointments 
 		 WHERE appointment_datetime >= NOW() AND status != 'cancelled'
 		 ORDER BY appointment_datetime ASC
@@ -169,7 +473,7 @@ func (ar *AppointmentRepository) GetUpcoming(limit int) ([]Appointment, error) {
 	var appointments []Appointment
 	for rows.Next() {
 		var a Appointment
-		if err := rows.Scan(&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
 			return nil, err
 		}
 		appointments = append(appointments, a)
@@ -180,85 +484,279 @@ func (ar *AppointmentRepository) GetUpcoming(limit int) ([]Appointment, error) {
 	return appointments, nil
 }
 
-// CheckConflict returns true if there is a conflicting appointment for the given staff at the given datetime
-func (ar *AppointmentRepository) CheckConflict(staffID int, appointmentTime time.Time, durationMinutes int, excludeID ...int) (bool, error) {
-	endTime := appointmentTime.Add(time.Duration(durationMinutes) * time.Minute)
-	
-	// Query for any existing appointment that overlaps with the requested time slot
-	// Overlap condition: existing.start < new.end AND existing.end > new.start
-	query := `
+// CheckConflict returns true if booking serviceID with the given staff at the given datetime
+// would overlap another appointment or hold, including the services' buffers
+func (ar *AppointmentRepository) CheckConflict(ctx context.Context, staffID, serviceID int, appointmentTime time.Time, durationMinutes int, excludeID ...int) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return checkConflict(ctx, ar.db, staffID, serviceID, appointmentTime, durationMinutes, excludeID...)
+}
+
+// checkConflict runs the overlap query using the given connection or transaction.
+// Each appointment or hold blocks its staff member from its service's buffer before the
+// start until its buffer after the end; two bookings conflict if their blocked times overlap.
+func checkConflict(ctx context.Context, q queryRower, staffID, serviceID int, appointmentTime time.Time, durationMinutes int, excludeID ...int) (bool, error) {
+	var bufferBefore, bufferAfter int
+	err := q.QueryRowContext(ctx,
+		"SELECT buffer_before_minutes, buffer_after_minutes FROM services WHERE id = $1",
+		serviceID,
+	).Scan(&bufferBefore, &bufferAfter)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	blockedFrom := appointmentTime.Add(-time.Duration(bufferBefore) * time.Minute)
+	blockedUntil := appointmentTime.Add(time.Duration(durationMinutes+bufferAfter) * time.Minute)
+
+	// Query for any existing appointment or unexpired hold whose blocked time overlaps the requested one
+	// Overlap condition: existing.blocked_from < new.blocked_until AND existing.blocked_until > new.blocked_from
+	appointmentsQuery := `
 		SELECT COUNT(*) 
-		FROM appointments 
+		FROM appointments ` + serviceBuffersJoin + `
 		WHERE staff_id = $1 
 		  AND status != 'cancelled'
-		  AND appointment_datetime < $2 
-		  AND (appointment_datetime + (duration_minutes * INTERVAL '1 minute')) > $3
+		  AND appointment_datetime - (buffer_before_minutes * INTERVAL '1 minute') < $2 
+		  AND (appointment_datetime + ((duration_minutes + buffer_after_minutes) * INTERVAL '1 minute')) > $3
 	`
-	args := []interface{}{staffID, endTime, appointmentTime}
-	
+	args := []interface{}{staffID, blockedUntil.UTC(), blockedFrom.UTC(), time.Now().UTC()}
+
 	// Exclude current appointment ID if provided (for updates)
 	if len(excludeID) > 0 {
-		query += " AND id != $4"
+		appointmentsQuery += " AND id != $5"
 		args = append(args, excludeID[0])
 	}
-	
+	query := `SELECT (` + appointmentsQuery + `) + (
+		SELECT COUNT(*)
+		FROM slot_holds ` + serviceBuffersJoin + `
+		WHERE staff_id = $1
+		  AND expires_at > $4
+		  AND starts_at - (buffer_before_minutes * INTERVAL '1 minute') < $2
+		  AND (starts_at + ((duration_minutes + buffer_after_minutes) * INTERVAL '1 minute')) > $3
+	)`
+
 	var count int
-	err := ar.db.QueryRow(query, args...).Scan(&count)
+	err = q.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return false, err
 	}
-	
+
 	return count > 0, nil
 }
 
+// GetActiveByStaffBetween retrieves non-cancelled appointments for a staff member whose
+// blocked time, including their service's buffers, overlaps [from, to)
+func (ar *AppointmentRepository) GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	// Same overlap condition as CheckConflict: existing.blocked_from < to AND existing.blocked_until > from
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at 
+		 FROM appointments `+serviceBuffersJoin+`
+		 WHERE staff_id = $1 
+		   AND status != 'cancelled'
+		   AND appointment_datetime - (buffer_before_minutes * INTERVAL '1 minute') < $2 
+		   AND (appointment_datetime + ((duration_minutes + buffer_after_minutes) * INTERVAL '1 minute')) > $3
+		 ORDER BY appointment_datetime ASC`,
+		staffID, to.UTC(), from.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appointments []Appointment
+	for rows.Next() {
+		var a Appointment
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
 // Delete removes an appointment
-func (ar *AppointmentRepository) Delete(id int) error {
-	_, err := ar.db.Exec("DELETE FROM appointments WHERE id = $1", id)
+func (ar *AppointmentRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := ar.db.ExecContext(ctx, "DELETE FROM appointments WHERE id = $1", id)
 	return err
 }
 
-// Cancel updates an appointment status to 'cancelled'
-func (ar *AppointmentRepository) Cancel(id int) error {
+// TransitionStatus changes an appointment's status from fromStatus to toStatus and records
+// the change in appointment_status_history and event in the same transaction. The update only
+// applies if the status is still fromStatus; otherwise ErrAppointmentStatusChanged is returned.
+func (ar *AppointmentRepository) TransitionStatus(ctx context.Context, id int, fromStatus, toStatus, changedBy, reason string, event *OutboxEvent) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	a, err := transitionStatus(ctx, tx, id, fromStatus, toStatus, changedBy, reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, a.ID, a); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Cancel moves an appointment from fromStatus to cancelled like TransitionStatus and records
+// the cancellation and event in the same transaction
+func (ar *AppointmentRepository) Cancel(ctx context.Context, id int, fromStatus, changedBy string, cancellation AppointmentCancellation, event *OutboxEvent) (*Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	_, err := ar.db.Exec(
-		"UPDATE appointments SET status = 'cancelled', updated_at = $1 WHERE id = $2",
-		now, id,
+	a, err := transitionStatus(ctx, tx, id, fromStatus, AppointmentStatusCancelled, changedBy, cancellation.Reason, now)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO appointment_cancellations (appointment_id, cancelled_by, reason, late, created_at) 
+		 VALUES ($1, $2, $3, $4, $5)`,
+		id, cancellation.CancelledBy, cancellation.Reason, cancellation.Late, now,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, a.ID, a); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return a, nil
 }
 
-// Complete updates an appointment status to 'completed'
-func (ar *AppointmentRepository) Complete(id int) error {
-	now := time.Now()
-	_, err := ar.db.Exec(
-		"UPDATE appointments SET status = 'completed', updated_at = $1 WHERE id = $2",
-		now, id,
+// transitionStatus updates the status and inserts the history row within tx
+func transitionStatus(ctx context.Context, tx *sql.Tx, id int, fromStatus, toStatus, changedBy, reason string, now time.Time) (*Appointment, error) {
+	a := &Appointment{}
+	err := tx.QueryRowContext(ctx,
+		`UPDATE appointments 
+		 SET status = $1, updated_at = $2 
+		 WHERE id = $3 AND status = $4 
+		 RETURNING id, customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes, created_at, updated_at`,
+		toStatus, now, id, fromStatus,
+	).Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAppointmentStatusChanged
+		}
+		// Reviving a cancelled appointment can collide with a booking made since
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentConflict
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO appointment_status_history (appointment_id, from_status, to_status, changed_by, reason, created_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, fromStatus, toStatus, changedBy, reason, now,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetCancellation retrieves the cancellation of an appointment; returns nil if it was not cancelled
+func (ar *AppointmentRepository) GetCancellation(ctx context.Context, appointmentID int) (*AppointmentCancellation, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	c := &AppointmentCancellation{}
+	err := ar.db.QueryRowContext(ctx,
+		"SELECT appointment_id, cancelled_by, reason, late, created_at FROM appointment_cancellations WHERE appointment_id = $1",
+		appointmentID,
+	).Scan(&c.AppointmentID, &c.CancelledBy, &c.Reason, &c.Late, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetStatusHistory retrieves the status transitions of an appointment, oldest first
+func (ar *AppointmentRepository) GetStatusHistory(ctx context.Context, appointmentID int) ([]AppointmentStatusChange, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx,
+		`SELECT id, appointment_id, from_status, to_status, changed_by, reason, created_at 
+		 FROM appointment_status_history 
+		 WHERE appointment_id = $1 
+		 ORDER BY created_at ASC, id ASC`,
+		appointmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []AppointmentStatusChange
+	for rows.Next() {
+		var h AppointmentStatusChange
+		if err := rows.Scan(&h.ID, &h.AppointmentID, &h.FromStatus, &h.ToStatus, &h.ChangedBy, &h.Reason, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
 }
 
 // AppointmentWithService represents an appointment joined with service price
 type AppointmentWithService struct {
-	ID                 int       `json:"id"`
-	CustomerName       string    `json:"customer_name"`
-	CustomerEmail      string    `json:"customer_email"`
-	CustomerPhone      string    `json:"customer_phone"`
-	StaffID            int       `json:"staff_id"`
-	ServiceID          int       `json:"service_id"`
+	ID                  int       `json:"id"`
+	CustomerID          int       `json:"customer_id"`
+	CustomerName        string    `json:"customer_name"`
+	CustomerEmail       string    `json:"customer_email"`
+	CustomerPhone       string    `json:"customer_phone"`
+	StaffID             int       `json:"staff_id"`
+	ServiceID           int       `json:"service_id"`
 	AppointmentDatetime time.Time `json:"appointment_datetime"`
-	DurationMinutes    int       `json:"duration_minutes"`
-	Status             string    `json:"status"`
-	Notes              string    `json:"notes"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
-	PriceCents         int       `json:"price_cents"`
+	DurationMinutes     int       `json:"duration_minutes"`
+	Status              string    `json:"status"`
+	Notes               string    `json:"notes"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	PriceCents          int       `json:"price_cents"`
 }
 
 // GetAllWithServiceDetails retrieves all appointments with service price
-func (ar *AppointmentRepository) GetAllWithServiceDetails() ([]AppointmentWithService, error) {
-	rows, err := ar.db.Query(`
+func (ar *AppointmentRepository) GetAllWithServiceDetails(ctx context.Context) ([]AppointmentWithService, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx, `
 		SELECT
-			a.id, a.customer_name, a.customer_email, a.customer_phone,
+			a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone,
 			a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes,
 			a.status, a.notes, a.created_at, a.updated_at,
 			s.price_cents
@@ -275,7 +773,7 @@ func (ar *AppointmentRepository) GetAllWithServiceDetails() ([]AppointmentWithSe
 	for rows.Next() {
 		var a AppointmentWithService
 		if err := rows.Scan(
-			&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
+			&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
 			&a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes,
 			&a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
 			&a.PriceCents,
@@ -291,10 +789,13 @@ func (ar *AppointmentRepository) GetAllWithServiceDetails() ([]AppointmentWithSe
 }
 
 // GetByStaffWithServiceDetails retrieves appointments for a staff member with service price
-func (ar *AppointmentRepository) GetByStaffWithServiceDetails(staffID int) ([]AppointmentWithService, error) {
-	rows, err := ar.db.Query(`
+func (ar *AppointmentRepository) GetByStaffWithServiceDetails(ctx context.Context, staffID int) ([]AppointmentWithService, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx, `
 		SELECT
-			a.id, a.customer_name, a.customer_email, a.customer_phone,
+			a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone,
 			a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes,
 			a.status, a.notes, a.created_at, a.updated_at,
 			s.price_cents
@@ -312,7 +813,7 @@ func (ar *AppointmentRepository) GetByStaffWithServiceDetails(staffID int) ([]Ap
 	for rows.Next() {
 		var a AppointmentWithService
 		if err := rows.Scan(
-			&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
+			&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
 			&a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes,
 			&a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
 			&a.PriceCents,
@@ -327,17 +828,21 @@ func (ar *AppointmentRepository) GetByStaffWithServiceDetails(staffID int) ([]Ap
 	return appointments, nil
 }
 
-// GetByCustomerEmailWithServiceDetails retrieves appointments for a customer with service price
-func (ar *AppointmentRepository) GetByCustomerEmailWithServiceDetails(email string) ([]AppointmentWithService, error) {
-	rows, err := ar.db.Query(`
+// GetByCustomerEmailWithServiceDetails retrieves appointments of the customer with the given
+// email with service price
+func (ar *AppointmentRepository) GetByCustomerEmailWithServiceDetails(ctx context.Context, email string) ([]AppointmentWithService, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := ar.db.QueryContext(ctx, `
 		SELECT
-			a.id, a.customer_name, a.customer_email, a.customer_phone,
+			a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone,
 			a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes,
 			a.status, a.notes, a.created_at, a.updated_at,
 			s.price_cents
 		FROM appointments a
 		JOIN services s ON a.service_id = s.id
-		WHERE a.customer_email = $1
+		WHERE a.customer_id = (SELECT id FROM customers WHERE email = LOWER($1))
 		ORDER BY a.appointment_datetime DESC
 	`, email)
 	if err != nil {
@@ -349,7 +854,7 @@ func (ar *AppointmentRepository) GetByCustomerEmailWithServiceDetails(email stri
 	for rows.Next() {
 		var a AppointmentWithService
 		if err := rows.Scan(
-			&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
+			&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
 			&a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes,
 			&a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
 			&a.PriceCents,
@@ -365,13 +870,16 @@ func (ar *AppointmentRepository) GetByCustomerEmailWithServiceDetails(email stri
 }
 
 // GetUpcomingWithServiceDetails retrieves upcoming appointments with service price
-func (ar *AppointmentRepository) GetUpcomingWithServiceDetails(limit int) ([]AppointmentWithService, error) {
+func (ar *AppointmentRepository) GetUpcomingWithServiceDetails(ctx context.Context, limit int) ([]AppointmentWithService, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	if limit <= 0 {
 		limit = 50 // default
 	}
-	rows, err := ar.db.Query(`
+	rows, err := ar.db.QueryContext(ctx, `
 		SELECT
-			a.id, a.customer_name, a.customer_email, a.customer_phone,
+			a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone,
 			a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes,
 			a.status, a.notes, a.created_at, a.updated_at,
 			s.price_cents
@@ -390,7 +898,7 @@ func (ar *AppointmentRepository) GetUpcomingWithServiceDetails(limit int) ([]App
 	for rows.Next() {
 		var a AppointmentWithService
 		if err := rows.Scan(
-			&a.ID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
+			&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
 			&a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes,
 			&a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
 			&a.PriceCents,
@@ -404,3 +912,75 @@ func (ar *AppointmentRepository) GetUpcomingWithServiceDetails(limit int) ([]App
 	}
 	return appointments, nil
 }
+
+// ListWithServiceDetails retrieves one page of the appointments matching filter with service
+// price, together with the number of matching appointments across all pages
+func (ar *AppointmentRepository) ListWithServiceDetails(ctx context.Context, filter AppointmentFilter, opts ListOptions) ([]AppointmentWithService, int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	w := &whereBuilder{}
+	if filter.StaffID != 0 {
+		w.add("a.staff_id = ?", filter.StaffID)
+	}
+	if filter.ServiceID != 0 {
+		w.add("a.service_id = ?", filter.ServiceID)
+	}
+	if filter.CustomerID != 0 {
+		w.add("a.customer_id = ?", filter.CustomerID)
+	}
+	if filter.CustomerEmail != "" {
+		w.add("a.customer_id = (SELECT id FROM customers WHERE email = LOWER(?))", filter.CustomerEmail)
+	}
+	if len(filter.Statuses) > 0 {
+		w.add("a.status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if filter.From != nil {
+		w.add("a.appointment_datetime >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		w.add("a.appointment_datetime < ?", filter.To.UTC())
+	}
+	from := "FROM appointments a JOIN services s ON a.service_id = s.id " + w.sql()
+
+	var total int
+	if err := ar.db.QueryRowContext(ctx, "SELECT COUNT(*) "+from, w.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, err := orderBy(opts.Sort, appointmentSortColumns, "a.id DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := ar.db.QueryContext(ctx, `
+		SELECT
+			a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone,
+			a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes,
+			a.status, a.notes, a.created_at, a.updated_at,
+			s.price_cents
+		`+from+" "+order+limitOffset(w, opts),
+		w.args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var appointments []AppointmentWithService
+	for rows.Next() {
+		var a AppointmentWithService
+		if err := rows.Scan(
+			&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone,
+			&a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes,
+			&a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
+			&a.PriceCents,
+		); err != nil {
+			return nil, 0, err
+		}
+		appointments = append(appointments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return appointments, total, nil
+}
@@ -0,0 +1,68 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CalendarFeedRepository handles database operations for staff calendar feed tokens
+type CalendarFeedRepository struct {
+	db *sql.DB
+}
+
+// NewCalendarFeedRepository creates a new calendar feed repository
+func NewCalendarFeedRepository(db *sql.DB) *CalendarFeedRepository {
+	return &CalendarFeedRepository{db: db}
+}
+
+// GetByStaff retrieves a staff member's feed; returns nil if it has none
+func (fr *CalendarFeedRepository) GetByStaff(ctx context.Context, staffID int) (*StaffCalendarFeed, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	f := &StaffCalendarFeed{}
+	err := fr.db.QueryRowContext(ctx,
+		"SELECT staff_id, token, created_at FROM staff_calendar_feeds WHERE staff_id = $1",
+		staffID,
+	).Scan(&f.StaffID, &f.Token, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Create adds a feed for a staff member unless it has one. Returns the staff member's
+// feed, which has a different token if another was created first.
+func (fr *CalendarFeedRepository) Create(ctx context.Context, staffID int, token string) (*StaffCalendarFeed, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := fr.db.ExecContext(ctx,
+		"INSERT INTO staff_calendar_feeds (staff_id, token) VALUES ($1, $2) ON CONFLICT (staff_id) DO NOTHING",
+		staffID, token,
+	); err != nil {
+		return nil, err
+	}
+	return fr.GetByStaff(ctx, staffID)
+}
+
+// Replace sets a staff member's feed token, creating the feed if needed
+func (fr *CalendarFeedRepository) Replace(ctx context.Context, staffID int, token string) (*StaffCalendarFeed, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	f := &StaffCalendarFeed{}
+	err := fr.db.QueryRowContext(ctx,
+		`INSERT INTO staff_calendar_feeds (staff_id, token) VALUES ($1, $2)
+		 ON CONFLICT (staff_id) DO UPDATE SET token = EXCLUDED.token, created_at = NOW()
+		 RETURNING staff_id, token, created_at`,
+		staffID, token,
+	).Scan(&f.StaffID, &f.Token, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -8,7 +9,7 @@ import (
 	"os"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Connect establishes a connection to the appointment_booking database.
@@ -85,6 +86,11 @@ func Connect() (*sql.DB, error) {
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
+	// Per-query deadline for repository calls
+	if err := configureQueryTimeout(); err != nil {
+		return nil, err
+	}
+
 	// Test connection
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
@@ -112,137 +118,42 @@ func maskPassword(dbURL string) string {
 	return dbURL
 }
 
-// getEnv retrieves an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
-}
-
-// InitSchema creates all necessary tables for the appointment booking feature if they don't exist.
-// This is a temporary scaffold solution. For production, use proper database migrations.
-// TODO: Replace with proper migration tool (e.g., golang-migrate, goose) for versioned, repeatable migrations.
-// WARNING: Current approach is not suitable for production deployments without migration strategy.
-func InitSchema(db *sql.DB) error {
-	log.Println("Initializing appointment booking database schema...")
-
-	// Create services table
-	createServicesTable := `
-	CREATE TABLE IF NOT EXISTS services (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		description TEXT,
-		duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
-		price_cents INTEGER NOT NULL CHECK (price_cents >= 0),
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-	)`
-
-	_, err := db.Exec(createServicesTable)
-	if err != nil {
-		return fmt.Errorf("failed to create services table: %w", err)
-	}
-
-	// Create staff table
-	createStaffTable := `
-	CREATE TABLE IF NOT EXISTS staff (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) UNIQUE NOT NULL,
-		phone VARCHAR(50),
-		role VARCHAR(100) NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-	)`
-
-	_, err = db.Exec(createStaffTable)
-	if err != nil {
-		return fmt.Errorf("failed to create staff table: %w", err)
-	}
-
-	// Create staff_services junction table
-	createStaffServicesTable := `
-	CREATE TABLE IF NOT EXISTS staff_services (
-		staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
-		service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
-		PRIMARY KEY (staff_id, service_id)
-	)`
-
-	_, err = db.Exec(createStaffServicesTable)
-	if err != nil {
-		return fmt.Errorf("failed to create staff_services table: %w", err)
-	}
-
-	// Create schedules table
-	createSchedulesTable := `
-	CREATE TABLE IF NOT EXISTS schedules (
-		id SERIAL PRIMARY KEY,
-		staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
-		day_of_week INTEGER NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
-		start_time TIME NOT NULL,
-		end_time TIME NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		UNIQUE(staff_id, day_of_week, start_time, end_time)
-	)`
-
-	_, err = db.Exec(createSchedulesTable)
-	if err != nil {
-		return fmt.Errorf("failed to create schedules table: %w", err)
-	}
+// queryTimeout bounds every repository call so a slow query cannot hold a pooled
+// connection indefinitely. Override with DB_QUERY_TIMEOUT (e.g. "3s").
+var queryTimeout = 5 * time.Second
 
-	// Create appointments table
-	createAppointmentsTable := `
-	CREATE TABLE IF NOT EXISTS appointments (
-		id SERIAL PRIMARY KEY,
-		customer_name VARCHAR(255) NOT NULL,
-		customer_email VARCHAR(255) NOT NULL,
-		customer_phone VARCHAR(50),
-		staff_id INTEGER NOT NULL REFERENCES staff(id),
-		service_id INTEGER NOT NULL REFERENCES services(id),
-		appointment_datetime TIMESTAMP NOT NULL,
-		duration_minutes INTEGER NOT NULL,
-		status VARCHAR(50) NOT NULL DEFAULT 'confirmed',
-		notes TEXT,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-	)`
-
-	_, err = db.Exec(createAppointmentsTable)
-	if err != nil {
-		return fmt.Errorf("failed to create appointments table: %w", err)
+// configureQueryTimeout applies DB_QUERY_TIMEOUT if set
+func configureQueryTimeout() error {
+	value := getEnv("DB_QUERY_TIMEOUT", "")
+	if value == "" {
+		return nil
 	}
-
-	// Create indexes for better query performance
-	_, err = db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_appt_booking_appointments_staff_datetime ON appointments(staff_id, appointment_datetime)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on appointments(staff_id, appointment_datetime): %w", err)
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid DB_QUERY_TIMEOUT %q, expected a positive duration such as 5s", value)
 	}
+	queryTimeout = timeout
+	return nil
+}
 
-	_, err = db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_appt_booking_appointments_customer_email ON appointments(customer_email)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on appointments(customer_email): %w", err)
-	}
+// withQueryTimeout derives the context for a single repository call. The caller's
+// deadline (e.g. the request timeout) still applies when it is earlier.
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
 
-	_, err = db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_appt_booking_schedules_staff_day ON schedules(staff_id, day_of_week)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create index on schedules(staff_id, day_of_week): %w", err)
+// getEnv retrieves an environment variable or returns a default value
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
 	}
-
-	log.Println("Appointment booking database schema initialized successfully")
-	return nil
+	return defaultValue
 }
 
 // SeedSampleData inserts preloaded sample data for testing and demonstration.
+// Sample appointment times are wall-clock times in loc (the business default timezone).
 // This is idempotent - can be safely called multiple times.
-func SeedSampleData(db *sql.DB) error {
+func SeedSampleData(db *sql.DB, loc *time.Location) error {
 	log.Println("Seeding sample data for appointment booking...")
 
 	// Check if data already exists to avoid duplicates
@@ -371,21 +282,32 @@ func SeedSampleData(db *sql.DB) error {
 		log.Printf("  Inserted schedule: %s day %d %s-%s", s.staffName, s.day, s.start, s.end)
 	}
 
+	// Insert the sample customer the appointments below belong to
+	var customerID int
+	err = db.QueryRow(
+		"INSERT INTO customers (name, email, phone) VALUES ($1, $2, $3) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name RETURNING id",
+		"Sample Customer", "customer@example.com", "555-1234",
+	).Scan(&customerID)
+	if err != nil {
+		return fmt.Errorf("failed to insert sample customer: %w", err)
+	}
+	log.Printf("  Inserted customer: Sample Customer (ID: %d)", customerID)
+
 	// Insert some sample appointments (upcoming)
 	// Use a helper to create appointments
 	createAppointment := func(staffName string, serviceName string, year, month, day, hour, minute int, duration int, status string) error {
 		staffID := staffIDs[staffName]
 		serviceID := serviceIDs[serviceName]
-		datetime := time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.Local)
+		datetime := time.Date(year, time.Month(month), day, hour, minute, 0, 0, loc)
 		_, err := db.Exec(
-			"INSERT INTO appointments (customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
-			"Sample Customer", "customer@example.com", "555-1234", staffID, serviceID, datetime, duration, status, "",
+			"INSERT INTO appointments (customer_id, customer_name, customer_email, customer_phone, staff_id, service_id, appointment_datetime, duration_minutes, status, notes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+			customerID, "Sample Customer", "customer@example.com", "555-1234", staffID, serviceID, datetime.UTC(), duration, status, "",
 		)
 		return err
 	}
 
 	// Create a few appointments for John and Jane
-	now := time.Now()
+	now := time.Now().In(loc)
 	year, month, day := now.Year(), int(now.Month()), now.Day()
 	_ = createAppointment("John Smith", "Haircut", year, month, day+1, 9, 30, 30, "confirmed")
 	_ = createAppointment("John Smith", "Beard Trim", year, month, day+2, 14, 0, 15, "confirmed")
@@ -397,17 +319,8 @@ func SeedSampleData(db *sql.DB) error {
 	return nil
 }
 
-// isDuplicateKeyError checks if an error is a PostgreSQL duplicate key violation
+// isDuplicateKeyError checks if an error is a PostgreSQL unique violation (23505)
 func isDuplicateKeyError(err error) bool {
-	// PostgreSQL error code for unique_violation is 23505
-	// This is a simple check; for more robust handling, use pgerror package
-	if err == nil {
-		return false
-	}
-	// Check if error message contains "duplicate key" or "unique constraint"
-	// This is a heuristic; proper way is to check sql.State
-	if err.Error()[0:23] == "ERROR: duplicate key value" {
-		return true
-	}
-	return false
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
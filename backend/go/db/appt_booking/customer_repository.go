@@ -0,0 +1,175 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrCustomerEmailTaken is returned when a customer with the same email already exists
+var ErrCustomerEmailTaken = errors.New("a customer with this email already exists")
+
+// CustomerRepository handles database operations for customers
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+// NewCustomerRepository creates a new customer repository
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// Create inserts a new customer; returns ErrCustomerEmailTaken if the email is in use
+func (cr *CustomerRepository) Create(ctx context.Context, name, email, phone string) (*Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	c := &Customer{}
+	err := cr.db.QueryRowContext(ctx,
+		"INSERT INTO customers (name, email, phone, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, name, email, phone, created_at, updated_at",
+		name, email, phone, now, now,
+	).Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, ErrCustomerEmailTaken
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update modifies an existing customer; returns nil if it does not exist and
+// ErrCustomerEmailTaken if another customer has the email
+func (cr *CustomerRepository) Update(ctx context.Context, id int, name, email, phone string) (*Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	c := &Customer{}
+	err := cr.db.QueryRowContext(ctx,
+		"UPDATE customers SET name = $1, email = $2, phone = $3, updated_at = $4 WHERE id = $5 RETURNING id, name, email, phone, created_at, updated_at",
+		name, email, phone, time.Now(), id,
+	).Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if isDuplicateKeyError(err) {
+			return nil, ErrCustomerEmailTaken
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// List retrieves one page of customers, together with the total number of customers
+func (cr *CustomerRepository) List(ctx context.Context, opts ListOptions) ([]Customer, int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var total int
+	if err := cr.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM customers").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, err := orderBy(opts.Sort, customerSortColumns, "id")
+	if err != nil {
+		return nil, 0, err
+	}
+	w := &whereBuilder{}
+	rows, err := cr.db.QueryContext(ctx,
+		"SELECT id, name, email, phone, created_at, updated_at FROM customers "+order+limitOffset(w, opts),
+		w.args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var customers []Customer
+	for rows.Next() {
+		var c Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+// GetByID retrieves a customer by ID
+func (cr *CustomerRepository) GetByID(ctx context.Context, id int) (*Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	c := &Customer{}
+	err := cr.db.QueryRowContext(ctx,
+		"SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE id = $1",
+		id,
+	).Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetByEmail retrieves a customer by (lower-cased) email
+func (cr *CustomerRepository) GetByEmail(ctx context.Context, email string) (*Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	c := &Customer{}
+	err := cr.db.QueryRowContext(ctx,
+		"SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE email = $1",
+		email,
+	).Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetStats aggregates the appointments of a customer
+func (cr *CustomerRepository) GetStats(ctx context.Context, id int) (*CustomerStats, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	stats := &CustomerStats{}
+	err := cr.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE a.status = 'completed'),
+			COALESCE(SUM(s.price_cents) FILTER (WHERE a.status = 'completed'), 0),
+			MAX(a.appointment_datetime) FILTER (WHERE a.status = 'completed'),
+			COUNT(*) FILTER (WHERE a.status = 'no_show'),
+			COUNT(*) FILTER (WHERE ac.late)
+		FROM appointments a
+		JOIN services s ON a.service_id = s.id
+		LEFT JOIN appointment_cancellations ac ON ac.appointment_id = a.id
+		WHERE a.customer_id = $1
+	`, id).Scan(&stats.AppointmentCount, &stats.VisitCount, &stats.LifetimeSpendCents, &stats.LastVisit, &stats.NoShowCount, &stats.LateCancelCount)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Delete removes a customer. Appointments reference customers without ON DELETE,
+// so deleting a customer who has appointments fails.
+func (cr *CustomerRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := cr.db.ExecContext(ctx, "DELETE FROM customers WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,195 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ExternalCalendarRepository handles database operations for staff members' external
+// calendars and the busy times expanded from them
+type ExternalCalendarRepository struct {
+	db *sql.DB
+}
+
+// NewExternalCalendarRepository creates a new external calendar repository
+func NewExternalCalendarRepository(db *sql.DB) *ExternalCalendarRepository {
+	return &ExternalCalendarRepository{db: db}
+}
+
+const externalCalendarColumns = "id, staff_id, name, url, ics_data, last_synced_at, last_error, created_at, updated_at"
+
+// scanExternalCalendar scans a row selected with externalCalendarColumns
+func scanExternalCalendar(row interface{ Scan(...interface{}) error }) (*ExternalCalendar, error) {
+	c := &ExternalCalendar{}
+	err := row.Scan(&c.ID, &c.StaffID, &c.Name, &c.URL, &c.Data, &c.LastSyncedAt, &c.LastError, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// scanExternalCalendars scans every row of a query selecting externalCalendarColumns
+func scanExternalCalendars(rows *sql.Rows) ([]ExternalCalendar, error) {
+	defer rows.Close()
+
+	var calendars []ExternalCalendar
+	for rows.Next() {
+		c, err := scanExternalCalendar(rows)
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, *c)
+	}
+	return calendars, rows.Err()
+}
+
+// Create adds an external calendar fetched from url, or uploaded as data when url is empty
+func (cr *ExternalCalendarRepository) Create(ctx context.Context, staffID int, name, url, data string) (*ExternalCalendar, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return scanExternalCalendar(cr.db.QueryRowContext(ctx,
+		`INSERT INTO external_calendars (staff_id, name, url, ics_data, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $5)
+		 RETURNING `+externalCalendarColumns,
+		staffID, name, url, data, now,
+	))
+}
+
+// GetByID retrieves an external calendar; returns nil if it does not exist
+func (cr *ExternalCalendarRepository) GetByID(ctx context.Context, id int) (*ExternalCalendar, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	c, err := scanExternalCalendar(cr.db.QueryRowContext(ctx,
+		"SELECT "+externalCalendarColumns+" FROM external_calendars WHERE id = $1", id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// GetByStaff retrieves a staff member's external calendars by name
+func (cr *ExternalCalendarRepository) GetByStaff(ctx context.Context, staffID int) ([]ExternalCalendar, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := cr.db.QueryContext(ctx,
+		"SELECT "+externalCalendarColumns+" FROM external_calendars WHERE staff_id = $1 ORDER BY name, id", staffID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanExternalCalendars(rows)
+}
+
+// GetAll retrieves every external calendar
+func (cr *ExternalCalendarRepository) GetAll(ctx context.Context) ([]ExternalCalendar, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := cr.db.QueryContext(ctx, "SELECT "+externalCalendarColumns+" FROM external_calendars ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return scanExternalCalendars(rows)
+}
+
+// Delete removes an external calendar and its busy times
+func (cr *ExternalCalendarRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := cr.db.ExecContext(ctx, "DELETE FROM external_calendars WHERE id = $1", id)
+	return err
+}
+
+// ReplaceBusyTimes swaps a calendar's busy times for busy and records a successful sync
+// at syncedAt, atomically
+func (cr *ExternalCalendarRepository) ReplaceBusyTimes(ctx context.Context, calendarID int, busy []ExternalBusyTime, syncedAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := cr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var staffID int
+	err = tx.QueryRowContext(ctx,
+		`UPDATE external_calendars SET last_synced_at = $1, last_error = '', updated_at = $1
+		 WHERE id = $2
+		 RETURNING staff_id`,
+		syncedAt.UTC(), calendarID,
+	).Scan(&staffID)
+	if err == sql.ErrNoRows {
+		return nil // deleted meanwhile
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM external_busy_times WHERE calendar_id = $1", calendarID); err != nil {
+		return err
+	}
+	if len(busy) > 0 {
+		starts := make([]time.Time, len(busy))
+		ends := make([]time.Time, len(busy))
+		for i, b := range busy {
+			starts[i], ends[i] = b.StartTime.UTC(), b.EndTime.UTC()
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO external_busy_times (calendar_id, staff_id, start_time, end_time)
+			 SELECT $1, $2, s, e FROM unnest($3::timestamp[], $4::timestamp[]) AS t(s, e)`,
+			calendarID, staffID, pq.Array(starts), pq.Array(ends),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordSyncError notes that refreshing a calendar failed. Its busy times are kept.
+func (cr *ExternalCalendarRepository) RecordSyncError(ctx context.Context, calendarID int, message string, at time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := cr.db.ExecContext(ctx,
+		"UPDATE external_calendars SET last_error = $1, updated_at = $2 WHERE id = $3",
+		message, at.UTC(), calendarID,
+	)
+	return err
+}
+
+// GetBusyByStaffBetween retrieves a staff member's busy times overlapping [from, to),
+// ordered by start
+func (cr *ExternalCalendarRepository) GetBusyByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]ExternalBusyTime, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := cr.db.QueryContext(ctx,
+		`SELECT calendar_id, staff_id, start_time, end_time FROM external_busy_times
+		 WHERE staff_id = $1 AND start_time < $3 AND end_time > $2
+		 ORDER BY start_time, end_time`,
+		staffID, from.UTC(), to.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var busy []ExternalBusyTime
+	for rows.Next() {
+		var b ExternalBusyTime
+		if err := rows.Scan(&b.CalendarID, &b.StaffID, &b.StartTime, &b.EndTime); err != nil {
+			return nil, err
+		}
+		busy = append(busy, b)
+	}
+	return busy, rows.Err()
+}
@@ -0,0 +1,208 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrHoldNotFound is returned when a hold does not exist or has expired
+var ErrHoldNotFound = errors.New("hold not found or expired")
+
+// ErrHoldLimitReached is returned when an account already has as many unexpired holds as
+// it may
+var ErrHoldLimitReached = errors.New("too many active holds")
+
+// holdOwnerLockNamespace is the first key of the per-account advisory lock taken while
+// counting an account's holds. It is taken before the per-staff lock.
+const holdOwnerLockNamespace = 1002
+
+// HoldRepository handles database operations for slot holds
+type HoldRepository struct {
+	db *sql.DB
+}
+
+// NewHoldRepository creates a new hold repository
+func NewHoldRepository(db *sql.DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+const holdColumns = "id, token, staff_id, service_id, starts_at, duration_minutes, waitlist_entry_id, user_id, expires_at, created_at"
+
+// scanHold scans a row selected with holdColumns
+func scanHold(row interface{ Scan(...interface{}) error }) (*SlotHold, error) {
+	h := &SlotHold{}
+	err := row.Scan(&h.ID, &h.Token, &h.StaffID, &h.ServiceID, &h.StartsAt, &h.DurationMinutes, &h.WaitlistEntryID, &h.UserID, &h.ExpiresAt, &h.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// CreateExclusive reserves [start, start+durationMinutes) for staffID until expiresAt.
+// Overlaps with appointments or other unexpired holds return ErrAppointmentConflict. A hold
+// owned by userID is refused with ErrHoldLimitReached when the account already has
+// maxActive unexpired holds; 0 means no limit.
+func (hr *HoldRepository) CreateExclusive(ctx context.Context, token string, staffID, serviceID int, start time.Time, durationMinutes int, waitlistEntryID, userID *int, maxActive int, expiresAt time.Time) (*SlotHold, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := hr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if userID != nil && maxActive > 0 {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1::int, $2::int)", holdOwnerLockNamespace, *userID); err != nil {
+			return nil, err
+		}
+		var active int
+		err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM slot_holds WHERE user_id = $1 AND expires_at > $2",
+			*userID, time.Now().UTC(),
+		).Scan(&active)
+		if err != nil {
+			return nil, err
+		}
+		if active >= maxActive {
+			return nil, ErrHoldLimitReached
+		}
+	}
+
+	// Holds and bookings for a staff member share one lock
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1::int, $2::int)", appointmentLockNamespace, staffID); err != nil {
+		return nil, err
+	}
+
+	hasConflict, err := checkConflict(ctx, tx, staffID, serviceID, start, durationMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if hasConflict {
+		return nil, ErrAppointmentConflict
+	}
+
+	hold, err := scanHold(tx.QueryRowContext(ctx,
+		`INSERT INTO slot_holds (token, staff_id, service_id, starts_at, duration_minutes, waitlist_entry_id, user_id, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING `+holdColumns,
+		token, staffID, serviceID, start.UTC(), durationMinutes, waitlistEntryID, userID, expiresAt.UTC(), time.Now(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetByToken retrieves a hold by its token, expired or not; returns nil if none matches
+func (hr *HoldRepository) GetByToken(ctx context.Context, token string) (*SlotHold, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	h, err := scanHold(hr.db.QueryRowContext(ctx,
+		"SELECT "+holdColumns+" FROM slot_holds WHERE token = $1",
+		token,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return h, err
+}
+
+// GetByWaitlistEntry retrieves the hold offered to a waitlist entry; returns nil if there is none
+func (hr *HoldRepository) GetByWaitlistEntry(ctx context.Context, entryID int) (*SlotHold, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	h, err := scanHold(hr.db.QueryRowContext(ctx,
+		"SELECT "+holdColumns+" FROM slot_holds WHERE waitlist_entry_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1",
+		entryID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return h, err
+}
+
+// GetActiveByStaffBetween retrieves unexpired holds for a staff member whose blocked time,
+// including their service's buffers, overlaps [from, to)
+func (hr *HoldRepository) GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]SlotHold, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := hr.db.QueryContext(ctx,
+		`SELECT `+holdColumns+`
+		 FROM slot_holds `+serviceBuffersJoin+`
+		 WHERE staff_id = $1
+		   AND expires_at > $2
+		   AND starts_at - (buffer_before_minutes * INTERVAL '1 minute') < $3
+		   AND (starts_at + ((duration_minutes + buffer_after_minutes) * INTERVAL '1 minute')) > $4
+		 ORDER BY starts_at ASC`,
+		staffID, time.Now().UTC(), to.UTC(), from.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []SlotHold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *h)
+	}
+	return holds, rows.Err()
+}
+
+// Delete releases a hold
+func (hr *HoldRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := hr.db.ExecContext(ctx, "DELETE FROM slot_holds WHERE id = $1", id)
+	return err
+}
+
+// DeleteExpired deletes the holds that expired by now and returns them
+func (hr *HoldRepository) DeleteExpired(ctx context.Context, now time.Time) ([]SlotHold, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := hr.db.QueryContext(ctx,
+		"DELETE FROM slot_holds WHERE expires_at <= $1 RETURNING "+holdColumns,
+		now.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []SlotHold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, *h)
+	}
+	return holds, rows.Err()
+}
+
+// deleteActiveHold deletes an unexpired hold within tx and returns it, or ErrHoldNotFound
+func deleteActiveHold(ctx context.Context, tx *sql.Tx, id int) (*SlotHold, error) {
+	h, err := scanHold(tx.QueryRowContext(ctx,
+		"DELETE FROM slot_holds WHERE id = $1 AND expires_at > $2 RETURNING "+holdColumns,
+		id, time.Now().UTC(),
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrHoldNotFound
+	}
+	return h, err
+}
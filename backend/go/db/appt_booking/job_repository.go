@@ -0,0 +1,96 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// JobRepository handles database operations for the schedules of periodic jobs
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+const scheduledJobColumns = "name, next_run_at, locked_until, last_started_at, last_finished_at, last_error"
+
+// scanScheduledJob scans a row selected with scheduledJobColumns
+func scanScheduledJob(row interface{ Scan(...interface{}) error }) (*ScheduledJob, error) {
+	j := &ScheduledJob{}
+	err := row.Scan(&j.Name, &j.NextRunAt, &j.LockedUntil, &j.LastStartedAt, &j.LastFinishedAt, &j.LastError)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Register adds a job first due at firstRun, unless it is already registered
+func (jr *JobRepository) Register(ctx context.Context, name string, firstRun time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := jr.db.ExecContext(ctx,
+		"INSERT INTO scheduled_jobs (name, next_run_at) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING",
+		name, firstRun.UTC(),
+	)
+	return err
+}
+
+// Claim locks a job until now+lease if it is due at now and no other runner holds it.
+// Returns whether it was claimed; of several concurrent claims only one succeeds.
+func (jr *JobRepository) Claim(ctx context.Context, name string, now time.Time, lease time.Duration) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := jr.db.ExecContext(ctx,
+		`UPDATE scheduled_jobs
+		 SET locked_until = $1, last_started_at = $2
+		 WHERE name = $3 AND next_run_at <= $2 AND (locked_until IS NULL OR locked_until <= $2)`,
+		now.Add(lease).UTC(), now.UTC(), name,
+	)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed > 0, err
+}
+
+// Complete releases a claimed job, recording when it finished and how, and when it runs next
+func (jr *JobRepository) Complete(ctx context.Context, name string, nextRun, finishedAt time.Time, lastError string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := jr.db.ExecContext(ctx,
+		`UPDATE scheduled_jobs
+		 SET next_run_at = $1, locked_until = NULL, last_finished_at = $2, last_error = $3
+		 WHERE name = $4`,
+		nextRun.UTC(), finishedAt.UTC(), lastError, name,
+	)
+	return err
+}
+
+// GetAll retrieves every job schedule by name
+func (jr *JobRepository) GetAll(ctx context.Context) ([]ScheduledJob, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := jr.db.QueryContext(ctx, "SELECT "+scheduledJobColumns+" FROM scheduled_jobs ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []ScheduledJob
+	for rows.Next() {
+		j, err := scanScheduledJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,120 @@
+package appt_booking
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SortField orders a list by one field; Field is an API name such as "appointment_datetime"
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions pages and orders a list query. Limit 0 means no limit.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Sort   []SortField
+}
+
+// AppointmentFilter restricts an appointment list; zero values do not filter.
+// All set fields must match.
+type AppointmentFilter struct {
+	StaffID       int
+	ServiceID     int
+	CustomerID    int
+	CustomerEmail string     // matched through the customer record, like GetByCustomerEmail
+	Statuses      []string   // any of these
+	From          *time.Time // appointments starting at or after From
+	To            *time.Time // appointments starting before To
+}
+
+// appointmentSortColumns maps the fields appointment lists can be sorted by to columns
+var appointmentSortColumns = map[string]string{
+	"appointment_datetime": "a.appointment_datetime",
+	"created_at":           "a.created_at",
+	"updated_at":           "a.updated_at",
+	"status":               "a.status",
+	"customer_name":        "a.customer_name",
+	"staff_id":             "a.staff_id",
+	"service_id":           "a.service_id",
+	"price_cents":          "s.price_cents",
+}
+
+// customerSortColumns maps the fields customer lists can be sorted by to columns
+var customerSortColumns = map[string]string{
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// IsAppointmentSortField reports whether appointment lists can be sorted by field
+func IsAppointmentSortField(field string) bool {
+	_, ok := appointmentSortColumns[field]
+	return ok
+}
+
+// IsCustomerSortField reports whether customer lists can be sorted by field
+func IsCustomerSortField(field string) bool {
+	_, ok := customerSortColumns[field]
+	return ok
+}
+
+// whereBuilder collects SQL conditions and their positional arguments
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition; each "?" in cond is replaced by the next placeholder for args
+func (w *whereBuilder) add(cond string, args ...interface{}) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conditions = append(w.conditions, cond)
+}
+
+// sql returns the WHERE clause, or "" without conditions
+func (w *whereBuilder) sql() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// orderBy renders sort as an ORDER BY clause over columns, ending with tiebreak so
+// pages are stable. Unknown fields are an error; callers validate them beforehand.
+func orderBy(sort []SortField, columns map[string]string, tiebreak string) (string, error) {
+	terms := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return "", fmt.Errorf("unknown sort field %q", field.Field)
+		}
+		direction := "ASC"
+		if field.Desc {
+			direction = "DESC"
+		}
+		terms = append(terms, column+" "+direction)
+	}
+	terms = append(terms, tiebreak)
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// limitOffset renders the LIMIT/OFFSET clause, adding its arguments to w
+func limitOffset(w *whereBuilder, opts ListOptions) string {
+	clause := ""
+	if opts.Limit > 0 {
+		w.args = append(w.args, opts.Limit)
+		clause += fmt.Sprintf(" LIMIT $%d", len(w.args))
+	}
+	if opts.Offset > 0 {
+		w.args = append(w.args, opts.Offset)
+		clause += fmt.Sprintf(" OFFSET $%d", len(w.args))
+	}
+	return clause
+}
@@ -0,0 +1,42 @@
+package appt_booking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereBuilder_NumbersPlaceholders(t *testing.T) {
+	w := &whereBuilder{}
+	if got := w.sql(); got != "" {
+		t.Errorf("expected no WHERE clause without conditions, got %q", got)
+	}
+
+	w.add("a.staff_id = ?", 3)
+	w.add("a.appointment_datetime >= ? AND a.appointment_datetime < ?", "from", "to")
+	page := limitOffset(w, ListOptions{Limit: 10, Offset: 20})
+
+	want := "WHERE a.staff_id = $1 AND a.appointment_datetime >= $2 AND a.appointment_datetime < $3"
+	if got := w.sql(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if page != " LIMIT $4 OFFSET $5" {
+		t.Errorf("unexpected LIMIT/OFFSET clause %q", page)
+	}
+	if wantArgs := []interface{}{3, "from", "to", 10, 20}; !reflect.DeepEqual(w.args, wantArgs) {
+		t.Errorf("expected args %v, got %v", wantArgs, w.args)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	got, err := orderBy([]SortField{{Field: "status"}, {Field: "appointment_datetime", Desc: true}}, appointmentSortColumns, "a.id DESC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "ORDER BY a.status ASC, a.appointment_datetime DESC, a.id DESC"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := orderBy([]SortField{{Field: "a.id; DROP TABLE appointments"}}, appointmentSortColumns, "a.id"); err == nil {
+		t.Error("expected unknown sort fields to be rejected")
+	}
+}
@@ -0,0 +1,438 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// AppointmentRepository is the in-memory counterpart of appt_booking.AppointmentRepository.
+// The store's lock makes the overlap check and write atomic, which is what the advisory
+// lock and exclusion constraint guarantee in Postgres.
+type AppointmentRepository struct {
+	store *Store
+}
+
+// CreateExclusive inserts a new appointment unless it overlaps a non-cancelled appointment
+// for the same staff member, in which case appt_booking.ErrAppointmentConflict is returned.
+// event is recorded for the new appointment.
+func (r *AppointmentRepository) CreateExclusive(ctx context.Context, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.customers[customerID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.services[serviceID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if status != appt_booking.AppointmentStatusCancelled && s.hasConflict(staffID, serviceID, appointmentDatetime, durationMinutes, 0) {
+		return nil, appt_booking.ErrAppointmentConflict
+	}
+	a := s.insertAppointment(customerID, customerName, customerEmail, customerPhone, staffID, serviceID, durationMinutes, appointmentDatetime, status, notes)
+	return a, s.recordEvent(event, a.ID, a)
+}
+
+// CreateFromHold books the slot reserved by an unexpired hold, releases the hold and records
+// event for the new appointment. Returns appt_booking.ErrHoldNotFound if the hold does not
+// exist or has expired.
+func (r *AppointmentRepository) CreateFromHold(ctx context.Context, holdID, customerID int, customerName, customerEmail, customerPhone, status, notes string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold, ok := s.holds[holdID]
+	if !ok || !hold.ExpiresAt.After(time.Now()) {
+		return nil, appt_booking.ErrHoldNotFound
+	}
+	if _, ok := s.customers[customerID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	delete(s.holds, holdID)
+	if s.hasConflict(hold.StaffID, hold.ServiceID, hold.StartsAt, hold.DurationMinutes, 0) {
+		s.holds[holdID] = hold
+		return nil, appt_booking.ErrAppointmentConflict
+	}
+	a := s.insertAppointment(customerID, customerName, customerEmail, customerPhone, hold.StaffID, hold.ServiceID, hold.DurationMinutes, hold.StartsAt, status, notes)
+	return a, s.recordEvent(event, a.ID, a)
+}
+
+// insertAppointment stores a new appointment; callers hold s.mu and have checked references
+func (s *Store) insertAppointment(customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string) *appt_booking.Appointment {
+	now := time.Now()
+	a := appt_booking.Appointment{
+		ID:                  s.nextID(),
+		CustomerID:          customerID,
+		CustomerName:        customerName,
+		CustomerEmail:       customerEmail,
+		CustomerPhone:       customerPhone,
+		StaffID:             staffID,
+		ServiceID:           serviceID,
+		AppointmentDatetime: appointmentDatetime.UTC(),
+		DurationMinutes:     durationMinutes,
+		Status:              status,
+		Notes:               notes,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	s.appointments[a.ID] = a
+	return &a
+}
+
+// RescheduleExclusive moves an appointment to a new staff member and/or datetime and
+// records the previous values and event. Overlaps with other appointments return
+// appt_booking.ErrAppointmentConflict and an appointment that can no longer be moved
+// appt_booking.ErrAppointmentStatusChanged; returns nil if the appointment does not exist.
+func (r *AppointmentRepository) RescheduleExclusive(ctx context.Context, id, staffID int, appointmentDatetime time.Time, durationMinutes int, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, nil
+	}
+	if !slices.Contains(appt_booking.ReschedulableStatuses, a.Status) {
+		return nil, appt_booking.ErrAppointmentStatusChanged
+	}
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if s.hasConflict(staffID, a.ServiceID, appointmentDatetime, durationMinutes, id) {
+		return nil, appt_booking.ErrAppointmentConflict
+	}
+
+	now := time.Now()
+	s.reschedules = append(s.reschedules, appt_booking.AppointmentReschedule{
+		ID:               s.nextID(),
+		AppointmentID:    id,
+		PreviousStaffID:  a.StaffID,
+		PreviousDatetime: a.AppointmentDatetime,
+		NewStaffID:       staffID,
+		NewDatetime:      appointmentDatetime.UTC(),
+		CreatedAt:        now,
+	})
+	a.StaffID = staffID
+	a.AppointmentDatetime = appointmentDatetime.UTC()
+	a.UpdatedAt = now
+	s.appointments[id] = a
+	return &a, s.recordEvent(event, id, a)
+}
+
+// TransitionStatus changes an appointment's status from fromStatus to toStatus and records
+// the change and event. If the status is no longer fromStatus (or the appointment does not
+// exist) appt_booking.ErrAppointmentStatusChanged is returned.
+func (r *AppointmentRepository) TransitionStatus(ctx context.Context, id int, fromStatus, toStatus, changedBy, reason string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, err := s.transitionStatus(id, fromStatus, toStatus, changedBy, reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return a, s.recordEvent(event, id, a)
+}
+
+// Cancel moves an appointment from fromStatus to cancelled like TransitionStatus and records
+// the cancellation and event
+func (r *AppointmentRepository) Cancel(ctx context.Context, id int, fromStatus, changedBy string, cancellation appt_booking.AppointmentCancellation, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cancellations[id]; ok {
+		return nil, ErrUniqueViolation
+	}
+	now := time.Now()
+	a, err := s.transitionStatus(id, fromStatus, appt_booking.AppointmentStatusCancelled, changedBy, cancellation.Reason, now)
+	if err != nil {
+		return nil, err
+	}
+	cancellation.AppointmentID = id
+	cancellation.CreatedAt = now
+	s.cancellations[id] = cancellation
+	return a, s.recordEvent(event, id, a)
+}
+
+// transitionStatus updates the status and appends the history entry; callers hold s.mu
+func (s *Store) transitionStatus(id int, fromStatus, toStatus, changedBy, reason string, now time.Time) (*appt_booking.Appointment, error) {
+	a, ok := s.appointments[id]
+	if !ok || a.Status != fromStatus {
+		return nil, appt_booking.ErrAppointmentStatusChanged
+	}
+	// Reviving a cancelled appointment can collide with a booking made since
+	if fromStatus == appt_booking.AppointmentStatusCancelled && toStatus != appt_booking.AppointmentStatusCancelled &&
+		s.hasConflict(a.StaffID, a.ServiceID, a.AppointmentDatetime, a.DurationMinutes, id) {
+		return nil, appt_booking.ErrAppointmentConflict
+	}
+
+	a.Status = toStatus
+	a.UpdatedAt = now
+	s.appointments[id] = a
+	s.statusHistory = append(s.statusHistory, appt_booking.AppointmentStatusChange{
+		ID:            s.nextID(),
+		AppointmentID: id,
+		FromStatus:    fromStatus,
+		ToStatus:      toStatus,
+		ChangedBy:     changedBy,
+		Reason:        reason,
+		CreatedAt:     now,
+	})
+	return &a, nil
+}
+
+// GetCancellation retrieves the cancellation of an appointment; returns nil if it was not cancelled
+func (r *AppointmentRepository) GetCancellation(ctx context.Context, appointmentID int) (*appt_booking.AppointmentCancellation, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cancellations[appointmentID]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// GetAll retrieves all appointments, latest first
+func (r *AppointmentRepository) GetAll(ctx context.Context) ([]appt_booking.Appointment, error) {
+	return r.filter(func(appt_booking.Appointment) bool { return true }, false), nil
+}
+
+// GetByID retrieves an appointment by ID; returns nil if it does not exist
+func (r *AppointmentRepository) GetByID(ctx context.Context, id int) (*appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// GetByStaff retrieves all appointments for a staff member, latest first
+func (r *AppointmentRepository) GetByStaff(ctx context.Context, staffID int) ([]appt_booking.Appointment, error) {
+	return r.filter(func(a appt_booking.Appointment) bool { return a.StaffID == staffID }, false), nil
+}
+
+// GetByCustomerEmail retrieves all appointments of the customer with the given email, latest first
+func (r *AppointmentRepository) GetByCustomerEmail(ctx context.Context, email string) ([]appt_booking.Appointment, error) {
+	customerID := r.store.customerIDByEmail(email)
+	return r.filter(func(a appt_booking.Appointment) bool { return a.CustomerID == customerID }, false), nil
+}
+
+// GetUpcoming retrieves up to limit non-cancelled appointments from now onwards, soonest first
+func (r *AppointmentRepository) GetUpcoming(ctx context.Context, limit int) ([]appt_booking.Appointment, error) {
+	now := time.Now()
+	appointments := r.filter(func(a appt_booking.Appointment) bool {
+		return !a.AppointmentDatetime.Before(now) && a.Status != appt_booking.AppointmentStatusCancelled
+	}, true)
+	return truncate(appointments, limit), nil
+}
+
+// GetActiveByStaffBetween retrieves non-cancelled appointments for a staff member whose
+// blocked time, including their service's buffers, overlaps [from, to)
+func (r *AppointmentRepository) GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.Appointment, error) {
+	s := r.store
+	return r.filter(func(a appt_booking.Appointment) bool {
+		return a.StaffID == staffID && a.Status != appt_booking.AppointmentStatusCancelled && s.appointmentBlocks(a, from, to)
+	}, true), nil
+}
+
+// GetReschedules retrieves the reschedule history of an appointment, most recent first
+func (r *AppointmentRepository) GetReschedules(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentReschedule, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reschedules []appt_booking.AppointmentReschedule
+	for i := len(s.reschedules) - 1; i >= 0; i-- {
+		if s.reschedules[i].AppointmentID == appointmentID {
+			reschedules = append(reschedules, s.reschedules[i])
+		}
+	}
+	return reschedules, nil
+}
+
+// CountReschedules returns how often each of the given appointments has been rescheduled
+func (r *AppointmentRepository) CountReschedules(ctx context.Context, appointmentIDs []int) (map[int]int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[int]bool, len(appointmentIDs))
+	for _, id := range appointmentIDs {
+		wanted[id] = true
+	}
+	counts := make(map[int]int)
+	for _, reschedule := range s.reschedules {
+		if wanted[reschedule.AppointmentID] {
+			counts[reschedule.AppointmentID]++
+		}
+	}
+	return counts, nil
+}
+
+// GetStatusHistory retrieves the status transitions of an appointment, oldest first
+func (r *AppointmentRepository) GetStatusHistory(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentStatusChange, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history []appt_booking.AppointmentStatusChange
+	for _, h := range s.statusHistory {
+		if h.AppointmentID == appointmentID {
+			history = append(history, h)
+		}
+	}
+	return history, nil
+}
+
+// GetAllWithServiceDetails retrieves all appointments with service price, latest first
+func (r *AppointmentRepository) GetAllWithServiceDetails(ctx context.Context) ([]appt_booking.AppointmentWithService, error) {
+	return r.withServiceDetails(r.filter(func(appt_booking.Appointment) bool { return true }, false)), nil
+}
+
+// GetByStaffWithServiceDetails retrieves appointments for a staff member with service price, latest first
+func (r *AppointmentRepository) GetByStaffWithServiceDetails(ctx context.Context, staffID int) ([]appt_booking.AppointmentWithService, error) {
+	return r.withServiceDetails(r.filter(func(a appt_booking.Appointment) bool { return a.StaffID == staffID }, false)), nil
+}
+
+// GetByCustomerEmailWithServiceDetails retrieves appointments of the customer with the given
+// email with service price, latest first
+func (r *AppointmentRepository) GetByCustomerEmailWithServiceDetails(ctx context.Context, email string) ([]appt_booking.AppointmentWithService, error) {
+	customerID := r.store.customerIDByEmail(email)
+	return r.withServiceDetails(r.filter(func(a appt_booking.Appointment) bool { return a.CustomerID == customerID }, false)), nil
+}
+
+// GetUpcomingWithServiceDetails retrieves up to limit appointments (default 50) from now
+// onwards with service price, soonest first
+func (r *AppointmentRepository) GetUpcomingWithServiceDetails(ctx context.Context, limit int) ([]appt_booking.AppointmentWithService, error) {
+	if limit <= 0 {
+		limit = 50 // default
+	}
+	now := time.Now()
+	appointments := r.filter(func(a appt_booking.Appointment) bool { return !a.AppointmentDatetime.Before(now) }, true)
+	return r.withServiceDetails(truncate(appointments, limit)), nil
+}
+
+// ListWithServiceDetails retrieves one page of the appointments matching filter with service
+// price, together with the number of matching appointments across all pages
+func (r *AppointmentRepository) ListWithServiceDetails(ctx context.Context, filter appt_booking.AppointmentFilter, opts appt_booking.ListOptions) ([]appt_booking.AppointmentWithService, int, error) {
+	customerID := 0
+	if filter.CustomerEmail != "" {
+		customerID = r.store.customerIDByEmail(filter.CustomerEmail)
+	}
+	matching := r.withServiceDetails(r.filter(func(a appt_booking.Appointment) bool {
+		return matchesAppointmentFilter(a, filter, customerID)
+	}, false))
+	if err := sortAppointments(matching, opts.Sort); err != nil {
+		return nil, 0, err
+	}
+	start, end := page(len(matching), opts)
+	return matching[start:end], len(matching), nil
+}
+
+// filter returns the matching appointments ordered by appointment_datetime
+func (r *AppointmentRepository) filter(match func(appt_booking.Appointment) bool, ascending bool) []appt_booking.Appointment {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var appointments []appt_booking.Appointment
+	for _, a := range s.appointments {
+		if match(a) {
+			appointments = append(appointments, a)
+		}
+	}
+	sort.Slice(appointments, func(i, j int) bool {
+		a, b := appointments[i], appointments[j]
+		if !a.AppointmentDatetime.Equal(b.AppointmentDatetime) {
+			return a.AppointmentDatetime.Before(b.AppointmentDatetime) == ascending
+		}
+		return a.ID < b.ID
+	})
+	return appointments
+}
+
+// withServiceDetails joins appointments with their service's price
+func (r *AppointmentRepository) withServiceDetails(appointments []appt_booking.Appointment) []appt_booking.AppointmentWithService {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var joined []appt_booking.AppointmentWithService
+	for _, a := range appointments {
+		service, ok := s.services[a.ServiceID]
+		if !ok {
+			continue
+		}
+		joined = append(joined, appt_booking.AppointmentWithService{
+			ID:                  a.ID,
+			CustomerID:          a.CustomerID,
+			CustomerName:        a.CustomerName,
+			CustomerEmail:       a.CustomerEmail,
+			CustomerPhone:       a.CustomerPhone,
+			StaffID:             a.StaffID,
+			ServiceID:           a.ServiceID,
+			AppointmentDatetime: a.AppointmentDatetime,
+			DurationMinutes:     a.DurationMinutes,
+			Status:              a.Status,
+			Notes:               a.Notes,
+			CreatedAt:           a.CreatedAt,
+			UpdatedAt:           a.UpdatedAt,
+			PriceCents:          service.PriceCents,
+		})
+	}
+	return joined
+}
+
+// hasConflict reports whether the blocked time of a booking of serviceID at
+// [start, start+durationMinutes) overlaps that of a non-cancelled appointment for staffID
+// other than excludeID, or of an unexpired hold; callers hold s.mu
+func (s *Store) hasConflict(staffID, serviceID int, start time.Time, durationMinutes, excludeID int) bool {
+	from, to := s.blockedRange(serviceID, start, durationMinutes)
+	for _, a := range s.appointments {
+		if a.ID != excludeID && a.StaffID == staffID && a.Status != appt_booking.AppointmentStatusCancelled && s.appointmentBlocks(a, from, to) {
+			return true
+		}
+	}
+	now := time.Now()
+	for _, h := range s.holds {
+		if h.StaffID == staffID && h.ExpiresAt.After(now) && s.holdBlocks(h, from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedRange returns the staff time taken by a booking of serviceID, from its service's
+// buffer before the start until its buffer after the end; callers hold s.mu
+func (s *Store) blockedRange(serviceID int, start time.Time, durationMinutes int) (time.Time, time.Time) {
+	before, after := s.services[serviceID].Buffers()
+	return start.Add(-before), start.Add(time.Duration(durationMinutes)*time.Minute + after)
+}
+
+// appointmentBlocks reports whether a's blocked time intersects [from, to); callers hold s.mu
+func (s *Store) appointmentBlocks(a appt_booking.Appointment, from, to time.Time) bool {
+	start, end := s.blockedRange(a.ServiceID, a.AppointmentDatetime, a.DurationMinutes)
+	return start.Before(to) && end.After(from)
+}
+
+// truncate applies a LIMIT
+func truncate(appointments []appt_booking.Appointment, limit int) []appt_booking.Appointment {
+	if limit >= 0 && len(appointments) > limit {
+		return appointments[:limit]
+	}
+	return appointments
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func TestCreateExclusive_ConcurrentBookingsForSameSlot(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	staff, err := store.Staff().Create(ctx, "Alice", "alice@example.com", "", "provider", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := store.Services().Create(ctx, "Haircut", "", 60, 3000, appt_booking.BookingPolicy{SlotGranularityMin: 15}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer, err := store.Customers().Create(ctx, "Bob", "bob@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	booked, conflicts := 0, 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Staggered starts so every pair of attempts overlaps
+			start := at.Add(time.Duration(i) * time.Minute)
+			_, err := store.Appointments().CreateExclusive(ctx, customer.ID, "Bob", "bob@example.com", "", staff.ID, service.ID, 60, start, appt_booking.AppointmentStatusConfirmed, "", nil)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				booked++
+			case errors.Is(err, appt_booking.ErrAppointmentConflict):
+				conflicts++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if booked != 1 || conflicts != attempts-1 {
+		t.Errorf("expected 1 booking and %d conflicts, got %d and %d", attempts-1, booked, conflicts)
+	}
+}
+
+func TestTransitionStatus_StaleFromStatus(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	staff, _ := store.Staff().Create(ctx, "Alice", "alice@example.com", "", "provider", "", nil)
+	service, _ := store.Services().Create(ctx, "Haircut", "", 60, 3000, appt_booking.BookingPolicy{SlotGranularityMin: 15}, nil)
+	customer, _ := store.Customers().Create(ctx, "Bob", "bob@example.com", "")
+	a, err := store.Appointments().CreateExclusive(ctx, customer.ID, "Bob", "bob@example.com", "", staff.ID, service.ID, 60, time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC), appt_booking.AppointmentStatusConfirmed, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := store.Appointments()
+	if _, err := repo.TransitionStatus(ctx, a.ID, appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusCancelled, "", "", nil); err != nil {
+		t.Fatalf("first transition: %v", err)
+	}
+	if _, err := repo.TransitionStatus(ctx, a.ID, appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusCompleted, "", "", nil); !errors.Is(err, appt_booking.ErrAppointmentStatusChanged) {
+		t.Errorf("expected ErrAppointmentStatusChanged, got %v", err)
+	}
+
+	history, err := repo.GetStatusHistory(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].ToStatus != appt_booking.AppointmentStatusCancelled {
+		t.Errorf("expected one transition to cancelled, got %+v", history)
+	}
+}
+
+func TestRescheduleExclusive_CancelledAppointment(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	staff, _ := store.Staff().Create(ctx, "Alice", "alice@example.com", "", "provider", "", nil)
+	service, _ := store.Services().Create(ctx, "Haircut", "", 60, 3000, appt_booking.BookingPolicy{SlotGranularityMin: 15}, nil)
+	customer, _ := store.Customers().Create(ctx, "Bob", "bob@example.com", "")
+	at := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+	a, err := store.Appointments().CreateExclusive(ctx, customer.ID, "Bob", "bob@example.com", "", staff.ID, service.ID, 60, at, appt_booking.AppointmentStatusConfirmed, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancelled after the caller read it as confirmed
+	repo := store.Appointments()
+	if _, err := repo.TransitionStatus(ctx, a.ID, appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusCancelled, "", "", nil); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := repo.RescheduleExclusive(ctx, a.ID, staff.ID, at.Add(2*time.Hour), 60, nil); !errors.Is(err, appt_booking.ErrAppointmentStatusChanged) {
+		t.Errorf("expected ErrAppointmentStatusChanged, got %v", err)
+	}
+
+	reschedules, err := repo.GetReschedules(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reschedules) != 0 {
+		t.Errorf("expected no reschedules, got %+v", reschedules)
+	}
+}
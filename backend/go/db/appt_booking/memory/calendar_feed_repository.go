@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// CalendarFeedRepository is the in-memory counterpart of appt_booking.CalendarFeedRepository
+type CalendarFeedRepository struct {
+	store *Store
+}
+
+// GetByStaff retrieves a staff member's feed; returns nil if it has none
+func (r *CalendarFeedRepository) GetByStaff(ctx context.Context, staffID int) (*appt_booking.StaffCalendarFeed, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.calendarFeeds[staffID]
+	if !ok {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// Create adds a feed for a staff member unless it has one, and returns its feed
+func (r *CalendarFeedRepository) Create(ctx context.Context, staffID int, token string) (*appt_booking.StaffCalendarFeed, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.calendarFeeds[staffID]; ok {
+		return &f, nil
+	}
+	return s.putCalendarFeed(staffID, token)
+}
+
+// Replace sets a staff member's feed token, creating the feed if needed
+func (r *CalendarFeedRepository) Replace(ctx context.Context, staffID int, token string) (*appt_booking.StaffCalendarFeed, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putCalendarFeed(staffID, token)
+}
+
+// putCalendarFeed stores a feed, checking its constraints; callers hold s.mu
+func (s *Store) putCalendarFeed(staffID int, token string) (*appt_booking.StaffCalendarFeed, error) {
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	for _, f := range s.calendarFeeds {
+		if f.Token == token && f.StaffID != staffID {
+			return nil, ErrUniqueViolation
+		}
+	}
+	f := appt_booking.StaffCalendarFeed{StaffID: staffID, Token: token, CreatedAt: time.Now().UTC()}
+	s.calendarFeeds[staffID] = f
+	return &f, nil
+}
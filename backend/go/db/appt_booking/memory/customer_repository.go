@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// CustomerRepository is the in-memory counterpart of appt_booking.CustomerRepository
+type CustomerRepository struct {
+	store *Store
+}
+
+// Create inserts a new customer; emails are unique (appt_booking.ErrCustomerEmailTaken)
+func (r *CustomerRepository) Create(ctx context.Context, name, email, phone string) (*appt_booking.Customer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.customerEmailTaken(email, 0) {
+		return nil, appt_booking.ErrCustomerEmailTaken
+	}
+	now := time.Now()
+	c := appt_booking.Customer{
+		ID:        s.nextID(),
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.customers[c.ID] = c
+	return &c, nil
+}
+
+// Update modifies an existing customer; returns nil if it does not exist
+func (r *CustomerRepository) Update(ctx context.Context, id int, name, email, phone string) (*appt_booking.Customer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.customers[id]
+	if !ok {
+		return nil, nil
+	}
+	if s.customerEmailTaken(email, id) {
+		return nil, appt_booking.ErrCustomerEmailTaken
+	}
+	c.Name = name
+	c.Email = email
+	c.Phone = phone
+	c.UpdatedAt = time.Now()
+	s.customers[id] = c
+	return &c, nil
+}
+
+// List retrieves one page of customers, together with the total number of customers
+func (r *CustomerRepository) List(ctx context.Context, opts appt_booking.ListOptions) ([]appt_booking.Customer, int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var customers []appt_booking.Customer
+	for _, c := range s.customers {
+		customers = append(customers, c)
+	}
+	if err := sortCustomers(customers, opts.Sort); err != nil {
+		return nil, 0, err
+	}
+	start, end := page(len(customers), opts)
+	return customers[start:end], len(customers), nil
+}
+
+// GetByID retrieves a customer by ID; returns nil if it does not exist
+func (r *CustomerRepository) GetByID(ctx context.Context, id int) (*appt_booking.Customer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.customers[id]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// GetByEmail retrieves a customer by email; returns nil if none matches
+func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*appt_booking.Customer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.customers {
+		if c.Email == email {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetStats aggregates the appointments of a customer
+func (r *CustomerRepository) GetStats(ctx context.Context, id int) (*appt_booking.CustomerStats, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &appt_booking.CustomerStats{}
+	for _, a := range s.appointments {
+		if a.CustomerID != id {
+			continue
+		}
+		stats.AppointmentCount++
+		if a.Status == appt_booking.AppointmentStatusNoShow {
+			stats.NoShowCount++
+		}
+		if s.cancellations[a.ID].Late {
+			stats.LateCancelCount++
+		}
+		if a.Status != appt_booking.AppointmentStatusCompleted {
+			continue
+		}
+		stats.VisitCount++
+		stats.LifetimeSpendCents += s.services[a.ServiceID].PriceCents
+		if stats.LastVisit == nil || a.AppointmentDatetime.After(*stats.LastVisit) {
+			visit := a.AppointmentDatetime
+			stats.LastVisit = &visit
+		}
+	}
+	return stats, nil
+}
+
+// Delete removes a customer. Deleting a customer who has appointments fails with
+// ErrForeignKeyViolation.
+func (r *CustomerRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.appointments {
+		if a.CustomerID == id {
+			return ErrForeignKeyViolation
+		}
+	}
+	s.deleteSeriesWhere(func(series appt_booking.AppointmentSeries) bool { return series.CustomerID == id })
+	s.deleteWaitlistWhere(func(e appt_booking.WaitlistEntry) bool { return e.CustomerID == id })
+	delete(s.customers, id)
+	return nil
+}
+
+// customerEmailTaken reports whether a customer other than exceptID uses email; callers hold s.mu
+func (s *Store) customerEmailTaken(email string, exceptID int) bool {
+	for _, c := range s.customers {
+		if c.Email == email && c.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// customerIDByEmail returns the ID of the customer with email (compared lower-cased like
+// the Postgres query), or 0 if there is none
+func (s *Store) customerIDByEmail(email string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email = strings.ToLower(email)
+	for _, c := range s.customers {
+		if c.Email == email {
+			return c.ID
+		}
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ExternalCalendarRepository is the in-memory counterpart of appt_booking.ExternalCalendarRepository
+type ExternalCalendarRepository struct {
+	store *Store
+}
+
+// Create adds an external calendar fetched from url, or uploaded as data when url is empty
+func (r *ExternalCalendarRepository) Create(ctx context.Context, staffID int, name, url, data string) (*appt_booking.ExternalCalendar, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if (url == "") == (data == "") {
+		return nil, ErrCheckViolation
+	}
+	now := time.Now().UTC()
+	c := appt_booking.ExternalCalendar{ID: s.nextID(), StaffID: staffID, Name: name, URL: url, Data: data, CreatedAt: now, UpdatedAt: now}
+	s.externalCalendars[c.ID] = c
+	return copyExternalCalendar(c), nil
+}
+
+// GetByID retrieves an external calendar; returns nil if it does not exist
+func (r *ExternalCalendarRepository) GetByID(ctx context.Context, id int) (*appt_booking.ExternalCalendar, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.externalCalendars[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyExternalCalendar(c), nil
+}
+
+// GetByStaff retrieves a staff member's external calendars by name
+func (r *ExternalCalendarRepository) GetByStaff(ctx context.Context, staffID int) ([]appt_booking.ExternalCalendar, error) {
+	return r.list(func(c appt_booking.ExternalCalendar) bool { return c.StaffID == staffID }, func(a, b appt_booking.ExternalCalendar) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
+
+// GetAll retrieves every external calendar
+func (r *ExternalCalendarRepository) GetAll(ctx context.Context) ([]appt_booking.ExternalCalendar, error) {
+	return r.list(func(appt_booking.ExternalCalendar) bool { return true }, func(a, b appt_booking.ExternalCalendar) bool { return a.ID < b.ID })
+}
+
+// list returns the calendars matching keep, sorted by less
+func (r *ExternalCalendarRepository) list(keep func(appt_booking.ExternalCalendar) bool, less func(a, b appt_booking.ExternalCalendar) bool) ([]appt_booking.ExternalCalendar, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calendars []appt_booking.ExternalCalendar
+	for _, c := range s.externalCalendars {
+		if keep(c) {
+			calendars = append(calendars, *copyExternalCalendar(c))
+		}
+	}
+	sort.Slice(calendars, func(i, j int) bool { return less(calendars[i], calendars[j]) })
+	return calendars, nil
+}
+
+// Delete removes an external calendar and its busy times
+func (r *ExternalCalendarRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteExternalCalendarsWhere(func(c appt_booking.ExternalCalendar) bool { return c.ID == id })
+	return nil
+}
+
+// ReplaceBusyTimes swaps a calendar's busy times for busy and records a successful sync
+func (r *ExternalCalendarRepository) ReplaceBusyTimes(ctx context.Context, calendarID int, busy []appt_booking.ExternalBusyTime, syncedAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.externalCalendars[calendarID]
+	if !ok {
+		return nil
+	}
+	kept := s.externalBusyTimes[:0]
+	for _, b := range s.externalBusyTimes {
+		if b.CalendarID != calendarID {
+			kept = append(kept, b)
+		}
+	}
+	for _, b := range busy {
+		if !b.EndTime.After(b.StartTime) {
+			return ErrCheckViolation
+		}
+		kept = append(kept, appt_booking.ExternalBusyTime{CalendarID: calendarID, StaffID: c.StaffID, StartTime: b.StartTime.UTC(), EndTime: b.EndTime.UTC()})
+	}
+	s.externalBusyTimes = kept
+
+	synced := syncedAt.UTC()
+	c.LastSyncedAt = &synced
+	c.LastError = ""
+	c.UpdatedAt = synced
+	s.externalCalendars[calendarID] = c
+	return nil
+}
+
+// RecordSyncError notes that refreshing a calendar failed. Its busy times are kept.
+func (r *ExternalCalendarRepository) RecordSyncError(ctx context.Context, calendarID int, message string, at time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.externalCalendars[calendarID]; ok {
+		c.LastError = message
+		c.UpdatedAt = at.UTC()
+		s.externalCalendars[calendarID] = c
+	}
+	return nil
+}
+
+// GetBusyByStaffBetween retrieves a staff member's busy times overlapping [from, to),
+// ordered by start
+func (r *ExternalCalendarRepository) GetBusyByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.ExternalBusyTime, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var busy []appt_booking.ExternalBusyTime
+	for _, b := range s.externalBusyTimes {
+		if b.StaffID == staffID && b.StartTime.Before(to) && b.EndTime.After(from) {
+			busy = append(busy, b)
+		}
+	}
+	sort.Slice(busy, func(i, j int) bool {
+		if !busy[i].StartTime.Equal(busy[j].StartTime) {
+			return busy[i].StartTime.Before(busy[j].StartTime)
+		}
+		return busy[i].EndTime.Before(busy[j].EndTime)
+	})
+	return busy, nil
+}
+
+// deleteExternalCalendarsWhere removes the calendars matching match with their busy
+// times; callers hold s.mu
+func (s *Store) deleteExternalCalendarsWhere(match func(appt_booking.ExternalCalendar) bool) {
+	for id, c := range s.externalCalendars {
+		if !match(c) {
+			continue
+		}
+		delete(s.externalCalendars, id)
+		kept := s.externalBusyTimes[:0]
+		for _, b := range s.externalBusyTimes {
+			if b.CalendarID != id {
+				kept = append(kept, b)
+			}
+		}
+		s.externalBusyTimes = kept
+	}
+}
+
+// copyExternalCalendar returns a copy of c that shares no memory with the store
+func copyExternalCalendar(c appt_booking.ExternalCalendar) *appt_booking.ExternalCalendar {
+	cp := c
+	if c.LastSyncedAt != nil {
+		at := *c.LastSyncedAt
+		cp.LastSyncedAt = &at
+	}
+	return &cp
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// HoldRepository is the in-memory counterpart of appt_booking.HoldRepository
+type HoldRepository struct {
+	store *Store
+}
+
+// CreateExclusive reserves a slot unless it overlaps an appointment or unexpired hold
+// (appt_booking.ErrAppointmentConflict) or userID already has maxActive unexpired holds
+// (appt_booking.ErrHoldLimitReached); tokens are unique
+func (r *HoldRepository) CreateExclusive(ctx context.Context, token string, staffID, serviceID int, start time.Time, durationMinutes int, waitlistEntryID, userID *int, maxActive int, expiresAt time.Time) (*appt_booking.SlotHold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.services[serviceID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if waitlistEntryID != nil {
+		if _, ok := s.waitlist[*waitlistEntryID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	if userID != nil {
+		if _, ok := s.users[*userID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	if durationMinutes <= 0 {
+		return nil, ErrCheckViolation
+	}
+	for _, h := range s.holds {
+		if h.Token == token {
+			return nil, ErrUniqueViolation
+		}
+	}
+	if userID != nil && maxActive > 0 {
+		now := time.Now()
+		active := 0
+		for _, h := range s.holds {
+			if h.UserID != nil && *h.UserID == *userID && h.ExpiresAt.After(now) {
+				active++
+			}
+		}
+		if active >= maxActive {
+			return nil, appt_booking.ErrHoldLimitReached
+		}
+	}
+	if s.hasConflict(staffID, serviceID, start, durationMinutes, 0) {
+		return nil, appt_booking.ErrAppointmentConflict
+	}
+
+	h := appt_booking.SlotHold{
+		ID:              s.nextID(),
+		Token:           token,
+		StaffID:         staffID,
+		ServiceID:       serviceID,
+		StartsAt:        start.UTC(),
+		DurationMinutes: durationMinutes,
+		ExpiresAt:       expiresAt.UTC(),
+		CreatedAt:       time.Now(),
+	}
+	if waitlistEntryID != nil {
+		id := *waitlistEntryID
+		h.WaitlistEntryID = &id
+	}
+	if userID != nil {
+		id := *userID
+		h.UserID = &id
+	}
+	s.holds[h.ID] = h
+	return copyHold(h), nil
+}
+
+// GetByToken retrieves a hold by its token, expired or not; returns nil if none matches
+func (r *HoldRepository) GetByToken(ctx context.Context, token string) (*appt_booking.SlotHold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, h := range s.holds {
+		if h.Token == token {
+			return copyHold(h), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByWaitlistEntry retrieves the latest hold offered to a waitlist entry; returns nil if there is none
+func (r *HoldRepository) GetByWaitlistEntry(ctx context.Context, entryID int) (*appt_booking.SlotHold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *appt_booking.SlotHold
+	for _, h := range s.holds {
+		if h.WaitlistEntryID != nil && *h.WaitlistEntryID == entryID && (latest == nil || h.ID > latest.ID) {
+			latest = copyHold(h)
+		}
+	}
+	return latest, nil
+}
+
+// GetActiveByStaffBetween retrieves unexpired holds for a staff member whose blocked time,
+// including their service's buffers, overlaps [from, to)
+func (r *HoldRepository) GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.SlotHold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var holds []appt_booking.SlotHold
+	for _, h := range s.holds {
+		if h.StaffID == staffID && h.ExpiresAt.After(now) && s.holdBlocks(h, from, to) {
+			holds = append(holds, *copyHold(h))
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].StartsAt.Before(holds[j].StartsAt) })
+	return holds, nil
+}
+
+// Delete releases a hold
+func (r *HoldRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.holds, id)
+	return nil
+}
+
+// DeleteExpired deletes the holds that expired by now and returns them
+func (r *HoldRepository) DeleteExpired(ctx context.Context, now time.Time) ([]appt_booking.SlotHold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []appt_booking.SlotHold
+	for id, h := range s.holds {
+		if !h.ExpiresAt.After(now) {
+			expired = append(expired, *copyHold(h))
+			delete(s.holds, id)
+		}
+	}
+	return expired, nil
+}
+
+// deleteHoldsWhere deletes the matching holds, like ON DELETE CASCADE; callers hold s.mu
+func (s *Store) deleteHoldsWhere(match func(appt_booking.SlotHold) bool) {
+	for id, h := range s.holds {
+		if match(h) {
+			delete(s.holds, id)
+		}
+	}
+}
+
+// holdBlocks reports whether h's blocked time intersects [from, to); callers hold s.mu
+func (s *Store) holdBlocks(h appt_booking.SlotHold, from, to time.Time) bool {
+	start, end := s.blockedRange(h.ServiceID, h.StartsAt, h.DurationMinutes)
+	return start.Before(to) && end.After(from)
+}
+
+// copyHold returns a copy of h that shares no pointers with the store
+func copyHold(h appt_booking.SlotHold) *appt_booking.SlotHold {
+	c := h
+	if h.WaitlistEntryID != nil {
+		id := *h.WaitlistEntryID
+		c.WaitlistEntryID = &id
+	}
+	if h.UserID != nil {
+		id := *h.UserID
+		c.UserID = &id
+	}
+	return &c
+}
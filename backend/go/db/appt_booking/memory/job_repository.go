@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// JobRepository is the in-memory counterpart of appt_booking.JobRepository
+type JobRepository struct {
+	store *Store
+}
+
+// Register adds a job first due at firstRun, unless it is already registered
+func (r *JobRepository) Register(ctx context.Context, name string, firstRun time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; !ok {
+		s.jobs[name] = appt_booking.ScheduledJob{Name: name, NextRunAt: firstRun.UTC()}
+	}
+	return nil
+}
+
+// Claim locks a job until now+lease if it is due at now and no other runner holds it
+func (r *JobRepository) Claim(ctx context.Context, name string, now time.Time, lease time.Duration) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok || j.NextRunAt.After(now) || (j.LockedUntil != nil && j.LockedUntil.After(now)) {
+		return false, nil
+	}
+	lockedUntil, startedAt := now.Add(lease).UTC(), now.UTC()
+	j.LockedUntil = &lockedUntil
+	j.LastStartedAt = &startedAt
+	s.jobs[name] = j
+	return true, nil
+}
+
+// Complete releases a claimed job, recording when it finished and how, and when it runs next
+func (r *JobRepository) Complete(ctx context.Context, name string, nextRun, finishedAt time.Time, lastError string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return nil
+	}
+	finished := finishedAt.UTC()
+	j.NextRunAt = nextRun.UTC()
+	j.LockedUntil = nil
+	j.LastFinishedAt = &finished
+	j.LastError = lastError
+	s.jobs[name] = j
+	return nil
+}
+
+// GetAll retrieves every job schedule by name
+func (r *JobRepository) GetAll(ctx context.Context) ([]appt_booking.ScheduledJob, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []appt_booking.ScheduledJob
+	for _, j := range s.jobs {
+		jobs = append(jobs, *copyScheduledJob(j))
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Name < jobs[k].Name })
+	return jobs, nil
+}
+
+// copyScheduledJob returns a copy of j that shares no memory with the store
+func copyScheduledJob(j appt_booking.ScheduledJob) *appt_booking.ScheduledJob {
+	c := j
+	for _, at := range []**time.Time{&c.LockedUntil, &c.LastStartedAt, &c.LastFinishedAt} {
+		if *at != nil {
+			t := **at
+			*at = &t
+		}
+	}
+	return &c
+}
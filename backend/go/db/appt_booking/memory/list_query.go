@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// matchesAppointmentFilter applies an appointment filter; customerID is the ID the filter's
+// CustomerEmail resolved to (0 if none)
+func matchesAppointmentFilter(a appt_booking.Appointment, filter appt_booking.AppointmentFilter, customerID int) bool {
+	if filter.StaffID != 0 && a.StaffID != filter.StaffID {
+		return false
+	}
+	if filter.ServiceID != 0 && a.ServiceID != filter.ServiceID {
+		return false
+	}
+	if filter.CustomerID != 0 && a.CustomerID != filter.CustomerID {
+		return false
+	}
+	if filter.CustomerEmail != "" && a.CustomerID != customerID {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !containsString(filter.Statuses, a.Status) {
+		return false
+	}
+	if filter.From != nil && a.AppointmentDatetime.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !a.AppointmentDatetime.Before(*filter.To) {
+		return false
+	}
+	return true
+}
+
+// sortAppointments orders appointments like the Postgres ORDER BY, ending with id DESC
+func sortAppointments(appointments []appt_booking.AppointmentWithService, fields []appt_booking.SortField) error {
+	for _, field := range fields {
+		if !appt_booking.IsAppointmentSortField(field.Field) {
+			return fmt.Errorf("unknown sort field %q", field.Field)
+		}
+	}
+	sort.SliceStable(appointments, func(i, j int) bool {
+		a, b := appointments[i], appointments[j]
+		for _, field := range fields {
+			var c int
+			switch field.Field {
+			case "appointment_datetime":
+				c = compareTimes(a.AppointmentDatetime, b.AppointmentDatetime)
+			case "created_at":
+				c = compareTimes(a.CreatedAt, b.CreatedAt)
+			case "updated_at":
+				c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+			case "status":
+				c = strings.Compare(a.Status, b.Status)
+			case "customer_name":
+				c = strings.Compare(a.CustomerName, b.CustomerName)
+			case "staff_id":
+				c = a.StaffID - b.StaffID
+			case "service_id":
+				c = a.ServiceID - b.ServiceID
+			case "price_cents":
+				c = a.PriceCents - b.PriceCents
+			}
+			if c != 0 {
+				return (c < 0) != field.Desc
+			}
+		}
+		return a.ID > b.ID
+	})
+	return nil
+}
+
+// sortCustomers orders customers like the Postgres ORDER BY, ending with id
+func sortCustomers(customers []appt_booking.Customer, fields []appt_booking.SortField) error {
+	for _, field := range fields {
+		if !appt_booking.IsCustomerSortField(field.Field) {
+			return fmt.Errorf("unknown sort field %q", field.Field)
+		}
+	}
+	sort.SliceStable(customers, func(i, j int) bool {
+		a, b := customers[i], customers[j]
+		for _, field := range fields {
+			var c int
+			switch field.Field {
+			case "name":
+				c = strings.Compare(a.Name, b.Name)
+			case "email":
+				c = strings.Compare(a.Email, b.Email)
+			case "created_at":
+				c = compareTimes(a.CreatedAt, b.CreatedAt)
+			case "updated_at":
+				c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+			}
+			if c != 0 {
+				return (c < 0) != field.Desc
+			}
+		}
+		return a.ID < b.ID
+	})
+	return nil
+}
+
+// page applies LIMIT/OFFSET to n rows, returning the slice bounds
+func page(n int, opts appt_booking.ListOptions) (int, int) {
+	start := opts.Offset
+	if start > n {
+		start = n
+	}
+	end := n
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+	return start, end
+}
+
+// compareTimes returns -1, 0 or 1 as a is before, equal to or after b
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// NotificationRepository is the in-memory counterpart of appt_booking.NotificationRepository
+type NotificationRepository struct {
+	store *Store
+}
+
+// Create adds a pending notification; returns nil if one with the same dedupe key exists
+func (r *NotificationRepository) Create(ctx context.Context, n appt_booking.Notification) (*appt_booking.Notification, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n.AppointmentID != nil {
+		if _, ok := s.appointments[*n.AppointmentID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	for _, existing := range s.notifications {
+		if existing.DedupeKey == n.DedupeKey {
+			return nil, nil
+		}
+	}
+
+	n.ID = int64(s.nextID())
+	n.Status = appt_booking.NotificationStatusPending
+	n.Attempts = 0
+	n.LastError = ""
+	n.CreatedAt = time.Now().UTC()
+	n.SentAt = nil
+	s.notifications[n.ID] = *copyNotification(n)
+	return copyNotification(n), nil
+}
+
+// GetByDedupeKey retrieves the notification with a dedupe key; returns nil if there is none
+func (r *NotificationRepository) GetByDedupeKey(ctx context.Context, key string) (*appt_booking.Notification, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.notifications {
+		if n.DedupeKey == key {
+			return copyNotification(n), nil
+		}
+	}
+	return nil, nil
+}
+
+// RecordAttempts saves the outcome of sending a notification
+func (r *NotificationRepository) RecordAttempts(ctx context.Context, n appt_booking.Notification) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.notifications[n.ID]
+	if !ok {
+		return nil
+	}
+	stored.Recipient = n.Recipient
+	stored.Subject = n.Subject
+	stored.Status = n.Status
+	stored.Attempts = n.Attempts
+	stored.LastError = n.LastError
+	stored.SentAt = n.SentAt
+	s.notifications[n.ID] = *copyNotification(stored)
+	return nil
+}
+
+// List retrieves up to limit of the notifications matching filter, newest first
+func (r *NotificationRepository) List(ctx context.Context, filter appt_booking.NotificationFilter, limit int) ([]appt_booking.Notification, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []appt_booking.Notification
+	for _, n := range s.notifications {
+		if filter.AppointmentID != 0 && (n.AppointmentID == nil || *n.AppointmentID != filter.AppointmentID) {
+			continue
+		}
+		if filter.Status != "" && n.Status != filter.Status {
+			continue
+		}
+		notifications = append(notifications, *copyNotification(n))
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID > notifications[j].ID })
+	if limit > 0 && len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+// copyNotification returns a copy of n that shares no memory with the store
+func copyNotification(n appt_booking.Notification) *appt_booking.Notification {
+	c := n
+	if n.AppointmentID != nil {
+		id := *n.AppointmentID
+		c.AppointmentID = &id
+	}
+	if n.SentAt != nil {
+		at := *n.SentAt
+		c.SentAt = &at
+	}
+	return &c
+}
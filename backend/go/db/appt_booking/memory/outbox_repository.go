@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// OutboxRepository is the in-memory counterpart of appt_booking.OutboxRepository. The
+// other repositories record events under the store's lock, atomically with their change.
+type OutboxRepository struct {
+	store *Store
+}
+
+// recordEvent appends event for the row with aggregateID, which is row after the change
+// (nil if it was deleted); callers hold s.mu. A nil event records nothing.
+func (s *Store) recordEvent(event *appt_booking.OutboxEvent, aggregateID int, row interface{}) error {
+	if event == nil {
+		return nil
+	}
+	payload, err := event.PayloadFor(row)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	id := int64(s.nextID())
+	s.outbox[id] = appt_booking.OutboxEvent{
+		ID:            id,
+		EventType:     event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+		Status:        appt_booking.OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	return nil
+}
+
+// ClaimDue returns up to limit pending events due at now, oldest first, counting an attempt
+// for each and pushing their next attempt back to now+lease
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]appt_booking.OutboxEvent, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []appt_booking.OutboxEvent
+	for _, e := range s.outbox {
+		if e.Status == appt_booking.OutboxStatusPending && !e.NextAttemptAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].Attempts++
+		due[i].NextAttemptAt = now.Add(lease).UTC()
+		s.outbox[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// MarkDelivered records that every handler has processed an event
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, id int64, now time.Time) error {
+	return r.update(id, func(e *appt_booking.OutboxEvent) {
+		delivered := now.UTC()
+		e.Status = appt_booking.OutboxStatusDelivered
+		e.DeliveredAt = &delivered
+		e.LastError = ""
+	})
+}
+
+// RetryLater schedules another attempt at an event after a handler failed with lastError
+func (r *OutboxRepository) RetryLater(ctx context.Context, id int64, at time.Time, lastError string) error {
+	return r.update(id, func(e *appt_booking.OutboxEvent) {
+		e.NextAttemptAt = at.UTC()
+		e.LastError = lastError
+	})
+}
+
+// MarkFailed gives up on an event whose handlers kept failing
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	return r.update(id, func(e *appt_booking.OutboxEvent) {
+		e.Status = appt_booking.OutboxStatusFailed
+		e.LastError = lastError
+	})
+}
+
+// GetByID retrieves an event by ID; returns nil if it does not exist
+func (r *OutboxRepository) GetByID(ctx context.Context, id int64) (*appt_booking.OutboxEvent, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.outbox[id]
+	if !ok {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// GetAll retrieves all events, oldest first
+func (r *OutboxRepository) GetAll(ctx context.Context) ([]appt_booking.OutboxEvent, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []appt_booking.OutboxEvent
+	for _, e := range s.outbox {
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// update applies change to the event with id, if it exists, like an UPDATE ... WHERE id
+func (r *OutboxRepository) update(id int64, change func(*appt_booking.OutboxEvent)) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.outbox[id]
+	if !ok {
+		return nil
+	}
+	change(&e)
+	s.outbox[id] = e
+	return nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ReminderRepository is the in-memory counterpart of appt_booking.ReminderRepository
+type ReminderRepository struct {
+	store *Store
+}
+
+// reminderKey is the primary key of appointment_reminders
+type reminderKey struct {
+	appointmentID       int
+	offsetMinutes       int
+	appointmentDatetime int64 // Unix nanoseconds
+}
+
+func reminderKeyOf(appointmentID, offsetMinutes int, appointmentDatetime time.Time) reminderKey {
+	return reminderKey{appointmentID, offsetMinutes, appointmentDatetime.UnixNano()}
+}
+
+// GetDue retrieves up to limit appointments due a reminder offset before they start
+func (r *ReminderRepository) GetDue(ctx context.Context, offset, skipWithin time.Duration, now time.Time, limit int) ([]appt_booking.Appointment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offsetMinutes := int(offset / time.Minute)
+	from, to := now.Add(skipWithin), now.Add(offset)
+	var appointments []appt_booking.Appointment
+	for _, a := range s.appointments {
+		if a.Status != appt_booking.AppointmentStatusPending && a.Status != appt_booking.AppointmentStatusConfirmed {
+			continue
+		}
+		if !a.AppointmentDatetime.After(from) || a.AppointmentDatetime.After(to) {
+			continue
+		}
+		if a.CreatedAt.After(a.AppointmentDatetime.Add(-time.Duration(offsetMinutes) * time.Minute)) {
+			continue
+		}
+		if _, sent := s.reminders[reminderKeyOf(a.ID, offsetMinutes, a.AppointmentDatetime)]; sent {
+			continue
+		}
+		appointments = append(appointments, a)
+	}
+	sort.Slice(appointments, func(i, j int) bool {
+		if !appointments[i].AppointmentDatetime.Equal(appointments[j].AppointmentDatetime) {
+			return appointments[i].AppointmentDatetime.Before(appointments[j].AppointmentDatetime)
+		}
+		return appointments[i].ID < appointments[j].ID
+	})
+	if limit > 0 && len(appointments) > limit {
+		appointments = appointments[:limit]
+	}
+	return appointments, nil
+}
+
+// Record notes that a reminder was sent. Returns false if it had been recorded already.
+func (r *ReminderRepository) Record(ctx context.Context, reminder appt_booking.AppointmentReminder) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.appointments[reminder.AppointmentID]; !ok {
+		return false, ErrForeignKeyViolation
+	}
+	if reminder.NotificationID != nil {
+		if _, ok := s.notifications[*reminder.NotificationID]; !ok {
+			return false, ErrForeignKeyViolation
+		}
+	}
+	if reminder.OffsetMinutes <= 0 {
+		return false, ErrCheckViolation
+	}
+	key := reminderKeyOf(reminder.AppointmentID, reminder.OffsetMinutes, reminder.AppointmentDatetime)
+	if _, ok := s.reminders[key]; ok {
+		return false, nil
+	}
+	reminder.AppointmentDatetime = reminder.AppointmentDatetime.UTC()
+	reminder.SentAt = reminder.SentAt.UTC()
+	s.reminders[key] = *copyReminder(reminder)
+	return true, nil
+}
+
+// GetByAppointment retrieves the reminders sent for an appointment, oldest first
+func (r *ReminderRepository) GetByAppointment(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentReminder, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reminders []appt_booking.AppointmentReminder
+	for _, reminder := range s.reminders {
+		if reminder.AppointmentID == appointmentID {
+			reminders = append(reminders, *copyReminder(reminder))
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].SentAt.Equal(reminders[j].SentAt) {
+			return reminders[i].SentAt.Before(reminders[j].SentAt)
+		}
+		return reminders[i].OffsetMinutes > reminders[j].OffsetMinutes
+	})
+	return reminders, nil
+}
+
+// copyReminder returns a copy of r that shares no memory with the store
+func copyReminder(r appt_booking.AppointmentReminder) *appt_booking.AppointmentReminder {
+	c := r
+	if r.NotificationID != nil {
+		id := *r.NotificationID
+		c.NotificationID = &id
+	}
+	return &c
+}
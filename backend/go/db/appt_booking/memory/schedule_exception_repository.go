@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ScheduleExceptionRepository is the in-memory counterpart of appt_booking.ScheduleExceptionRepository
+type ScheduleExceptionRepository struct {
+	store *Store
+}
+
+// Create inserts a new schedule exception; staffID nil makes it business-wide
+func (r *ScheduleExceptionRepository) Create(ctx context.Context, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*appt_booking.ScheduleException, error) {
+	e, err := newScheduleException(staffID, startDate, endDate, isClosed, startTime, endTime, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if staffID != nil {
+		if _, ok := s.staff[*staffID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	now := time.Now()
+	e.ID = s.nextID()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+	s.scheduleExceptions[e.ID] = e
+	return copyScheduleException(e), nil
+}
+
+// Update modifies an existing schedule exception; returns nil if it does not exist
+func (r *ScheduleExceptionRepository) Update(ctx context.Context, id int, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*appt_booking.ScheduleException, error) {
+	e, err := newScheduleException(staffID, startDate, endDate, isClosed, startTime, endTime, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.scheduleExceptions[id]
+	if !ok {
+		return nil, nil
+	}
+	if staffID != nil {
+		if _, ok := s.staff[*staffID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	e.ID = id
+	e.CreatedAt = existing.CreatedAt
+	e.UpdatedAt = time.Now()
+	s.scheduleExceptions[id] = e
+	return copyScheduleException(e), nil
+}
+
+// GetAll retrieves all schedule exceptions ordered by start date, business-wide first
+func (r *ScheduleExceptionRepository) GetAll(ctx context.Context) ([]appt_booking.ScheduleException, error) {
+	return r.filter(func(appt_booking.ScheduleException) bool { return true }), nil
+}
+
+// GetByID retrieves a schedule exception by ID; returns nil if it does not exist
+func (r *ScheduleExceptionRepository) GetByID(ctx context.Context, id int) (*appt_booking.ScheduleException, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.scheduleExceptions[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyScheduleException(e), nil
+}
+
+// GetByStaff retrieves a staff member's exceptions including business-wide ones
+func (r *ScheduleExceptionRepository) GetByStaff(ctx context.Context, staffID int) ([]appt_booking.ScheduleException, error) {
+	return r.filter(func(e appt_booking.ScheduleException) bool {
+		return e.StaffID == nil || *e.StaffID == staffID
+	}), nil
+}
+
+// GetApplicable retrieves the exceptions for a staff member (including business-wide ones)
+// whose date range overlaps [fromDate, toDate]
+func (r *ScheduleExceptionRepository) GetApplicable(ctx context.Context, staffID int, fromDate, toDate string) ([]appt_booking.ScheduleException, error) {
+	from, err := parseDate(fromDate)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseDate(toDate)
+	if err != nil {
+		return nil, err
+	}
+	return r.filter(func(e appt_booking.ScheduleException) bool {
+		return (e.StaffID == nil || *e.StaffID == staffID) && !e.StartDate.After(to) && !e.EndDate.Before(from)
+	}), nil
+}
+
+// Delete removes a schedule exception
+func (r *ScheduleExceptionRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.scheduleExceptions, id)
+	return nil
+}
+
+// filter returns copies of the matching exceptions ordered by start_date, staff_id NULLS FIRST
+func (r *ScheduleExceptionRepository) filter(match func(appt_booking.ScheduleException) bool) []appt_booking.ScheduleException {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exceptions []appt_booking.ScheduleException
+	for _, e := range s.scheduleExceptions {
+		if match(e) {
+			exceptions = append(exceptions, *copyScheduleException(e))
+		}
+	}
+	sort.Slice(exceptions, func(i, j int) bool {
+		a, b := exceptions[i], exceptions[j]
+		if !a.StartDate.Equal(b.StartDate) {
+			return a.StartDate.Before(b.StartDate)
+		}
+		if (a.StaffID == nil) != (b.StaffID == nil) {
+			return a.StaffID == nil
+		}
+		if a.StaffID != nil && *a.StaffID != *b.StaffID {
+			return *a.StaffID < *b.StaffID
+		}
+		return a.ID < b.ID
+	})
+	return exceptions
+}
+
+// newScheduleException parses the input and enforces the table's CHECK constraints
+func newScheduleException(staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (appt_booking.ScheduleException, error) {
+	e := appt_booking.ScheduleException{IsClosed: isClosed, Reason: reason}
+	if staffID != nil {
+		id := *staffID
+		e.StaffID = &id
+	}
+
+	var err error
+	if e.StartDate, err = parseDate(startDate); err != nil {
+		return e, err
+	}
+	if e.EndDate, err = parseDate(endDate); err != nil {
+		return e, err
+	}
+	if e.EndDate.Before(e.StartDate) {
+		return e, ErrCheckViolation
+	}
+
+	if startTime != nil {
+		t, err := parseClock(*startTime)
+		if err != nil {
+			return e, err
+		}
+		e.StartTime = &t
+	}
+	if endTime != nil {
+		t, err := parseClock(*endTime)
+		if err != nil {
+			return e, err
+		}
+		e.EndTime = &t
+	}
+	if !isClosed && (e.StartTime == nil || e.EndTime == nil || !e.StartTime.Before(*e.EndTime)) {
+		return e, ErrCheckViolation
+	}
+	return e, nil
+}
+
+// copyScheduleException returns a copy of e that shares no pointers with the store
+func copyScheduleException(e appt_booking.ScheduleException) *appt_booking.ScheduleException {
+	c := e
+	if e.StaffID != nil {
+		staffID := *e.StaffID
+		c.StaffID = &staffID
+	}
+	if e.StartTime != nil {
+		start := *e.StartTime
+		c.StartTime = &start
+	}
+	if e.EndTime != nil {
+		end := *e.EndTime
+		c.EndTime = &end
+	}
+	return &c
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ScheduleRepository is the in-memory counterpart of appt_booking.ScheduleRepository
+type ScheduleRepository struct {
+	store *Store
+}
+
+// Create inserts a new schedule. The staff member must exist and
+// (staff_id, day_of_week, start_time, end_time) must be unique.
+func (r *ScheduleRepository) Create(ctx context.Context, staffID, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if s.scheduleExists(staffID, dayOfWeek, start, end, 0) {
+		return nil, ErrUniqueViolation
+	}
+	now := time.Now()
+	schedule := appt_booking.Schedule{
+		ID:        s.nextID(),
+		StaffID:   staffID,
+		DayOfWeek: dayOfWeek,
+		StartTime: start,
+		EndTime:   end,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.schedules[schedule.ID] = schedule
+	return &schedule, nil
+}
+
+// Update modifies an existing schedule; returns nil if it does not exist
+func (r *ScheduleRepository) Update(ctx context.Context, id int, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+	if s.scheduleExists(schedule.StaffID, dayOfWeek, start, end, id) {
+		return nil, ErrUniqueViolation
+	}
+	schedule.DayOfWeek = dayOfWeek
+	schedule.StartTime = start
+	schedule.EndTime = end
+	schedule.UpdatedAt = time.Now()
+	s.schedules[id] = schedule
+	return &schedule, nil
+}
+
+// GetAll retrieves all schedules ordered by staff and day
+func (r *ScheduleRepository) GetAll(ctx context.Context) ([]appt_booking.Schedule, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var schedules []appt_booking.Schedule
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		a, b := schedules[i], schedules[j]
+		if a.StaffID != b.StaffID {
+			return a.StaffID < b.StaffID
+		}
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		return a.StartTime.Before(b.StartTime)
+	})
+	return schedules, nil
+}
+
+// GetByID retrieves a schedule by ID; returns nil if it does not exist
+func (r *ScheduleRepository) GetByID(ctx context.Context, id int) (*appt_booking.Schedule, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+	return &schedule, nil
+}
+
+// GetByStaff retrieves a staff member's schedules ordered by day and start time
+func (r *ScheduleRepository) GetByStaff(ctx context.Context, staffID int) ([]appt_booking.Schedule, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var schedules []appt_booking.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.StaffID == staffID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].DayOfWeek != schedules[j].DayOfWeek {
+			return schedules[i].DayOfWeek < schedules[j].DayOfWeek
+		}
+		return schedules[i].StartTime.Before(schedules[j].StartTime)
+	})
+	return schedules, nil
+}
+
+// Delete removes a schedule
+func (r *ScheduleRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.schedules, id)
+	return nil
+}
+
+// scheduleExists reports whether a schedule other than exceptID has the same key; callers hold s.mu
+func (s *Store) scheduleExists(staffID, dayOfWeek int, start, end time.Time, exceptID int) bool {
+	for _, schedule := range s.schedules {
+		if schedule.ID != exceptID && schedule.StaffID == staffID && schedule.DayOfWeek == dayOfWeek &&
+			schedule.StartTime.Equal(start) && schedule.EndTime.Equal(end) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// SeriesRepository is the in-memory counterpart of appt_booking.SeriesRepository
+type SeriesRepository struct {
+	store *Store
+}
+
+// Create inserts a new series; the customer, staff member and service must exist and
+// at least one of count and until must be set
+func (r *SeriesRepository) Create(ctx context.Context, customerID, staffID, serviceID int, frequency string, interval int, count *int, until *time.Time, startsAt time.Time, notes string) (*appt_booking.AppointmentSeries, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.customers[customerID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.staff[staffID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.services[serviceID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if (frequency != appt_booking.SeriesFrequencyWeekly && frequency != appt_booking.SeriesFrequencyMonthly) ||
+		interval <= 0 || (count == nil && until == nil) || (count != nil && *count <= 0) {
+		return nil, ErrCheckViolation
+	}
+
+	now := time.Now()
+	series := appt_booking.AppointmentSeries{
+		ID:         s.nextID(),
+		CustomerID: customerID,
+		StaffID:    staffID,
+		ServiceID:  serviceID,
+		Frequency:  frequency,
+		Interval:   interval,
+		StartsAt:   startsAt.UTC(),
+		Notes:      notes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if count != nil {
+		n := *count
+		series.Count = &n
+	}
+	if until != nil {
+		// DATE drops the time of day
+		d := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+		series.Until = &d
+	}
+	s.series[series.ID] = series
+	return copySeries(series), nil
+}
+
+// GetByID retrieves a series by ID; returns nil if it does not exist
+func (r *SeriesRepository) GetByID(ctx context.Context, id int) (*appt_booking.AppointmentSeries, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	series, ok := s.series[id]
+	if !ok {
+		return nil, nil
+	}
+	return copySeries(series), nil
+}
+
+// Delete removes a series and its occurrence links
+func (r *SeriesRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteSeriesWhere(func(series appt_booking.AppointmentSeries) bool { return series.ID == id })
+	return nil
+}
+
+// AddOccurrence links an appointment to a series; an appointment belongs to at most one
+// series and each index is used once per series
+func (r *SeriesRepository) AddOccurrence(ctx context.Context, seriesID, appointmentID, index int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.series[seriesID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if _, ok := s.appointments[appointmentID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if index < 0 {
+		return ErrCheckViolation
+	}
+	if _, ok := s.seriesOccurrences[appointmentID]; ok {
+		return ErrUniqueViolation
+	}
+	for _, o := range s.seriesOccurrences {
+		if o.SeriesID == seriesID && o.Index == index {
+			return ErrUniqueViolation
+		}
+	}
+	s.seriesOccurrences[appointmentID] = appt_booking.SeriesOccurrence{
+		SeriesID:      seriesID,
+		AppointmentID: appointmentID,
+		Index:         index,
+	}
+	return nil
+}
+
+// GetOccurrences retrieves the occurrences of a series in recurrence order
+func (r *SeriesRepository) GetOccurrences(ctx context.Context, seriesID int) ([]appt_booking.SeriesOccurrence, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var occurrences []appt_booking.SeriesOccurrence
+	for _, o := range s.seriesOccurrences {
+		if o.SeriesID == seriesID {
+			occurrences = append(occurrences, o)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Index < occurrences[j].Index })
+	return occurrences, nil
+}
+
+// GetOccurrence retrieves the series link of an appointment; returns nil if it is not part of a series
+func (r *SeriesRepository) GetOccurrence(ctx context.Context, appointmentID int) (*appt_booking.SeriesOccurrence, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.seriesOccurrences[appointmentID]
+	if !ok {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+// deleteSeriesWhere deletes the matching series and their occurrence links, like
+// ON DELETE CASCADE; callers hold s.mu
+func (s *Store) deleteSeriesWhere(match func(appt_booking.AppointmentSeries) bool) {
+	for id, series := range s.series {
+		if !match(series) {
+			continue
+		}
+		for appointmentID, o := range s.seriesOccurrences {
+			if o.SeriesID == id {
+				delete(s.seriesOccurrences, appointmentID)
+			}
+		}
+		delete(s.series, id)
+	}
+}
+
+// copySeries returns a copy of series that shares no pointers with the store
+func copySeries(series appt_booking.AppointmentSeries) *appt_booking.AppointmentSeries {
+	c := series
+	if series.Count != nil {
+		n := *series.Count
+		c.Count = &n
+	}
+	if series.Until != nil {
+		d := *series.Until
+		c.Until = &d
+	}
+	return &c
+}
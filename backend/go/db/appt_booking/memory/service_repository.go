@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ServiceRepository is the in-memory counterpart of appt_booking.ServiceRepository
+type ServiceRepository struct {
+	store *Store
+}
+
+// Create inserts a new service and records event for it
+func (r *ServiceRepository) Create(ctx context.Context, name, description string, duration, priceCents int, policy appt_booking.BookingPolicy, event *appt_booking.OutboxEvent) (*appt_booking.Service, error) {
+	if !validPolicy(policy) {
+		return nil, ErrCheckViolation
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	service := appt_booking.Service{
+		ID:            s.nextID(),
+		Name:          name,
+		Description:   description,
+		DurationMin:   duration,
+		PriceCents:    priceCents,
+		BookingPolicy: policy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.services[service.ID] = service
+	return &service, s.recordEvent(event, service.ID, service)
+}
+
+// Update modifies an existing service and records event for it; returns nil if it does not exist
+func (r *ServiceRepository) Update(ctx context.Context, id int, name, description string, duration, priceCents int, policy appt_booking.BookingPolicy, event *appt_booking.OutboxEvent) (*appt_booking.Service, error) {
+	if !validPolicy(policy) {
+		return nil, ErrCheckViolation
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	service, ok := s.services[id]
+	if !ok {
+		return nil, nil
+	}
+	service.Name = name
+	service.Description = description
+	service.DurationMin = duration
+	service.PriceCents = priceCents
+	service.BookingPolicy = policy
+	service.UpdatedAt = time.Now()
+	s.services[id] = service
+	return &service, s.recordEvent(event, id, service)
+}
+
+// GetAll retrieves all services ordered by name
+func (r *ServiceRepository) GetAll(ctx context.Context) ([]appt_booking.Service, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var services []appt_booking.Service
+	for _, service := range s.services {
+		services = append(services, service)
+	}
+	sortServicesByName(services)
+	return services, nil
+}
+
+// GetByID retrieves a service by ID; returns nil if it does not exist
+func (r *ServiceRepository) GetByID(ctx context.Context, id int) (*appt_booking.Service, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	service, ok := s.services[id]
+	if !ok {
+		return nil, nil
+	}
+	return &service, nil
+}
+
+// Delete removes a service and its staff assignments. Appointments reference services
+// without ON DELETE, so deleting a booked service fails with ErrForeignKeyViolation.
+// event is recorded if the service existed.
+func (r *ServiceRepository) Delete(ctx context.Context, id int, event *appt_booking.OutboxEvent) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.appointments {
+		if a.ServiceID == id {
+			return ErrForeignKeyViolation
+		}
+	}
+	for link := range s.staffServices {
+		if link.ServiceID == id {
+			delete(s.staffServices, link)
+		}
+	}
+	s.deleteSeriesWhere(func(series appt_booking.AppointmentSeries) bool { return series.ServiceID == id })
+	s.deleteWaitlistWhere(func(e appt_booking.WaitlistEntry) bool { return e.ServiceID == id })
+	s.deleteHoldsWhere(func(h appt_booking.SlotHold) bool { return h.ServiceID == id })
+	if _, ok := s.services[id]; !ok {
+		return nil
+	}
+	delete(s.services, id)
+	return s.recordEvent(event, id, nil)
+}
+
+// validPolicy mirrors the CHECK constraints on the services policy columns
+func validPolicy(p appt_booking.BookingPolicy) bool {
+	return p.BufferBeforeMin >= 0 && p.BufferAfterMin >= 0 && p.MinNoticeMin >= 0 && p.MaxAdvanceDays >= 0 && p.SlotGranularityMin > 0 && p.CancelNoticeMin >= 0
+}
+
+// sortServicesByName orders services like ORDER BY name
+func sortServicesByName(services []appt_booking.Service) {
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Name != services[j].Name {
+			return services[i].Name < services[j].Name
+		}
+		return services[i].ID < services[j].ID
+	})
+}
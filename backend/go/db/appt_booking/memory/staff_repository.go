@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// StaffRepository is the in-memory counterpart of appt_booking.StaffRepository
+type StaffRepository struct {
+	store *Store
+}
+
+// Create inserts a new staff member and records event for it; emails are unique
+func (r *StaffRepository) Create(ctx context.Context, name, email, phone, role, timezone string, event *appt_booking.OutboxEvent) (*appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emailTaken(email, 0) {
+		return nil, ErrUniqueViolation
+	}
+	now := time.Now()
+	staff := appt_booking.Staff{
+		ID:        s.nextID(),
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		Role:      role,
+		Timezone:  timezone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.staff[staff.ID] = staff
+	return &staff, s.recordEvent(event, staff.ID, staff)
+}
+
+// Update modifies an existing staff member and records event for it; returns nil if it does not exist
+func (r *StaffRepository) Update(ctx context.Context, id int, name, email, phone, role, timezone string, event *appt_booking.OutboxEvent) (*appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staff, ok := s.staff[id]
+	if !ok {
+		return nil, nil
+	}
+	if s.emailTaken(email, id) {
+		return nil, ErrUniqueViolation
+	}
+	staff.Name = name
+	staff.Email = email
+	staff.Phone = phone
+	staff.Role = role
+	staff.Timezone = timezone
+	staff.UpdatedAt = time.Now()
+	s.staff[id] = staff
+	return &staff, s.recordEvent(event, id, staff)
+}
+
+// GetAll retrieves all staff members ordered by name
+func (r *StaffRepository) GetAll(ctx context.Context) ([]appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var staffList []appt_booking.Staff
+	for _, staff := range s.staff {
+		staffList = append(staffList, staff)
+	}
+	sortStaffByName(staffList)
+	return staffList, nil
+}
+
+// GetByID retrieves a staff member by ID; returns nil if it does not exist
+func (r *StaffRepository) GetByID(ctx context.Context, id int) (*appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staff, ok := s.staff[id]
+	if !ok {
+		return nil, nil
+	}
+	return &staff, nil
+}
+
+// GetByEmail retrieves a staff member by email; returns nil if none matches
+func (r *StaffRepository) GetByEmail(ctx context.Context, email string) (*appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, staff := range s.staff {
+		if staff.Email == email {
+			return &staff, nil
+		}
+	}
+	return nil, nil
+}
+
+// Delete removes a staff member together with their service assignments, schedules,
+// schedule exceptions, series, waitlist entries, holds and login account (ON DELETE
+// CASCADE). Appointments reference staff without ON DELETE, so deleting a staff member
+// who has appointments fails with ErrForeignKeyViolation. event is recorded if the staff
+// member existed.
+func (r *StaffRepository) Delete(ctx context.Context, id int, event *appt_booking.OutboxEvent) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.appointments {
+		if a.StaffID == id {
+			return ErrForeignKeyViolation
+		}
+	}
+	for link := range s.staffServices {
+		if link.StaffID == id {
+			delete(s.staffServices, link)
+		}
+	}
+	for scheduleID, schedule := range s.schedules {
+		if schedule.StaffID == id {
+			delete(s.schedules, scheduleID)
+		}
+	}
+	for exceptionID, e := range s.scheduleExceptions {
+		if e.StaffID != nil && *e.StaffID == id {
+			delete(s.scheduleExceptions, exceptionID)
+		}
+	}
+	for userID, u := range s.users {
+		if u.StaffID != nil && *u.StaffID == id {
+			delete(s.users, userID)
+		}
+	}
+	s.deleteSeriesWhere(func(series appt_booking.AppointmentSeries) bool { return series.StaffID == id })
+	s.deleteWaitlistWhere(func(e appt_booking.WaitlistEntry) bool { return e.StaffID != nil && *e.StaffID == id })
+	s.deleteHoldsWhere(func(h appt_booking.SlotHold) bool { return h.StaffID == id })
+	delete(s.calendarFeeds, id)
+	s.deleteExternalCalendarsWhere(func(c appt_booking.ExternalCalendar) bool { return c.StaffID == id })
+	if _, ok := s.staff[id]; !ok {
+		return nil
+	}
+	delete(s.staff, id)
+	return s.recordEvent(event, id, nil)
+}
+
+// emailTaken reports whether another staff member than exceptID uses email; callers hold s.mu
+func (s *Store) emailTaken(email string, exceptID int) bool {
+	for _, staff := range s.staff {
+		if staff.Email == email && staff.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// sortStaffByName orders staff like ORDER BY name
+func sortStaffByName(staffList []appt_booking.Staff) {
+	sort.Slice(staffList, func(i, j int) bool {
+		if staffList[i].Name != staffList[j].Name {
+			return staffList[i].Name < staffList[j].Name
+		}
+		return staffList[i].ID < staffList[j].ID
+	})
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// StaffServiceRepository is the in-memory counterpart of appt_booking.StaffServiceRepository
+type StaffServiceRepository struct {
+	store *Store
+}
+
+// Assign links a staff member to a service. Both must exist and the link must be new.
+func (r *StaffServiceRepository) Assign(ctx context.Context, staffID, serviceID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.staff[staffID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if _, ok := s.services[serviceID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	link := appt_booking.StaffService{StaffID: staffID, ServiceID: serviceID}
+	if s.staffServices[link] {
+		return ErrUniqueViolation
+	}
+	s.staffServices[link] = true
+	return nil
+}
+
+// Unassign removes a service from a staff member
+func (r *StaffServiceRepository) Unassign(ctx context.Context, staffID, serviceID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.staffServices, appt_booking.StaffService{StaffID: staffID, ServiceID: serviceID})
+	return nil
+}
+
+// GetServicesForStaff retrieves the services offered by a staff member, ordered by name
+func (r *StaffServiceRepository) GetServicesForStaff(ctx context.Context, staffID int) ([]appt_booking.Service, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var services []appt_booking.Service
+	for link := range s.staffServices {
+		if link.StaffID == staffID {
+			services = append(services, s.services[link.ServiceID])
+		}
+	}
+	sortServicesByName(services)
+	return services, nil
+}
+
+// GetStaffForService retrieves the staff members who offer a service, ordered by name
+func (r *StaffServiceRepository) GetStaffForService(ctx context.Context, serviceID int) ([]appt_booking.Staff, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var staffList []appt_booking.Staff
+	for link := range s.staffServices {
+		if link.ServiceID == serviceID {
+			staffList = append(staffList, s.staff[link.StaffID])
+		}
+	}
+	sortStaffByName(staffList)
+	return staffList, nil
+}
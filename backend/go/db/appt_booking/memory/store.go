@@ -0,0 +1,194 @@
+// Package memory is an in-memory implementation of the appointment booking repositories,
+// intended for tests that exercise ApptBookingService without Postgres. It mirrors the
+// schema's constraints: unique keys, foreign keys with their ON DELETE behaviour, and the
+// no-overlap rule for non-cancelled appointments.
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ErrUniqueViolation is returned where Postgres would raise unique_violation (23505)
+var ErrUniqueViolation = errors.New("memory: unique constraint violation")
+
+// ErrForeignKeyViolation is returned where Postgres would raise foreign_key_violation (23503)
+var ErrForeignKeyViolation = errors.New("memory: foreign key violation")
+
+// ErrCheckViolation is returned where Postgres would raise check_violation (23514)
+var ErrCheckViolation = errors.New("memory: check constraint violation")
+
+// Store holds all tables. The repositories returned by its accessors share it, so
+// cascading deletes and cross-table checks behave as they do in the database.
+// A Store is safe for concurrent use.
+type Store struct {
+	mu sync.Mutex
+
+	services             map[int]appt_booking.Service
+	staff                map[int]appt_booking.Staff
+	staffServices        map[appt_booking.StaffService]bool
+	schedules            map[int]appt_booking.Schedule
+	scheduleExceptions   map[int]appt_booking.ScheduleException
+	appointments         map[int]appt_booking.Appointment
+	reschedules          []appt_booking.AppointmentReschedule
+	statusHistory        []appt_booking.AppointmentStatusChange
+	cancellations        map[int]appt_booking.AppointmentCancellation // keyed by appointment ID
+	users                map[int]appt_booking.User
+	customers            map[int]appt_booking.Customer
+	series               map[int]appt_booking.AppointmentSeries
+	seriesOccurrences    map[int]appt_booking.SeriesOccurrence // keyed by appointment ID
+	waitlist             map[int]appt_booking.WaitlistEntry
+	holds                map[int]appt_booking.SlotHold
+	outbox               map[int64]appt_booking.OutboxEvent
+	webhookSubscriptions map[int]appt_booking.WebhookSubscription
+	webhookDeliveries    map[int64]appt_booking.WebhookDelivery
+	notifications        map[int64]appt_booking.Notification
+	jobs                 map[string]appt_booking.ScheduledJob
+	reminders            map[reminderKey]appt_booking.AppointmentReminder
+	calendarFeeds        map[int]appt_booking.StaffCalendarFeed // keyed by staff ID
+	externalCalendars    map[int]appt_booking.ExternalCalendar
+	externalBusyTimes    []appt_booking.ExternalBusyTime
+
+	lastID int // shared sequence; IDs only need to be unique per table
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		services:             make(map[int]appt_booking.Service),
+		staff:                make(map[int]appt_booking.Staff),
+		staffServices:        make(map[appt_booking.StaffService]bool),
+		schedules:            make(map[int]appt_booking.Schedule),
+		scheduleExceptions:   make(map[int]appt_booking.ScheduleException),
+		appointments:         make(map[int]appt_booking.Appointment),
+		cancellations:        make(map[int]appt_booking.AppointmentCancellation),
+		users:                make(map[int]appt_booking.User),
+		customers:            make(map[int]appt_booking.Customer),
+		series:               make(map[int]appt_booking.AppointmentSeries),
+		seriesOccurrences:    make(map[int]appt_booking.SeriesOccurrence),
+		waitlist:             make(map[int]appt_booking.WaitlistEntry),
+		holds:                make(map[int]appt_booking.SlotHold),
+		outbox:               make(map[int64]appt_booking.OutboxEvent),
+		webhookSubscriptions: make(map[int]appt_booking.WebhookSubscription),
+		webhookDeliveries:    make(map[int64]appt_booking.WebhookDelivery),
+		notifications:        make(map[int64]appt_booking.Notification),
+		jobs:                 make(map[string]appt_booking.ScheduledJob),
+		reminders:            make(map[reminderKey]appt_booking.AppointmentReminder),
+		calendarFeeds:        make(map[int]appt_booking.StaffCalendarFeed),
+		externalCalendars:    make(map[int]appt_booking.ExternalCalendar),
+	}
+}
+
+// Services returns the service repository backed by s
+func (s *Store) Services() *ServiceRepository {
+	return &ServiceRepository{store: s}
+}
+
+// Staff returns the staff repository backed by s
+func (s *Store) Staff() *StaffRepository {
+	return &StaffRepository{store: s}
+}
+
+// StaffServices returns the staff-service assignment repository backed by s
+func (s *Store) StaffServices() *StaffServiceRepository {
+	return &StaffServiceRepository{store: s}
+}
+
+// Schedules returns the schedule repository backed by s
+func (s *Store) Schedules() *ScheduleRepository {
+	return &ScheduleRepository{store: s}
+}
+
+// ScheduleExceptions returns the schedule exception repository backed by s
+func (s *Store) ScheduleExceptions() *ScheduleExceptionRepository {
+	return &ScheduleExceptionRepository{store: s}
+}
+
+// Appointments returns the appointment repository backed by s
+func (s *Store) Appointments() *AppointmentRepository {
+	return &AppointmentRepository{store: s}
+}
+
+// Users returns the user repository backed by s
+func (s *Store) Users() *UserRepository {
+	return &UserRepository{store: s}
+}
+
+// Customers returns the customer repository backed by s
+func (s *Store) Customers() *CustomerRepository {
+	return &CustomerRepository{store: s}
+}
+
+// Series returns the appointment series repository backed by s
+func (s *Store) Series() *SeriesRepository {
+	return &SeriesRepository{store: s}
+}
+
+// Waitlist returns the waitlist repository backed by s
+func (s *Store) Waitlist() *WaitlistRepository {
+	return &WaitlistRepository{store: s}
+}
+
+// Holds returns the slot hold repository backed by s
+func (s *Store) Holds() *HoldRepository {
+	return &HoldRepository{store: s}
+}
+
+// Outbox returns the outbox repository backed by s
+func (s *Store) Outbox() *OutboxRepository {
+	return &OutboxRepository{store: s}
+}
+
+// Webhooks returns the webhook repository backed by s
+func (s *Store) Webhooks() *WebhookRepository {
+	return &WebhookRepository{store: s}
+}
+
+// Notifications returns the notification log repository backed by s
+func (s *Store) Notifications() *NotificationRepository {
+	return &NotificationRepository{store: s}
+}
+
+// Jobs returns the job schedule repository backed by s
+func (s *Store) Jobs() *JobRepository {
+	return &JobRepository{store: s}
+}
+
+// Reminders returns the appointment reminder repository backed by s
+func (s *Store) Reminders() *ReminderRepository {
+	return &ReminderRepository{store: s}
+}
+
+// CalendarFeeds returns the staff calendar feed repository backed by s
+func (s *Store) CalendarFeeds() *CalendarFeedRepository {
+	return &CalendarFeedRepository{store: s}
+}
+
+// ExternalCalendars returns the external calendar repository backed by s
+func (s *Store) ExternalCalendars() *ExternalCalendarRepository {
+	return &ExternalCalendarRepository{store: s}
+}
+
+// nextID returns a new row ID; callers hold s.mu
+func (s *Store) nextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+// parseClock parses a TIME value the way Postgres accepts it (HH:MM or HH:MM:SS).
+// Like lib/pq, the result is on day 0000-01-01 UTC.
+func parseClock(value string) (time.Time, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		t, err = time.Parse("15:04:05", value)
+	}
+	return t, err
+}
+
+// parseDate parses a YYYY-MM-DD DATE value as midnight UTC
+func parseDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value)
+}
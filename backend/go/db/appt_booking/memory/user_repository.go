@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// UserRepository is the in-memory counterpart of appt_booking.UserRepository
+type UserRepository struct {
+	store *Store
+}
+
+// Create inserts a new user. Emails and staff links are unique (appt_booking.ErrUserEmailTaken)
+// and a linked staff member must exist.
+func (r *UserRepository) Create(ctx context.Context, email, passwordHash, role string, staffID *int) (*appt_booking.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email || (staffID != nil && u.StaffID != nil && *u.StaffID == *staffID) {
+			return nil, appt_booking.ErrUserEmailTaken
+		}
+	}
+	if staffID != nil {
+		if _, ok := s.staff[*staffID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	if role == appt_booking.RoleProvider && staffID == nil {
+		return nil, ErrCheckViolation
+	}
+
+	now := time.Now()
+	u := appt_booking.User{
+		ID:           s.nextID(),
+		Email:        email,
+		PasswordHash: passwordHash,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if staffID != nil {
+		id := *staffID
+		u.StaffID = &id
+	}
+	s.users[u.ID] = u
+	return copyUser(u), nil
+}
+
+// GetByID retrieves a user by ID; returns nil if it does not exist
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*appt_booking.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyUser(u), nil
+}
+
+// GetByEmail retrieves a user by email; returns nil if none matches
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*appt_booking.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return copyUser(u), nil
+		}
+	}
+	return nil, nil
+}
+
+// MarkEmailVerified records that user id confirmed email at verifiedAt; returns nil if the
+// user does not exist or its email is no longer email
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id int, email string, verifiedAt time.Time) (*appt_booking.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok || u.Email != email {
+		return nil, nil
+	}
+	if u.EmailVerifiedAt == nil {
+		at := verifiedAt
+		u.EmailVerifiedAt = &at
+	}
+	u.UpdatedAt = verifiedAt
+	s.users[id] = u
+	return copyUser(u), nil
+}
+
+// copyUser returns a copy of u that shares no pointers with the store
+func copyUser(u appt_booking.User) *appt_booking.User {
+	c := u
+	if u.StaffID != nil {
+		staffID := *u.StaffID
+		c.StaffID = &staffID
+	}
+	if u.EmailVerifiedAt != nil {
+		verifiedAt := *u.EmailVerifiedAt
+		c.EmailVerifiedAt = &verifiedAt
+	}
+	return &c
+}
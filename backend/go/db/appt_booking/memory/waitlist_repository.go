@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// WaitlistRepository is the in-memory counterpart of appt_booking.WaitlistRepository
+type WaitlistRepository struct {
+	store *Store
+}
+
+// Create adds a waiting entry; the customer, service and staff member must exist
+func (r *WaitlistRepository) Create(ctx context.Context, customerID, serviceID int, staffID *int, windowStart, windowEnd time.Time, priority int, notes string) (*appt_booking.WaitlistEntry, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.customers[customerID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.services[serviceID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if staffID != nil {
+		if _, ok := s.staff[*staffID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+	}
+	if !windowStart.Before(windowEnd) {
+		return nil, ErrCheckViolation
+	}
+
+	now := time.Now()
+	e := appt_booking.WaitlistEntry{
+		ID:          s.nextID(),
+		CustomerID:  customerID,
+		ServiceID:   serviceID,
+		WindowStart: windowStart.UTC(),
+		WindowEnd:   windowEnd.UTC(),
+		Priority:    priority,
+		Status:      appt_booking.WaitlistStatusWaiting,
+		Notes:       notes,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if staffID != nil {
+		id := *staffID
+		e.StaffID = &id
+	}
+	s.waitlist[e.ID] = e
+	return copyWaitlistEntry(e), nil
+}
+
+// GetByID retrieves a waitlist entry by ID; returns nil if it does not exist
+func (r *WaitlistRepository) GetByID(ctx context.Context, id int) (*appt_booking.WaitlistEntry, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.waitlist[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWaitlistEntry(e), nil
+}
+
+// List retrieves the entries matching filter in the order they are offered slots
+func (r *WaitlistRepository) List(ctx context.Context, filter appt_booking.WaitlistFilter) ([]appt_booking.WaitlistEntry, error) {
+	return r.matching(func(e appt_booking.WaitlistEntry) bool {
+		return (filter.ServiceID == 0 || e.ServiceID == filter.ServiceID) &&
+			(filter.StaffID == 0 || e.StaffID == nil || *e.StaffID == filter.StaffID) &&
+			(filter.CustomerID == 0 || e.CustomerID == filter.CustomerID) &&
+			(len(filter.Statuses) == 0 || containsString(filter.Statuses, e.Status))
+	}), nil
+}
+
+// GetWaiting retrieves the waiting entries whose window has not ended by now, in the
+// order they are offered slots; staffID 0 matches every staff member
+func (r *WaitlistRepository) GetWaiting(ctx context.Context, staffID int, now time.Time) ([]appt_booking.WaitlistEntry, error) {
+	return r.matching(func(e appt_booking.WaitlistEntry) bool {
+		return e.Status == appt_booking.WaitlistStatusWaiting && e.WindowEnd.After(now) &&
+			(staffID == 0 || e.StaffID == nil || *e.StaffID == staffID)
+	}), nil
+}
+
+// UpdateStatus moves an entry from fromStatus to toStatus and records event against it;
+// returns nil if the entry does not exist or is no longer in fromStatus
+func (r *WaitlistRepository) UpdateStatus(ctx context.Context, id int, fromStatus, toStatus string, event *appt_booking.OutboxEvent) (*appt_booking.WaitlistEntry, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.waitlist[id]
+	if !ok || e.Status != fromStatus {
+		return nil, nil
+	}
+	e.Status = toStatus
+	e.UpdatedAt = time.Now()
+	s.waitlist[id] = e
+	return copyWaitlistEntry(e), s.recordEvent(event, id, e)
+}
+
+// ExpireOffers marks offered entries whose hold expired by now as expired, and returns them
+func (r *WaitlistRepository) ExpireOffers(ctx context.Context, now time.Time) ([]appt_booking.WaitlistEntry, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []appt_booking.WaitlistEntry
+	for _, h := range s.holds {
+		if h.WaitlistEntryID == nil || h.ExpiresAt.After(now) {
+			continue
+		}
+		e, ok := s.waitlist[*h.WaitlistEntryID]
+		if !ok || e.Status != appt_booking.WaitlistStatusOffered {
+			continue
+		}
+		e.Status = appt_booking.WaitlistStatusExpired
+		e.UpdatedAt = time.Now()
+		s.waitlist[e.ID] = e
+		expired = append(expired, *copyWaitlistEntry(e))
+	}
+	return expired, nil
+}
+
+// matching returns copies of the entries matching match, ordered like waitlistOrder
+func (r *WaitlistRepository) matching(match func(appt_booking.WaitlistEntry) bool) []appt_booking.WaitlistEntry {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []appt_booking.WaitlistEntry
+	for _, e := range s.waitlist {
+		if match(e) {
+			entries = append(entries, *copyWaitlistEntry(e))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return entries
+}
+
+// deleteWaitlistWhere deletes the matching entries and the holds offered to them, like
+// ON DELETE CASCADE; callers hold s.mu
+func (s *Store) deleteWaitlistWhere(match func(appt_booking.WaitlistEntry) bool) {
+	for id, e := range s.waitlist {
+		if !match(e) {
+			continue
+		}
+		for holdID, h := range s.holds {
+			if h.WaitlistEntryID != nil && *h.WaitlistEntryID == id {
+				delete(s.holds, holdID)
+			}
+		}
+		delete(s.waitlist, id)
+	}
+}
+
+// copyWaitlistEntry returns a copy of e that shares no pointers with the store
+func copyWaitlistEntry(e appt_booking.WaitlistEntry) *appt_booking.WaitlistEntry {
+	c := e
+	if e.StaffID != nil {
+		id := *e.StaffID
+		c.StaffID = &id
+	}
+	return &c
+}
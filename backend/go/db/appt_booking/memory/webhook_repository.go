@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// WebhookRepository is the in-memory counterpart of appt_booking.WebhookRepository
+type WebhookRepository struct {
+	store *Store
+}
+
+// CreateSubscription adds a webhook subscription
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, url string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sub := appt_booking.WebhookSubscription{
+		ID:          s.nextID(),
+		URL:         url,
+		EventTypes:  append([]string{}, eventTypes...),
+		Secret:      secret,
+		Description: description,
+		Active:      active,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.webhookSubscriptions[sub.ID] = sub
+	return copyWebhookSubscription(sub), nil
+}
+
+// UpdateSubscription replaces a subscription's settings; returns nil if it does not exist
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, id int, url string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.webhookSubscriptions[id]
+	if !ok {
+		return nil, nil
+	}
+	sub.URL = url
+	sub.EventTypes = append([]string{}, eventTypes...)
+	sub.Secret = secret
+	sub.Description = description
+	sub.Active = active
+	sub.UpdatedAt = time.Now()
+	s.webhookSubscriptions[id] = sub
+	return copyWebhookSubscription(sub), nil
+}
+
+// GetSubscription retrieves a subscription by ID; returns nil if it does not exist
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id int) (*appt_booking.WebhookSubscription, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.webhookSubscriptions[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWebhookSubscription(sub), nil
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]appt_booking.WebhookSubscription, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subscriptions []appt_booking.WebhookSubscription
+	for _, sub := range s.webhookSubscriptions {
+		subscriptions = append(subscriptions, *copyWebhookSubscription(sub))
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions, nil
+}
+
+// DeleteSubscription deletes a subscription along with its delivery log (ON DELETE CASCADE)
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.webhookSubscriptions, id)
+	for deliveryID, d := range s.webhookDeliveries {
+		if d.SubscriptionID == id {
+			delete(s.webhookDeliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// EnqueueDeliveries schedules the delivery of event to each of subscriptionIDs, skipping
+// subscriptions that already have a delivery of it
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event appt_booking.OutboxEvent, subscriptionIDs []int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.outbox[event.ID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	for _, id := range subscriptionIDs {
+		if _, ok := s.webhookSubscriptions[id]; !ok {
+			return ErrForeignKeyViolation
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, id := range subscriptionIDs {
+		if r.hasDelivery(id, event.ID) {
+			continue
+		}
+		d := appt_booking.WebhookDelivery{
+			ID:             int64(s.nextID()),
+			SubscriptionID: id,
+			EventID:        event.ID,
+			EventType:      event.EventType,
+			Status:         appt_booking.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		s.webhookDeliveries[d.ID] = d
+	}
+	return nil
+}
+
+// hasDelivery reports whether the subscription has a delivery of the event; callers hold s.mu
+func (r *WebhookRepository) hasDelivery(subscriptionID int, eventID int64) bool {
+	for _, d := range r.store.webhookDeliveries {
+		if d.SubscriptionID == subscriptionID && d.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries to active subscriptions that
+// are due at now, oldest first, counting an attempt for each and pushing their next attempt
+// back to now+lease
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]appt_booking.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []appt_booking.WebhookDelivery
+	for _, d := range s.webhookDeliveries {
+		if d.Status == appt_booking.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && s.webhookSubscriptions[d.SubscriptionID].Active {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].Attempts++
+		due[i].NextAttemptAt = now.Add(lease).UTC()
+		s.webhookDeliveries[due[i].ID] = due[i]
+		due[i] = *copyWebhookDelivery(due[i])
+	}
+	return due, nil
+}
+
+// RecordAttempt saves the outcome of an attempt at a delivery
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, d appt_booking.WebhookDelivery) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.webhookDeliveries[d.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = d.Status
+	stored.NextAttemptAt = d.NextAttemptAt.UTC()
+	stored.ResponseStatus = d.ResponseStatus
+	stored.ResponseBody = d.ResponseBody
+	stored.LastError = d.LastError
+	stored.DeliveredAt = d.DeliveredAt
+	s.webhookDeliveries[d.ID] = *copyWebhookDelivery(stored)
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID; returns nil if it does not exist
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*appt_booking.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.webhookDeliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWebhookDelivery(d), nil
+}
+
+// ListDeliveries retrieves up to limit of a subscription's deliveries, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]appt_booking.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []appt_booking.WebhookDelivery
+	for _, d := range s.webhookDeliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, *copyWebhookDelivery(d))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// Redeliver makes a delivery pending again with fresh attempts, due at now; returns nil if
+// it does not exist
+func (r *WebhookRepository) Redeliver(ctx context.Context, id int64, now time.Time) (*appt_booking.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.webhookDeliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	d.Status = appt_booking.WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now.UTC()
+	d.DeliveredAt = nil
+	s.webhookDeliveries[id] = d
+	return copyWebhookDelivery(d), nil
+}
+
+// copyWebhookSubscription returns a copy of sub that shares no memory with the store
+func copyWebhookSubscription(sub appt_booking.WebhookSubscription) *appt_booking.WebhookSubscription {
+	c := sub
+	c.EventTypes = append([]string{}, sub.EventTypes...)
+	return &c
+}
+
+// copyWebhookDelivery returns a copy of d that shares no memory with the store
+func copyWebhookDelivery(d appt_booking.WebhookDelivery) *appt_booking.WebhookDelivery {
+	c := d
+	if d.ResponseStatus != nil {
+		status := *d.ResponseStatus
+		c.ResponseStatus = &status
+	}
+	if d.DeliveredAt != nil {
+		at := *d.DeliveredAt
+		c.DeliveredAt = &at
+	}
+	return &c
+}
@@ -0,0 +1,16 @@
+package appt_booking
+
+import (
+	"database/sql"
+	"embed"
+
+	"k8s-fullstack-blueprint-backend/db/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// NewMigrator returns the schema migrator for the appointment booking database
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, "appt_booking", migrationFiles, "migrations")
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS appointments;
+DROP TABLE IF EXISTS schedules;
+DROP TABLE IF EXISTS staff_services;
+DROP TABLE IF EXISTS staff;
+DROP TABLE IF EXISTS services;
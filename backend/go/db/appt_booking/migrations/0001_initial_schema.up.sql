@@ -0,0 +1,59 @@
+-- Core appointment booking tables. IF NOT EXISTS keeps this safe on databases
+-- created before versioned migrations were introduced.
+
+CREATE TABLE IF NOT EXISTS services (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT,
+	duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+	price_cents INTEGER NOT NULL CHECK (price_cents >= 0),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS staff (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	phone VARCHAR(50),
+	role VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS staff_services (
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+	PRIMARY KEY (staff_id, service_id)
+);
+
+CREATE TABLE IF NOT EXISTS schedules (
+	id SERIAL PRIMARY KEY,
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	day_of_week INTEGER NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+	start_time TIME NOT NULL,
+	end_time TIME NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE(staff_id, day_of_week, start_time, end_time)
+);
+
+-- appointment_datetime holds UTC wall-clock time
+CREATE TABLE IF NOT EXISTS appointments (
+	id SERIAL PRIMARY KEY,
+	customer_name VARCHAR(255) NOT NULL,
+	customer_email VARCHAR(255) NOT NULL,
+	customer_phone VARCHAR(50),
+	staff_id INTEGER NOT NULL REFERENCES staff(id),
+	service_id INTEGER NOT NULL REFERENCES services(id),
+	appointment_datetime TIMESTAMP NOT NULL,
+	duration_minutes INTEGER NOT NULL,
+	status VARCHAR(50) NOT NULL DEFAULT 'confirmed',
+	notes TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_appointments_staff_datetime ON appointments(staff_id, appointment_datetime);
+CREATE INDEX IF NOT EXISTS idx_appt_booking_appointments_customer_email ON appointments(customer_email);
+CREATE INDEX IF NOT EXISTS idx_appt_booking_schedules_staff_day ON schedules(staff_id, day_of_week);
@@ -0,0 +1,24 @@
+-- Prevent overlapping non-cancelled appointments for the same staff member at the database level.
+-- appointment_datetime holds UTC wall-clock time, so the range is built in UTC.
+-- btree_gist is required to combine the staff_id equality with the range overlap in one GiST index.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Existing overlapping rows would make the constraint fail to apply; in that case
+-- log a warning and rely on the transactional conflict check until they are resolved.
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_no_overlap') THEN
+		ALTER TABLE appointments ADD CONSTRAINT appointments_no_overlap
+			EXCLUDE USING gist (
+				staff_id WITH =,
+				tstzrange(
+					appointment_datetime AT TIME ZONE 'UTC',
+					(appointment_datetime + (duration_minutes * INTERVAL '1 minute')) AT TIME ZONE 'UTC',
+					'[)'
+				) WITH &&
+			) WHERE (status <> 'cancelled');
+	END IF;
+EXCEPTION WHEN exclusion_violation THEN
+	RAISE WARNING 'appointments_no_overlap not created: existing appointments overlap';
+END
+$$;
@@ -0,0 +1 @@
+ALTER TABLE staff DROP COLUMN IF EXISTS timezone;
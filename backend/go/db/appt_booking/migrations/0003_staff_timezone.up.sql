@@ -0,0 +1,2 @@
+-- Staff timezone (IANA name); empty means the business default timezone
+ALTER TABLE staff ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+-- History of time/staff changes per appointment
+CREATE TABLE IF NOT EXISTS appointment_reschedules (
+	id SERIAL PRIMARY KEY,
+	appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+	previous_staff_id INTEGER NOT NULL,
+	previous_datetime TIMESTAMP NOT NULL,
+	new_staff_id INTEGER NOT NULL,
+	new_datetime TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_appointment_reschedules_appointment ON appointment_reschedules(appointment_id);
@@ -0,0 +1,17 @@
+-- Time off, holidays and one-off hours; staff_id NULL means the exception applies business-wide
+CREATE TABLE IF NOT EXISTS schedule_exceptions (
+	id SERIAL PRIMARY KEY,
+	staff_id INTEGER REFERENCES staff(id) ON DELETE CASCADE,
+	start_date DATE NOT NULL,
+	end_date DATE NOT NULL,
+	is_closed BOOLEAN NOT NULL,
+	start_time TIME,
+	end_time TIME,
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (end_date >= start_date),
+	CHECK (is_closed OR (start_time IS NOT NULL AND end_time IS NOT NULL AND start_time < end_time))
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_schedule_exceptions_staff_dates ON schedule_exceptions(staff_id, start_date, end_date);
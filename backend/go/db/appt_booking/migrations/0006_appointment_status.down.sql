@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_status_history;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
@@ -0,0 +1,22 @@
+-- Restrict appointment status to the known lifecycle values
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_status_check') THEN
+		ALTER TABLE appointments ADD CONSTRAINT appointments_status_check
+			CHECK (status IN ('pending', 'confirmed', 'checked_in', 'in_progress', 'completed', 'cancelled', 'no_show'));
+	END IF;
+END
+$$;
+
+-- Who/when/why for each status transition
+CREATE TABLE IF NOT EXISTS appointment_status_history (
+	id SERIAL PRIMARY KEY,
+	appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+	from_status VARCHAR(50) NOT NULL,
+	to_status VARCHAR(50) NOT NULL,
+	changed_by VARCHAR(255) NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_appointment_status_history_appointment ON appointment_status_history(appointment_id);
@@ -0,0 +1,12 @@
+-- Login accounts. Staff accounts link to a staff row and take their role from staff.role;
+-- accounts without a staff member are customers or standalone admins.
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	role VARCHAR(50) NOT NULL CHECK (role IN ('customer', 'provider', 'admin')),
+	staff_id INTEGER UNIQUE REFERENCES staff(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (role <> 'provider' OR staff_id IS NOT NULL)
+);
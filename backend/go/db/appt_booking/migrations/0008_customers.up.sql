@@ -0,0 +1,34 @@
+-- Customers as their own entity. Appointments keep customer_name/email/phone as
+-- entered at booking time; customer_id ties a customer's appointments together.
+-- Emails are stored lower-cased.
+CREATE TABLE IF NOT EXISTS customers (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	phone VARCHAR(50) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One customer per distinct email; name and phone come from their latest appointment
+INSERT INTO customers (name, email, phone, created_at, updated_at)
+SELECT DISTINCT ON (LOWER(TRIM(customer_email)))
+	customer_name,
+	LOWER(TRIM(customer_email)),
+	COALESCE(customer_phone, ''),
+	MIN(created_at) OVER (PARTITION BY LOWER(TRIM(customer_email))),
+	NOW()
+FROM appointments
+ORDER BY LOWER(TRIM(customer_email)), appointment_datetime DESC, id DESC
+ON CONFLICT (email) DO NOTHING;
+
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS customer_id INTEGER REFERENCES customers(id);
+
+UPDATE appointments a
+SET customer_id = c.id
+FROM customers c
+WHERE a.customer_id IS NULL AND c.email = LOWER(TRIM(a.customer_email));
+
+ALTER TABLE appointments ALTER COLUMN customer_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_appointments_customer ON appointments(customer_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_series_occurrences;
+DROP TABLE IF EXISTS appointment_series;
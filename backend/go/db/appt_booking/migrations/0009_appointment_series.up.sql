@@ -0,0 +1,27 @@
+-- Recurring appointments. A series holds the recurrence rule; each occurrence is an
+-- ordinary appointment linked through appointment_series_occurrences, so it can be
+-- cancelled or rescheduled on its own.
+CREATE TABLE IF NOT EXISTS appointment_series (
+	id SERIAL PRIMARY KEY,
+	customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+	frequency VARCHAR(16) NOT NULL CHECK (frequency IN ('weekly', 'monthly')),
+	repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval > 0),
+	occurrence_count INTEGER CHECK (occurrence_count > 0),
+	until_date DATE, -- inclusive, in the staff member's timezone
+	starts_at TIMESTAMP NOT NULL, -- first occurrence, UTC wall-clock time
+	notes TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (occurrence_count IS NOT NULL OR until_date IS NOT NULL)
+);
+
+-- occurrence_index is the position in the recurrence (0 for the first) and is kept
+-- when an occurrence is rescheduled
+CREATE TABLE IF NOT EXISTS appointment_series_occurrences (
+	appointment_id INTEGER PRIMARY KEY REFERENCES appointments(id) ON DELETE CASCADE,
+	series_id INTEGER NOT NULL REFERENCES appointment_series(id) ON DELETE CASCADE,
+	occurrence_index INTEGER NOT NULL CHECK (occurrence_index >= 0),
+	UNIQUE (series_id, occurrence_index)
+);
@@ -0,0 +1,36 @@
+-- Customers waiting for a slot to open up. Entries are offered in priority order
+-- (highest first, then first come first served).
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+	id SERIAL PRIMARY KEY,
+	customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+	staff_id INTEGER REFERENCES staff(id) ON DELETE CASCADE, -- NULL = any staff member
+	window_start TIMESTAMP NOT NULL, -- UTC wall-clock time
+	window_end TIMESTAMP NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	status VARCHAR(16) NOT NULL DEFAULT 'waiting'
+		CHECK (status IN ('waiting', 'offered', 'booked', 'expired', 'cancelled')),
+	notes TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (window_start < window_end)
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_waitlist_entries_status ON waitlist_entries(status, priority DESC, created_at);
+
+-- Slots reserved until expires_at. An unexpired hold blocks the slot like an appointment;
+-- waitlist offers are holds linked to their entry.
+CREATE TABLE IF NOT EXISTS slot_holds (
+	id SERIAL PRIMARY KEY,
+	token VARCHAR(64) UNIQUE NOT NULL,
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	service_id INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+	starts_at TIMESTAMP NOT NULL, -- UTC wall-clock time
+	duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+	waitlist_entry_id INTEGER REFERENCES waitlist_entries(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL, -- UTC wall-clock time
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_slot_holds_staff_start ON slot_holds(staff_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_appt_booking_slot_holds_waitlist_entry ON slot_holds(waitlist_entry_id);
@@ -0,0 +1,5 @@
+ALTER TABLE services DROP COLUMN IF EXISTS slot_granularity_minutes;
+ALTER TABLE services DROP COLUMN IF EXISTS max_advance_days;
+ALTER TABLE services DROP COLUMN IF EXISTS min_notice_minutes;
+ALTER TABLE services DROP COLUMN IF EXISTS buffer_after_minutes;
+ALTER TABLE services DROP COLUMN IF EXISTS buffer_before_minutes;
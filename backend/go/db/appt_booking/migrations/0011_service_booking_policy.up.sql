@@ -0,0 +1,11 @@
+-- Per-service buffers and booking policy.
+-- Buffers block the staff member's time before and after each appointment of the service
+-- (preparation and cleanup). They are enforced by the transactional conflict check, which
+-- reads them from services; appointments_no_overlap still guards the appointments themselves.
+ALTER TABLE services ADD COLUMN IF NOT EXISTS buffer_before_minutes INTEGER NOT NULL DEFAULT 0 CHECK (buffer_before_minutes >= 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS buffer_after_minutes INTEGER NOT NULL DEFAULT 0 CHECK (buffer_after_minutes >= 0);
+-- Bookings must start at least min_notice_minutes from now and at most max_advance_days ahead (0 = no limit)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS min_notice_minutes INTEGER NOT NULL DEFAULT 0 CHECK (min_notice_minutes >= 0);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS max_advance_days INTEGER NOT NULL DEFAULT 0 CHECK (max_advance_days >= 0);
+-- Bookings start on multiples of slot_granularity_minutes past midnight in the staff member's timezone
+ALTER TABLE services ADD COLUMN IF NOT EXISTS slot_granularity_minutes INTEGER NOT NULL DEFAULT 15 CHECK (slot_granularity_minutes > 0);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_cancellations;
+ALTER TABLE services DROP COLUMN IF EXISTS cancel_notice_minutes;
@@ -0,0 +1,12 @@
+-- Customers cancelling within cancel_notice_minutes of the start cancel late (0 = never late)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS cancel_notice_minutes INTEGER NOT NULL DEFAULT 0 CHECK (cancel_notice_minutes >= 0);
+
+-- Who cancelled an appointment, why, and whether it was a late cancellation.
+-- Written with the status change; cancelled is terminal, so there is one row per appointment.
+CREATE TABLE IF NOT EXISTS appointment_cancellations (
+	appointment_id INTEGER PRIMARY KEY REFERENCES appointments(id) ON DELETE CASCADE,
+	cancelled_by VARCHAR(16) NOT NULL CHECK (cancelled_by IN ('customer', 'staff')),
+	reason TEXT NOT NULL DEFAULT '',
+	late BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+-- Domain events, written in the same transaction as the change they describe and
+-- delivered at least once by the event dispatcher. aggregate_id is the ID of the
+-- changed row in the table named by aggregate_type.
+CREATE TABLE IF NOT EXISTS outbox (
+	id BIGSERIAL PRIMARY KEY,
+	event_type VARCHAR(64) NOT NULL,
+	aggregate_type VARCHAR(32) NOT NULL,
+	aggregate_id INTEGER NOT NULL,
+	payload JSONB NOT NULL DEFAULT '{}',
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(), -- UTC; pushed back while claimed and between retries
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_outbox_pending ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_appt_booking_outbox_aggregate ON outbox(aggregate_type, aggregate_id);
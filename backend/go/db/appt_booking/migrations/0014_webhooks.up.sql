@@ -0,0 +1,34 @@
+-- Partner endpoints that receive domain events as signed HTTP POSTs. An empty
+-- event_types list subscribes to every event.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	event_types TEXT[] NOT NULL DEFAULT '{}',
+	secret VARCHAR(128) NOT NULL, -- HMAC-SHA256 key for the signature header
+	description TEXT NOT NULL DEFAULT '',
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One delivery per subscription per outbox event, kept as the delivery log. The response
+-- columns describe the latest attempt.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id BIGSERIAL PRIMARY KEY,
+	subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+	event_id BIGINT NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+	event_type VARCHAR(64) NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(), -- UTC; pushed back while claimed and between retries
+	response_status INTEGER, -- NULL when the last attempt got no response
+	response_body TEXT NOT NULL DEFAULT '', -- truncated
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMP,
+	UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_appt_booking_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, id DESC);
@@ -0,0 +1,21 @@
+-- Log of the notifications sent to customers. dedupe_key identifies what a notification
+-- is about (e.g. the outbox event that triggered it) so that it is sent only once even
+-- when its trigger is handled more than once.
+CREATE TABLE IF NOT EXISTS notifications (
+	id BIGSERIAL PRIMARY KEY,
+	dedupe_key VARCHAR(128) NOT NULL UNIQUE,
+	kind VARCHAR(32) NOT NULL,
+	appointment_id INTEGER REFERENCES appointments(id) ON DELETE SET NULL,
+	channel VARCHAR(16) NOT NULL DEFAULT 'email',
+	recipient VARCHAR(255) NOT NULL,
+	subject TEXT NOT NULL DEFAULT '',
+	status VARCHAR(16) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'sent', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_notifications_appointment ON notifications(appointment_id);
+CREATE INDEX IF NOT EXISTS idx_appt_booking_notifications_status ON notifications(status, id DESC);
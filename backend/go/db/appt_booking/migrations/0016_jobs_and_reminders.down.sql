@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_reminders;
+DROP TABLE IF EXISTS scheduled_jobs;
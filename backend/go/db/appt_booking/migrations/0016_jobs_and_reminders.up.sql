@@ -0,0 +1,22 @@
+-- Periodic jobs run by the job scheduler. A replica runs a job by claiming its row: the
+-- claim succeeds only when the job is due and not locked by another replica, so each run
+-- happens on exactly one replica. locked_until frees the job if its runner dies.
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+	name VARCHAR(64) PRIMARY KEY,
+	next_run_at TIMESTAMP NOT NULL, -- UTC
+	locked_until TIMESTAMP,         -- UTC; set while a replica runs the job
+	last_started_at TIMESTAMP,
+	last_finished_at TIMESTAMP,
+	last_error TEXT NOT NULL DEFAULT ''
+);
+
+-- Appointment reminders that have been sent, one per reminder offset and appointment
+-- time, so that rescheduled appointments are reminded of their new time
+CREATE TABLE IF NOT EXISTS appointment_reminders (
+	appointment_id INTEGER NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+	offset_minutes INTEGER NOT NULL CHECK (offset_minutes > 0),
+	appointment_datetime TIMESTAMP NOT NULL, -- UTC; the time the reminder was for
+	notification_id BIGINT REFERENCES notifications(id) ON DELETE SET NULL,
+	sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (appointment_id, offset_minutes, appointment_datetime)
+);
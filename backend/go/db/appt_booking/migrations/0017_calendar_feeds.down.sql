@@ -0,0 +1 @@
+DROP TABLE IF EXISTS staff_calendar_feeds;
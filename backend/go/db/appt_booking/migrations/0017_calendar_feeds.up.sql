@@ -0,0 +1,8 @@
+-- Secret tokens for subscribing to a staff member's appointments as an iCalendar feed.
+-- Calendar apps cannot send bearer tokens, so the token in the feed URL is the credential;
+-- replacing it revokes every existing subscription.
+CREATE TABLE IF NOT EXISTS staff_calendar_feeds (
+	staff_id INTEGER PRIMARY KEY REFERENCES staff(id) ON DELETE CASCADE,
+	token VARCHAR(64) UNIQUE NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,29 @@
+-- Staff members' calendars elsewhere, fetched from a URL or uploaded as an ICS file.
+-- Their events block the staff member like appointments do.
+CREATE TABLE IF NOT EXISTS external_calendars (
+	id SERIAL PRIMARY KEY,
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	url TEXT NOT NULL DEFAULT '',      -- '' for uploaded calendars
+	ics_data TEXT NOT NULL DEFAULT '', -- the uploaded file; re-expanded on every refresh
+	last_synced_at TIMESTAMP,          -- UTC; last successful refresh
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK ((url = '') <> (ics_data = ''))
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_external_calendars_staff ON external_calendars(staff_id);
+
+-- Busy intervals expanded from the events of external calendars, replaced on each refresh
+CREATE TABLE IF NOT EXISTS external_busy_times (
+	id BIGSERIAL PRIMARY KEY,
+	calendar_id INTEGER NOT NULL REFERENCES external_calendars(id) ON DELETE CASCADE,
+	staff_id INTEGER NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+	start_time TIMESTAMP NOT NULL, -- UTC
+	end_time TIMESTAMP NOT NULL,   -- UTC
+	CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_external_busy_times_staff ON external_busy_times(staff_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS idx_appt_booking_external_busy_times_calendar ON external_busy_times(calendar_id);
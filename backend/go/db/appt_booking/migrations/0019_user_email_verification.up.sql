@@ -0,0 +1,3 @@
+-- When an account's email address was confirmed. Customers are matched to the
+-- appointments and waitlist entries booked under their email only once it is.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
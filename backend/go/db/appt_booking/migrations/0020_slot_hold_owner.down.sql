@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_appt_booking_slot_holds_user_expires;
+ALTER TABLE slot_holds DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,5 @@
+-- The account that placed a checkout hold. Only it can book or release the hold, and
+-- each account may have a limited number of unexpired holds. NULL for waitlist offers.
+ALTER TABLE slot_holds ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_appt_booking_slot_holds_user_expires ON slot_holds(user_id, expires_at);
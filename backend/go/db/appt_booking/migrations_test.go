@@ -0,0 +1,23 @@
+package appt_booking
+
+import "testing"
+
+func TestMigrations_AreSequentialAndReversible(t *testing.T) {
+	m, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	migrations := m.Migrations()
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("expected version %d, got %d (%s)", i+1, migration.Version, migration.Name)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,10 +12,29 @@ type Service struct {
 	Description  string    `json:"description" db:"description"`
 	DurationMin  int       `json:"duration_minutes" db:"duration_minutes"`
 	PriceCents   int       `json:"price_cents" db:"price_cents"`
+	BookingPolicy
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// BookingPolicy controls when and how closely a service can be booked
+type BookingPolicy struct {
+	BufferBeforeMin    int `json:"buffer_before_minutes" db:"buffer_before_minutes"`       // staff time blocked before each appointment
+	BufferAfterMin     int `json:"buffer_after_minutes" db:"buffer_after_minutes"`         // staff time blocked after each appointment
+	MinNoticeMin       int `json:"min_notice_minutes" db:"min_notice_minutes"`             // earliest booking, in minutes from now
+	MaxAdvanceDays     int `json:"max_advance_days" db:"max_advance_days"`                 // latest booking, in days from now; 0 = no limit
+	SlotGranularityMin int `json:"slot_granularity_minutes" db:"slot_granularity_minutes"` // bookings start on multiples of this past local midnight
+	CancelNoticeMin    int `json:"cancel_notice_minutes" db:"cancel_notice_minutes"`       // customers cancelling later than this before the start cancel late; 0 = never late
+}
+
+// DefaultSlotGranularityMinutes is used when a service does not set a slot granularity
+const DefaultSlotGranularityMinutes = 15
+
+// Buffers returns the time blocked before and after each appointment of the service
+func (p BookingPolicy) Buffers() (before, after time.Duration) {
+	return time.Duration(p.BufferBeforeMin) * time.Minute, time.Duration(p.BufferAfterMin) * time.Minute
+}
+
 // Staff represents a provider or admin
 type Staff struct {
 	ID        int       `json:"id" db:"id"`
@@ -22,10 +42,51 @@ type Staff struct {
 	Email     string    `json:"email" db:"email"`
 	Phone     string    `json:"phone" db:"phone"`
 	Role      string    `json:"role" db:"role"` // "provider" or "admin"
+	Timezone  string    `json:"timezone" db:"timezone"` // IANA name, empty means business default
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// User roles. Staff accounts use their staff member's role (provider or admin).
+const (
+	RoleCustomer = "customer"
+	RoleProvider = "provider"
+	RoleAdmin    = "admin"
+)
+
+// User is a login account. StaffID links staff accounts to their staff member.
+type User struct {
+	ID              int        `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	PasswordHash    string     `json:"-" db:"password_hash"` // bcrypt
+	Role            string     `json:"role" db:"role"`       // one of the Role* constants
+	StaffID         *int       `json:"staff_id" db:"staff_id"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"` // nil until the address is confirmed
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Customer is a person who books appointments. Emails are stored lower-cased.
+type Customer struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Email     string    `json:"email" db:"email"`
+	Phone     string    `json:"phone" db:"phone"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CustomerStats aggregates a customer's appointments. A visit is a completed
+// appointment; spend is the current price of the services visited.
+type CustomerStats struct {
+	AppointmentCount   int        `json:"appointment_count"`
+	VisitCount         int        `json:"visit_count"`
+	LifetimeSpendCents int        `json:"lifetime_spend_cents"`
+	LastVisit          *time.Time `json:"last_visit"` // nil before the first visit
+	NoShowCount        int        `json:"no_show_count"`
+	LateCancelCount    int        `json:"late_cancel_count"`
+}
+
 // StaffService is a junction table linking staff to services (many-to-many)
 type StaffService struct {
 	StaffID  int `json:"staff_id" db:"staff_id"`
@@ -43,18 +104,349 @@ type Schedule struct {
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ScheduleException overrides the weekly schedule for a date range, either for one
+// staff member or business-wide (StaffID nil). A closed exception removes all working
+// hours on those dates; otherwise StartTime/EndTime give alternate hours.
+type ScheduleException struct {
+	ID        int        `json:"id" db:"id"`
+	StaffID   *int       `json:"staff_id" db:"staff_id"`     // nil = business-wide
+	StartDate time.Time  `json:"start_date" db:"start_date"` // DATE, inclusive
+	EndDate   time.Time  `json:"end_date" db:"end_date"`     // DATE, inclusive
+	IsClosed  bool       `json:"is_closed" db:"is_closed"`
+	StartTime *time.Time `json:"start_time" db:"start_time"` // TIME, nil when closed
+	EndTime   *time.Time `json:"end_time" db:"end_time"`     // TIME, nil when closed
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Appointment lifecycle statuses. Allowed transitions between them are defined in
+// service/appt_booking; the appointments table CHECK constraint restricts the values.
+const (
+	AppointmentStatusPending    = "pending"
+	AppointmentStatusConfirmed  = "confirmed"
+	AppointmentStatusCheckedIn  = "checked_in"
+	AppointmentStatusInProgress = "in_progress"
+	AppointmentStatusCompleted  = "completed"
+	AppointmentStatusCancelled  = "cancelled"
+	AppointmentStatusNoShow     = "no_show"
+)
+
 // Appointment represents a booked appointment
 type Appointment struct {
 	ID                 int       `json:"id" db:"id"`
-	CustomerName       string    `json:"customer_name" db:"customer_name"`
+	CustomerID         int       `json:"customer_id" db:"customer_id"`
+	CustomerName       string    `json:"customer_name" db:"customer_name"` // as entered at booking time
 	CustomerEmail      string    `json:"customer_email" db:"customer_email"`
 	CustomerPhone      string    `json:"customer_phone" db:"customer_phone"`
 	StaffID            int       `json:"staff_id" db:"staff_id"`
 	ServiceID          int       `json:"service_id" db:"service_id"`
 	AppointmentDatetime time.Time `json:"appointment_datetime" db:"appointment_datetime"`
 	DurationMinutes    int       `json:"duration_minutes" db:"duration_minutes"`
-	Status             string    `json:"status" db:"status"` // "confirmed", "cancelled", "completed"
+	Status             string    `json:"status" db:"status"` // one of the AppointmentStatus* constants
 	Notes              string    `json:"notes" db:"notes"`
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// AppointmentReschedule records a change to an appointment's time and/or staff member
+type AppointmentReschedule struct {
+	ID               int       `json:"id" db:"id"`
+	AppointmentID    int       `json:"appointment_id" db:"appointment_id"`
+	PreviousStaffID  int       `json:"previous_staff_id" db:"previous_staff_id"`
+	PreviousDatetime time.Time `json:"previous_datetime" db:"previous_datetime"`
+	NewStaffID       int       `json:"new_staff_id" db:"new_staff_id"`
+	NewDatetime      time.Time `json:"new_datetime" db:"new_datetime"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// AppointmentStatusChange records one status transition of an appointment
+type AppointmentStatusChange struct {
+	ID            int       `json:"id" db:"id"`
+	AppointmentID int       `json:"appointment_id" db:"appointment_id"`
+	FromStatus    string    `json:"from_status" db:"from_status"`
+	ToStatus      string    `json:"to_status" db:"to_status"`
+	ChangedBy     string    `json:"changed_by" db:"changed_by"`
+	Reason        string    `json:"reason" db:"reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Who cancelled an appointment
+const (
+	CancelledByCustomer = "customer"
+	CancelledByStaff    = "staff"
+)
+
+// AppointmentCancellation records who cancelled an appointment and why
+type AppointmentCancellation struct {
+	AppointmentID int       `json:"appointment_id" db:"appointment_id"`
+	CancelledBy   string    `json:"cancelled_by" db:"cancelled_by"` // one of the CancelledBy* constants
+	Reason        string    `json:"reason" db:"reason"`
+	Late          bool      `json:"late" db:"late"` // cancelled by the customer within the service's cancellation notice
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Recurrence frequencies of an appointment series
+const (
+	SeriesFrequencyWeekly  = "weekly"
+	SeriesFrequencyMonthly = "monthly"
+)
+
+// AppointmentSeries is a recurring booking: every Interval weeks or months from StartsAt,
+// ending after Count occurrences or on Until, whichever comes first. Its occurrences are
+// ordinary appointments linked through SeriesOccurrence.
+type AppointmentSeries struct {
+	ID         int        `json:"id" db:"id"`
+	CustomerID int        `json:"customer_id" db:"customer_id"`
+	StaffID    int        `json:"staff_id" db:"staff_id"`
+	ServiceID  int        `json:"service_id" db:"service_id"`
+	Frequency  string     `json:"frequency" db:"frequency"` // one of the SeriesFrequency* constants
+	Interval   int        `json:"interval" db:"repeat_interval"`
+	Count      *int       `json:"count" db:"occurrence_count"`
+	Until      *time.Time `json:"until" db:"until_date"` // DATE, inclusive, in the staff member's timezone
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	Notes      string     `json:"notes" db:"notes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SeriesOccurrence links an appointment to its series. Index is the occurrence's
+// position in the recurrence (0 for the first) and survives rescheduling.
+type SeriesOccurrence struct {
+	SeriesID      int `json:"series_id" db:"series_id"`
+	AppointmentID int `json:"appointment_id" db:"appointment_id"`
+	Index         int `json:"index" db:"occurrence_index"`
+}
+
+// Waitlist entry statuses. An entry is offered a slot at most once: if the offer's hold
+// expires the entry is expired, and booking the offer completes it.
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusBooked    = "booked"
+	WaitlistStatusExpired   = "expired"
+	WaitlistStatusCancelled = "cancelled"
+)
+
+// WaitlistEntry is a customer's request to be offered a slot for a service, optionally
+// with a specific staff member, starting within [WindowStart, WindowEnd)
+type WaitlistEntry struct {
+	ID          int       `json:"id" db:"id"`
+	CustomerID  int       `json:"customer_id" db:"customer_id"`
+	ServiceID   int       `json:"service_id" db:"service_id"`
+	StaffID     *int      `json:"staff_id" db:"staff_id"` // nil = any staff member
+	WindowStart time.Time `json:"window_start" db:"window_start"`
+	WindowEnd   time.Time `json:"window_end" db:"window_end"`
+	Priority    int       `json:"priority" db:"priority"` // higher is offered first
+	Status      string    `json:"status" db:"status"`     // one of the WaitlistStatus* constants
+	Notes       string    `json:"notes" db:"notes"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WaitlistFilter restricts a waitlist listing; zero values do not filter
+type WaitlistFilter struct {
+	ServiceID  int
+	StaffID    int // entries for this staff member or for any staff member
+	CustomerID int
+	Statuses   []string
+}
+
+// SlotHold reserves a slot until ExpiresAt. While it is unexpired no appointment or other
+// hold may overlap it.
+type SlotHold struct {
+	ID              int       `json:"id" db:"id"`
+	Token           string    `json:"token" db:"token"` // secret used to book the held slot
+	StaffID         int       `json:"staff_id" db:"staff_id"`
+	ServiceID       int       `json:"service_id" db:"service_id"`
+	StartsAt        time.Time `json:"starts_at" db:"starts_at"`
+	DurationMinutes int       `json:"duration_minutes" db:"duration_minutes"`
+	WaitlistEntryID *int      `json:"waitlist_entry_id" db:"waitlist_entry_id"` // set for waitlist offers
+	UserID          *int      `json:"user_id" db:"user_id"`                     // the account that placed a checkout hold
+	ExpiresAt       time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Outbox event statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusFailed    = "failed" // gave up after the dispatcher's maximum attempts
+)
+
+// Outbox aggregate types, naming the table an event's AggregateID refers to
+const (
+	AggregateAppointment   = "appointment"
+	AggregateService       = "service"
+	AggregateStaff         = "staff"
+	AggregateWaitlistEntry = "waitlist_entry"
+)
+
+// OutboxEvent is a domain event stored in the outbox in the same transaction as the change
+// it describes. Repositories record it against the ID of the row they changed.
+type OutboxEvent struct {
+	ID            int64           `json:"id" db:"id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	AggregateType string          `json:"aggregate_type" db:"aggregate_type"` // one of the Aggregate* constants
+	AggregateID   int             `json:"aggregate_id" db:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"` // one of the OutboxStatus* constants
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string          `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+// PayloadFor returns the payload to store for e when it describes a change to row: e's own
+// payload if it has one, otherwise row as JSON, or an empty object when row is nil
+func (e *OutboxEvent) PayloadFor(row interface{}) (json.RawMessage, error) {
+	if len(e.Payload) > 0 {
+		return e.Payload, nil
+	}
+	if row == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(row)
+}
+
+// WebhookSubscription is a partner endpoint that receives domain events
+type WebhookSubscription struct {
+	ID          int       `json:"id" db:"id"`
+	URL         string    `json:"url" db:"url"`
+	EventTypes  []string  `json:"event_types" db:"event_types"` // empty = every event
+	Secret      string    `json:"-" db:"secret"`                // signs deliveries; only shown when set
+	Description string    `json:"description" db:"description"`
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Matches reports whether the subscription receives events of eventType
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // gave up after the maximum attempts; can be redelivered manually
+)
+
+// WebhookDelivery is the delivery of one outbox event to one subscription. The Response*
+// and LastError fields describe the latest attempt.
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	SubscriptionID int        `json:"subscription_id" db:"subscription_id"`
+	EventID        int64      `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Status         string     `json:"status" db:"status"` // one of the WebhookDelivery* constants
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseStatus *int       `json:"response_status" db:"response_status"` // nil when there was no response
+	ResponseBody   string     `json:"response_body" db:"response_body"`
+	LastError      string     `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at" db:"delivered_at"`
+}
+
+// Notification kinds, one per message template
+const (
+	NotificationBooked        = "booked"
+	NotificationRescheduled   = "rescheduled"
+	NotificationCancelled     = "cancelled"
+	NotificationReminder      = "reminder"
+	NotificationWaitlistOffer = "waitlist_offer"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+)
+
+// Notification statuses
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+)
+
+// Notification is an entry in the log of messages sent to customers
+type Notification struct {
+	ID            int64      `json:"id" db:"id"`
+	DedupeKey     string     `json:"dedupe_key" db:"dedupe_key"` // unique; what the notification is about
+	Kind          string     `json:"kind" db:"kind"`             // one of the Notification* kinds
+	AppointmentID *int       `json:"appointment_id" db:"appointment_id"`
+	Channel       string     `json:"channel" db:"channel"`
+	Recipient     string     `json:"recipient" db:"recipient"`
+	Subject       string     `json:"subject" db:"subject"`
+	Status        string     `json:"status" db:"status"` // one of the NotificationStatus* constants
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     string     `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	SentAt        *time.Time `json:"sent_at" db:"sent_at"`
+}
+
+// NotificationFilter restricts a notification listing; zero values do not filter
+type NotificationFilter struct {
+	AppointmentID int
+	Status        string
+}
+
+// ScheduledJob is the schedule of a periodic job shared by all replicas
+type ScheduledJob struct {
+	Name           string     `json:"name" db:"name"`
+	NextRunAt      time.Time  `json:"next_run_at" db:"next_run_at"`
+	LockedUntil    *time.Time `json:"locked_until" db:"locked_until"` // set while a replica runs the job
+	LastStartedAt  *time.Time `json:"last_started_at" db:"last_started_at"`
+	LastFinishedAt *time.Time `json:"last_finished_at" db:"last_finished_at"`
+	LastError      string     `json:"last_error" db:"last_error"`
+}
+
+// AppointmentReminder records that a reminder was sent OffsetMinutes before an
+// appointment starting at AppointmentDatetime
+type AppointmentReminder struct {
+	AppointmentID       int       `json:"appointment_id" db:"appointment_id"`
+	OffsetMinutes       int       `json:"offset_minutes" db:"offset_minutes"`
+	AppointmentDatetime time.Time `json:"appointment_datetime" db:"appointment_datetime"`
+	NotificationID      *int64    `json:"notification_id" db:"notification_id"`
+	SentAt              time.Time `json:"sent_at" db:"sent_at"`
+}
+
+// StaffCalendarFeed holds the secret token of a staff member's iCalendar feed
+type StaffCalendarFeed struct {
+	StaffID   int       `json:"staff_id" db:"staff_id"`
+	Token     string    `json:"token" db:"token"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExternalCalendar is a staff member's calendar kept elsewhere, either fetched from URL
+// or uploaded, whose events block the staff member's availability
+type ExternalCalendar struct {
+	ID           int        `json:"id" db:"id"`
+	StaffID      int        `json:"staff_id" db:"staff_id"`
+	Name         string     `json:"name" db:"name"`
+	URL          string     `json:"url" db:"url"` // empty for uploaded calendars
+	Data         string     `json:"-" db:"ics_data"`
+	LastSyncedAt *time.Time `json:"last_synced_at" db:"last_synced_at"`
+	LastError    string     `json:"last_error" db:"last_error"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ExternalBusyTime is a busy interval expanded from an external calendar's events
+type ExternalBusyTime struct {
+	CalendarID int       `json:"calendar_id" db:"calendar_id"`
+	StaffID    int       `json:"staff_id" db:"staff_id"`
+	StartTime  time.Time `json:"start_time" db:"start_time"`
+	EndTime    time.Time `json:"end_time" db:"end_time"`
+}
@@ -0,0 +1,122 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NotificationRepository handles database operations for the notification log
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationColumns = "id, dedupe_key, kind, appointment_id, channel, recipient, subject, status, attempts, last_error, created_at, sent_at"
+
+// scanNotification scans a row selected with notificationColumns
+func scanNotification(row interface{ Scan(...interface{}) error }) (*Notification, error) {
+	n := &Notification{}
+	var appointmentID sql.NullInt64
+	err := row.Scan(&n.ID, &n.DedupeKey, &n.Kind, &appointmentID, &n.Channel, &n.Recipient, &n.Subject, &n.Status, &n.Attempts, &n.LastError, &n.CreatedAt, &n.SentAt)
+	if err != nil {
+		return nil, err
+	}
+	if appointmentID.Valid {
+		id := int(appointmentID.Int64)
+		n.AppointmentID = &id
+	}
+	return n, nil
+}
+
+// Create adds a pending notification. Returns nil if one with the same dedupe key exists.
+func (nr *NotificationRepository) Create(ctx context.Context, n Notification) (*Notification, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	created, err := scanNotification(nr.db.QueryRowContext(ctx,
+		`INSERT INTO notifications (dedupe_key, kind, appointment_id, channel, recipient, subject, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (dedupe_key) DO NOTHING
+		 RETURNING `+notificationColumns,
+		n.DedupeKey, n.Kind, n.AppointmentID, n.Channel, n.Recipient, n.Subject, NotificationStatusPending, time.Now().UTC(),
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return created, err
+}
+
+// GetByDedupeKey retrieves the notification with a dedupe key; returns nil if there is none
+func (nr *NotificationRepository) GetByDedupeKey(ctx context.Context, key string) (*Notification, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	n, err := scanNotification(nr.db.QueryRowContext(ctx,
+		"SELECT "+notificationColumns+" FROM notifications WHERE dedupe_key = $1",
+		key,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return n, err
+}
+
+// RecordAttempts saves the outcome of sending a notification: its subject, status,
+// attempts, last error and when it was sent
+func (nr *NotificationRepository) RecordAttempts(ctx context.Context, n Notification) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var sentAt *time.Time
+	if n.SentAt != nil {
+		at := n.SentAt.UTC()
+		sentAt = &at
+	}
+	_, err := nr.db.ExecContext(ctx,
+		`UPDATE notifications
+		 SET recipient = $1, subject = $2, status = $3, attempts = $4, last_error = $5, sent_at = $6
+		 WHERE id = $7`,
+		n.Recipient, n.Subject, n.Status, n.Attempts, n.LastError, sentAt, n.ID,
+	)
+	return err
+}
+
+// List retrieves up to limit of the notifications matching filter, newest first
+func (nr *NotificationRepository) List(ctx context.Context, filter NotificationFilter, limit int) ([]Notification, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	w := &whereBuilder{}
+	if filter.AppointmentID != 0 {
+		w.add("appointment_id = ?", filter.AppointmentID)
+	}
+	if filter.Status != "" {
+		w.add("status = ?", filter.Status)
+	}
+	where := w.sql()
+	page := limitOffset(w, ListOptions{Limit: limit})
+
+	rows, err := nr.db.QueryContext(ctx,
+		"SELECT "+notificationColumns+" FROM notifications "+where+" ORDER BY id DESC"+page,
+		w.args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+	return notifications, rows.Err()
+}
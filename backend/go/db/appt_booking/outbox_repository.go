@@ -0,0 +1,191 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// OutboxRepository handles database operations for delivering outbox events. Events are
+// written by the other repositories, in the transaction of the change they describe.
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+const outboxColumns = "id, event_type, aggregate_type, aggregate_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at"
+
+// scanOutboxEvent scans a row selected with outboxColumns
+func scanOutboxEvent(row interface{ Scan(...interface{}) error }) (*OutboxEvent, error) {
+	e := &OutboxEvent{}
+	var payload []byte
+	err := row.Scan(&e.ID, &e.EventType, &e.AggregateType, &e.AggregateID, &payload, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt, &e.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = payload
+	return e, nil
+}
+
+// insertOutboxEvent records event against aggregateID within tx; a nil event records nothing.
+// row is the changed row as stored, or nil if it was deleted.
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event *OutboxEvent, aggregateID int, row interface{}) error {
+	if event == nil {
+		return nil
+	}
+	payload, err := event.PayloadFor(row)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, aggregate_type, aggregate_id, payload, status, next_attempt_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		event.EventType, event.AggregateType, aggregateID, []byte(payload), OutboxStatusPending, now, now,
+	)
+	return err
+}
+
+// deleteWithEvent runs a DELETE by id and, if it removed a row, records event in the same transaction
+func deleteWithEvent(ctx context.Context, db *sql.DB, query string, id int, event *OutboxEvent) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		if err := insertOutboxEvent(ctx, tx, event, id, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClaimDue returns up to limit pending events due at now, oldest first, counting an attempt
+// for each. Their next attempt is pushed back to now+lease so that other dispatchers skip
+// them while they are handled; an event that is neither delivered nor retried before the
+// lease runs out (e.g. because the process died) is claimed again.
+func (or *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OutboxEvent, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := or.db.QueryContext(ctx,
+		`UPDATE outbox
+		 SET attempts = attempts + 1, next_attempt_at = $1
+		 WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+outboxColumns,
+		now.Add(lease).UTC(), OutboxStatusPending, now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING does not follow the subquery's order
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// MarkDelivered records that every handler has processed an event
+func (or *OutboxRepository) MarkDelivered(ctx context.Context, id int64, now time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := or.db.ExecContext(ctx,
+		"UPDATE outbox SET status = $1, delivered_at = $2, last_error = '' WHERE id = $3",
+		OutboxStatusDelivered, now.UTC(), id,
+	)
+	return err
+}
+
+// RetryLater schedules another attempt at an event after a handler failed with lastError
+func (or *OutboxRepository) RetryLater(ctx context.Context, id int64, at time.Time, lastError string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := or.db.ExecContext(ctx,
+		"UPDATE outbox SET next_attempt_at = $1, last_error = $2 WHERE id = $3",
+		at.UTC(), lastError, id,
+	)
+	return err
+}
+
+// MarkFailed gives up on an event whose handlers kept failing
+func (or *OutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := or.db.ExecContext(ctx,
+		"UPDATE outbox SET status = $1, last_error = $2 WHERE id = $3",
+		OutboxStatusFailed, lastError, id,
+	)
+	return err
+}
+
+// GetByID retrieves an event by ID; returns nil if it does not exist
+func (or *OutboxRepository) GetByID(ctx context.Context, id int64) (*OutboxEvent, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	e, err := scanOutboxEvent(or.db.QueryRowContext(ctx, "SELECT "+outboxColumns+" FROM outbox WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// GetAll retrieves all events, oldest first
+func (or *OutboxRepository) GetAll(ctx context.Context) ([]OutboxEvent, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := or.db.QueryContext(ctx, "SELECT "+outboxColumns+" FROM outbox ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		e, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,114 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ReminderRepository handles database operations for appointment reminders
+type ReminderRepository struct {
+	db *sql.DB
+}
+
+// NewReminderRepository creates a new reminder repository
+func NewReminderRepository(db *sql.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// RemindableStatuses are the statuses of appointments that customers are reminded of
+var RemindableStatuses = []string{AppointmentStatusPending, AppointmentStatusConfirmed}
+
+// GetDue retrieves up to limit appointments due a reminder offset before they start: those
+// in a RemindableStatuses status starting after now+skipWithin and by now+offset, booked
+// before the reminder fell due, and not yet reminded at this offset of their current time.
+// Appointments starting within skipWithin (the next shorter offset) are left to that
+// reminder instead.
+func (rr *ReminderRepository) GetDue(ctx context.Context, offset, skipWithin time.Duration, now time.Time, limit int) ([]Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	offsetMinutes := int(offset / time.Minute)
+	rows, err := rr.db.QueryContext(ctx,
+		`SELECT a.id, a.customer_id, a.customer_name, a.customer_email, a.customer_phone, a.staff_id, a.service_id, a.appointment_datetime, a.duration_minutes, a.status, a.notes, a.created_at, a.updated_at
+		 FROM appointments a
+		 WHERE a.status = ANY($1)
+		   AND a.appointment_datetime > $2
+		   AND a.appointment_datetime <= $3
+		   AND a.created_at <= a.appointment_datetime - make_interval(mins => $4)
+		   AND NOT EXISTS (
+			SELECT 1 FROM appointment_reminders r
+			WHERE r.appointment_id = a.id AND r.offset_minutes = $4 AND r.appointment_datetime = a.appointment_datetime
+		   )
+		 ORDER BY a.appointment_datetime, a.id
+		 LIMIT $5`,
+		pq.Array(RemindableStatuses), now.Add(skipWithin).UTC(), now.Add(offset).UTC(), offsetMinutes, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appointments []Appointment
+	for rows.Next() {
+		var a Appointment
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.CustomerName, &a.CustomerEmail, &a.CustomerPhone, &a.StaffID, &a.ServiceID, &a.AppointmentDatetime, &a.DurationMinutes, &a.Status, &a.Notes, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, a)
+	}
+	return appointments, rows.Err()
+}
+
+// Record notes that a reminder was sent. Returns false if it had been recorded already.
+func (rr *ReminderRepository) Record(ctx context.Context, reminder AppointmentReminder) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := rr.db.ExecContext(ctx,
+		`INSERT INTO appointment_reminders (appointment_id, offset_minutes, appointment_datetime, notification_id, sent_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT DO NOTHING`,
+		reminder.AppointmentID, reminder.OffsetMinutes, reminder.AppointmentDatetime.UTC(), reminder.NotificationID, reminder.SentAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	recorded, err := result.RowsAffected()
+	return recorded > 0, err
+}
+
+// GetByAppointment retrieves the reminders sent for an appointment, oldest first
+func (rr *ReminderRepository) GetByAppointment(ctx context.Context, appointmentID int) ([]AppointmentReminder, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := rr.db.QueryContext(ctx,
+		`SELECT appointment_id, offset_minutes, appointment_datetime, notification_id, sent_at
+		 FROM appointment_reminders
+		 WHERE appointment_id = $1
+		 ORDER BY sent_at, offset_minutes DESC`,
+		appointmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []AppointmentReminder
+	for rows.Next() {
+		var r AppointmentReminder
+		var notificationID sql.NullInt64
+		if err := rows.Scan(&r.AppointmentID, &r.OffsetMinutes, &r.AppointmentDatetime, &notificationID, &r.SentAt); err != nil {
+			return nil, err
+		}
+		if notificationID.Valid {
+			id := notificationID.Int64
+			r.NotificationID = &id
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
@@ -0,0 +1,168 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ScheduleExceptionRepository handles database operations for schedule exceptions
+type ScheduleExceptionRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleExceptionRepository creates a new schedule exception repository
+func NewScheduleExceptionRepository(db *sql.DB) *ScheduleExceptionRepository {
+	return &ScheduleExceptionRepository{db: db}
+}
+
+// Create inserts a new schedule exception
+// staffID nil creates a business-wide exception; startTime/endTime are nil for closed exceptions.
+// Dates are YYYY-MM-DD and times are HH:MM strings.
+func (sr *ScheduleExceptionRepository) Create(ctx context.Context, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	e := &ScheduleException{}
+	err := sr.db.QueryRowContext(ctx,
+		`INSERT INTO schedule_exceptions (staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+		 RETURNING id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at`,
+		staffID, startDate, endDate, isClosed, startTime, endTime, reason, now, now,
+	).Scan(&e.ID, &e.StaffID, &e.StartDate, &e.EndDate, &e.IsClosed, &e.StartTime, &e.EndTime, &e.Reason, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update modifies an existing schedule exception
+func (sr *ScheduleExceptionRepository) Update(ctx context.Context, id int, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	e := &ScheduleException{}
+	err := sr.db.QueryRowContext(ctx,
+		`UPDATE schedule_exceptions 
+		 SET staff_id = $1, start_date = $2, end_date = $3, is_closed = $4, start_time = $5, end_time = $6, reason = $7, updated_at = $8 
+		 WHERE id = $9 
+		 RETURNING id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at`,
+		staffID, startDate, endDate, isClosed, startTime, endTime, reason, now, id,
+	).Scan(&e.ID, &e.StaffID, &e.StartDate, &e.EndDate, &e.IsClosed, &e.StartTime, &e.EndTime, &e.Reason, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetAll retrieves all schedule exceptions
+func (sr *ScheduleExceptionRepository) GetAll(ctx context.Context) ([]ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at 
+		 FROM schedule_exceptions 
+		 ORDER BY start_date, staff_id NULLS FIRST`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanScheduleExceptions(rows)
+}
+
+// GetByID retrieves a single schedule exception by ID
+func (sr *ScheduleExceptionRepository) GetByID(ctx context.Context, id int) (*ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	e := &ScheduleException{}
+	err := sr.db.QueryRowContext(ctx,
+		`SELECT id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at 
+		 FROM schedule_exceptions 
+		 WHERE id = $1`,
+		id,
+	).Scan(&e.ID, &e.StaffID, &e.StartDate, &e.EndDate, &e.IsClosed, &e.StartTime, &e.EndTime, &e.Reason, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetByStaff retrieves the exceptions that apply to a staff member, including business-wide ones
+func (sr *ScheduleExceptionRepository) GetByStaff(ctx context.Context, staffID int) ([]ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at 
+		 FROM schedule_exceptions 
+		 WHERE staff_id = $1 OR staff_id IS NULL
+		 ORDER BY start_date, staff_id NULLS FIRST`,
+		staffID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanScheduleExceptions(rows)
+}
+
+// GetApplicable retrieves the exceptions for a staff member (including business-wide ones)
+// whose date range overlaps [fromDate, toDate]. Dates are YYYY-MM-DD strings.
+func (sr *ScheduleExceptionRepository) GetApplicable(ctx context.Context, staffID int, fromDate, toDate string) ([]ScheduleException, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT id, staff_id, start_date, end_date, is_closed, start_time, end_time, reason, created_at, updated_at 
+		 FROM schedule_exceptions 
+		 WHERE (staff_id = $1 OR staff_id IS NULL)
+		   AND start_date <= $3
+		   AND end_date >= $2
+		 ORDER BY start_date, staff_id NULLS FIRST`,
+		staffID, fromDate, toDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanScheduleExceptions(rows)
+}
+
+// Delete removes a schedule exception
+func (sr *ScheduleExceptionRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM schedule_exceptions WHERE id = $1", id)
+	return err
+}
+
+// scanScheduleExceptions reads all rows of a schedule exception query
+func scanScheduleExceptions(rows *sql.Rows) ([]ScheduleException, error) {
+	var exceptions []ScheduleException
+	for rows.Next() {
+		var e ScheduleException
+		if err := rows.Scan(&e.ID, &e.StaffID, &e.StartDate, &e.EndDate, &e.IsClosed, &e.StartTime, &e.EndTime, &e.Reason, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		exceptions = append(exceptions, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return exceptions, nil
+}
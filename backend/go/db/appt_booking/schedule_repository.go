@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -16,10 +17,13 @@ func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
 }
 
 // Create inserts a new schedule
-func (sr *ScheduleRepository) Create(staffID, dayOfWeek int, startTime, endTime string) (*Schedule, error) {
+func (sr *ScheduleRepository) Create(ctx context.Context, staffID, dayOfWeek int, startTime, endTime string) (*Schedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	now := time.Now()
 	schedule := &Schedule{}
-	err := sr.db.QueryRow(
+	err := sr.db.QueryRowContext(ctx,
 		"INSERT INTO schedules (staff_id, day_of_week, start_time, end_time, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, staff_id, day_of_week, start_time, end_time, created_at, updated_at",
 		staffID, dayOfWeek, startTime, endTime, now, now,
 	).Scan(&schedule.ID, &schedule.StaffID, &schedule.DayOfWeek, &schedule.StartTime, &schedule.EndTime, &schedule.CreatedAt, &schedule.UpdatedAt)
@@ -30,10 +34,13 @@ func (sr *ScheduleRepository) Create(staffID, dayOfWeek int, startTime, endTime
 }
 
 // Update modifies an existing schedule
-func (sr *ScheduleRepository) Update(id int, dayOfWeek int, startTime, endTime string) (*Schedule, error) {
+func (sr *ScheduleRepository) Update(ctx context.Context, id int, dayOfWeek int, startTime, endTime string) (*Schedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	now := time.Now()
 	schedule := &Schedule{}
-	err := sr.db.QueryRow(
+	err := sr.db.QueryRowContext(ctx,
 		"UPDATE schedules SET day_of_week = $1, start_time = $2, end_time = $3, updated_at = $4 WHERE id = $5 RETURNING id, staff_id, day_of_week, start_time, end_time, created_at, updated_at",
 		dayOfWeek, startTime, endTime, now, id,
 	).Scan(&schedule.ID, &schedule.StaffID, &schedule.DayOfWeek, &schedule.StartTime, &schedule.EndTime, &schedule.CreatedAt, &schedule.UpdatedAt)
@@ -47,8 +54,11 @@ func (sr *ScheduleRepository) Update(id int, dayOfWeek int, startTime, endTime s
 }
 
 // GetAll retrieves all schedules
-func (sr *ScheduleRepository) GetAll() ([]Schedule, error) {
-	rows, err := sr.db.Query(
+func (sr *ScheduleRepository) GetAll(ctx context.Context) ([]Schedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
 		"SELECT id, staff_id, day_of_week, start_time, end_time, created_at, updated_at FROM schedules ORDER BY staff_id, day_of_week",
 	)
 	if err != nil {
@@ -71,9 +81,12 @@ func (sr *ScheduleRepository) GetAll() ([]Schedule, error) {
 }
 
 // GetByID retrieves a single schedule by ID
-func (sr *ScheduleRepository) GetByID(id int) (*Schedule, error) {
+func (sr *ScheduleRepository) GetByID(ctx context.Context, id int) (*Schedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	s := &Schedule{}
-	err := sr.db.QueryRow(
+	err := sr.db.QueryRowContext(ctx,
 		"SELECT id, staff_id, day_of_week, start_time, end_time, created_at, updated_at FROM schedules WHERE id = $1",
 		id,
 	).Scan(&s.ID, &s.StaffID, &s.DayOfWeek, &s.StartTime, &s.EndTime, &s.CreatedAt, &s.UpdatedAt)
@@ -87,8 +100,11 @@ func (sr *ScheduleRepository) GetByID(id int) (*Schedule, error) {
 }
 
 // GetByStaff retrieves all schedules for a specific staff member
-func (sr *ScheduleRepository) GetByStaff(staffID int) ([]Schedule, error) {
-	rows, err := sr.db.Query(
+func (sr *ScheduleRepository) GetByStaff(ctx context.Context, staffID int) ([]Schedule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
 		"SELECT id, staff_id, day_of_week, start_time, end_time, created_at, updated_at FROM schedules WHERE staff_id = $1 ORDER BY day_of_week, start_time",
 		staffID,
 	)
@@ -112,13 +128,19 @@ func (sr *ScheduleRepository) GetByStaff(staffID int) ([]Schedule, error) {
 }
 
 // Delete removes a schedule
-func (sr *ScheduleRepository) Delete(id int) error {
-	_, err := sr.db.Exec("DELETE FROM schedules WHERE id = $1", id)
+func (sr *ScheduleRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM schedules WHERE id = $1", id)
 	return err
 }
 
 // DeleteByStaff removes all schedules for a staff member
-func (sr *ScheduleRepository) DeleteByStaff(staffID int) error {
-	_, err := sr.db.Exec("DELETE FROM schedules WHERE staff_id = $1", staffID)
+func (sr *ScheduleRepository) DeleteByStaff(ctx context.Context, staffID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM schedules WHERE staff_id = $1", staffID)
 	return err
 }
@@ -0,0 +1,127 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SeriesRepository handles database operations for appointment series and their occurrences
+type SeriesRepository struct {
+	db *sql.DB
+}
+
+// NewSeriesRepository creates a new series repository
+func NewSeriesRepository(db *sql.DB) *SeriesRepository {
+	return &SeriesRepository{db: db}
+}
+
+const seriesColumns = "id, customer_id, staff_id, service_id, frequency, repeat_interval, occurrence_count, until_date, starts_at, notes, created_at, updated_at"
+
+// scanSeries scans a row selected with seriesColumns
+func scanSeries(row interface{ Scan(...interface{}) error }) (*AppointmentSeries, error) {
+	s := &AppointmentSeries{}
+	err := row.Scan(&s.ID, &s.CustomerID, &s.StaffID, &s.ServiceID, &s.Frequency, &s.Interval, &s.Count, &s.Until, &s.StartsAt, &s.Notes, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create inserts a new series. At least one of count and until must be set;
+// startsAt is stored as UTC wall-clock time.
+func (sr *SeriesRepository) Create(ctx context.Context, customerID, staffID, serviceID int, frequency string, interval int, count *int, until *time.Time, startsAt time.Time, notes string) (*AppointmentSeries, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return scanSeries(sr.db.QueryRowContext(ctx,
+		`INSERT INTO appointment_series (customer_id, staff_id, service_id, frequency, repeat_interval, occurrence_count, until_date, starts_at, notes, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 RETURNING `+seriesColumns,
+		customerID, staffID, serviceID, frequency, interval, count, until, startsAt.UTC(), notes, now, now,
+	))
+}
+
+// GetByID retrieves a series by ID
+func (sr *SeriesRepository) GetByID(ctx context.Context, id int) (*AppointmentSeries, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanSeries(sr.db.QueryRowContext(ctx,
+		"SELECT "+seriesColumns+" FROM appointment_series WHERE id = $1",
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// Delete removes a series and its occurrence links; the appointments themselves are kept
+func (sr *SeriesRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM appointment_series WHERE id = $1", id)
+	return err
+}
+
+// AddOccurrence links an appointment to a series as its index-th occurrence
+func (sr *SeriesRepository) AddOccurrence(ctx context.Context, seriesID, appointmentID, index int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx,
+		"INSERT INTO appointment_series_occurrences (series_id, appointment_id, occurrence_index) VALUES ($1, $2, $3)",
+		seriesID, appointmentID, index,
+	)
+	return err
+}
+
+// GetOccurrences retrieves the occurrences of a series in recurrence order
+func (sr *SeriesRepository) GetOccurrences(ctx context.Context, seriesID int) ([]SeriesOccurrence, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT series_id, appointment_id, occurrence_index
+		 FROM appointment_series_occurrences
+		 WHERE series_id = $1
+		 ORDER BY occurrence_index`,
+		seriesID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var occurrences []SeriesOccurrence
+	for rows.Next() {
+		var o SeriesOccurrence
+		if err := rows.Scan(&o.SeriesID, &o.AppointmentID, &o.Index); err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, rows.Err()
+}
+
+// GetOccurrence retrieves the series link of an appointment; returns nil if it is not part of a series
+func (sr *SeriesRepository) GetOccurrence(ctx context.Context, appointmentID int) (*SeriesOccurrence, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	o := &SeriesOccurrence{}
+	err := sr.db.QueryRowContext(ctx,
+		"SELECT series_id, appointment_id, occurrence_index FROM appointment_series_occurrences WHERE appointment_id = $1",
+		appointmentID,
+	).Scan(&o.SeriesID, &o.AppointmentID, &o.Index)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return o, nil
+}
@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -15,41 +16,97 @@ func NewServiceRepository(db *sql.DB) *ServiceRepository {
 	return &ServiceRepository{db: db}
 }
 
-// Create inserts a new service
-func (sr *ServiceRepository) Create(name, description string, duration, priceCents int) (*Service, error) {
+const serviceColumns = "id, name, description, duration_minutes, price_cents, buffer_before_minutes, buffer_after_minutes, min_notice_minutes, max_advance_days, slot_granularity_minutes, cancel_notice_minutes, created_at, updated_at"
+
+// scanService scans a row selected with serviceColumns
+func scanService(row interface{ Scan(...interface{}) error }) (*Service, error) {
+	s := &Service{}
+	err := row.Scan(&s.ID, &s.Name, &s.Description, &s.DurationMin, &s.PriceCents,
+		&s.BufferBeforeMin, &s.BufferAfterMin, &s.MinNoticeMin, &s.MaxAdvanceDays, &s.SlotGranularityMin, &s.CancelNoticeMin,
+		&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create inserts a new service and records event for it
+func (sr *ServiceRepository) Create(ctx context.Context, name, description string, duration, priceCents int, policy BookingPolicy, event *OutboxEvent) (*Service, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	service := &Service{}
-	err := sr.db.QueryRow(
-		"INSERT INTO services (name, description, duration_minutes, price_cents, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, name, description, duration_minutes, price_cents, created_at, updated_at",
-		name, description, duration, priceCents, now, now,
-	).Scan(&service.ID, &service.Name, &service.Description, &service.DurationMin, &service.PriceCents, &service.CreatedAt, &service.UpdatedAt)
+	service, err := scanService(tx.QueryRowContext(ctx,
+		`INSERT INTO services (name, description, duration_minutes, price_cents, buffer_before_minutes, buffer_after_minutes, min_notice_minutes, max_advance_days, slot_granularity_minutes, cancel_notice_minutes, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING `+serviceColumns,
+		name, description, duration, priceCents,
+		policy.BufferBeforeMin, policy.BufferAfterMin, policy.MinNoticeMin, policy.MaxAdvanceDays, policy.SlotGranularityMin, policy.CancelNoticeMin,
+		now, now,
+	))
 	if err != nil {
 		return nil, err
 	}
+	if err := insertOutboxEvent(ctx, tx, event, service.ID, service); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return service, nil
 }
 
-// Update modifies an existing service
-func (sr *ServiceRepository) Update(id int, name, description string, duration, priceCents int) (*Service, error) {
+// Update modifies an existing service and records event for it; returns nil if it does not exist
+func (sr *ServiceRepository) Update(ctx context.Context, id int, name, description string, duration, priceCents int, policy BookingPolicy, event *OutboxEvent) (*Service, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	service := &Service{}
-	err := sr.db.QueryRow(
-		"UPDATE services SET name = $1, description = $2, duration_minutes = $3, price_cents = $4, updated_at = $5 WHERE id = $6 RETURNING id, name, description, duration_minutes, price_cents, created_at, updated_at",
-		name, description, duration, priceCents, now, id,
-	).Scan(&service.ID, &service.Name, &service.Description, &service.DurationMin, &service.PriceCents, &service.CreatedAt, &service.UpdatedAt)
+	service, err := scanService(tx.QueryRowContext(ctx,
+		`UPDATE services
+		 SET name = $1, description = $2, duration_minutes = $3, price_cents = $4, buffer_before_minutes = $5, buffer_after_minutes = $6, min_notice_minutes = $7, max_advance_days = $8, slot_granularity_minutes = $9, cancel_notice_minutes = $10, updated_at = $11
+		 WHERE id = $12
+		 RETURNING `+serviceColumns,
+		name, description, duration, priceCents,
+		policy.BufferBeforeMin, policy.BufferAfterMin, policy.MinNoticeMin, policy.MaxAdvanceDays, policy.SlotGranularityMin, policy.CancelNoticeMin,
+		now, id,
+	))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	if err := insertOutboxEvent(ctx, tx, event, service.ID, service); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return service, nil
 }
 
 // GetAll retrieves all services
-func (sr *ServiceRepository) GetAll() ([]Service, error) {
-	rows, err := sr.db.Query(
-		"SELECT id, name, description, duration_minutes, price_cents, created_at, updated_at FROM services ORDER BY name",
+func (sr *ServiceRepository) GetAll(ctx context.Context) ([]Service, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		"SELECT "+serviceColumns+" FROM services ORDER BY name",
 	)
 	if err != nil {
 		return nil, err
@@ -58,11 +115,11 @@ func (sr *ServiceRepository) GetAll() ([]Service, error) {
 
 	var services []Service
 	for rows.Next() {
-		var s Service
-		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.DurationMin, &s.PriceCents, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		s, err := scanService(rows)
+		if err != nil {
 			return nil, err
 		}
-		services = append(services, s)
+		services = append(services, *s)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -71,12 +128,14 @@ func (sr *ServiceRepository) GetAll() ([]Service, error) {
 }
 
 // GetByID retrieves a single service by ID
-func (sr *ServiceRepository) GetByID(id int) (*Service, error) {
-	s := &Service{}
-	err := sr.db.QueryRow(
-		"SELECT id, name, description, duration_minutes, price_cents, created_at, updated_at FROM services WHERE id = $1",
+func (sr *ServiceRepository) GetByID(ctx context.Context, id int) (*Service, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanService(sr.db.QueryRowContext(ctx,
+		"SELECT "+serviceColumns+" FROM services WHERE id = $1",
 		id,
-	).Scan(&s.ID, &s.Name, &s.Description, &s.DurationMin, &s.PriceCents, &s.CreatedAt, &s.UpdatedAt)
+	))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -86,8 +145,10 @@ func (sr *ServiceRepository) GetByID(id int) (*Service, error) {
 	return s, nil
 }
 
-// Delete removes a service
-func (sr *ServiceRepository) Delete(id int) error {
-	_, err := sr.db.Exec("DELETE FROM services WHERE id = $1", id)
-	return err
+// Delete removes a service, recording event if it existed
+func (sr *ServiceRepository) Delete(ctx context.Context, id int, event *OutboxEvent) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return deleteWithEvent(ctx, sr.db, "DELETE FROM services WHERE id = $1", id, event)
 }
@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -15,41 +16,76 @@ func NewStaffRepository(db *sql.DB) *StaffRepository {
 	return &StaffRepository{db: db}
 }
 
-// Create inserts a new staff member
-func (sr *StaffRepository) Create(name, email, phone, role string) (*Staff, error) {
+// Create inserts a new staff member and records event for it
+func (sr *StaffRepository) Create(ctx context.Context, name, email, phone, role, timezone string, event *OutboxEvent) (*Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
 	staff := &Staff{}
-	err := sr.db.QueryRow(
-		"INSERT INTO staff (name, email, phone, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, name, email, phone, role, created_at, updated_at",
-		name, email, phone, role, now, now,
-	).Scan(&staff.ID, &staff.Name, &staff.Email, &staff.Phone, &staff.Role, &staff.CreatedAt, &staff.UpdatedAt)
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO staff (name, email, phone, role, timezone, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, name, email, phone, role, timezone, created_at, updated_at",
+		name, email, phone, role, timezone, now, now,
+	).Scan(&staff.ID, &staff.Name, &staff.Email, &staff.Phone, &staff.Role, &staff.Timezone, &staff.CreatedAt, &staff.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+	if err := insertOutboxEvent(ctx, tx, event, staff.ID, staff); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return staff, nil
 }
 
-// Update modifies an existing staff member
-func (sr *StaffRepository) Update(id int, name, email, phone, role string) (*Staff, error) {
+// Update modifies an existing staff member and records event for it; returns nil if it does not exist
+func (sr *StaffRepository) Update(ctx context.Context, id int, name, email, phone, role, timezone string, event *OutboxEvent) (*Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
 	staff := &Staff{}
-	err := sr.db.QueryRow(
-		"UPDATE staff SET name = $1, email = $2, phone = $3, role = $4, updated_at = $5 WHERE id = $6 RETURNING id, name, email, phone, role, created_at, updated_at",
-		name, email, phone, role, now, id,
-	).Scan(&staff.ID, &staff.Name, &staff.Email, &staff.Phone, &staff.Role, &staff.CreatedAt, &staff.UpdatedAt)
+	err = tx.QueryRowContext(ctx,
+		"UPDATE staff SET name = $1, email = $2, phone = $3, role = $4, timezone = $5, updated_at = $6 WHERE id = $7 RETURNING id, name, email, phone, role, timezone, created_at, updated_at",
+		name, email, phone, role, timezone, now, id,
+	).Scan(&staff.ID, &staff.Name, &staff.Email, &staff.Phone, &staff.Role, &staff.Timezone, &staff.CreatedAt, &staff.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	if err := insertOutboxEvent(ctx, tx, event, staff.ID, staff); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return staff, nil
 }
 
 // GetAll retrieves all staff members
-func (sr *StaffRepository) GetAll() ([]Staff, error) {
-	rows, err := sr.db.Query(
-		"SELECT id, name, email, phone, role, created_at, updated_at FROM staff ORDER BY name",
+func (sr *StaffRepository) GetAll(ctx context.Context) ([]Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		"SELECT id, name, email, phone, role, timezone, created_at, updated_at FROM staff ORDER BY name",
 	)
 	if err != nil {
 		return nil, err
@@ -59,7 +95,7 @@ func (sr *StaffRepository) GetAll() ([]Staff, error) {
 	var staffList []Staff
 	for rows.Next() {
 		var s Staff
-		if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.Timezone, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		staffList = append(staffList, s)
@@ -71,12 +107,15 @@ func (sr *StaffRepository) GetAll() ([]Staff, error) {
 }
 
 // GetByID retrieves a single staff member by ID
-func (sr *StaffRepository) GetByID(id int) (*Staff, error) {
+func (sr *StaffRepository) GetByID(ctx context.Context, id int) (*Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	s := &Staff{}
-	err := sr.db.QueryRow(
-		"SELECT id, name, email, phone, role, created_at, updated_at FROM staff WHERE id = $1",
+	err := sr.db.QueryRowContext(ctx,
+		"SELECT id, name, email, phone, role, timezone, created_at, updated_at FROM staff WHERE id = $1",
 		id,
-	).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.CreatedAt, &s.UpdatedAt)
+	).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.Timezone, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -87,12 +126,15 @@ func (sr *StaffRepository) GetByID(id int) (*Staff, error) {
 }
 
 // GetByEmail retrieves a staff member by email
-func (sr *StaffRepository) GetByEmail(email string) (*Staff, error) {
+func (sr *StaffRepository) GetByEmail(ctx context.Context, email string) (*Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	s := &Staff{}
-	err := sr.db.QueryRow(
-		"SELECT id, name, email, phone, role, created_at, updated_at FROM staff WHERE email = $1",
+	err := sr.db.QueryRowContext(ctx,
+		"SELECT id, name, email, phone, role, timezone, created_at, updated_at FROM staff WHERE email = $1",
 		email,
-	).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.CreatedAt, &s.UpdatedAt)
+	).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.Timezone, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -102,8 +144,10 @@ func (sr *StaffRepository) GetByEmail(email string) (*Staff, error) {
 	return s, nil
 }
 
-// Delete removes a staff member
-func (sr *StaffRepository) Delete(id int) error {
-	_, err := sr.db.Exec("DELETE FROM staff WHERE id = $1", id)
-	return err
+// Delete removes a staff member, recording event if they existed
+func (sr *StaffRepository) Delete(ctx context.Context, id int, event *OutboxEvent) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return deleteWithEvent(ctx, sr.db, "DELETE FROM staff WHERE id = $1", id, event)
 }
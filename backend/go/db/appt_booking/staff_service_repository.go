@@ -1,6 +1,7 @@
 package appt_booking
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -15,8 +16,11 @@ func NewStaffServiceRepository(db *sql.DB) *StaffServiceRepository {
 }
 
 // Assign links a staff member to a service
-func (sr *StaffServiceRepository) Assign(staffID, serviceID int) error {
-	_, err := sr.db.Exec(
+func (sr *StaffServiceRepository) Assign(ctx context.Context, staffID, serviceID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx,
 		"INSERT INTO staff_services (staff_id, service_id) VALUES ($1, $2)",
 		staffID, serviceID,
 	)
@@ -24,8 +28,11 @@ func (sr *StaffServiceRepository) Assign(staffID, serviceID int) error {
 }
 
 // Unassign removes a service from a staff member
-func (sr *StaffServiceRepository) Unassign(staffID, serviceID int) error {
-	_, err := sr.db.Exec(
+func (sr *StaffServiceRepository) Unassign(ctx context.Context, staffID, serviceID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx,
 		"DELETE FROM staff_services WHERE staff_id = $1 AND service_id = $2",
 		staffID, serviceID,
 	)
@@ -33,9 +40,12 @@ func (sr *StaffServiceRepository) Unassign(staffID, serviceID int) error {
 }
 
 // GetServicesForStaff retrieves all services offered by a specific staff member
-func (sr *StaffServiceRepository) GetServicesForStaff(staffID int) ([]Service, error) {
-	rows, err := sr.db.Query(
-		`SELECT s.id, s.name, s.description, s.duration_minutes, s.price_cents, s.created_at, s.updated_at 
+func (sr *StaffServiceRepository) GetServicesForStaff(ctx context.Context, staffID int) ([]Service, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT s.id, s.name, s.description, s.duration_minutes, s.price_cents, s.buffer_before_minutes, s.buffer_after_minutes, s.min_notice_minutes, s.max_advance_days, s.slot_granularity_minutes, s.cancel_notice_minutes, s.created_at, s.updated_at 
 		 FROM services s
 		 INNER JOIN staff_services ss ON s.id = ss.service_id
 		 WHERE ss.staff_id = $1
@@ -49,11 +59,11 @@ func (sr *StaffServiceRepository) GetServicesForStaff(staffID int) ([]Service, e
 
 	var services []Service
 	for rows.Next() {
-		var s Service
-		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.DurationMin, &s.PriceCents, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		s, err := scanService(rows)
+		if err != nil {
 			return nil, err
 		}
-		services = append(services, s)
+		services = append(services, *s)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -62,9 +72,12 @@ func (sr *StaffServiceRepository) GetServicesForStaff(staffID int) ([]Service, e
 }
 
 // GetStaffForService retrieves all staff members who offer a specific service
-func (sr *StaffServiceRepository) GetStaffForService(serviceID int) ([]Staff, error) {
-	rows, err := sr.db.Query(
-		`SELECT st.id, st.name, st.email, st.phone, st.role, st.created_at, st.updated_at
+func (sr *StaffServiceRepository) GetStaffForService(ctx context.Context, serviceID int) ([]Staff, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT st.id, st.name, st.email, st.phone, st.role, st.timezone, st.created_at, st.updated_at
 		 FROM staff st
 		 INNER JOIN staff_services ss ON st.id = ss.staff_id
 		 WHERE ss.service_id = $1
@@ -79,7 +92,7 @@ func (sr *StaffServiceRepository) GetStaffForService(serviceID int) ([]Staff, er
 	var staffList []Staff
 	for rows.Next() {
 		var s Staff
-		if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role, &s.Timezone, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		staffList = append(staffList, s)
@@ -91,13 +104,19 @@ func (sr *StaffServiceRepository) GetStaffForService(serviceID int) ([]Staff, er
 }
 
 // RemoveAllForStaff removes all service assignments for a staff member
-func (sr *StaffServiceRepository) RemoveAllForStaff(staffID int) error {
-	_, err := sr.db.Exec("DELETE FROM staff_services WHERE staff_id = $1", staffID)
+func (sr *StaffServiceRepository) RemoveAllForStaff(ctx context.Context, staffID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM staff_services WHERE staff_id = $1", staffID)
 	return err
 }
 
 // RemoveAllForService removes all staff assignments for a service
-func (sr *StaffServiceRepository) RemoveAllForService(serviceID int) error {
-	_, err := sr.db.Exec("DELETE FROM staff_services WHERE service_id = $1", serviceID)
+func (sr *StaffServiceRepository) RemoveAllForService(ctx context.Context, serviceID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM staff_services WHERE service_id = $1", serviceID)
 	return err
 }
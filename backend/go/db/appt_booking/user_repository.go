@@ -0,0 +1,85 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrUserEmailTaken is returned when a user with the same email already exists
+var ErrUserEmailTaken = errors.New("a user with this email already exists")
+
+// UserRepository handles database operations for login accounts
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = "id, email, password_hash, role, staff_id, email_verified_at, created_at, updated_at"
+
+// scanUser scans a row selected with userColumns; returns nil if there is none
+func scanUser(row *sql.Row) (*User, error) {
+	u := &User{}
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.StaffID, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// Create inserts a new user; staffID is nil for accounts not linked to a staff member.
+// Returns ErrUserEmailTaken if the email (or staff member) already has an account.
+func (ur *UserRepository) Create(ctx context.Context, email, passwordHash, role string, staffID *int) (*User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	u, err := scanUser(ur.db.QueryRowContext(ctx,
+		"INSERT INTO users (email, password_hash, role, staff_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "+userColumns,
+		email, passwordHash, role, staffID, now, now,
+	))
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, ErrUserEmailTaken
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetByID retrieves a user by ID
+func (ur *UserRepository) GetByID(ctx context.Context, id int) (*User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return scanUser(ur.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+// GetByEmail retrieves a user by email
+func (ur *UserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return scanUser(ur.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = $1", email))
+}
+
+// MarkEmailVerified records that user id confirmed email at verifiedAt. Returns nil if
+// the user does not exist or its email is no longer email; an address that is already
+// verified keeps its original time.
+func (ur *UserRepository) MarkEmailVerified(ctx context.Context, id int, email string, verifiedAt time.Time) (*User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return scanUser(ur.db.QueryRowContext(ctx,
+		"UPDATE users SET email_verified_at = COALESCE(email_verified_at, $3), updated_at = $3 WHERE id = $1 AND email = $2 RETURNING "+userColumns,
+		id, email, verifiedAt,
+	))
+}
@@ -0,0 +1,181 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WaitlistRepository handles database operations for waitlist entries
+type WaitlistRepository struct {
+	db *sql.DB
+}
+
+// NewWaitlistRepository creates a new waitlist repository
+func NewWaitlistRepository(db *sql.DB) *WaitlistRepository {
+	return &WaitlistRepository{db: db}
+}
+
+const waitlistColumns = "id, customer_id, service_id, staff_id, window_start, window_end, priority, status, notes, created_at, updated_at"
+
+// waitlistOrder offers entries by priority, then first come first served
+const waitlistOrder = "ORDER BY priority DESC, created_at ASC, id ASC"
+
+// scanWaitlistEntry scans a row selected with waitlistColumns
+func scanWaitlistEntry(row interface{ Scan(...interface{}) error }) (*WaitlistEntry, error) {
+	e := &WaitlistEntry{}
+	err := row.Scan(&e.ID, &e.CustomerID, &e.ServiceID, &e.StaffID, &e.WindowStart, &e.WindowEnd, &e.Priority, &e.Status, &e.Notes, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// scanWaitlistEntries scans every row of a query selecting waitlistColumns
+func scanWaitlistEntries(rows *sql.Rows) ([]WaitlistEntry, error) {
+	defer rows.Close()
+
+	var entries []WaitlistEntry
+	for rows.Next() {
+		e, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// Create adds a waiting entry; the window is stored as UTC wall-clock time
+func (wr *WaitlistRepository) Create(ctx context.Context, customerID, serviceID int, staffID *int, windowStart, windowEnd time.Time, priority int, notes string) (*WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return scanWaitlistEntry(wr.db.QueryRowContext(ctx,
+		`INSERT INTO waitlist_entries (customer_id, service_id, staff_id, window_start, window_end, priority, status, notes, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING `+waitlistColumns,
+		customerID, serviceID, staffID, windowStart.UTC(), windowEnd.UTC(), priority, WaitlistStatusWaiting, notes, now, now,
+	))
+}
+
+// GetByID retrieves a waitlist entry by ID
+func (wr *WaitlistRepository) GetByID(ctx context.Context, id int) (*WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	e, err := scanWaitlistEntry(wr.db.QueryRowContext(ctx,
+		"SELECT "+waitlistColumns+" FROM waitlist_entries WHERE id = $1",
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// List retrieves the entries matching filter in the order they are offered slots
+func (wr *WaitlistRepository) List(ctx context.Context, filter WaitlistFilter) ([]WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	w := &whereBuilder{}
+	if filter.ServiceID != 0 {
+		w.add("service_id = ?", filter.ServiceID)
+	}
+	if filter.StaffID != 0 {
+		w.add("(staff_id IS NULL OR staff_id = ?)", filter.StaffID)
+	}
+	if filter.CustomerID != 0 {
+		w.add("customer_id = ?", filter.CustomerID)
+	}
+	if len(filter.Statuses) > 0 {
+		w.add("status = ANY(?)", pq.Array(filter.Statuses))
+	}
+
+	rows, err := wr.db.QueryContext(ctx,
+		"SELECT "+waitlistColumns+" FROM waitlist_entries "+w.sql()+" "+waitlistOrder,
+		w.args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWaitlistEntries(rows)
+}
+
+// GetWaiting retrieves the waiting entries whose window has not ended by now, in the
+// order they are offered slots. staffID 0 matches every entry; otherwise only entries
+// for that staff member or for any staff member.
+func (wr *WaitlistRepository) GetWaiting(ctx context.Context, staffID int, now time.Time) ([]WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := wr.db.QueryContext(ctx,
+		`SELECT `+waitlistColumns+`
+		 FROM waitlist_entries
+		 WHERE status = $1
+		   AND window_end > $2
+		   AND ($3 = 0 OR staff_id IS NULL OR staff_id = $3)
+		 `+waitlistOrder,
+		WaitlistStatusWaiting, now.UTC(), staffID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWaitlistEntries(rows)
+}
+
+// UpdateStatus moves an entry from fromStatus to toStatus and records event against it in
+// the same transaction. Returns nil if the entry does not exist or is no longer in fromStatus.
+func (wr *WaitlistRepository) UpdateStatus(ctx context.Context, id int, fromStatus, toStatus string, event *OutboxEvent) (*WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := wr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	e, err := scanWaitlistEntry(tx.QueryRowContext(ctx,
+		"UPDATE waitlist_entries SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4 RETURNING "+waitlistColumns,
+		toStatus, time.Now(), id, fromStatus,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := insertOutboxEvent(ctx, tx, event, e.ID, e); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ExpireOffers marks offered entries whose hold expired by now as expired, and returns them.
+// Holds must be kept until their entry has been expired.
+func (wr *WaitlistRepository) ExpireOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := wr.db.QueryContext(ctx,
+		`UPDATE waitlist_entries w
+		 SET status = $1, updated_at = $2
+		 WHERE w.status = $3
+		   AND EXISTS (SELECT 1 FROM slot_holds h WHERE h.waitlist_entry_id = w.id AND h.expires_at <= $4)
+		 RETURNING `+waitlistColumns,
+		WaitlistStatusExpired, time.Now(), WaitlistStatusOffered, now.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWaitlistEntries(rows)
+}
@@ -0,0 +1,272 @@
+package appt_booking
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WebhookRepository handles database operations for webhook subscriptions and their deliveries
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = "id, url, event_types, secret, description, active, created_at, updated_at"
+
+// scanWebhookSubscription scans a row selected with webhookSubscriptionColumns
+func scanWebhookSubscription(row interface{ Scan(...interface{}) error }) (*WebhookSubscription, error) {
+	s := &WebhookSubscription{}
+	err := row.Scan(&s.ID, &s.URL, pq.Array(&s.EventTypes), &s.Secret, &s.Description, &s.Active, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+const webhookDeliveryColumns = "id, subscription_id, event_id, event_type, status, attempts, next_attempt_at, response_status, response_body, last_error, created_at, delivered_at"
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var responseStatus sql.NullInt64
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt, &responseStatus, &d.ResponseBody, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		d.ResponseStatus = &status
+	}
+	return d, nil
+}
+
+// scanWebhookDeliveries scans every row of a query selecting webhookDeliveryColumns
+func scanWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// CreateSubscription adds a webhook subscription
+func (wr *WebhookRepository) CreateSubscription(ctx context.Context, url string, eventTypes []string, secret, description string, active bool) (*WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return scanWebhookSubscription(wr.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_subscriptions (url, event_types, secret, description, active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+webhookSubscriptionColumns,
+		url, pq.Array(nonNilStrings(eventTypes)), secret, description, active, now, now,
+	))
+}
+
+// UpdateSubscription replaces a subscription's settings. Returns nil if it does not exist.
+func (wr *WebhookRepository) UpdateSubscription(ctx context.Context, id int, url string, eventTypes []string, secret, description string, active bool) (*WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanWebhookSubscription(wr.db.QueryRowContext(ctx,
+		`UPDATE webhook_subscriptions
+		 SET url = $1, event_types = $2, secret = $3, description = $4, active = $5, updated_at = $6
+		 WHERE id = $7
+		 RETURNING `+webhookSubscriptionColumns,
+		url, pq.Array(nonNilStrings(eventTypes)), secret, description, active, time.Now(), id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// GetSubscription retrieves a subscription by ID
+func (wr *WebhookRepository) GetSubscription(ctx context.Context, id int) (*WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanWebhookSubscription(wr.db.QueryRowContext(ctx,
+		"SELECT "+webhookSubscriptionColumns+" FROM webhook_subscriptions WHERE id = $1",
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// ListSubscriptions retrieves every subscription, oldest first
+func (wr *WebhookRepository) ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := wr.db.QueryContext(ctx, "SELECT "+webhookSubscriptionColumns+" FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []WebhookSubscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// DeleteSubscription deletes a subscription along with its delivery log
+func (wr *WebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := wr.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	return err
+}
+
+// EnqueueDeliveries schedules the delivery of event to each of subscriptionIDs. A
+// subscription that already has a delivery of event is skipped, so enqueueing the same
+// event again is harmless.
+func (wr *WebhookRepository) EnqueueDeliveries(ctx context.Context, event OutboxEvent, subscriptionIDs []int) error {
+	if len(subscriptionIDs) == 0 {
+		return nil
+	}
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	_, err := wr.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, status, next_attempt_at, created_at)
+		 SELECT id, $1, $2, $3, $4, $4 FROM unnest($5::int[]) AS id
+		 ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		event.ID, event.EventType, WebhookDeliveryPending, now, pq.Array(subscriptionIDs),
+	)
+	return err
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries to active subscriptions that
+// are due at now, oldest first, counting an attempt for each. Like OutboxRepository.ClaimDue,
+// their next attempt is pushed back to now+lease so that other workers skip them.
+func (wr *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := wr.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET attempts = attempts + 1, next_attempt_at = $1
+		 WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = $2 AND d.next_attempt_at <= $3 AND s.active
+			ORDER BY d.id
+			LIMIT $4
+			FOR UPDATE OF d SKIP LOCKED
+		 )
+		 RETURNING `+webhookDeliveryColumns,
+		now.Add(lease).UTC(), WebhookDeliveryPending, now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not follow the subquery's order
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+// RecordAttempt saves the outcome of an attempt at a delivery: its status, next attempt,
+// response and error
+func (wr *WebhookRepository) RecordAttempt(ctx context.Context, d WebhookDelivery) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var deliveredAt *time.Time
+	if d.DeliveredAt != nil {
+		at := d.DeliveredAt.UTC()
+		deliveredAt = &at
+	}
+	_, err := wr.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $1, next_attempt_at = $2, response_status = $3, response_body = $4, last_error = $5, delivered_at = $6
+		 WHERE id = $7`,
+		d.Status, d.NextAttemptAt.UTC(), d.ResponseStatus, d.ResponseBody, d.LastError, deliveredAt, d.ID,
+	)
+	return err
+}
+
+// GetDelivery retrieves a delivery by ID
+func (wr *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	d, err := scanWebhookDelivery(wr.db.QueryRowContext(ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = $1",
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// ListDeliveries retrieves up to limit of a subscription's deliveries, newest first
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := wr.db.QueryContext(ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2",
+		subscriptionID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// Redeliver makes a delivery pending again with fresh attempts, due at now. Returns nil if
+// it does not exist.
+func (wr *WebhookRepository) Redeliver(ctx context.Context, id int64, now time.Time) (*WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	d, err := scanWebhookDelivery(wr.db.QueryRowContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $1, attempts = 0, next_attempt_at = $2, delivered_at = NULL
+		 WHERE id = $3
+		 RETURNING `+webhookDeliveryColumns,
+		WebhookDeliveryPending, now.UTC(), id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// nonNilStrings returns values, or an empty slice if it is nil, which pq.Array would store as NULL
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,6 +13,30 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// queryTimeout bounds every repository call so a slow query cannot hold a pooled
+// connection indefinitely. Override with DB_QUERY_TIMEOUT (e.g. "3s").
+var queryTimeout = 5 * time.Second
+
+// configureQueryTimeout applies DB_QUERY_TIMEOUT if set
+func configureQueryTimeout() error {
+	value := getEnv("DB_QUERY_TIMEOUT", "")
+	if value == "" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid DB_QUERY_TIMEOUT %q, expected a positive duration such as 5s", value)
+	}
+	queryTimeout = timeout
+	return nil
+}
+
+// withQueryTimeout derives the context for a single repository call. The caller's
+// deadline (e.g. the request timeout) still applies when it is earlier.
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -55,6 +80,11 @@ func Connect() (*sql.DB, error) {
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
+	// Per-query deadline for repository calls
+	if err := configureQueryTimeout(); err != nil {
+		return nil, err
+	}
+
 	// Test connection
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
@@ -92,30 +122,6 @@ func maskPassword(dbURL string) string {
 	return dbURL
 }
 
-// InitSchema creates necessary tables if they don't exist
-// TODO/WARNING: This is a temporary scaffold solution. For production,
-// use proper database migrations (e.g., goose, golang-migrate) to manage schema changes.
-func InitSchema() error {
-	log.Println("Initializing database schema...")
-
-	// Create demo_data table
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS demo_data (
-		id SERIAL PRIMARY KEY,
-		content TEXT NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	)`
-
-	_, err := DB.Exec(createTableSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create demo_data table: %w", err)
-	}
-
-	log.Println("Database schema initialized successfully (demo_data table ready)")
-	return nil
-}
-
 // Close closes the database connection
 func Close() error {
 	if DB != nil {
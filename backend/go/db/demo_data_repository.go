@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -17,7 +18,10 @@ func NewDemoDataRepository(db *sql.DB) *DemoDataRepository {
 
 // Upsert inserts or updates a demo record
 // If id is 0, it creates a new record; otherwise it updates the existing one
-func (dr *DemoDataRepository) Upsert(id int, content string) (*DemoData, error) {
+func (dr *DemoDataRepository) Upsert(ctx context.Context, id int, content string) (*DemoData, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	now := time.Now()
 
 	var data DemoData
@@ -25,13 +29,13 @@ func (dr *DemoDataRepository) Upsert(id int, content string) (*DemoData, error)
 
 	if id == 0 {
 		// Insert new record
-		err = dr.db.QueryRow(
+		err = dr.db.QueryRowContext(ctx,
 			"INSERT INTO demo_data (content, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id, content, created_at, updated_at",
 			content, now, now,
 		).Scan(&data.ID, &data.Content, &data.CreatedAt, &data.UpdatedAt)
 	} else {
 		// Update existing record
-		err = dr.db.QueryRow(
+		err = dr.db.QueryRowContext(ctx,
 			"UPDATE demo_data SET content = $1, updated_at = $2 WHERE id = $3 RETURNING id, content, created_at, updated_at",
 			content, now, id,
 		).Scan(&data.ID, &data.Content, &data.CreatedAt, &data.UpdatedAt)
@@ -45,8 +49,11 @@ func (dr *DemoDataRepository) Upsert(id int, content string) (*DemoData, error)
 }
 
 // GetAll retrieves all demo records
-func (dr *DemoDataRepository) GetAll() ([]DemoData, error) {
-	rows, err := dr.db.Query(
+func (dr *DemoDataRepository) GetAll(ctx context.Context) ([]DemoData, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := dr.db.QueryContext(ctx,
 		"SELECT id, content, created_at, updated_at FROM demo_data ORDER BY created_at DESC",
 	)
 	if err != nil {
@@ -71,9 +78,12 @@ func (dr *DemoDataRepository) GetAll() ([]DemoData, error) {
 }
 
 // GetByID retrieves a single record by ID
-func (dr *DemoDataRepository) GetByID(id int) (*DemoData, error) {
+func (dr *DemoDataRepository) GetByID(ctx context.Context, id int) (*DemoData, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	data := &DemoData{}
-	err := dr.db.QueryRow(
+	err := dr.db.QueryRowContext(ctx,
 		"SELECT id, content, created_at, updated_at FROM demo_data WHERE id = $1",
 		id,
 	).Scan(&data.ID, &data.Content, &data.CreatedAt, &data.UpdatedAt)
@@ -89,7 +99,10 @@ func (dr *DemoDataRepository) GetByID(id int) (*DemoData, error) {
 }
 
 // Delete removes a record
-func (dr *DemoDataRepository) Delete(id int) error {
-	_, err := dr.db.Exec("DELETE FROM demo_data WHERE id = $1", id)
+func (dr *DemoDataRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := dr.db.ExecContext(ctx, "DELETE FROM demo_data WHERE id = $1", id)
 	return err
 }
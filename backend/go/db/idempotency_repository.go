@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+const idempotencyColumns = "scope, key, request_hash, status_code, content_type, location, body, locked_until, created_at, expires_at"
+
+// scanIdempotencyRecord scans a row selected with idempotencyColumns
+func scanIdempotencyRecord(row interface{ Scan(...interface{}) error }) (*IdempotencyRecord, error) {
+	r := &IdempotencyRecord{}
+	err := row.Scan(&r.Scope, &r.Key, &r.RequestHash, &r.StatusCode, &r.ContentType, &r.Location, &r.Body, &r.LockedUntil, &r.CreatedAt, &r.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Begin claims key within scope for a request with requestHash until lockUntil. A key
+// is free if it is new, has expired, or was claimed for the same request by a caller whose
+// claim has lapsed. Otherwise the existing record is returned unclaimed, so that exactly
+// one of several concurrent requests with a key gets to run. The record is nil if the key
+// kept changing hands while being read.
+func (ir *IdempotencyRepository) Begin(ctx context.Context, scope, key, requestHash string, now, lockUntil, expiresAt time.Time) (*IdempotencyRecord, bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	for attempt := 0; attempt < 3; attempt++ {
+		record, err := scanIdempotencyRecord(ir.db.QueryRowContext(ctx,
+			`INSERT INTO idempotency_keys (scope, key, request_hash, locked_until, created_at, expires_at)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (scope, key) DO UPDATE SET
+				request_hash = EXCLUDED.request_hash, status_code = 0, content_type = '', location = '', body = NULL,
+				locked_until = EXCLUDED.locked_until, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			 WHERE idempotency_keys.expires_at <= $5
+				OR (idempotency_keys.status_code = 0 AND idempotency_keys.locked_until <= $5
+					AND idempotency_keys.request_hash = EXCLUDED.request_hash)
+			 RETURNING `+idempotencyColumns,
+			scope, key, requestHash, lockUntil.UTC(), now.UTC(), expiresAt.UTC(),
+		))
+		if err == nil {
+			return record, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, err
+		}
+
+		// Held by another request
+		record, err = scanIdempotencyRecord(ir.db.QueryRowContext(ctx,
+			"SELECT "+idempotencyColumns+" FROM idempotency_keys WHERE scope = $1 AND key = $2",
+			scope, key,
+		))
+		if err == sql.ErrNoRows {
+			continue // released in between; try to claim it again
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return record, false, nil
+	}
+	return nil, false, nil
+}
+
+// Complete stores the response to the request that claimed key
+func (ir *IdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, contentType, location string, body []byte) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := ir.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status_code = $1, content_type = $2, location = $3, body = $4
+		 WHERE scope = $5 AND key = $6 AND status_code = 0`,
+		statusCode, contentType, location, body, scope, key,
+	)
+	return err
+}
+
+// Release frees a key whose request did not complete, so that it can be retried
+func (ir *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := ir.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code = 0",
+		scope, key,
+	)
+	return err
+}
+
+// DeleteExpired removes the keys that expired before now and returns how many there were
+func (ir *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := ir.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,386 @@
+// Package migrate applies versioned SQL migrations embedded in the binary.
+//
+// Migrations are files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// e.g. 0003_schedule_exceptions.up.sql. Applied versions are recorded in a
+// schema_migrations table together with a checksum of the up script, so a migration
+// that was edited after being applied is detected instead of silently skipped.
+// A Postgres advisory lock serialises runners, so several pods starting at once
+// apply each migration exactly once.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // empty if the migration cannot be rolled back
+	Checksum string // sha256 of Up
+}
+
+// Status describes a migration known to the files and/or recorded in the database
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set when the applied checksum differs from the embedded file
+	Modified bool
+	// Missing is set when the version is recorded in the database but has no file
+	Missing bool
+}
+
+// Migrator runs migrations against a single database
+type Migrator struct {
+	db         *sql.DB
+	name       string
+	migrations []Migration
+	lockKey    int64
+}
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// New creates a migrator for db using the migration files in fsys (searched in dir).
+// name identifies the database in logs and in the advisory lock key.
+func New(db *sql.DB, name string, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s migrations: %w", name, err)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte("schema_migrations:" + name))
+
+	return &Migrator{
+		db:         db,
+		name:       name,
+		migrations: migrations,
+		lockKey:    int64(h.Sum64()),
+	}, nil
+}
+
+// Load reads and validates the migration files in dir, ordered by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q, expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations returns the migrations known to the migrator, ordered by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies all pending migrations in order and returns how many were applied.
+// It refuses to run if an applied migration's file has been modified since.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verifyChecksums(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			log.Printf("[%s] applying migration %d_%s", m.name, migration.Version, migration.Name)
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					migration.Version, migration.Name, migration.Checksum, time.Now().UTC(),
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down rolls back the n most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("number of migrations to roll back must be positive")
+	}
+
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		if n > len(versions) {
+			n = len(versions)
+		}
+
+		for _, version := range versions[:n] {
+			migration := m.find(version)
+			if migration == nil {
+				return fmt.Errorf("migration %d is applied but has no file; cannot roll it back", version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+			log.Printf("[%s] rolling back migration %d_%s", m.name, migration.Version, migration.Name)
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status reports every migration in the files and every version recorded in the database
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var result []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			s := Status{Version: migration.Version, Name: migration.Name}
+			if a, ok := applied[migration.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = a.appliedAt
+				s.Modified = a.checksum != migration.Checksum
+			}
+			result = append(result, s)
+		}
+		for version, a := range applied {
+			if m.find(version) == nil {
+				result = append(result, Status{Version: version, Name: a.name, Applied: true, AppliedAt: a.appliedAt, Missing: true})
+			}
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Version < result[j].Version
+		})
+		return nil
+	})
+	return result, err
+}
+
+// Force records the database as being at version without running any SQL:
+// migrations up to version are marked applied with their current checksums and
+// any later records are removed. Use it to adopt an existing database or to
+// recover after fixing a failed migration by hand. Version 0 clears all records.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version < 0 {
+		return errors.New("version cannot be negative")
+	}
+	if version > 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					migration.Version, migration.Name, migration.Checksum, now,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			log.Printf("[%s] forced schema version to %d", m.name, version)
+			return nil
+		})
+	})
+}
+
+// withLock runs fn on a dedicated connection holding the migrator's advisory lock,
+// after making sure the schema_migrations table exists
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Session-level lock: it must be taken and released on the same connection
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, m.lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, m.lockKey); err != nil {
+			log.Printf("[%s] failed to release migration lock: %v", m.name, err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// applied returns the recorded migrations keyed by version
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+	return applied, rows.Err()
+}
+
+// verifyChecksums fails if an applied migration no longer matches its file.
+// Versions without a file are ignored: they were applied by a newer binary.
+func (m *Migrator) verifyChecksums(applied map[int]appliedMigration) error {
+	for _, migration := range m.migrations {
+		a, ok := applied[migration.Version]
+		if ok && a.checksum != migration.Checksum {
+			return fmt.Errorf("migration %d_%s was modified after it was applied (checksum mismatch); restore the file or run force", migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// find returns the migration with the given version, or nil
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction on conn, committing if it succeeds
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_column.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+		"migrations/0002_add_column.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN c;")},
+		"migrations/0001_create_t.up.sql":     {Data: []byte("CREATE TABLE t (id INT);")},
+	}
+
+	migrations, err := Load(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+
+	first, second := migrations[0], migrations[1]
+	if first.Version != 1 || first.Name != "create_t" {
+		t.Errorf("expected 1_create_t first, got %d_%s", first.Version, first.Name)
+	}
+	if first.Down != "" {
+		t.Errorf("expected no down script for migration 1, got %q", first.Down)
+	}
+	if second.Version != 2 || second.Down == "" {
+		t.Errorf("expected migration 2 with a down script, got %+v", second)
+	}
+	if len(first.Checksum) != 64 || first.Checksum == second.Checksum {
+		t.Errorf("expected distinct sha256 checksums, got %q and %q", first.Checksum, second.Checksum)
+	}
+}
+
+func TestLoad_ChecksumIgnoresDownScript(t *testing.T) {
+	up := []byte("CREATE TABLE t (id INT);")
+	a, err := Load(fstest.MapFS{"m/0001_t.up.sql": {Data: up}}, "m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := Load(fstest.MapFS{
+		"m/0001_t.up.sql":   {Data: up},
+		"m/0001_t.down.sql": {Data: []byte("DROP TABLE t;")},
+	}, "m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a[0].Checksum != b[0].Checksum {
+		t.Error("adding a down script should not change the checksum")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "bad file name",
+			files:   fstest.MapFS{"m/create_t.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "down without up",
+			files:   fstest.MapFS{"m/0001_t.down.sql": {Data: []byte("DROP TABLE t;")}},
+			wantErr: "has no up script",
+		},
+		{
+			name: "same version with different names",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql": {Data: []byte("SELECT 1;")},
+				"m/0001_b.up.sql": {Data: []byte("SELECT 2;")},
+			},
+			wantErr: "conflicting names",
+		},
+		{
+			name:    "version zero",
+			files:   fstest.MapFS{"m/0000_t.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "invalid migration version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files, "m")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS demo_data (
+	id SERIAL PRIMARY KEY,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- Responses to requests sent with an Idempotency-Key header, replayed when a client
+-- retries the request with the same key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	scope VARCHAR(512) NOT NULL,      -- method, path and caller the key was used for
+	key VARCHAR(255) NOT NULL,
+	request_hash CHAR(64) NOT NULL,   -- SHA-256 of the request, to detect a reused key
+	status_code INTEGER NOT NULL DEFAULT 0, -- 0 while the first request is in flight
+	content_type TEXT NOT NULL DEFAULT '',
+	location TEXT NOT NULL DEFAULT '',
+	body BYTEA,
+	locked_until TIMESTAMP NOT NULL,  -- UTC; an in-flight request's claim lapses after this
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,    -- UTC
+	PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
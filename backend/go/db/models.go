@@ -11,3 +11,23 @@ type DemoData struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// IdempotencyRecord is a request made with an Idempotency-Key header and, once it has
+// completed, its response
+type IdempotencyRecord struct {
+	Scope       string    `json:"scope" db:"scope"`
+	Key         string    `json:"key" db:"key"`
+	RequestHash string    `json:"request_hash" db:"request_hash"`
+	StatusCode  int       `json:"status_code" db:"status_code"` // 0 while in flight
+	ContentType string    `json:"content_type" db:"content_type"`
+	Location    string    `json:"location" db:"location"`
+	Body        []byte    `json:"body" db:"body"`
+	LockedUntil time.Time `json:"locked_until" db:"locked_until"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// Completed reports whether the request's response has been stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"k8s-fullstack-blueprint-backend/api"
 	"k8s-fullstack-blueprint-backend/api/appt_booking"
+	api_middleware "k8s-fullstack-blueprint-backend/api/middleware"
 	"k8s-fullstack-blueprint-backend/db"
 	"k8s-fullstack-blueprint-backend/service"
 	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
@@ -21,45 +26,115 @@ type DependencyContainer struct {
 	ServiceHandler     *appt_booking.ServiceHandler
 	StaffHandler       *appt_booking.StaffHandler
 	ScheduleHandler    *appt_booking.ScheduleHandler
+	ScheduleExceptionHandler *appt_booking.ScheduleExceptionHandler
 	AppointmentHandler *appt_booking.AppointmentHandler
+	AvailabilityHandler *appt_booking.AvailabilityHandler
+	CustomerHandler    *appt_booking.CustomerHandler
+	WaitlistHandler    *appt_booking.WaitlistHandler
+	HoldHandler        *appt_booking.HoldHandler
+	AuthHandler        *appt_booking.AuthHandler
+	WebhookHandler     *appt_booking.WebhookHandler
+	NotificationHandler *appt_booking.NotificationHandler
+	CalendarHandler    *appt_booking.CalendarHandler
+	ExternalCalendarHandler *appt_booking.ExternalCalendarHandler
 	// Repositories (for direct access if needed)
 	ApptBookingDB      *sql.DB
 	ServiceRepo        *appt_booking_db.ServiceRepository
 	StaffRepo          *appt_booking_db.StaffRepository
 	StaffServiceRepo   *appt_booking_db.StaffServiceRepository
 	ScheduleRepo       *appt_booking_db.ScheduleRepository
+	ScheduleExceptionRepo *appt_booking_db.ScheduleExceptionRepository
 	AppointmentRepo    *appt_booking_db.AppointmentRepository
+	CustomerRepo       *appt_booking_db.CustomerRepository
+	UserRepo           *appt_booking_db.UserRepository
+	IdempotencyRepo    *db.IdempotencyRepository
+	IdempotencyConfig  api_middleware.IdempotencyConfig
 	ApptBookingService *appt_booking_service.ApptBookingService
+	AuthService        *appt_booking_service.AuthService
+	EventDispatcher    *appt_booking_service.EventDispatcher
+	WebhookService     *appt_booking_service.WebhookService
+	NotificationService *appt_booking_service.NotificationService
+	Scheduler          *appt_booking_service.Scheduler
 }
 
 // NewDependencyContainer constructs and wires all dependencies
 func NewDependencyContainer() (*DependencyContainer, error) {
 	log.Println("Initializing application dependencies...")
 
+	// Business default timezone, used for staff members without their own timezone
+	businessTimezone := getEnv("BUSINESS_TIMEZONE", "America/Los_Angeles")
+	businessLocation, err := time.LoadLocation(businessTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUSINESS_TIMEZONE %q: %w", businessTimezone, err)
+	}
+
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	holdConfig, err := loadHoldConfig()
+	if err != nil {
+		return nil, err
+	}
+	cancellationConfig, err := loadCancellationConfig()
+	if err != nil {
+		return nil, err
+	}
+	dispatcherConfig, err := loadDispatcherConfig("EVENT")
+	if err != nil {
+		return nil, err
+	}
+	webhookConfig, err := loadWebhookConfig()
+	if err != nil {
+		return nil, err
+	}
+	notificationConfig, err := loadNotificationConfig()
+	if err != nil {
+		return nil, err
+	}
+	notifier, err := loadNotifier()
+	if err != nil {
+		return nil, err
+	}
+	schedulerConfig, err := loadSchedulerConfig()
+	if err != nil {
+		return nil, err
+	}
+	reminderConfig, err := loadReminderConfig()
+	if err != nil {
+		return nil, err
+	}
+	externalCalendarConfig, err := loadExternalCalendarConfig()
+	if err != nil {
+		return nil, err
+	}
+	idempotencyConfig, err := loadIdempotencyConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize main database connection (for demo_data)
 	dbConn, err := db.Connect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Initialize main database schema
-	if err := db.InitSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
-	}
-
 	// Initialize appointment booking database connection (separate database)
 	apptBookingDB, err := appt_booking_db.Connect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to appointment booking database: %w", err)
 	}
 
-	// Initialize appointment booking database schema
-	if err := appt_booking_db.InitSchema(apptBookingDB); err != nil {
-		return nil, fmt.Errorf("failed to initialize appointment booking schema: %w", err)
+	// Apply pending schema migrations. Set AUTO_MIGRATE=false when migrations are run
+	// separately (e.g. `backend migrate up` in a release job).
+	if getEnv("AUTO_MIGRATE", "true") != "false" {
+		if err := migrateUp(dbConn, apptBookingDB); err != nil {
+			return nil, err
+		}
 	}
 
 	// Seed sample data for appointment booking (idempotent)
-	if err := appt_booking_db.SeedSampleData(apptBookingDB); err != nil {
+	if err := appt_booking_db.SeedSampleData(apptBookingDB, businessLocation); err != nil {
 		return nil, fmt.Errorf("failed to seed appointment booking sample data: %w", err)
 	}
 
@@ -67,18 +142,58 @@ func NewDependencyContainer() (*DependencyContainer, error) {
 
 	// Initialize main repository layer
 	demoDataRepo := db.NewDemoDataRepository(dbConn)
+	idempotencyRepo := db.NewIdempotencyRepository(dbConn)
 
 	// Initialize appointment booking repository layer
 	serviceRepo := appt_booking_db.NewServiceRepository(apptBookingDB)
 	staffRepo := appt_booking_db.NewStaffRepository(apptBookingDB)
 	staffServiceRepo := appt_booking_db.NewStaffServiceRepository(apptBookingDB)
 	scheduleRepo := appt_booking_db.NewScheduleRepository(apptBookingDB)
+	scheduleExceptionRepo := appt_booking_db.NewScheduleExceptionRepository(apptBookingDB)
 	appointmentRepo := appt_booking_db.NewAppointmentRepository(apptBookingDB)
+	customerRepo := appt_booking_db.NewCustomerRepository(apptBookingDB)
+	seriesRepo := appt_booking_db.NewSeriesRepository(apptBookingDB)
+	waitlistRepo := appt_booking_db.NewWaitlistRepository(apptBookingDB)
+	holdRepo := appt_booking_db.NewHoldRepository(apptBookingDB)
+	userRepo := appt_booking_db.NewUserRepository(apptBookingDB)
+	outboxRepo := appt_booking_db.NewOutboxRepository(apptBookingDB)
+	webhookRepo := appt_booking_db.NewWebhookRepository(apptBookingDB)
+	notificationRepo := appt_booking_db.NewNotificationRepository(apptBookingDB)
+	jobRepo := appt_booking_db.NewJobRepository(apptBookingDB)
+	reminderRepo := appt_booking_db.NewReminderRepository(apptBookingDB)
+	calendarFeedRepo := appt_booking_db.NewCalendarFeedRepository(apptBookingDB)
+	externalCalendarRepo := appt_booking_db.NewExternalCalendarRepository(apptBookingDB)
 
 	// Initialize service layer
 	healthService := service.NewHealthService()
 	demoDataService := service.NewDemoDataService(demoDataRepo)
-	apptBookingService := appt_booking_service.NewApptBookingService(serviceRepo, staffRepo, staffServiceRepo, scheduleRepo, appointmentRepo)
+	apptBookingService := appt_booking_service.NewApptBookingService(serviceRepo, staffRepo, staffServiceRepo, scheduleRepo, appointmentRepo, scheduleExceptionRepo, customerRepo, seriesRepo, waitlistRepo, holdRepo, externalCalendarRepo, holdConfig, cancellationConfig, businessLocation)
+	authService, err := appt_booking_service.NewAuthService(userRepo, staffRepo, notifier, authConfig)
+	if err != nil {
+		return nil, err
+	}
+	eventDispatcher := appt_booking_service.NewEventDispatcher(outboxRepo, dispatcherConfig)
+	eventDispatcher.Subscribe("log", "", appt_booking_service.LogEventHandler)
+	webhookService := appt_booking_service.NewWebhookService(webhookRepo, outboxRepo, nil, webhookConfig)
+	eventDispatcher.Subscribe("webhooks", "", webhookService.HandleEvent)
+	notificationService := appt_booking_service.NewNotificationService(apptBookingService, notificationRepo, notifier, notificationConfig)
+	for _, eventType := range []string{appt_booking_service.EventAppointmentBooked, appt_booking_service.EventAppointmentRescheduled, appt_booking_service.EventAppointmentCancelled, appt_booking_service.EventWaitlistSlotOffered} {
+		eventDispatcher.Subscribe("notifications", eventType, notificationService.HandleEvent)
+	}
+	reminderService := appt_booking_service.NewReminderService(reminderRepo, notificationService, reminderConfig)
+	scheduler := appt_booking_service.NewScheduler(jobRepo, schedulerConfig)
+	scheduler.Register(reminderService.Job())
+	calendarService := appt_booking_service.NewCalendarService(apptBookingService, calendarFeedRepo)
+	externalCalendarService := appt_booking_service.NewExternalCalendarService(apptBookingService, externalCalendarRepo, nil, externalCalendarConfig)
+	scheduler.Register(externalCalendarService.Job())
+	scheduler.Register(appt_booking_service.Job{
+		Name:     "idempotency-keys-cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context, now time.Time) error {
+			_, err := idempotencyRepo.DeleteExpired(ctx, now)
+			return err
+		},
+	})
 
 	// Initialize API layer with dependencies
 	healthHandler := api.NewHealthHandler(healthService)
@@ -86,7 +201,17 @@ func NewDependencyContainer() (*DependencyContainer, error) {
 	serviceHandler := appt_booking.NewServiceHandler(apptBookingService)
 	staffHandler := appt_booking.NewStaffHandler(apptBookingService)
 	scheduleHandler := appt_booking.NewScheduleHandler(apptBookingService)
+	scheduleExceptionHandler := appt_booking.NewScheduleExceptionHandler(apptBookingService)
 	appointmentHandler := appt_booking.NewAppointmentHandler(apptBookingService)
+	availabilityHandler := appt_booking.NewAvailabilityHandler(apptBookingService)
+	customerHandler := appt_booking.NewCustomerHandler(apptBookingService)
+	waitlistHandler := appt_booking.NewWaitlistHandler(apptBookingService)
+	holdHandler := appt_booking.NewHoldHandler(apptBookingService)
+	authHandler := appt_booking.NewAuthHandler(authService)
+	webhookHandler := appt_booking.NewWebhookHandler(webhookService)
+	notificationHandler := appt_booking.NewNotificationHandler(notificationService)
+	calendarHandler := appt_booking.NewCalendarHandler(calendarService)
+	externalCalendarHandler := appt_booking.NewExternalCalendarHandler(externalCalendarService)
 
 	return &DependencyContainer{
 		HealthHandler:      healthHandler,
@@ -94,13 +219,330 @@ func NewDependencyContainer() (*DependencyContainer, error) {
 		ServiceHandler:     serviceHandler,
 		StaffHandler:       staffHandler,
 		ScheduleHandler:    scheduleHandler,
+		ScheduleExceptionHandler: scheduleExceptionHandler,
 		AppointmentHandler: appointmentHandler,
+		AvailabilityHandler: availabilityHandler,
+		CustomerHandler:    customerHandler,
+		WaitlistHandler:    waitlistHandler,
+		HoldHandler:        holdHandler,
+		AuthHandler:        authHandler,
+		WebhookHandler:     webhookHandler,
+		NotificationHandler: notificationHandler,
+		CalendarHandler:    calendarHandler,
+		ExternalCalendarHandler: externalCalendarHandler,
 		ApptBookingDB:      apptBookingDB,
 		ServiceRepo:        serviceRepo,
 		StaffRepo:          staffRepo,
 		StaffServiceRepo:   staffServiceRepo,
 		ScheduleRepo:       scheduleRepo,
+		ScheduleExceptionRepo: scheduleExceptionRepo,
 		AppointmentRepo:    appointmentRepo,
+		CustomerRepo:       customerRepo,
+		UserRepo:           userRepo,
+		IdempotencyRepo:    idempotencyRepo,
+		IdempotencyConfig:  idempotencyConfig,
 		ApptBookingService: apptBookingService,
+		AuthService:        authService,
+		EventDispatcher:    eventDispatcher,
+		WebhookService:     webhookService,
+		NotificationService: notificationService,
+		Scheduler:          scheduler,
 	}, nil
 }
+
+// loadAuthConfig reads token signing settings from the environment. JWT_SECRET has no
+// default: a guessable key would let anyone mint admin tokens.
+func loadAuthConfig() (appt_booking_service.AuthConfig, error) {
+	config := appt_booking_service.AuthConfig{
+		Secret: []byte(getEnv("JWT_SECRET", "")),
+		Issuer: getEnv("JWT_ISSUER", "k8s-fullstack-blueprint"),
+	}
+	if len(config.Secret) < 32 {
+		return config, fmt.Errorf("JWT_SECRET must be set to at least 32 bytes")
+	}
+
+	var err error
+	config.AccessTTL, err = time.ParseDuration(getEnv("JWT_ACCESS_TTL", "15m"))
+	if err != nil || config.AccessTTL <= 0 {
+		return config, fmt.Errorf("invalid JWT_ACCESS_TTL %q", getEnv("JWT_ACCESS_TTL", ""))
+	}
+	config.RefreshTTL, err = time.ParseDuration(getEnv("JWT_REFRESH_TTL", "720h"))
+	if err != nil || config.RefreshTTL <= 0 {
+		return config, fmt.Errorf("invalid JWT_REFRESH_TTL %q", getEnv("JWT_REFRESH_TTL", ""))
+	}
+	config.VerifyEmailTTL, err = time.ParseDuration(getEnv("VERIFY_EMAIL_TTL", "48h"))
+	if err != nil || config.VerifyEmailTTL <= 0 {
+		return config, fmt.Errorf("invalid VERIFY_EMAIL_TTL %q", getEnv("VERIFY_EMAIL_TTL", ""))
+	}
+	config.VerifyEmailURL = getEnv("VERIFY_EMAIL_URL", "http://localhost:4200/appt-booking/verify-email")
+	return config, nil
+}
+
+// loadHoldConfig reads slot hold settings from the environment
+func loadHoldConfig() (appt_booking_service.HoldConfig, error) {
+	var config appt_booking_service.HoldConfig
+	for name, dst := range map[string]*time.Duration{
+		"HOLD_TTL":            &config.HoldTTL,
+		"WAITLIST_OFFER_TTL":  &config.WaitlistOfferTTL,
+		"HOLD_SWEEP_INTERVAL": &config.SweepInterval,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // service default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	if value := getEnv("MAX_ACTIVE_HOLDS", ""); value != "" {
+		holds, err := strconv.Atoi(value)
+		if err != nil || holds <= 0 {
+			return config, fmt.Errorf("invalid MAX_ACTIVE_HOLDS %q", value)
+		}
+		config.MaxActiveHolds = holds
+	}
+	return config, nil
+}
+
+// loadCancellationConfig reads cancellation policy settings from the environment
+func loadCancellationConfig() (appt_booking_service.CancellationConfig, error) {
+	var config appt_booking_service.CancellationConfig
+	if value := getEnv("NO_SHOW_LIMIT", ""); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return config, fmt.Errorf("invalid NO_SHOW_LIMIT %q", value)
+		}
+		config.NoShowLimit = limit
+	}
+	return config, nil
+}
+
+// loadDispatcherConfig reads delivery settings from the environment variables starting
+// with prefix, e.g. EVENT_POLL_INTERVAL for domain events
+func loadDispatcherConfig(prefix string) (appt_booking_service.DispatcherConfig, error) {
+	var config appt_booking_service.DispatcherConfig
+	for name, dst := range map[string]*time.Duration{
+		prefix + "_POLL_INTERVAL": &config.PollInterval,
+		prefix + "_RETRY_BACKOFF": &config.RetryBackoff,
+		prefix + "_MAX_BACKOFF":   &config.MaxBackoff,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // service default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	if value := getEnv(prefix+"_MAX_ATTEMPTS", ""); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts <= 0 {
+			return config, fmt.Errorf("invalid %s_MAX_ATTEMPTS %q", prefix, value)
+		}
+		config.MaxAttempts = attempts
+	}
+	return config, nil
+}
+
+// loadWebhookConfig reads webhook delivery settings from the environment
+func loadWebhookConfig() (appt_booking_service.WebhookConfig, error) {
+	dispatcherConfig, err := loadDispatcherConfig("WEBHOOK")
+	if err != nil {
+		return appt_booking_service.WebhookConfig{}, err
+	}
+	config := appt_booking_service.WebhookConfig{DispatcherConfig: dispatcherConfig}
+	if value := getEnv("WEBHOOK_TIMEOUT", ""); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid WEBHOOK_TIMEOUT %q", value)
+		}
+		config.Timeout = d
+	}
+	return config, nil
+}
+
+// loadNotificationConfig reads notification retry settings and the page waitlist offers
+// link to from the environment
+func loadNotificationConfig() (appt_booking_service.NotificationConfig, error) {
+	config := appt_booking_service.NotificationConfig{
+		WaitlistOfferURL: getEnv("WAITLIST_OFFER_URL", "http://localhost:4200/appt-booking/waitlist-offer"),
+	}
+	for name, dst := range map[string]*time.Duration{
+		"NOTIFICATION_RETRY_BACKOFF": &config.RetryBackoff,
+		"NOTIFICATION_MAX_BACKOFF":   &config.MaxBackoff,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // service default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	if value := getEnv("NOTIFICATION_MAX_ATTEMPTS", ""); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts <= 0 {
+			return config, fmt.Errorf("invalid NOTIFICATION_MAX_ATTEMPTS %q", value)
+		}
+		config.MaxAttempts = attempts
+	}
+	return config, nil
+}
+
+// loadSchedulerConfig reads job scheduler settings from the environment
+func loadSchedulerConfig() (appt_booking_service.SchedulerConfig, error) {
+	var config appt_booking_service.SchedulerConfig
+	for name, dst := range map[string]*time.Duration{
+		"JOB_POLL_INTERVAL": &config.PollInterval,
+		"JOB_LEASE":         &config.Lease,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // service default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	return config, nil
+}
+
+// loadReminderConfig reads appointment reminder settings from the environment.
+// REMINDER_OFFSETS is a comma-separated list of durations before the appointment, e.g.
+// "24h,2h"; each must be at least a minute.
+func loadReminderConfig() (appt_booking_service.ReminderConfig, error) {
+	var config appt_booking_service.ReminderConfig
+	if value := getEnv("REMINDER_OFFSETS", ""); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(field))
+			if err != nil || d < time.Minute {
+				return config, fmt.Errorf("invalid REMINDER_OFFSETS %q", value)
+			}
+			config.Offsets = append(config.Offsets, d)
+		}
+	}
+	if value := getEnv("REMINDER_INTERVAL", ""); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid REMINDER_INTERVAL %q", value)
+		}
+		config.Interval = d
+	}
+	return config, nil
+}
+
+// loadExternalCalendarConfig reads external calendar settings from the environment.
+// EXTERNAL_CALENDAR_MAX_SIZE is in bytes.
+func loadExternalCalendarConfig() (appt_booking_service.ExternalCalendarConfig, error) {
+	var config appt_booking_service.ExternalCalendarConfig
+	for name, dst := range map[string]*time.Duration{
+		"EXTERNAL_CALENDAR_REFRESH_INTERVAL": &config.RefreshInterval,
+		"EXTERNAL_CALENDAR_HORIZON":          &config.Horizon,
+		"EXTERNAL_CALENDAR_FETCH_TIMEOUT":    &config.FetchTimeout,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // service default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	if value := getEnv("EXTERNAL_CALENDAR_MAX_SIZE", ""); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size <= 0 {
+			return config, fmt.Errorf("invalid EXTERNAL_CALENDAR_MAX_SIZE %q", value)
+		}
+		config.MaxSize = size
+	}
+	return config, nil
+}
+
+// loadIdempotencyConfig reads Idempotency-Key settings from the environment.
+// IDEMPOTENCY_LOCK_TIMEOUT must exceed REQUEST_TIMEOUT, or a retry could run alongside a
+// request still in flight.
+func loadIdempotencyConfig() (api_middleware.IdempotencyConfig, error) {
+	var config api_middleware.IdempotencyConfig
+	for name, dst := range map[string]*time.Duration{
+		"IDEMPOTENCY_TTL":          &config.TTL,
+		"IDEMPOTENCY_LOCK_TIMEOUT": &config.LockTimeout,
+	} {
+		value := getEnv(name, "")
+		if value == "" {
+			continue // middleware default
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = d
+	}
+	return config, nil
+}
+
+// loadNotifier builds the notifier selected by NOTIFIER: "log" (the default) writes
+// notifications to the log, "file" writes them as .eml files to NOTIFICATION_DIR, and
+// "smtp" sends them through the relay configured by the SMTP_* variables
+func loadNotifier() (appt_booking_service.Notifier, error) {
+	from := getEnv("SMTP_FROM", "bookings@localhost")
+	switch kind := getEnv("NOTIFIER", "log"); kind {
+	case "log":
+		return appt_booking_service.LogNotifier{}, nil
+	case "file":
+		return appt_booking_service.FileNotifier{Dir: getEnv("NOTIFICATION_DIR", "notifications"), From: from}, nil
+	case "smtp":
+		config := appt_booking_service.SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		}
+		if config.Host == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be set when NOTIFIER=smtp")
+		}
+		port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+		if err != nil || port <= 0 {
+			return nil, fmt.Errorf("invalid SMTP_PORT %q", getEnv("SMTP_PORT", ""))
+		}
+		config.Port = port
+		if value := getEnv("SMTP_TIMEOUT", ""); value != "" {
+			config.Timeout, err = time.ParseDuration(value)
+			if err != nil || config.Timeout <= 0 {
+				return nil, fmt.Errorf("invalid SMTP_TIMEOUT %q", value)
+			}
+		}
+		return appt_booking_service.NewSMTPNotifier(config), nil
+	default:
+		return nil, fmt.Errorf("invalid NOTIFIER %q: use log, file or smtp", kind)
+	}
+}
+
+// migrateUp applies pending migrations to the main and appointment booking databases
+func migrateUp(mainDB, apptBookingDB *sql.DB) error {
+	mainMigrator, err := db.NewMigrator(mainDB)
+	if err != nil {
+		return err
+	}
+	if _, err := mainMigrator.Up(context.Background()); err != nil {
+		return fmt.Errorf("failed to migrate database schema: %w", err)
+	}
+
+	apptBookingMigrator, err := appt_booking_db.NewMigrator(apptBookingDB)
+	if err != nil {
+		return err
+	}
+	if _, err := apptBookingMigrator.Up(context.Background()); err != nil {
+		return fmt.Errorf("failed to migrate appointment booking schema: %w", err)
+	}
+	return nil
+}
@@ -1,23 +1,57 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
+	// Embedded zone database: staff and business timezones are loaded by name, and the
+	// runtime image ships without tzdata
+	_ "time/tzdata"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
 	"k8s-fullstack-blueprint-backend/api"
+	api_middleware "k8s-fullstack-blueprint-backend/api/middleware"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		if err := runCreateAdmin(os.Args[2:]); err != nil {
+			log.Fatalf("create-admin: %v", err)
+		}
+		return
+	}
+
 	// Create a new Echo instance
 	e := echo.New()
 
+	// Render handler errors as RFC 7807 problem details
+	e.HTTPErrorHandler = api.HTTPErrorHandler
+
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		// List endpoints report their total in X-Total-Count; replayed idempotent
+		// responses are marked with Idempotent-Replayed
+		ExposeHeaders: []string{"X-Total-Count", api_middleware.HeaderIdempotentReplayed},
+	}))
+
+	// Per-request deadline, propagated to database queries
+	requestTimeout, err := time.ParseDuration(getEnv("REQUEST_TIMEOUT", "15s"))
+	if err != nil || requestTimeout <= 0 {
+		log.Fatalf("Invalid REQUEST_TIMEOUT: %q", getEnv("REQUEST_TIMEOUT", ""))
+	}
+	e.Use(api_middleware.RequestTimeout(requestTimeout))
 
 	// Initialize dependency container
 	container, err := NewDependencyContainer()
@@ -25,6 +59,21 @@ func main() {
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
 
+	// Release slots whose holds have expired
+	go container.ApptBookingService.RunHoldSweeper(context.Background())
+
+	// Deliver domain events recorded in the outbox to their handlers
+	go container.EventDispatcher.Run(context.Background())
+
+	// Send queued webhook deliveries, retrying failed ones
+	go container.WebhookService.RunDeliveryWorker(context.Background())
+
+	// Run periodic jobs such as appointment reminders, each on one replica at a time
+	go container.Scheduler.Run(context.Background())
+
+	// Resolve the caller from a bearer token; routes enforce roles individually
+	e.Use(api_middleware.Authenticate(container.AuthService))
+
 	// Load routes
 	api.SetupRoutes(
 		e,
@@ -33,7 +82,18 @@ func main() {
 		container.ServiceHandler,
 		container.StaffHandler,
 		container.ScheduleHandler,
+		container.ScheduleExceptionHandler,
 		container.AppointmentHandler,
+		container.AvailabilityHandler,
+		container.AuthHandler,
+		container.CustomerHandler,
+		container.WaitlistHandler,
+		container.HoldHandler,
+		container.WebhookHandler,
+		container.NotificationHandler,
+		container.CalendarHandler,
+		container.ExternalCalendarHandler,
+		api_middleware.Idempotency(container.IdempotencyRepo, container.IdempotencyConfig),
 	)
 
 	// Get port from environment or default
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"k8s-fullstack-blueprint-backend/db"
+	appt_booking_db "k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/db/migrate"
+)
+
+const migrateUsage = `Usage: backend migrate [-db all|main|appt_booking] <command>
+
+Commands:
+  up         apply all pending migrations
+  down N     roll back the N most recently applied migrations (requires -db)
+  status     list migrations and whether they are applied
+  force V    record version V as current without running SQL (requires -db)
+`
+
+// namedMigrator pairs a database's migrator with the name used to select it
+type namedMigrator struct {
+	name     string
+	migrator *migrate.Migrator
+}
+
+// runMigrate implements the migrate subcommand
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	target := flags.String("db", "all", "database to migrate: all, main or appt_booking")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+	command := flags.Arg(0)
+
+	// down and force change recorded state, so they must name one database
+	var number int
+	switch command {
+	case "up", "status":
+		if flags.NArg() != 1 {
+			return fmt.Errorf("%s takes no arguments", command)
+		}
+	case "down", "force":
+		if *target == "all" {
+			return fmt.Errorf("%s requires -db main or -db appt_booking", command)
+		}
+		if flags.NArg() != 2 {
+			return fmt.Errorf("%s requires a number", command)
+		}
+		n, err := strconv.Atoi(flags.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid number %q", flags.Arg(1))
+		}
+		number = n
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	migrators, closeAll, err := openMigrators(*target)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	ctx := context.Background()
+	for _, m := range migrators {
+		switch command {
+		case "up":
+			applied, err := m.migrator.Up(ctx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+			log.Printf("[%s] %d migration(s) applied", m.name, applied)
+		case "down":
+			rolledBack, err := m.migrator.Down(ctx, number)
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+			log.Printf("[%s] %d migration(s) rolled back", m.name, rolledBack)
+		case "status":
+			statuses, err := m.migrator.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+			printMigrationStatus(m.name, statuses)
+		case "force":
+			if err := m.migrator.Force(ctx, number); err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// openMigrators connects to the selected databases and returns their migrators.
+// The returned function closes the connections.
+func openMigrators(target string) ([]namedMigrator, func(), error) {
+	var conns []*sql.DB
+	closeAll := func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+
+	var migrators []namedMigrator
+	if target == "all" || target == "main" {
+		conn, err := db.Connect()
+		if err != nil {
+			return nil, closeAll, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		conns = append(conns, conn)
+		m, err := db.NewMigrator(conn)
+		if err != nil {
+			return nil, closeAll, err
+		}
+		migrators = append(migrators, namedMigrator{name: "main", migrator: m})
+	}
+	if target == "all" || target == "appt_booking" {
+		conn, err := appt_booking_db.Connect()
+		if err != nil {
+			return nil, closeAll, fmt.Errorf("failed to connect to appointment booking database: %w", err)
+		}
+		conns = append(conns, conn)
+		m, err := appt_booking_db.NewMigrator(conn)
+		if err != nil {
+			return nil, closeAll, err
+		}
+		migrators = append(migrators, namedMigrator{name: "appt_booking", migrator: m})
+	}
+	if len(migrators) == 0 {
+		return nil, closeAll, fmt.Errorf("unknown database %q, expected all, main or appt_booking", target)
+	}
+	return migrators, closeAll, nil
+}
+
+// printMigrationStatus writes a table of migration states to stdout
+func printMigrationStatus(name string, statuses []migrate.Status) {
+	fmt.Printf("Database: %s\n", name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Missing:
+			state = "applied (no file)"
+		case s.Modified:
+			state = "applied (modified)"
+		case s.Applied:
+			state = "applied"
+		}
+		appliedAt := ""
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+	fmt.Println()
+}
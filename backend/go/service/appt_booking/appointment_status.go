@@ -0,0 +1,131 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ErrInvalidStatusTransition is returned when an appointment cannot move from its
+// current status to the requested one
+var ErrInvalidStatusTransition = errors.New("invalid appointment status transition")
+
+// appointmentTransitions lists, for each status, the statuses an appointment may move to.
+// completed, cancelled and no_show are terminal.
+var appointmentTransitions = map[string][]string{
+	appt_booking.AppointmentStatusPending: {
+		appt_booking.AppointmentStatusConfirmed,
+		appt_booking.AppointmentStatusCancelled,
+	},
+	appt_booking.AppointmentStatusConfirmed: {
+		appt_booking.AppointmentStatusCheckedIn,
+		appt_booking.AppointmentStatusCompleted,
+		appt_booking.AppointmentStatusCancelled,
+		appt_booking.AppointmentStatusNoShow,
+	},
+	appt_booking.AppointmentStatusCheckedIn: {
+		appt_booking.AppointmentStatusInProgress,
+		appt_booking.AppointmentStatusCompleted,
+		appt_booking.AppointmentStatusCancelled,
+		appt_booking.AppointmentStatusNoShow,
+	},
+	appt_booking.AppointmentStatusInProgress: {
+		appt_booking.AppointmentStatusCompleted,
+	},
+	appt_booking.AppointmentStatusCompleted: {},
+	appt_booking.AppointmentStatusCancelled: {},
+	appt_booking.AppointmentStatusNoShow:    {},
+}
+
+// IsValidAppointmentStatus reports whether status is a known appointment status
+func IsValidAppointmentStatus(status string) bool {
+	_, ok := appointmentTransitions[status]
+	return ok
+}
+
+// CanTransitionAppointment reports whether an appointment may move from one status to another
+func CanTransitionAppointment(from, to string) bool {
+	for _, next := range appointmentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedAppointmentTransitions returns the statuses an appointment in the given status may move to
+func AllowedAppointmentTransitions(from string) []string {
+	return append([]string(nil), appointmentTransitions[from]...)
+}
+
+// isReschedulable reports whether an appointment in the given status can still be moved
+func isReschedulable(status string) bool {
+	return slices.Contains(appt_booking.ReschedulableStatuses, status)
+}
+
+// TransitionAppointment moves an appointment to a new status, recording who made
+// the change and why in the appointment's status history. Cancellations also record
+// whether the customer or staff cancelled and whether it was late; no-shows can only be
+// marked once the appointment has started.
+func (s *ApptBookingService) TransitionAppointment(ctx context.Context, id int, toStatus, changedBy, reason string) (*appt_booking.Appointment, error) {
+	if !IsValidAppointmentStatus(toStatus) {
+		return nil, Invalid("status", "invalid status: "+toStatus)
+	}
+
+	appt, err := s.appointmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appt == nil {
+		return nil, NotFound("appointment")
+	}
+
+	if appt.Status == toStatus {
+		return nil, PreconditionFailed("appointment is already "+toStatus, nil)
+	}
+	if !CanTransitionAppointment(appt.Status, toStatus) {
+		return nil, PreconditionFailed(fmt.Sprintf("cannot move appointment from %s to %s", appt.Status, toStatus), ErrInvalidStatusTransition)
+	}
+	if toStatus == appt_booking.AppointmentStatusNoShow && time.Now().Before(appt.AppointmentDatetime) {
+		return nil, PreconditionFailed("cannot mark a no-show before the appointment starts", nil)
+	}
+
+	var updated *appt_booking.Appointment
+	event := domainEvent(appointmentStatusEvent(toStatus), appt_booking.AggregateAppointment)
+	if toStatus == appt_booking.AppointmentStatusCancelled {
+		var cancellation *appt_booking.AppointmentCancellation
+		cancellation, err = s.newCancellation(ctx, appt, reason)
+		if err != nil {
+			return nil, err
+		}
+		updated, err = s.appointmentRepo.Cancel(ctx, id, appt.Status, changedBy, *cancellation, event)
+	} else {
+		updated, err = s.appointmentRepo.TransitionStatus(ctx, id, appt.Status, toStatus, changedBy, reason, event)
+	}
+	if errors.Is(err, appt_booking.ErrAppointmentStatusChanged) || errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict(err.Error(), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if toStatus == appt_booking.AppointmentStatusCancelled {
+		s.offerFreedSlots(ctx, appt.StaffID)
+	}
+	return updated, nil
+}
+
+// GetAppointmentStatusHistory retrieves the status transitions of an appointment, oldest first
+func (s *ApptBookingService) GetAppointmentStatusHistory(ctx context.Context, id int) ([]appt_booking.AppointmentStatusChange, error) {
+	appt, err := s.appointmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appt == nil {
+		return nil, NotFound("appointment")
+	}
+	return s.appointmentRepo.GetStatusHistory(ctx, id)
+}
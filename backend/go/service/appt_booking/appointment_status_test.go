@@ -0,0 +1,68 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func TestCanTransitionAppointment(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{appt_booking.AppointmentStatusPending, appt_booking.AppointmentStatusConfirmed, true},
+		{appt_booking.AppointmentStatusPending, appt_booking.AppointmentStatusCheckedIn, false},
+		{appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusCheckedIn, true},
+		{appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusNoShow, true},
+		{appt_booking.AppointmentStatusConfirmed, appt_booking.AppointmentStatusPending, false},
+		{appt_booking.AppointmentStatusCheckedIn, appt_booking.AppointmentStatusInProgress, true},
+		{appt_booking.AppointmentStatusInProgress, appt_booking.AppointmentStatusCompleted, true},
+		{appt_booking.AppointmentStatusInProgress, appt_booking.AppointmentStatusCancelled, false},
+		{appt_booking.AppointmentStatusCompleted, appt_booking.AppointmentStatusCancelled, false},
+		{appt_booking.AppointmentStatusCancelled, appt_booking.AppointmentStatusConfirmed, false},
+		{appt_booking.AppointmentStatusNoShow, appt_booking.AppointmentStatusCompleted, false},
+		{"unknown", appt_booking.AppointmentStatusConfirmed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransitionAppointment(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionAppointment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppointmentTransitions_TargetsAreKnownStatuses(t *testing.T) {
+	for from, targets := range appointmentTransitions {
+		for _, to := range targets {
+			if !IsValidAppointmentStatus(to) {
+				t.Errorf("transition %s -> %s targets an unknown status", from, to)
+			}
+			if to == from {
+				t.Errorf("status %s transitions to itself", from)
+			}
+		}
+	}
+}
+
+func TestCompleteAppointment_RecordsWhoCompletedIt(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	ctx := WithPrincipal(f.ctx, &Principal{Role: appt_booking.RoleProvider, Email: "alice@example.com", StaffID: &f.staff.ID})
+	if err := f.svc.CompleteAppointment(ctx, a.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	history, err := f.svc.GetAppointmentStatusHistory(f.ctx, a.ID)
+	if err != nil {
+		t.Fatalf("status history: %v", err)
+	}
+	last := history[len(history)-1]
+	if last.ToStatus != appt_booking.AppointmentStatusCompleted || last.ChangedBy != "alice@example.com" {
+		t.Fatalf("expected completion by alice@example.com, got %+v", last)
+	}
+}
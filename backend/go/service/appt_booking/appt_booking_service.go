@@ -1,7 +1,9 @@
 package appt_booking
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"k8s-fullstack-blueprint-backend/db/appt_booking"
@@ -9,20 +11,40 @@ import (
 
 // ApptBookingService handles business logic for appointment booking
 type ApptBookingService struct {
-	serviceRepo      *appt_booking.ServiceRepository
-	staffRepo        *appt_booking.StaffRepository
-	staffServiceRepo *appt_booking.StaffServiceRepository
-	scheduleRepo     *appt_booking.ScheduleRepository
-	appointmentRepo  *appt_booking.AppointmentRepository
+	serviceRepo      ServiceRepository
+	staffRepo        StaffRepository
+	staffServiceRepo StaffServiceRepository
+	scheduleRepo     ScheduleRepository
+	appointmentRepo  AppointmentRepository
+	exceptionRepo    ScheduleExceptionRepository
+	customerRepo     CustomerRepository
+	seriesRepo       SeriesRepository
+	waitlistRepo     WaitlistRepository
+	holdRepo         HoldRepository
+	externalRepo     ExternalCalendarRepository
+	holdConfig       HoldConfig
+	cancellation     CancellationConfig
+	defaultLocation  *time.Location
 }
 
-// NewApptBookingService creates a new appointment booking service
+// NewApptBookingService creates a new appointment booking service.
+// The repositories are usually the Postgres ones from db/appt_booking; zero HoldConfig
+// fields take their defaults. The zero CancellationConfig puts no limit on no-shows.
 func NewApptBookingService(
-	serviceRepo *appt_booking.ServiceRepository,
-	staffRepo *appt_booking.StaffRepository,
-	staffServiceRepo *appt_booking.StaffServiceRepository,
-	scheduleRepo *appt_booking.ScheduleRepository,
-	appointmentRepo *appt_booking.AppointmentRepository,
+	serviceRepo ServiceRepository,
+	staffRepo StaffRepository,
+	staffServiceRepo StaffServiceRepository,
+	scheduleRepo ScheduleRepository,
+	appointmentRepo AppointmentRepository,
+	exceptionRepo ScheduleExceptionRepository,
+	customerRepo CustomerRepository,
+	seriesRepo SeriesRepository,
+	waitlistRepo WaitlistRepository,
+	holdRepo HoldRepository,
+	externalRepo ExternalCalendarRepository,
+	holdConfig HoldConfig,
+	cancellation CancellationConfig,
+	defaultLocation *time.Location,
 ) *ApptBookingService {
 	return &ApptBookingService{
 		serviceRepo:      serviceRepo,
@@ -30,250 +52,302 @@ func NewApptBookingService(
 		staffServiceRepo: staffServiceRepo,
 		scheduleRepo:     scheduleRepo,
 		appointmentRepo:  appointmentRepo,
+		exceptionRepo:    exceptionRepo,
+		customerRepo:     customerRepo,
+		seriesRepo:       seriesRepo,
+		waitlistRepo:     waitlistRepo,
+		holdRepo:         holdRepo,
+		externalRepo:     externalRepo,
+		holdConfig:       holdConfig.withDefaults(),
+		cancellation:     cancellation,
+		defaultLocation:  defaultLocation,
 	}
 }
 
 // ========== Service Operations ==========
 
-// CreateService creates a new service
-func (s *ApptBookingService) CreateService(name, description string, durationMinutes, priceCents int) (*appt_booking.Service, error) {
+// CreateService creates a new service. A zero policy.SlotGranularityMin uses the default granularity.
+func (s *ApptBookingService) CreateService(ctx context.Context, name, description string, durationMinutes, priceCents int, policy appt_booking.BookingPolicy) (*appt_booking.Service, error) {
 	// Validation
 	if name == "" {
-		return nil, errors.New("service name is required")
+		return nil, Invalid("name", "service name is required")
 	}
 	if durationMinutes <= 0 {
-		return nil, errors.New("duration must be positive")
+		return nil, Invalid("duration_min", "duration must be positive")
 	}
 	if priceCents < 0 {
-		return nil, errors.New("price cannot be negative")
+		return nil, Invalid("price_cents", "price cannot be negative")
+	}
+	policy, err := validateBookingPolicy(policy)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.serviceRepo.Create(name, description, durationMinutes, priceCents)
+	return s.serviceRepo.Create(ctx, name, description, durationMinutes, priceCents, policy, domainEvent(EventServiceCreated, appt_booking.AggregateService))
 }
 
-// UpdateService modifies an existing service
-func (s *ApptBookingService) UpdateService(id int, name, description string, durationMinutes, priceCents int) (*appt_booking.Service, error) {
+// UpdateService modifies an existing service. A zero policy.SlotGranularityMin uses the default granularity.
+func (s *ApptBookingService) UpdateService(ctx context.Context, id int, name, description string, durationMinutes, priceCents int, policy appt_booking.BookingPolicy) (*appt_booking.Service, error) {
 	// Validation
 	if name == "" {
-		return nil, errors.New("service name is required")
+		return nil, Invalid("name", "service name is required")
 	}
 	if durationMinutes <= 0 {
-		return nil, errors.New("duration must be positive")
+		return nil, Invalid("duration_min", "duration must be positive")
 	}
 	if priceCents < 0 {
-		return nil, errors.New("price cannot be negative")
+		return nil, Invalid("price_cents", "price cannot be negative")
+	}
+	policy, err := validateBookingPolicy(policy)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.serviceRepo.Update(id, name, description, durationMinutes, priceCents)
+	updated, err := s.serviceRepo.Update(ctx, id, name, description, durationMinutes, priceCents, policy, domainEvent(EventServiceUpdated, appt_booking.AggregateService))
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("service")
+	}
+	return updated, nil
 }
 
 // GetAllServices retrieves all services
-func (s *ApptBookingService) GetAllServices() ([]appt_booking.Service, error) {
-	return s.serviceRepo.GetAll()
+func (s *ApptBookingService) GetAllServices(ctx context.Context) ([]appt_booking.Service, error) {
+	return s.serviceRepo.GetAll(ctx)
 }
 
-// GetServiceByID retrieves a service by ID
-func (s *ApptBookingService) GetServiceByID(id int) (*appt_booking.Service, error) {
-	return s.serviceRepo.GetByID(id)
+// GetServiceByID retrieves a service by ID, returning a KindNotFound error if it does not exist
+func (s *ApptBookingService) GetServiceByID(ctx context.Context, id int) (*appt_booking.Service, error) {
+	found, err := s.serviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("service")
+	}
+	return found, nil
 }
 
 // DeleteService removes a service
-func (s *ApptBookingService) DeleteService(id int) error {
+func (s *ApptBookingService) DeleteService(ctx context.Context, id int) error {
 	// Check if service is in use by staff
-	staffList, err := s.staffServiceRepo.GetStaffForService(id)
+	staffList, err := s.staffServiceRepo.GetStaffForService(ctx, id)
 	if err != nil {
 		return err
 	}
 	if len(staffList) > 0 {
-		return errors.New("cannot delete service that is assigned to staff members")
+		return PreconditionFailed("cannot delete service that is assigned to staff members", nil)
 	}
 
 	// Check if service has existing appointments
 	// Note: We could also cascade delete or restrict based on business rules
 	// For now, we'll prevent deletion if appointments exist
-	appointments, err := s.appointmentRepo.GetAll()
+	appointments, err := s.appointmentRepo.GetAll(ctx)
 	if err != nil {
 		return err
 	}
 	for _, a := range appointments {
 		if a.ServiceID == id {
-			return errors.New("cannot delete service that has existing appointments")
+			return PreconditionFailed("cannot delete service that has existing appointments", nil)
 		}
 	}
 
-	return s.serviceRepo.Delete(id)
+	return s.serviceRepo.Delete(ctx, id, domainEvent(EventServiceDeleted, appt_booking.AggregateService))
 }
 
 // ========== Staff Operations ==========
 
 // CreateStaff creates a new staff member
-func (s *ApptBookingService) CreateStaff(name, email, phone, role string) (*appt_booking.Staff, error) {
+// An empty timezone means the staff member follows the business default timezone.
+func (s *ApptBookingService) CreateStaff(ctx context.Context, name, email, phone, role, timezone string) (*appt_booking.Staff, error) {
 	// Validation
 	if name == "" {
-		return nil, errors.New("staff name is required")
+		return nil, Invalid("name", "staff name is required")
 	}
 	if email == "" {
-		return nil, errors.New("staff email is required")
+		return nil, Invalid("email", "staff email is required")
 	}
 	if role == "" {
-		return nil, errors.New("staff role is required")
+		return nil, Invalid("role", "staff role is required")
 	}
 	// Basic email format check
 	if !contains(email, "@") {
-		return nil, errors.New("invalid email format")
+		return nil, Invalid("email", "invalid email format")
+	}
+	if !isValidTimezone(timezone) {
+		return nil, Invalid("timezone", "invalid timezone, must be an IANA name such as America/New_York")
 	}
 
 	// Check if email already exists
-	existing, err := s.staffRepo.GetByEmail(email)
+	existing, err := s.staffRepo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if existing != nil {
-		return nil, errors.New("staff with this email already exists")
+		return nil, Conflict("staff with this email already exists", nil)
 	}
 
-	return s.staffRepo.Create(name, email, phone, role)
+	return s.staffRepo.Create(ctx, name, email, phone, role, timezone, domainEvent(EventStaffCreated, appt_booking.AggregateStaff))
 }
 
 // UpdateStaff modifies an existing staff member
-func (s *ApptBookingService) UpdateStaff(id int, name, email, phone, role string) (*appt_booking.Staff, error) {
+func (s *ApptBookingService) UpdateStaff(ctx context.Context, id int, name, email, phone, role, timezone string) (*appt_booking.Staff, error) {
 	// Validation
 	if name == "" {
-		return nil, errors.New("staff name is required")
+		return nil, Invalid("name", "staff name is required")
 	}
 	if email == "" {
-		return nil, errors.New("staff email is required")
+		return nil, Invalid("email", "staff email is required")
 	}
 	if role == "" {
-		return nil, errors.New("staff role is required")
+		return nil, Invalid("role", "staff role is required")
+	}
+	if !isValidTimezone(timezone) {
+		return nil, Invalid("timezone", "invalid timezone, must be an IANA name such as America/New_York")
 	}
 
 	// Check if email is used by another staff member
-	existing, err := s.staffRepo.GetByEmail(email)
+	existing, err := s.staffRepo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if existing != nil && existing.ID != id {
-		return nil, errors.New("email is already used by another staff member")
+		return nil, Conflict("email is already used by another staff member", nil)
 	}
 
-	return s.staffRepo.Update(id, name, email, phone, role)
+	updated, err := s.staffRepo.Update(ctx, id, name, email, phone, role, timezone, domainEvent(EventStaffUpdated, appt_booking.AggregateStaff))
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("staff")
+	}
+	return updated, nil
 }
 
 // GetAllStaff retrieves all staff members
-func (s *ApptBookingService) GetAllStaff() ([]appt_booking.Staff, error) {
-	return s.staffRepo.GetAll()
+func (s *ApptBookingService) GetAllStaff(ctx context.Context) ([]appt_booking.Staff, error) {
+	return s.staffRepo.GetAll(ctx)
 }
 
 // GetStaffByID retrieves a staff member by ID
-func (s *ApptBookingService) GetStaffByID(id int) (*appt_booking.Staff, error) {
-	return s.staffRepo.GetByID(id)
+func (s *ApptBookingService) GetStaffByID(ctx context.Context, id int) (*appt_booking.Staff, error) {
+	found, err := s.staffRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("staff")
+	}
+	return found, nil
 }
 
 // DeleteStaff removes a staff member
-func (s *ApptBookingService) DeleteStaff(id int) error {
+func (s *ApptBookingService) DeleteStaff(ctx context.Context, id int) error {
 	// Check if staff has existing appointments
-	appointments, err := s.appointmentRepo.GetByStaff(id)
+	appointments, err := s.appointmentRepo.GetByStaff(ctx, id)
 	if err != nil {
 		return err
 	}
 	if len(appointments) > 0 {
-		return errors.New("cannot delete staff member with existing appointments")
+		return PreconditionFailed("cannot delete staff member with existing appointments", nil)
 	}
 
 	// Cascade delete schedules and staff_service assignments
 	// This is handled by ON DELETE CASCADE in the database schema
-	return s.staffRepo.Delete(id)
+	return s.staffRepo.Delete(ctx, id, domainEvent(EventStaffDeleted, appt_booking.AggregateStaff))
 }
 
 // ========== Staff-Service Assignment Operations ==========
 
 // AssignServiceToStaff links a service to a staff member
-func (s *ApptBookingService) AssignServiceToStaff(staffID, serviceID int) error {
+func (s *ApptBookingService) AssignServiceToStaff(ctx context.Context, staffID, serviceID int) error {
 	// Validate staff exists
-	staff, err := s.staffRepo.GetByID(staffID)
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
 	if err != nil {
 		return err
 	}
 	if staff == nil {
-		return errors.New("staff not found")
+		return NotFound("staff")
 	}
 
 	// Validate service exists
-	service, err := s.serviceRepo.GetByID(serviceID)
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		return err
 	}
 	if service == nil {
-		return errors.New("service not found")
+		return NotFound("service")
 	}
 
 	// Check if already assigned
-	existingServices, err := s.staffServiceRepo.GetServicesForStaff(staffID)
+	existingServices, err := s.staffServiceRepo.GetServicesForStaff(ctx, staffID)
 	if err != nil {
 		return err
 	}
 	for _, svc := range existingServices {
 		if svc.ID == serviceID {
-			return errors.New("service is already assigned to this staff member")
+			return Conflict("service is already assigned to this staff member", nil)
 		}
 	}
 
-	return s.staffServiceRepo.Assign(staffID, serviceID)
+	return s.staffServiceRepo.Assign(ctx, staffID, serviceID)
 }
 
 // UnassignServiceFromStaff removes a service from a staff member
-func (s *ApptBookingService) UnassignServiceFromStaff(staffID, serviceID int) error {
-	return s.staffServiceRepo.Unassign(staffID, serviceID)
+func (s *ApptBookingService) UnassignServiceFromStaff(ctx context.Context, staffID, serviceID int) error {
+	return s.staffServiceRepo.Unassign(ctx, staffID, serviceID)
 }
 
 // GetServicesForStaff retrieves all services offered by a staff member
-func (s *ApptBookingService) GetServicesForStaff(staffID int) ([]appt_booking.Service, error) {
-	return s.staffServiceRepo.GetServicesForStaff(staffID)
+func (s *ApptBookingService) GetServicesForStaff(ctx context.Context, staffID int) ([]appt_booking.Service, error) {
+	return s.staffServiceRepo.GetServicesForStaff(ctx, staffID)
 }
 
 // GetStaffForService retrieves all staff members who offer a service
-func (s *ApptBookingService) GetStaffForService(serviceID int) ([]appt_booking.Staff, error) {
-	return s.staffServiceRepo.GetStaffForService(serviceID)
+func (s *ApptBookingService) GetStaffForService(ctx context.Context, serviceID int) ([]appt_booking.Staff, error) {
+	return s.staffServiceRepo.GetStaffForService(ctx, serviceID)
 }
 
 // ========== Schedule Operations ==========
 
 // CreateSchedule creates a new schedule entry for a staff member
-func (s *ApptBookingService) CreateSchedule(staffID, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
+func (s *ApptBookingService) CreateSchedule(ctx context.Context, staffID, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
 	// Validation
 	if dayOfWeek < 0 || dayOfWeek > 6 {
-		return nil, errors.New("day of week must be between 0 (Sunday) and 6 (Saturday)")
+		return nil, Invalid("day_of_week", "day of week must be between 0 (Sunday) and 6 (Saturday)")
 	}
 	if startTime == "" || endTime == "" {
-		return nil, errors.New("start time and end time are required")
+		return nil, Invalid("start_time", "start time and end time are required")
 	}
 	// Basic time format validation (HH:MM)
 	if !isValidTimeFormat(startTime) || !isValidTimeFormat(endTime) {
-		return nil, errors.New("time must be in HH:MM format")
+		return nil, Invalid("start_time", "time must be in HH:MM format")
 	}
 
 	// Validate staff exists
-	staff, err := s.staffRepo.GetByID(staffID)
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
 	if err != nil {
 		return nil, err
 	}
 	if staff == nil {
-		return nil, errors.New("staff not found")
+		return nil, NotFound("staff")
 	}
 
 	// Parse input times to time.Time for validation
 	startTimeParsed, err := time.Parse("15:04", startTime)
 	if err != nil {
-		return nil, errors.New("invalid start time format, must be HH:MM")
+		return nil, Invalid("start_time", "invalid start time format, must be HH:MM")
 	}
 	endTimeParsed, err := time.Parse("15:04", endTime)
 	if err != nil {
-		return nil, errors.New("invalid end time format, must be HH:MM")
+		return nil, Invalid("end_time", "invalid end time format, must be HH:MM")
 	}
 
 	// Check for overlapping schedules for the same staff and day
-	existingSchedules, err := s.scheduleRepo.GetByStaff(staffID)
+	existingSchedules, err := s.scheduleRepo.GetByStaff(ctx, staffID)
 	if err != nil {
 		return nil, err
 	}
@@ -281,276 +355,566 @@ func (s *ApptBookingService) CreateSchedule(staffID, dayOfWeek int, startTime, e
 		if sch.DayOfWeek == dayOfWeek {
 			// Simple overlap check: if time ranges intersect
 			if timesOverlap(startTimeParsed, endTimeParsed, sch.StartTime, sch.EndTime) {
-				return nil, errors.New("schedule overlaps with an existing schedule for this staff member on the same day")
+				return nil, Conflict("schedule overlaps with an existing schedule for this staff member on the same day", nil)
 			}
 		}
 	}
 
-	return s.scheduleRepo.Create(staffID, dayOfWeek, startTime, endTime)
+	schedule, err := s.scheduleRepo.Create(ctx, staffID, dayOfWeek, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	s.offerFreedSlots(ctx, staffID)
+	return schedule, nil
 }
 
 // UpdateSchedule modifies an existing schedule
-func (s *ApptBookingService) UpdateSchedule(id int, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
+func (s *ApptBookingService) UpdateSchedule(ctx context.Context, id int, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error) {
 	// Validation
 	if dayOfWeek < 0 || dayOfWeek > 6 {
-		return nil, errors.New("day of week must be between 0 (Sunday) and 6 (Saturday)")
+		return nil, Invalid("day_of_week", "day of week must be between 0 (Sunday) and 6 (Saturday)")
 	}
 	if startTime == "" || endTime == "" {
-		return nil, errors.New("start time and end time are required")
+		return nil, Invalid("start_time", "start time and end time are required")
 	}
 	if !isValidTimeFormat(startTime) || !isValidTimeFormat(endTime) {
-		return nil, errors.New("time must be in HH:MM format")
+		return nil, Invalid("start_time", "time must be in HH:MM format")
 	}
 
 	// Get existing schedule to check staff ID
-	existing, err := s.scheduleRepo.GetByID(id)
+	existing, err := s.scheduleRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if existing == nil {
-		return nil, errors.New("schedule not found")
+		return nil, NotFound("schedule")
 	}
 
 	// Parse input times to time.Time for validation
 	startTimeParsed, err := time.Parse("15:04", startTime)
 	if err != nil {
-		return nil, errors.New("invalid start time format, must be HH:MM")
+		return nil, Invalid("start_time", "invalid start time format, must be HH:MM")
 	}
 	endTimeParsed, err := time.Parse("15:04", endTime)
 	if err != nil {
-		return nil, errors.New("invalid end time format, must be HH:MM")
+		return nil, Invalid("end_time", "invalid end time format, must be HH:MM")
 	}
 
 	// Check for overlapping schedules (excluding current)
-	schedules, err := s.scheduleRepo.GetByStaff(existing.StaffID)
+	schedules, err := s.scheduleRepo.GetByStaff(ctx, existing.StaffID)
 	if err != nil {
 		return nil, err
 	}
 	for _, sch := range schedules {
 		if sch.ID != id && sch.DayOfWeek == dayOfWeek {
 			if timesOverlap(startTimeParsed, endTimeParsed, sch.StartTime, sch.EndTime) {
-				return nil, errors.New("schedule overlaps with an existing schedule for this staff member on the same day")
+				return nil, Conflict("schedule overlaps with an existing schedule for this staff member on the same day", nil)
 			}
 		}
 	}
 
-	return s.scheduleRepo.Update(id, dayOfWeek, startTime, endTime)
+	updated, err := s.scheduleRepo.Update(ctx, id, dayOfWeek, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("schedule")
+	}
+	s.offerFreedSlots(ctx, existing.StaffID)
+	return updated, nil
 }
 
 // GetAllSchedules retrieves all schedules
-func (s *ApptBookingService) GetAllSchedules() ([]appt_booking.Schedule, error) {
-	return s.scheduleRepo.GetAll()
+func (s *ApptBookingService) GetAllSchedules(ctx context.Context) ([]appt_booking.Schedule, error) {
+	return s.scheduleRepo.GetAll(ctx)
 }
 
 // GetScheduleByID retrieves a schedule by ID
-func (s *ApptBookingService) GetScheduleByID(id int) (*appt_booking.Schedule, error) {
-	return s.scheduleRepo.GetByID(id)
+func (s *ApptBookingService) GetScheduleByID(ctx context.Context, id int) (*appt_booking.Schedule, error) {
+	found, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("schedule")
+	}
+	return found, nil
 }
 
 // GetSchedulesByStaff retrieves all schedules for a staff member
-func (s *ApptBookingService) GetSchedulesByStaff(staffID int) ([]appt_booking.Schedule, error) {
-	return s.scheduleRepo.GetByStaff(staffID)
+func (s *ApptBookingService) GetSchedulesByStaff(ctx context.Context, staffID int) ([]appt_booking.Schedule, error) {
+	return s.scheduleRepo.GetByStaff(ctx, staffID)
 }
 
 // DeleteSchedule removes a schedule
-func (s *ApptBookingService) DeleteSchedule(id int) error {
-	return s.scheduleRepo.Delete(id)
+func (s *ApptBookingService) DeleteSchedule(ctx context.Context, id int) error {
+	return s.scheduleRepo.Delete(ctx, id)
+}
+
+// ========== Schedule Exception Operations ==========
+
+// CreateScheduleException creates time off, a holiday closure or one-off hours.
+// staffID nil makes the exception business-wide. Dates are YYYY-MM-DD (inclusive);
+// startTime/endTime are HH:MM and required unless the exception is closed.
+func (s *ApptBookingService) CreateScheduleException(ctx context.Context, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime, reason string) (*appt_booking.ScheduleException, error) {
+	start, end, err := s.validateScheduleException(ctx, staffID, startDate, endDate, isClosed, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.exceptionRepo.Create(ctx, staffID, startDate, endDate, isClosed, start, end, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !isClosed {
+		s.offerFreedSlots(ctx, exceptionStaffID(created))
+	}
+	return created, nil
+}
+
+// UpdateScheduleException modifies an existing schedule exception
+func (s *ApptBookingService) UpdateScheduleException(ctx context.Context, id int, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime, reason string) (*appt_booking.ScheduleException, error) {
+	existing, err := s.exceptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, NotFound("schedule exception")
+	}
+
+	start, end, err := s.validateScheduleException(ctx, staffID, startDate, endDate, isClosed, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.exceptionRepo.Update(ctx, id, staffID, startDate, endDate, isClosed, start, end, reason)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("schedule exception")
+	}
+	// Narrowing or moving an exception can open time for the staff it applied to before
+	if staffID := exceptionStaffID(updated); staffID == exceptionStaffID(existing) {
+		s.offerFreedSlots(ctx, staffID)
+	} else {
+		s.offerFreedSlots(ctx, 0)
+	}
+	return updated, nil
+}
+
+// GetAllScheduleExceptions retrieves all schedule exceptions
+func (s *ApptBookingService) GetAllScheduleExceptions(ctx context.Context) ([]appt_booking.ScheduleException, error) {
+	return s.exceptionRepo.GetAll(ctx)
+}
+
+// GetScheduleExceptionByID retrieves a schedule exception by ID
+func (s *ApptBookingService) GetScheduleExceptionByID(ctx context.Context, id int) (*appt_booking.ScheduleException, error) {
+	found, err := s.exceptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("schedule exception")
+	}
+	return found, nil
+}
+
+// GetScheduleExceptionsByStaff retrieves the exceptions that apply to a staff member, including business-wide ones
+func (s *ApptBookingService) GetScheduleExceptionsByStaff(ctx context.Context, staffID int) ([]appt_booking.ScheduleException, error) {
+	return s.exceptionRepo.GetByStaff(ctx, staffID)
+}
+
+// DeleteScheduleException removes a schedule exception
+func (s *ApptBookingService) DeleteScheduleException(ctx context.Context, id int) error {
+	existing, err := s.exceptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.exceptionRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if existing != nil {
+		s.offerFreedSlots(ctx, exceptionStaffID(existing))
+	}
+	return nil
+}
+
+// exceptionStaffID returns the staff member an exception applies to, or 0 if it is business-wide
+func exceptionStaffID(e *appt_booking.ScheduleException) int {
+	if e.StaffID == nil {
+		return 0
+	}
+	return *e.StaffID
+}
+
+// validateScheduleException checks a schedule exception's fields and returns the
+// start/end times to store (nil for closed exceptions)
+func (s *ApptBookingService) validateScheduleException(ctx context.Context, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime string) (*string, *string, error) {
+	startParsed, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, nil, Invalid("start_date", "start date must be in YYYY-MM-DD format")
+	}
+	endParsed, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, nil, Invalid("end_date", "end date must be in YYYY-MM-DD format")
+	}
+	if endParsed.Before(startParsed) {
+		return nil, nil, Invalid("end_date", "end date cannot be before start date")
+	}
+
+	if staffID != nil {
+		staff, err := s.staffRepo.GetByID(ctx, *staffID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if staff == nil {
+			return nil, nil, NotFound("staff")
+		}
+	}
+
+	if isClosed {
+		if startTime != "" || endTime != "" {
+			return nil, nil, Invalid("is_closed", "closed exceptions cannot have start or end times")
+		}
+		return nil, nil, nil
+	}
+
+	if startTime == "" || endTime == "" {
+		return nil, nil, Invalid("start_time", "start time and end time are required unless the exception is closed")
+	}
+	if !isValidTimeFormat(startTime) || !isValidTimeFormat(endTime) {
+		return nil, nil, Invalid("start_time", "time must be in HH:MM format")
+	}
+	// HH:MM strings compare chronologically
+	if startTime >= endTime {
+		return nil, nil, Invalid("end_time", "start time must be before end time")
+	}
+	return &startTime, &endTime, nil
 }
 
 // ========== Appointment Operations ==========
 
 // BookAppointment creates a new appointment with conflict checking
 func (s *ApptBookingService) BookAppointment(
+	ctx context.Context,
 	customerName, customerEmail, customerPhone string,
 	staffID, serviceID int,
 	appointmentDatetime time.Time,
 	notes string,
 ) (*appt_booking.Appointment, error) {
+	staff, service, err := s.validateBooking(ctx, customerName, customerEmail, staffID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBookingPolicy(service, staff, appointmentDatetime, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Check the staff member offers the service and is working at that time
+	if err := s.checkStaffAvailableFor(ctx, staff, serviceID, appointmentDatetime, service.DurationMin); err != nil {
+		return nil, err
+	}
+
+	// Link the appointment to the customer with this email, creating them on first booking.
+	// Customers over the no-show limit cannot book for themselves.
+	customer, err := s.bookingCustomer(ctx, customerName, customerEmail, customerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createAppointment(ctx, customer.ID, customerName, customerEmail, customerPhone, staff, service, appointmentDatetime, notes)
+}
+
+// validateBooking checks the booking input and loads the staff member and service
+func (s *ApptBookingService) validateBooking(ctx context.Context, customerName, customerEmail string, staffID, serviceID int) (*appt_booking.Staff, *appt_booking.Service, error) {
 	// Validation
 	if customerName == "" {
-		return nil, errors.New("customer name is required")
+		return nil, nil, Invalid("customer_name", "customer name is required")
 	}
 	if customerEmail == "" {
-		return nil, errors.New("customer email is required")
+		return nil, nil, Invalid("customer_email", "customer email is required")
 	}
 	if !contains(customerEmail, "@") {
-		return nil, errors.New("invalid email format")
+		return nil, nil, Invalid("customer_email", "invalid email format")
 	}
 	if staffID <= 0 {
-		return nil, errors.New("valid staff ID is required")
+		return nil, nil, Invalid("staff_id", "valid staff ID is required")
 	}
 	if serviceID <= 0 {
-		return nil, errors.New("valid service ID is required")
+		return nil, nil, Invalid("service_id", "valid service ID is required")
 	}
 
 	// Validate staff exists
-	staff, err := s.staffRepo.GetByID(staffID)
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if staff == nil {
-		return nil, errors.New("staff not found")
+		return nil, nil, NotFound("staff")
 	}
 
 	// Validate service exists
-	service, err := s.serviceRepo.GetByID(serviceID)
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if service == nil {
-		return nil, errors.New("service not found")
+		return nil, nil, NotFound("service")
+	}
+	return staff, service, nil
+}
+
+// createAppointment inserts a confirmed appointment once the slot has been checked against
+// the staff member's working hours. The conflict check and insert run atomically so
+// concurrent requests for the same slot cannot both succeed.
+func (s *ApptBookingService) createAppointment(ctx context.Context, customerID int, customerName, customerEmail, customerPhone string, staff *appt_booking.Staff, service *appt_booking.Service, appointmentDatetime time.Time, notes string) (*appt_booking.Appointment, error) {
+	appointment, err := s.appointmentRepo.CreateExclusive(ctx,
+		customerID,
+		customerName,
+		customerEmail,
+		customerPhone,
+		staff.ID,
+		service.ID,
+		service.DurationMin,
+		appointmentDatetime,
+		appt_booking.AppointmentStatusConfirmed,
+		notes,
+		domainEvent(EventAppointmentBooked, appt_booking.AggregateAppointment),
+	)
+	if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict(err.Error(), err)
 	}
+	return appointment, err
+}
 
+// checkStaffAvailableFor verifies that staff offers the service, that [start, start+duration)
+// lies within their working hours, and that the service's buffers around it are clear of
+// busy times in their external calendars. Overlap with other appointments is checked
+// separately, atomically with the write.
+func (s *ApptBookingService) checkStaffAvailableFor(ctx context.Context, staff *appt_booking.Staff, serviceID int, start time.Time, durationMinutes int) error {
 	// Check if staff offers this service
-	staffServices, err := s.staffServiceRepo.GetServicesForStaff(staffID)
+	staffServices, err := s.staffServiceRepo.GetServicesForStaff(ctx, staff.ID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	serviceOffered := false
-	for _, svc := range staffServices {
-		if svc.ID == serviceID {
-			serviceOffered = true
+	var service *appt_booking.Service
+	for i := range staffServices {
+		if staffServices[i].ID == serviceID {
+			service = &staffServices[i]
 			break
 		}
 	}
-	if !serviceOffered {
-		return nil, errors.New("staff member does not offer this service")
+	if service == nil {
+		return PreconditionFailed("staff member does not offer this service", nil)
 	}
 
-	// Check staff schedule for the appointment day/time
-	// Convert appointment from UTC to staff's local timezone (America/Los_Angeles)
-	// Schedules are stored in local time, so we need to compare apples-to-apples
-	// TODO: Add DB field for "staff"'s local timezone
-	loc, _ := time.LoadLocation("America/Los_Angeles")
-	appointmentLocal := appointmentDatetime.In(loc)
-	appointmentDay := int(appointmentLocal.Weekday())
-	// Go's time.Weekday: Sunday=0, Monday=1, etc. matches our schema
-	schedules, err := s.scheduleRepo.GetByStaff(staffID)
+	// Check staff schedule and schedule exceptions for the appointment day/time
+	// Schedules are stored as wall-clock times in the staff member's timezone
+	schedules, err := s.scheduleRepo.GetByStaff(ctx, staff.ID)
+	if err != nil {
+		return err
+	}
+	loc := s.staffLocation(staff)
+	date := start.In(loc).Format("2006-01-02")
+	exceptions, err := s.exceptionRepo.GetApplicable(ctx, staff.ID, date, date)
+	if err != nil {
+		return err
+	}
+	end := start.Add(time.Duration(durationMinutes) * time.Minute)
+	if !fitsSchedule(schedules, exceptions, loc, start, end) {
+		return PreconditionFailed("appointment time is outside staff member's working hours", nil)
+	}
+
+	// Busy times imported from the staff member's own calendars
+	before, after := service.Buffers()
+	busy, err := s.externalRepo.GetBusyByStaffBetween(ctx, staff.ID, start.Add(-before), end.Add(after))
+	if err != nil {
+		return err
+	}
+	if len(busy) > 0 {
+		return Conflict("appointment time overlaps a busy time in the staff member's calendar", nil)
+	}
+	return nil
+}
+
+// RescheduleAppointment moves an appointment to a new datetime and/or staff member.
+// If staffID is 0 the current staff member is kept. The previous time and staff member
+// are recorded in the appointment's reschedule history.
+func (s *ApptBookingService) RescheduleAppointment(ctx context.Context, id, staffID int, appointmentDatetime time.Time) (*appt_booking.Appointment, error) {
+	appt, err := s.appointmentRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	appointmentEndTimeLocal := appointmentLocal.Add(time.Duration(service.DurationMin) * time.Minute)
+	if appt == nil {
+		return nil, NotFound("appointment")
+	}
 
-	isWithinSchedule := false
-	for _, sch := range schedules {
-		if sch.DayOfWeek == appointmentDay {
-			if isTimeWithin(sch.StartTime, sch.EndTime, appointmentLocal, appointmentEndTimeLocal) {
-				isWithinSchedule = true
-				break
-			}
-		}
+	if !isReschedulable(appt.Status) {
+		return nil, PreconditionFailed(fmt.Sprintf("cannot reschedule a %s appointment", appt.Status), ErrInvalidStatusTransition)
 	}
 
-	if !isWithinSchedule {
-		return nil, errors.New("appointment time is outside staff member's working hours")
+	if staffID <= 0 {
+		staffID = appt.StaffID
+	}
+	if staffID == appt.StaffID && appointmentDatetime.Equal(appt.AppointmentDatetime) {
+		return nil, Invalid("appointment_datetime", "appointment is already scheduled at this time with this staff member")
 	}
 
-	// Check for conflicts with existing appointments
-	hasConflict, err := s.appointmentRepo.CheckConflict(staffID, appointmentDatetime, service.DurationMin)
+	// Validate staff exists
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
 	if err != nil {
 		return nil, err
 	}
-	if hasConflict {
-		return nil, errors.New("appointment time conflicts with an existing appointment")
+	if staff == nil {
+		return nil, NotFound("staff")
 	}
 
-	// Create the appointment
-	return s.appointmentRepo.Create(
-		customerName,
-		customerEmail,
-		customerPhone,
-		staffID,
-		serviceID,
-		service.DurationMin,
-		appointmentDatetime,
-		"confirmed",
-		notes,
-	)
+	service, err := s.serviceRepo.GetByID(ctx, appt.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, NotFound("service")
+	}
+	if err := s.checkBookingPolicy(service, staff, appointmentDatetime, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Keep the duration the appointment was booked with
+	if err := s.checkStaffAvailableFor(ctx, staff, appt.ServiceID, appointmentDatetime, appt.DurationMinutes); err != nil {
+		return nil, err
+	}
+
+	rescheduled, err := s.appointmentRepo.RescheduleExclusive(ctx, id, staffID, appointmentDatetime, appt.DurationMinutes,
+		domainEvent(EventAppointmentRescheduled, appt_booking.AggregateAppointment))
+	if errors.Is(err, appt_booking.ErrAppointmentStatusChanged) {
+		// Cancelled or completed since it was read above
+		return nil, PreconditionFailed(err.Error(), ErrInvalidStatusTransition)
+	}
+	if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict(err.Error(), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rescheduled == nil {
+		return nil, NotFound("appointment")
+	}
+	// The old slot is free now
+	s.offerFreedSlots(ctx, appt.StaffID)
+	return rescheduled, nil
+}
+
+// GetAppointmentReschedules retrieves the reschedule history of an appointment, most recent first
+func (s *ApptBookingService) GetAppointmentReschedules(ctx context.Context, id int) ([]appt_booking.AppointmentReschedule, error) {
+	return s.appointmentRepo.GetReschedules(ctx, id)
 }
 
 // GetAppointment retrieves an appointment by ID
-func (s *ApptBookingService) GetAppointment(id int) (*appt_booking.Appointment, error) {
-	return s.appointmentRepo.GetByID(id)
+func (s *ApptBookingService) GetAppointment(ctx context.Context, id int) (*appt_booking.Appointment, error) {
+	found, err := s.appointmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("appointment")
+	}
+	return found, nil
 }
 
 // GetAppointmentsByStaff retrieves all appointments for a staff member
-func (s *ApptBookingService) GetAppointmentsByStaff(staffID int) ([]appt_booking.Appointment, error) {
-	return s.appointmentRepo.GetByStaff(staffID)
+func (s *ApptBookingService) GetAppointmentsByStaff(ctx context.Context, staffID int) ([]appt_booking.Appointment, error) {
+	return s.appointmentRepo.GetByStaff(ctx, staffID)
 }
 
 // GetAppointmentsByCustomer retrieves all appointments for a customer by email
-func (s *ApptBookingService) GetAppointmentsByCustomer(email string) ([]appt_booking.Appointment, error) {
-	return s.appointmentRepo.GetByCustomerEmail(email)
+func (s *ApptBookingService) GetAppointmentsByCustomer(ctx context.Context, email string) ([]appt_booking.Appointment, error) {
+	return s.appointmentRepo.GetByCustomerEmail(ctx, email)
 }
 
 // GetUpcomingAppointments retrieves upcoming appointments
-func (s *ApptBookingService) GetUpcomingAppointments(limit int) ([]appt_booking.Appointment, error) {
+func (s *ApptBookingService) GetUpcomingAppointments(ctx context.Context, limit int) ([]appt_booking.Appointment, error) {
 	if limit <= 0 {
 		limit = 50 // default
 	}
-	return s.appointmentRepo.GetUpcoming(limit)
+	return s.appointmentRepo.GetUpcoming(ctx, limit)
 }
 
-// CancelAppointment cancels an appointment
-func (s *ApptBookingService) CancelAppointment(id int) error {
-	// Check if appointment exists
-	appt, err := s.appointmentRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-	if appt == nil {
-		return errors.New("appointment not found")
-	}
-
-	if appt.Status == "cancelled" {
-		return errors.New("appointment is already cancelled")
-	}
-
-	return s.appointmentRepo.Cancel(id)
+// CompleteAppointment marks an appointment as completed, recording the request's principal
+// as who completed it
+func (s *ApptBookingService) CompleteAppointment(ctx context.Context, id int) error {
+	_, err := s.TransitionAppointment(ctx, id, appt_booking.AppointmentStatusCompleted, principalEmail(ctx), "")
+	return err
 }
 
-// CompleteAppointment marks an appointment as completed
-func (s *ApptBookingService) CompleteAppointment(id int) error {
-	appt, err := s.appointmentRepo.GetByID(id)
+// LocationForStaff returns the timezone used to present a staff member's appointments
+func (s *ApptBookingService) LocationForStaff(ctx context.Context, staffID int) (*time.Location, error) {
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
 	if err != nil {
-		return err
-	}
-	if appt == nil {
-		return errors.New("appointment not found")
+		return nil, err
 	}
+	return s.staffLocation(staff), nil
+}
 
-	if appt.Status == "completed" {
-		return errors.New("appointment is already completed")
+// StaffLocations returns the timezone of every staff member keyed by staff ID
+func (s *ApptBookingService) StaffLocations(ctx context.Context) (map[int]*time.Location, error) {
+	staffList, err := s.staffRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if appt.Status == "cancelled" {
-		return errors.New("cannot complete a cancelled appointment")
+	locations := make(map[int]*time.Location, len(staffList))
+	for i := range staffList {
+		locations[staffList[i].ID] = s.staffLocation(&staffList[i])
 	}
+	return locations, nil
+}
 
-	return s.appointmentRepo.Complete(id)
+// DefaultLocation returns the business default timezone
+func (s *ApptBookingService) DefaultLocation() *time.Location {
+	return s.defaultLocation
 }
 
 // GetAppointmentsWithDetails retrieves all appointments with service price for revenue calculation
-func (s *ApptBookingService) GetAppointmentsWithDetails() ([]appt_booking.AppointmentWithService, error) {
-	return s.appointmentRepo.GetAllWithServiceDetails()
+func (s *ApptBookingService) GetAppointmentsWithDetails(ctx context.Context) ([]appt_booking.AppointmentWithService, error) {
+	return s.appointmentRepo.GetAllWithServiceDetails(ctx)
 }
 
 // GetAppointmentsByStaffWithDetails retrieves appointments for a staff member with service price
-func (s *ApptBookingService) GetAppointmentsByStaffWithDetails(staffID int) ([]appt_booking.AppointmentWithService, error) {
-	return s.appointmentRepo.GetByStaffWithServiceDetails(staffID)
+func (s *ApptBookingService) GetAppointmentsByStaffWithDetails(ctx context.Context, staffID int) ([]appt_booking.AppointmentWithService, error) {
+	return s.appointmentRepo.GetByStaffWithServiceDetails(ctx, staffID)
 }
 
 // GetAppointmentsByCustomerWithDetails retrieves appointments for a customer with service price
-func (s *ApptBookingService) GetAppointmentsByCustomerWithDetails(email string) ([]appt_booking.AppointmentWithService, error) {
-	return s.appointmentRepo.GetByCustomerEmailWithServiceDetails(email)
+func (s *ApptBookingService) GetAppointmentsByCustomerWithDetails(ctx context.Context, email string) ([]appt_booking.AppointmentWithService, error) {
+	return s.appointmentRepo.GetByCustomerEmailWithServiceDetails(ctx, email)
 }
 
 // GetUpcomingAppointmentsWithDetails retrieves upcoming appointments with service price
-func (s *ApptBookingService) GetUpcomingAppointmentsWithDetails(limit int) ([]appt_booking.AppointmentWithService, error) {
-	return s.appointmentRepo.GetUpcomingWithServiceDetails(limit)
+func (s *ApptBookingService) GetUpcomingAppointmentsWithDetails(ctx context.Context, limit int) ([]appt_booking.AppointmentWithService, error) {
+	return s.appointmentRepo.GetUpcomingWithServiceDetails(ctx, limit)
 }
 
 // ========== Helper Functions ==========
 
+// staffLocation returns the timezone a staff member's schedule is expressed in,
+// falling back to the business default when none is set
+func (s *ApptBookingService) staffLocation(staff *appt_booking.Staff) *time.Location {
+	if staff != nil && staff.Timezone != "" {
+		if loc, err := time.LoadLocation(staff.Timezone); err == nil {
+			return loc
+		}
+	}
+	return s.defaultLocation
+}
+
+// isValidTimezone checks if tz is empty (business default) or a loadable IANA name
+func isValidTimezone(tz string) bool {
+	if tz == "" {
+		return true
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
 // isValidTimeFormat checks if time string is in HH:MM format
 func isValidTimeFormat(t string) bool {
 	if len(t) != 5 {
@@ -593,19 +957,96 @@ func timesOverlap(start1, end1, start2, end2 time.Time) bool {
 	return s1 < e2 && s2 < e1
 }
 
-// isTimeWithin checks if an appointment time range fits within a schedule slot
-// scheduleStart/End: schedule time-of-day (time.Time with zero date)
-// apptStart/End: appointment datetime (full time.Time, but only time-of-day considered)
-func isTimeWithin(scheduleStart, scheduleEnd, apptStart, apptEnd time.Time) bool {
-	toMinutes := func(t time.Time) int {
-		return t.Hour()*60 + t.Minute()
+// clockOn returns the instant the time-of-day clock occurs on the calendar date of day in loc.
+// Using time.Date keeps it correct across DST changes.
+func clockOn(day time.Time, clock time.Time, loc *time.Location) time.Time {
+	d := day.In(loc)
+	return time.Date(d.Year(), d.Month(), d.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+}
+
+// exceptionCovers checks if a schedule exception applies to the YYYY-MM-DD date
+func exceptionCovers(e appt_booking.ScheduleException, date string) bool {
+	return e.StartDate.Format("2006-01-02") <= date && date <= e.EndDate.Format("2006-01-02")
+}
+
+// workingWindows returns the intervals a staff member works on the calendar date of day in loc.
+// Exceptions are applied on top of the weekly schedule:
+//   - any closed exception (staff or business-wide) removes all hours that day
+//   - staff alternate hours replace the weekly schedule for that day
+//   - business-wide alternate hours limit whatever hours remain to the business's hours
+func workingWindows(schedules []appt_booking.Schedule, exceptions []appt_booking.ScheduleException, day time.Time, loc *time.Location) []timeRange {
+	date := day.In(loc).Format("2006-01-02")
+
+	var staffHours, businessHours []timeRange
+	for _, e := range exceptions {
+		if !exceptionCovers(e, date) {
+			continue
+		}
+		if e.IsClosed {
+			return nil
+		}
+		if e.StartTime == nil || e.EndTime == nil {
+			continue
+		}
+		window := timeRange{start: clockOn(day, *e.StartTime, loc), end: clockOn(day, *e.EndTime, loc)}
+		if e.StaffID == nil {
+			businessHours = append(businessHours, window)
+		} else {
+			staffHours = append(staffHours, window)
+		}
+	}
+
+	windows := staffHours
+	if len(windows) == 0 {
+		// Go's time.Weekday: Sunday=0, Monday=1, etc. matches our schema
+		weekday := int(day.In(loc).Weekday())
+		for _, sch := range schedules {
+			if sch.DayOfWeek == weekday {
+				windows = append(windows, timeRange{start: clockOn(day, sch.StartTime, loc), end: clockOn(day, sch.EndTime, loc)})
+			}
+		}
+	}
+
+	if len(businessHours) > 0 {
+		var limited []timeRange
+		for _, w := range windows {
+			for _, b := range businessHours {
+				if w.overlaps(b) {
+					limited = append(limited, timeRange{start: laterOf(w.start, b.start), end: earlierOf(w.end, b.end)})
+				}
+			}
+		}
+		windows = limited
+	}
+
+	return windows
+}
+
+// fitsSchedule checks if the appointment [start, end) lies fully within one of the
+// staff member's working windows on its local date
+func fitsSchedule(schedules []appt_booking.Schedule, exceptions []appt_booking.ScheduleException, loc *time.Location, start, end time.Time) bool {
+	for _, window := range workingWindows(schedules, exceptions, start, loc) {
+		if !start.Before(window.start) && !end.After(window.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// laterOf returns the later of two instants
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// earlierOf returns the earlier of two instants
+func earlierOf(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
 	}
-	ss := toMinutes(scheduleStart)
-	se := toMinutes(scheduleEnd)
-	as := toMinutes(apptStart)
-	ae := toMinutes(apptEnd)
-	// Appointment must be fully within schedule
-	return as >= ss && ae <= se
+	return b
 }
 
 // contains checks if a string contains a substring
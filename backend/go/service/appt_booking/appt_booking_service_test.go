@@ -0,0 +1,584 @@
+package appt_booking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+	"k8s-fullstack-blueprint-backend/db/appt_booking/memory"
+)
+
+// The in-memory repositories must keep satisfying the service's interfaces
+var (
+	_ ServiceRepository           = (*memory.ServiceRepository)(nil)
+	_ StaffRepository             = (*memory.StaffRepository)(nil)
+	_ StaffServiceRepository      = (*memory.StaffServiceRepository)(nil)
+	_ ScheduleRepository          = (*memory.ScheduleRepository)(nil)
+	_ ScheduleExceptionRepository = (*memory.ScheduleExceptionRepository)(nil)
+	_ AppointmentRepository       = (*memory.AppointmentRepository)(nil)
+	_ CustomerRepository          = (*memory.CustomerRepository)(nil)
+	_ WaitlistRepository          = (*memory.WaitlistRepository)(nil)
+	_ HoldRepository              = (*memory.HoldRepository)(nil)
+	_ UserRepository              = (*memory.UserRepository)(nil)
+	_ OutboxRepository            = (*memory.OutboxRepository)(nil)
+	_ WebhookRepository           = (*memory.WebhookRepository)(nil)
+	_ NotificationRepository      = (*memory.NotificationRepository)(nil)
+	_ JobRepository               = (*memory.JobRepository)(nil)
+	_ ReminderRepository          = (*memory.ReminderRepository)(nil)
+	_ CalendarFeedRepository      = (*memory.CalendarFeedRepository)(nil)
+	_ ExternalCalendarRepository  = (*memory.ExternalCalendarRepository)(nil)
+)
+
+func TestFitsSchedule_DST(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Sunday 01:00-04:00 local
+	schedules := []appt_booking.Schedule{
+		{StaffID: 1, DayOfWeek: 0, StartTime: clock("01:00"), EndTime: clock("04:00")},
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		dur   time.Duration
+		want  bool
+	}{
+		// 2024-03-10 02:00 PST becomes 03:00 PDT
+		{"spring forward: before gap", time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), time.Hour, true},             // 01:00-03:00 PDT
+		{"spring forward: ends at window end", time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC), time.Hour, true},    // 03:00-04:00 PDT
+		{"spring forward: past window end", time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC), time.Hour, false},     // 03:30-04:30 PDT
+		{"spring forward: 3h no longer fits", time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), 3 * time.Hour, false}, // window is 2 real hours
+		{"fall back: 3h fits", time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC), 3 * time.Hour, true},                 // 01:00 PDT-03:00 PST
+		{"fall back: second 01:30", time.Date(2024, 11, 3, 9, 30, 0, 0, time.UTC), 30 * time.Minute, true},        // 01:30 PST
+		{"fall back: before window", time.Date(2024, 11, 3, 7, 30, 0, 0, time.UTC), 30 * time.Minute, false},      // 00:30 PDT
+		{"ordinary Sunday", time.Date(2024, 6, 2, 8, 0, 0, 0, time.UTC), time.Hour, true},                         // 01:00-02:00 PDT
+		{"wrong weekday", time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC), time.Hour, false},                          // Monday
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitsSchedule(schedules, nil, la, tt.start, tt.start.Add(tt.dur)); got != tt.want {
+				t.Errorf("fitsSchedule(%s) = %v, want %v", tt.start.In(la), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidTimezone(t *testing.T) {
+	if !isValidTimezone("") {
+		t.Error("expected empty timezone (business default) to be valid")
+	}
+	if !isValidTimezone("UTC") {
+		t.Error("expected UTC to be valid")
+	}
+	if isValidTimezone("Not/AZone") {
+		t.Error("expected unknown timezone to be invalid")
+	}
+}
+
+func TestWorkingWindows_Exceptions(t *testing.T) {
+	staffID := 1
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	hhmm := func(s string) *time.Time {
+		c := clock(s)
+		return &c
+	}
+	// Monday-Friday 09:00-17:00
+	var schedules []appt_booking.Schedule
+	for day := 1; day <= 5; day++ {
+		schedules = append(schedules, appt_booking.Schedule{StaffID: staffID, DayOfWeek: day, StartTime: clock("09:00"), EndTime: clock("17:00")})
+	}
+
+	tests := []struct {
+		name       string
+		day        time.Time
+		exceptions []appt_booking.ScheduleException
+		want       []string
+	}{
+		{
+			name: "weekly schedule without exceptions",
+			day:  date("2024-06-03"),
+			want: []string{"09:00-17:00"},
+		},
+		{
+			name: "staff vacation closes the day",
+			day:  date("2024-06-04"),
+			exceptions: []appt_booking.ScheduleException{
+				{StaffID: &staffID, StartDate: date("2024-06-03"), EndDate: date("2024-06-07"), IsClosed: true},
+			},
+			want: nil,
+		},
+		{
+			name: "exception outside its date range is ignored",
+			day:  date("2024-06-10"),
+			exceptions: []appt_booking.ScheduleException{
+				{StaffID: &staffID, StartDate: date("2024-06-03"), EndDate: date("2024-06-07"), IsClosed: true},
+			},
+			want: []string{"09:00-17:00"},
+		},
+		{
+			name: "business-wide holiday closes the day",
+			day:  date("2024-07-04"),
+			exceptions: []appt_booking.ScheduleException{
+				{StartDate: date("2024-07-04"), EndDate: date("2024-07-04"), IsClosed: true},
+			},
+			want: nil,
+		},
+		{
+			name: "staff extra hours on a Saturday",
+			day:  date("2024-06-08"),
+			exceptions: []appt_booking.ScheduleException{
+				{StaffID: &staffID, StartDate: date("2024-06-08"), EndDate: date("2024-06-08"), StartTime: hhmm("10:00"), EndTime: hhmm("14:00")},
+			},
+			want: []string{"10:00-14:00"},
+		},
+		{
+			name: "staff alternate hours replace weekly schedule",
+			day:  date("2024-06-03"),
+			exceptions: []appt_booking.ScheduleException{
+				{StaffID: &staffID, StartDate: date("2024-06-03"), EndDate: date("2024-06-03"), StartTime: hhmm("12:00"), EndTime: hhmm("20:00")},
+			},
+			want: []string{"12:00-20:00"},
+		},
+		{
+			name: "business-wide short hours limit weekly schedule",
+			day:  date("2024-12-24"),
+			exceptions: []appt_booking.ScheduleException{
+				{StartDate: date("2024-12-24"), EndDate: date("2024-12-24"), StartTime: hhmm("08:00"), EndTime: hhmm("13:00")},
+			},
+			want: []string{"09:00-13:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := workingWindows(schedules, tt.exceptions, tt.day, time.UTC)
+			if len(windows) != len(tt.want) {
+				t.Fatalf("expected %d windows, got %d: %v", len(tt.want), len(windows), windows)
+			}
+			for i, w := range windows {
+				if got := w.start.Format("15:04") + "-" + w.end.Format("15:04"); got != tt.want[i] {
+					t.Errorf("window %d: expected %s, got %s", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+// testFixture is a service backed by an in-memory store with one staff member who works
+// Monday 09:00-17:00 UTC and offers a 60 minute service
+type testFixture struct {
+	ctx     context.Context
+	svc     *ApptBookingService
+	store   *memory.Store
+	staff   *appt_booking.Staff
+	service *appt_booking.Service
+}
+
+// monday is a Monday far enough ahead that bookings are always in the future
+var monday = time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+
+func newTestFixture(t *testing.T) *testFixture {
+	t.Helper()
+	store := memory.NewStore()
+	f := &testFixture{
+		ctx:   context.Background(),
+		store: store,
+		svc: NewApptBookingService(store.Services(), store.Staff(), store.StaffServices(),
+			store.Schedules(), store.Appointments(), store.ScheduleExceptions(), store.Customers(), store.Series(),
+			store.Waitlist(), store.Holds(), store.ExternalCalendars(), HoldConfig{}, CancellationConfig{}, time.UTC),
+	}
+
+	var err error
+	if f.staff, err = f.svc.CreateStaff(f.ctx, "Alice", "alice@example.com", "", "provider", ""); err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+	if f.service, err = f.svc.CreateService(f.ctx, "Haircut", "", 60, 3000, appt_booking.BookingPolicy{}); err != nil {
+		t.Fatalf("create service: %v", err)
+	}
+	if err := f.svc.AssignServiceToStaff(f.ctx, f.staff.ID, f.service.ID); err != nil {
+		t.Fatalf("assign service: %v", err)
+	}
+	if _, err := f.svc.CreateSchedule(f.ctx, f.staff.ID, 1, "09:00", "17:00"); err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	return f
+}
+
+// book books the fixture's service with the fixture's staff member at monday+offset
+func (f *testFixture) book(t *testing.T, offset time.Duration) *appt_booking.Appointment {
+	t.Helper()
+	a, err := f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(offset), "")
+	if err != nil {
+		t.Fatalf("book appointment: %v", err)
+	}
+	return a
+}
+
+// checkKind fails the test unless err is a domain error of kind want ("" meaning no error)
+func checkKind(t *testing.T, err error, want ErrorKind) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if got := KindOf(err); got != want {
+		t.Fatalf("expected %s error, got %v", want, err)
+	}
+}
+
+// bookingRequest holds the BookAppointment arguments a test case may change
+type bookingRequest struct {
+	customer, email    string
+	staffID, serviceID int
+	at                 time.Time
+}
+
+func TestBookAppointment(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the store and adjusts the request, which defaults to booking
+		// the fixture's staff member and service at 10:00 on Monday
+		setup    func(t *testing.T, f *testFixture, r *bookingRequest)
+		wantKind ErrorKind
+	}{
+		{
+			name: "within working hours",
+		},
+		{
+			name:  "ends at close of business",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) { r.at = monday.Add(16 * time.Hour) },
+		},
+		{
+			name:     "missing customer name",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.customer = "" },
+			wantKind: KindValidation,
+		},
+		{
+			name:     "invalid customer email",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.email = "carol.example.com" },
+			wantKind: KindValidation,
+		},
+		{
+			name:     "unknown staff",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.staffID = 9999 },
+			wantKind: KindNotFound,
+		},
+		{
+			name:     "unknown service",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.serviceID = 9999 },
+			wantKind: KindNotFound,
+		},
+		{
+			name: "service not offered by staff",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) {
+				other, err := f.svc.CreateService(f.ctx, "Colouring", "", 90, 8000, appt_booking.BookingPolicy{})
+				if err != nil {
+					t.Fatalf("create service: %v", err)
+				}
+				r.serviceID = other.ID
+			},
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name:     "before opening",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.at = monday.Add(8 * time.Hour) },
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name: "runs past closing",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) {
+				r.at = monday.Add(16*time.Hour + 30*time.Minute)
+			},
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name:     "day without schedule",
+			setup:    func(t *testing.T, f *testFixture, r *bookingRequest) { r.at = monday.Add(34 * time.Hour) }, // Tuesday 10:00
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name: "staff day off",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) {
+				staffID := f.staff.ID
+				if _, err := f.svc.CreateScheduleException(f.ctx, &staffID, "2030-01-07", "2030-01-07", true, "", "", "vacation"); err != nil {
+					t.Fatalf("create exception: %v", err)
+				}
+			},
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name: "overlaps existing appointment",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) {
+				f.book(t, 9*time.Hour+30*time.Minute)
+			},
+			wantKind: KindConflict,
+		},
+		{
+			name:  "starts when existing appointment ends",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) { f.book(t, 9*time.Hour) },
+		},
+		{
+			name: "slot of a cancelled appointment",
+			setup: func(t *testing.T, f *testFixture, r *bookingRequest) {
+				a := f.book(t, 10*time.Hour)
+				if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+					t.Fatalf("cancel appointment: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			r := &bookingRequest{
+				customer:  "Carol",
+				email:     "carol@example.com",
+				staffID:   f.staff.ID,
+				serviceID: f.service.ID,
+				at:        monday.Add(10 * time.Hour),
+			}
+			if tt.setup != nil {
+				tt.setup(t, f, r)
+			}
+
+			a, err := f.svc.BookAppointment(f.ctx, r.customer, r.email, "", r.staffID, r.serviceID, r.at, "")
+			checkKind(t, err, tt.wantKind)
+			if tt.wantKind != "" {
+				return
+			}
+			if !a.AppointmentDatetime.Equal(r.at) {
+				t.Errorf("expected appointment at %s, got %s", r.at, a.AppointmentDatetime)
+			}
+			if a.DurationMinutes != f.service.DurationMin {
+				t.Errorf("expected duration %d, got %d", f.service.DurationMin, a.DurationMinutes)
+			}
+			if a.Status != appt_booking.AppointmentStatusConfirmed {
+				t.Errorf("expected status %s, got %s", appt_booking.AppointmentStatusConfirmed, a.Status)
+			}
+		})
+	}
+}
+
+func TestCreateSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		staffID   int // 0 means the fixture's staff member
+		dayOfWeek int
+		start     string
+		end       string
+		wantKind  ErrorKind
+	}{
+		{name: "new day", dayOfWeek: 2, start: "09:00", end: "17:00"},
+		{name: "second shift on the same day", dayOfWeek: 1, start: "17:00", end: "20:00"},
+		{name: "day of week out of range", dayOfWeek: 7, start: "09:00", end: "17:00", wantKind: KindValidation},
+		{name: "missing end time", dayOfWeek: 2, start: "09:00", wantKind: KindValidation},
+		{name: "malformed time", dayOfWeek: 2, start: "9am", end: "17:00", wantKind: KindValidation},
+		{name: "unknown staff", staffID: 9999, dayOfWeek: 2, start: "09:00", end: "17:00", wantKind: KindNotFound},
+		{name: "overlaps existing schedule", dayOfWeek: 1, start: "16:00", end: "18:00", wantKind: KindConflict},
+		{name: "inside existing schedule", dayOfWeek: 1, start: "10:00", end: "12:00", wantKind: KindConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			staffID := tt.staffID
+			if staffID == 0 {
+				staffID = f.staff.ID
+			}
+
+			schedule, err := f.svc.CreateSchedule(f.ctx, staffID, tt.dayOfWeek, tt.start, tt.end)
+			checkKind(t, err, tt.wantKind)
+			if tt.wantKind != "" {
+				return
+			}
+			if got := schedule.StartTime.Format("15:04") + "-" + schedule.EndTime.Format("15:04"); got != tt.start+"-"+tt.end {
+				t.Errorf("expected %s-%s, got %s", tt.start, tt.end, got)
+			}
+			schedules, err := f.svc.GetSchedulesByStaff(f.ctx, staffID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(schedules) != 2 {
+				t.Errorf("expected 2 schedules, got %d", len(schedules))
+			}
+		})
+	}
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        func(f *testFixture, mondayID, wednesdayID int) int
+		dayOfWeek int
+		start     string
+		end       string
+		wantKind  ErrorKind
+	}{
+		{name: "shorten own hours", dayOfWeek: 1, start: "10:00", end: "16:00"},
+		{name: "overlap with itself is allowed", dayOfWeek: 1, start: "08:00", end: "18:00"},
+		{name: "move to a free day", dayOfWeek: 4, start: "09:00", end: "17:00"},
+		{name: "move onto another schedule", dayOfWeek: 3, start: "12:00", end: "14:00", wantKind: KindConflict},
+		{name: "invalid day", dayOfWeek: -1, start: "09:00", end: "17:00", wantKind: KindValidation},
+		{name: "malformed time", dayOfWeek: 1, start: "09:00", end: "25:00", wantKind: KindValidation},
+		{
+			name:      "unknown schedule",
+			id:        func(f *testFixture, mondayID, wednesdayID int) int { return 9999 },
+			dayOfWeek: 1, start: "09:00", end: "17:00",
+			wantKind: KindNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			schedules, err := f.svc.GetSchedulesByStaff(f.ctx, f.staff.ID)
+			if err != nil || len(schedules) != 1 {
+				t.Fatalf("expected the fixture's Monday schedule, got %v (%v)", schedules, err)
+			}
+			wednesday, err := f.svc.CreateSchedule(f.ctx, f.staff.ID, 3, "09:00", "17:00")
+			if err != nil {
+				t.Fatalf("create schedule: %v", err)
+			}
+			id := schedules[0].ID
+			if tt.id != nil {
+				id = tt.id(f, schedules[0].ID, wednesday.ID)
+			}
+
+			updated, err := f.svc.UpdateSchedule(f.ctx, id, tt.dayOfWeek, tt.start, tt.end)
+			checkKind(t, err, tt.wantKind)
+			if tt.wantKind != "" {
+				return
+			}
+			if updated.DayOfWeek != tt.dayOfWeek {
+				t.Errorf("expected day %d, got %d", tt.dayOfWeek, updated.DayOfWeek)
+			}
+			if got := updated.StartTime.Format("15:04") + "-" + updated.EndTime.Format("15:04"); got != tt.start+"-"+tt.end {
+				t.Errorf("expected %s-%s, got %s", tt.start, tt.end, got)
+			}
+		})
+	}
+}
+
+func TestDeleteService(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, f *testFixture) int // returns the service ID to delete
+		wantKind ErrorKind
+	}{
+		{
+			name: "unused service",
+			setup: func(t *testing.T, f *testFixture) int {
+				unused, err := f.svc.CreateService(f.ctx, "Shave", "", 15, 1000, appt_booking.BookingPolicy{})
+				if err != nil {
+					t.Fatalf("create service: %v", err)
+				}
+				return unused.ID
+			},
+		},
+		{
+			name:     "assigned to staff",
+			setup:    func(t *testing.T, f *testFixture) int { return f.service.ID },
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name: "unassigned but has appointments",
+			setup: func(t *testing.T, f *testFixture) int {
+				f.book(t, 10*time.Hour)
+				if err := f.svc.UnassignServiceFromStaff(f.ctx, f.staff.ID, f.service.ID); err != nil {
+					t.Fatalf("unassign service: %v", err)
+				}
+				return f.service.ID
+			},
+			wantKind: KindPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			id := tt.setup(t, f)
+
+			err := f.svc.DeleteService(f.ctx, id)
+			checkKind(t, err, tt.wantKind)
+
+			_, getErr := f.svc.GetServiceByID(f.ctx, id)
+			deleted := KindOf(getErr) == KindNotFound
+			if deleted != (tt.wantKind == "") {
+				t.Errorf("expected deleted=%v, got %v", tt.wantKind == "", deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteStaff(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, f *testFixture)
+		wantKind ErrorKind
+	}{
+		{
+			name: "without appointments",
+		},
+		{
+			name:     "with an appointment",
+			setup:    func(t *testing.T, f *testFixture) { f.book(t, 10*time.Hour) },
+			wantKind: KindPreconditionFailed,
+		},
+		{
+			name: "with only a cancelled appointment",
+			setup: func(t *testing.T, f *testFixture) {
+				a := f.book(t, 10*time.Hour)
+				if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+					t.Fatalf("cancel appointment: %v", err)
+				}
+			},
+			wantKind: KindPreconditionFailed, // history is kept, so the staff member stays
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+
+			err := f.svc.DeleteStaff(f.ctx, f.staff.ID)
+			checkKind(t, err, tt.wantKind)
+
+			_, getErr := f.svc.GetStaffByID(f.ctx, f.staff.ID)
+			deleted := KindOf(getErr) == KindNotFound
+			if deleted != (tt.wantKind == "") {
+				t.Fatalf("expected deleted=%v, got %v", tt.wantKind == "", deleted)
+			}
+			if !deleted {
+				return
+			}
+			// Schedules and service assignments go with the staff member
+			schedules, err := f.svc.GetSchedulesByStaff(f.ctx, f.staff.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(schedules) != 0 {
+				t.Errorf("expected schedules to be removed, got %d", len(schedules))
+			}
+			staffList, err := f.svc.GetStaffForService(f.ctx, f.service.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(staffList) != 0 {
+				t.Errorf("expected service assignment to be removed, got %d staff", len(staffList))
+			}
+		})
+	}
+}
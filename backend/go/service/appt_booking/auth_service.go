@@ -0,0 +1,354 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Token types, carried in the "typ" claim so a refresh token cannot be used as an access token
+const (
+	tokenTypeAccess      = "access"
+	tokenTypeRefresh     = "refresh"
+	tokenTypeVerifyEmail = "verify_email" // sent by email to confirm the address
+)
+
+// defaultVerifyEmailTTL is the lifetime of email verification links when
+// AuthConfig.VerifyEmailTTL is zero
+const defaultVerifyEmailTTL = 48 * time.Hour
+
+// minPasswordLength is the shortest password accepted for new accounts
+const minPasswordLength = 8
+
+// AuthConfig configures token signing
+type AuthConfig struct {
+	Secret     []byte        // HMAC key for HS256, at least 32 bytes
+	Issuer     string        // "iss" claim
+	AccessTTL  time.Duration // lifetime of access tokens
+	RefreshTTL time.Duration // lifetime of refresh tokens
+
+	// Email verification links open VerifyEmailURL with the token in its "token" query
+	// parameter (empty = the bare token is sent) and expire after VerifyEmailTTL (default 48h)
+	VerifyEmailURL string
+	VerifyEmailTTL time.Duration
+}
+
+// TokenPair is the result of a login or refresh
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// tokenClaims are the JWT claims of access and refresh tokens; Subject is the user ID
+type tokenClaims struct {
+	jwt.StandardClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Role          string `json:"role"`
+	StaffID       *int   `json:"staff_id,omitempty"`
+	Type          string `json:"typ"`
+}
+
+// AuthService manages login accounts and issues and verifies JWTs
+type AuthService struct {
+	users        UserRepository
+	staffRepo    StaffRepository
+	notifier     Notifier
+	config       AuthConfig
+	passwordCost int
+	parser       *jwt.Parser
+}
+
+// NewAuthService creates a new auth service that sends email verification links through notifier
+func NewAuthService(users UserRepository, staffRepo StaffRepository, notifier Notifier, config AuthConfig) (*AuthService, error) {
+	if len(config.Secret) < 32 {
+		return nil, errors.New("JWT secret must be at least 32 bytes")
+	}
+	if config.AccessTTL <= 0 || config.RefreshTTL <= 0 {
+		return nil, errors.New("token lifetimes must be positive")
+	}
+	if config.VerifyEmailTTL <= 0 {
+		config.VerifyEmailTTL = defaultVerifyEmailTTL
+	}
+	return &AuthService{
+		users:        users,
+		staffRepo:    staffRepo,
+		notifier:     notifier,
+		config:       config,
+		passwordCost: bcrypt.DefaultCost,
+		parser:       &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Name}},
+	}, nil
+}
+
+// Register creates a customer account and emails a link for confirming its address. Until
+// the address is confirmed the customer cannot see the bookings made under it. Failing to
+// send the link does not fail the registration; SendVerificationEmail sends a new one.
+func (s *AuthService) Register(ctx context.Context, email, password string) (*appt_booking.User, error) {
+	user, err := s.createUser(ctx, email, password, appt_booking.RoleCustomer, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("send verification email to user %d: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// SendVerificationEmail emails user userID a new link for confirming their address
+func (s *AuthService) SendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return Unauthorized("account no longer exists")
+	}
+	if user.EmailVerifiedAt != nil {
+		return Conflict("email address is already verified", nil)
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail confirms the address a verification token was sent to. The token no longer
+// works once the account's email changes. Tokens issued from then on carry the verified
+// address, so clients refresh theirs afterwards.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*appt_booking.User, error) {
+	claims, err := s.parseToken(token, tokenTypeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, Unauthorized("invalid token")
+	}
+	user, err := s.users.MarkEmailVerified(ctx, userID, claims.Email, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, Unauthorized("invalid token")
+	}
+	return user, nil
+}
+
+// sendVerificationEmail signs a verification token for user and emails it to them
+func (s *AuthService) sendVerificationEmail(ctx context.Context, user *appt_booking.User) error {
+	now := time.Now()
+	token, err := s.sign(user, user.Role, tokenTypeVerifyEmail, now, now.Add(s.config.VerifyEmailTTL))
+	if err != nil {
+		return err
+	}
+	link := token
+	if s.config.VerifyEmailURL != "" {
+		u, err := url.Parse(s.config.VerifyEmailURL)
+		if err != nil {
+			return fmt.Errorf("invalid verification URL: %w", err)
+		}
+		query := u.Query()
+		query.Set("token", token)
+		u.RawQuery = query.Encode()
+		link = u.String()
+	}
+	return s.notifier.Send(ctx, EmailMessage{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		TextBody: fmt.Sprintf("Confirm your email address to see your appointments:\n\n%s\n\n"+
+			"This link expires in %s. If you did not create an account, you can ignore this email.\n",
+			link, s.config.VerifyEmailTTL),
+	})
+}
+
+// CreateAdmin creates an admin account that is not linked to a staff member,
+// e.g. to bootstrap the first administrator
+func (s *AuthService) CreateAdmin(ctx context.Context, email, password string) (*appt_booking.User, error) {
+	return s.createUser(ctx, email, password, appt_booking.RoleAdmin, nil)
+}
+
+// CreateStaffAccount creates the login account of a staff member, using the staff member's email.
+// The account's role follows Staff.Role: admin stays admin, anything else is a provider.
+func (s *AuthService) CreateStaffAccount(ctx context.Context, staffID int, password string) (*appt_booking.User, error) {
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, NotFound("staff")
+	}
+	return s.createUser(ctx, staff.Email, password, staffRole(staff), &staff.ID)
+}
+
+// createUser validates the credentials and stores a new account
+func (s *AuthService) createUser(ctx context.Context, email, password, role string, staffID *int) (*appt_booking.User, error) {
+	email = normalizeEmail(email)
+	if email == "" {
+		return nil, Invalid("email", "email is required")
+	}
+	if !contains(email, "@") {
+		return nil, Invalid("email", "invalid email format")
+	}
+	if len(password) < minPasswordLength {
+		return nil, Invalid("password", fmt.Sprintf("password must be at least %d characters", minPasswordLength))
+	}
+
+	existing, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, Conflict("an account with this email already exists", nil)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.passwordCost)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.Create(ctx, email, string(hash), role, staffID)
+	if errors.Is(err, appt_booking.ErrUserEmailTaken) {
+		return nil, Conflict("an account with this email already exists", err)
+	}
+	return user, err
+}
+
+// Login checks the credentials and issues a new token pair
+func (s *AuthService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	user, err := s.users.GetByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, Unauthorized("invalid email or password")
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// Refresh exchanges a valid refresh token for a new token pair. The user is reloaded,
+// so role changes apply and deleted accounts cannot refresh.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := s.parseToken(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, Unauthorized("invalid token")
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, Unauthorized("account no longer exists")
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// VerifyAccessToken validates an access token and returns the principal it was issued to
+func (s *AuthService) VerifyAccessToken(token string) (*Principal, error) {
+	claims, err := s.parseToken(token, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, Unauthorized("invalid token")
+	}
+	return &Principal{
+		UserID:        userID,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Role:          claims.Role,
+		StaffID:       claims.StaffID,
+	}, nil
+}
+
+// issueTokens signs an access and a refresh token for user
+func (s *AuthService) issueTokens(ctx context.Context, user *appt_booking.User) (*TokenPair, error) {
+	role := user.Role
+	if user.StaffID != nil {
+		// Staff accounts follow the staff member's current role
+		staff, err := s.staffRepo.GetByID(ctx, *user.StaffID)
+		if err != nil {
+			return nil, err
+		}
+		if staff == nil {
+			return nil, Unauthorized("account no longer exists")
+		}
+		role = staffRole(staff)
+	}
+
+	now := time.Now()
+	pair := &TokenPair{
+		AccessExpiresAt:  now.Add(s.config.AccessTTL),
+		RefreshExpiresAt: now.Add(s.config.RefreshTTL),
+	}
+	var err error
+	if pair.AccessToken, err = s.sign(user, role, tokenTypeAccess, now, pair.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+	if pair.RefreshToken, err = s.sign(user, role, tokenTypeRefresh, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// sign creates an HS256 token of the given type
+func (s *AuthService) sign(user *appt_booking.User, role, tokenType string, issuedAt, expiresAt time.Time) (string, error) {
+	claims := tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(user.ID),
+			Issuer:    s.config.Issuer,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          role,
+		StaffID:       user.StaffID,
+		Type:          tokenType,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.Secret)
+}
+
+// parseToken verifies the signature, expiry, issuer and type of a token
+func (s *AuthService) parseToken(token, tokenType string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	_, err := s.parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.config.Secret, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, Unauthorized("token has expired")
+		}
+		return nil, Unauthorized("invalid token")
+	}
+	if claims.Type != tokenType || !claims.VerifyIssuer(s.config.Issuer, true) {
+		return nil, Unauthorized("invalid token")
+	}
+	return claims, nil
+}
+
+// staffRole maps Staff.Role to an account role
+func staffRole(staff *appt_booking.Staff) string {
+	if staff.Role == appt_booking.RoleAdmin {
+		return appt_booking.RoleAdmin
+	}
+	return appt_booking.RoleProvider
+}
+
+// normalizeEmail trims and lower-cases an email so logins are case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
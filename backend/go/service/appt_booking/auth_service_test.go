@@ -0,0 +1,242 @@
+package appt_booking
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// newTestAuthService returns an auth service over f's store with a fast password hash,
+// recording the verification emails it sends
+func newTestAuthService(t *testing.T, f *testFixture, accessTTL time.Duration) *AuthService {
+	t.Helper()
+	auth, err := NewAuthService(f.store.Users(), f.store.Staff(), &recordingNotifier{}, AuthConfig{
+		Secret:         []byte("test-secret-test-secret-test-secret"),
+		Issuer:         "test",
+		AccessTTL:      accessTTL,
+		RefreshTTL:     time.Hour,
+		VerifyEmailURL: "https://example.com/verify-email",
+	})
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	auth.passwordCost = bcrypt.MinCost
+	return auth
+}
+
+func TestNewAuthService_ShortSecret(t *testing.T) {
+	_, err := NewAuthService(nil, nil, nil, AuthConfig{Secret: []byte("short"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if err == nil {
+		t.Fatal("expected error for a secret shorter than 32 bytes")
+	}
+}
+
+func TestAuthService_RegisterAndLogin(t *testing.T) {
+	f := newTestFixture(t)
+	auth := newTestAuthService(t, f, time.Minute)
+
+	user, err := auth.Register(f.ctx, " Bob@Example.com ", "correct horse")
+	checkKind(t, err, "")
+	if user.Email != "bob@example.com" || user.Role != appt_booking.RoleCustomer {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	_, err = auth.Register(f.ctx, "bob@example.com", "another password")
+	checkKind(t, err, KindConflict)
+	_, err = auth.Register(f.ctx, "carol@example.com", "short")
+	checkKind(t, err, KindValidation)
+	_, err = auth.Register(f.ctx, "not-an-email", "correct horse")
+	checkKind(t, err, KindValidation)
+
+	_, err = auth.Login(f.ctx, "bob@example.com", "wrong password")
+	checkKind(t, err, KindUnauthorized)
+	_, err = auth.Login(f.ctx, "nobody@example.com", "correct horse")
+	checkKind(t, err, KindUnauthorized)
+
+	tokens, err := auth.Login(f.ctx, "BOB@example.com", "correct horse")
+	checkKind(t, err, "")
+	principal, err := auth.VerifyAccessToken(tokens.AccessToken)
+	checkKind(t, err, "")
+	if principal.UserID != user.ID || principal.Email != "bob@example.com" || principal.Role != appt_booking.RoleCustomer || principal.StaffID != nil {
+		t.Errorf("unexpected principal: %+v", principal)
+	}
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	f := newTestFixture(t)
+	auth := newTestAuthService(t, f, time.Minute)
+	mail := auth.notifier.(*recordingNotifier)
+
+	user, err := auth.Register(f.ctx, "bob@example.com", "correct horse")
+	checkKind(t, err, "")
+	if len(mail.sent) != 1 || mail.sent[0].To != "bob@example.com" {
+		t.Fatalf("expected a verification email to bob, got %+v", mail.sent)
+	}
+	link, err := url.Parse(strings.TrimSpace(strings.Split(mail.sent[0].TextBody, "\n")[2]))
+	if err != nil || link.Host != "example.com" || link.Query().Get("token") == "" {
+		t.Fatalf("expected a verification link, got %q", mail.sent[0].TextBody)
+	}
+	token := link.Query().Get("token")
+
+	// Not verified until the link is followed
+	tokens, err := auth.Login(f.ctx, "bob@example.com", "correct horse")
+	checkKind(t, err, "")
+	principal, err := auth.VerifyAccessToken(tokens.AccessToken)
+	checkKind(t, err, "")
+	if principal.EmailVerified {
+		t.Fatal("expected an unverified principal before verification")
+	}
+	_, err = auth.VerifyAccessToken(token)
+	checkKind(t, err, KindUnauthorized)
+	_, err = auth.VerifyEmail(f.ctx, tokens.AccessToken)
+	checkKind(t, err, KindUnauthorized)
+
+	verified, err := auth.VerifyEmail(f.ctx, token)
+	checkKind(t, err, "")
+	if verified.ID != user.ID || verified.EmailVerifiedAt == nil {
+		t.Fatalf("expected the account to be verified, got %+v", verified)
+	}
+	checkKind(t, auth.SendVerificationEmail(f.ctx, user.ID), KindConflict)
+
+	// Refreshed tokens carry the verified address
+	refreshed, err := auth.Refresh(f.ctx, tokens.RefreshToken)
+	checkKind(t, err, "")
+	principal, err = auth.VerifyAccessToken(refreshed.AccessToken)
+	checkKind(t, err, "")
+	if !principal.EmailVerified {
+		t.Error("expected a verified principal after refreshing")
+	}
+}
+
+func TestAuthService_TokenTypes(t *testing.T) {
+	f := newTestFixture(t)
+	auth := newTestAuthService(t, f, time.Minute)
+	if _, err := auth.Register(f.ctx, "bob@example.com", "correct horse"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	tokens, err := auth.Login(f.ctx, "bob@example.com", "correct horse")
+	checkKind(t, err, "")
+
+	// Each token only works for its own purpose
+	_, err = auth.VerifyAccessToken(tokens.RefreshToken)
+	checkKind(t, err, KindUnauthorized)
+	_, err = auth.Refresh(f.ctx, tokens.AccessToken)
+	checkKind(t, err, KindUnauthorized)
+
+	refreshed, err := auth.Refresh(f.ctx, tokens.RefreshToken)
+	checkKind(t, err, "")
+	_, err = auth.VerifyAccessToken(refreshed.AccessToken)
+	checkKind(t, err, "")
+
+	// A token signed with another key is rejected
+	other, err := NewAuthService(f.store.Users(), f.store.Staff(), &recordingNotifier{}, AuthConfig{
+		Secret:     []byte("another-secret-another-secret-xx"),
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	_, err = other.VerifyAccessToken(tokens.AccessToken)
+	checkKind(t, err, KindUnauthorized)
+	_, err = auth.VerifyAccessToken("not.a.token")
+	checkKind(t, err, KindUnauthorized)
+}
+
+func TestAuthService_ExpiredAccessToken(t *testing.T) {
+	f := newTestFixture(t)
+	auth := newTestAuthService(t, f, time.Minute)
+	if _, err := auth.Register(f.ctx, "bob@example.com", "correct horse"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	user, err := f.store.Users().GetByEmail(f.ctx, "bob@example.com")
+	if err != nil || user == nil {
+		t.Fatalf("get user: %v", err)
+	}
+
+	past := time.Now().Add(-2 * time.Hour)
+	token, err := auth.sign(user, user.Role, tokenTypeAccess, past, past.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	_, err = auth.VerifyAccessToken(token)
+	checkKind(t, err, KindUnauthorized)
+	if err.(*Error).Message != "token has expired" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestAuthService_StaffAccount(t *testing.T) {
+	f := newTestFixture(t)
+	auth := newTestAuthService(t, f, time.Minute)
+
+	_, err := auth.CreateStaffAccount(f.ctx, 9999, "correct horse")
+	checkKind(t, err, KindNotFound)
+
+	user, err := auth.CreateStaffAccount(f.ctx, f.staff.ID, "correct horse")
+	checkKind(t, err, "")
+	if user.Role != appt_booking.RoleProvider || user.StaffID == nil || *user.StaffID != f.staff.ID {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	_, err = auth.CreateStaffAccount(f.ctx, f.staff.ID, "correct horse")
+	checkKind(t, err, KindConflict)
+
+	tokens, err := auth.Login(f.ctx, f.staff.Email, "correct horse")
+	checkKind(t, err, "")
+	principal, err := auth.VerifyAccessToken(tokens.AccessToken)
+	checkKind(t, err, "")
+	if principal.Role != appt_booking.RoleProvider || !principal.IsStaff(f.staff.ID) {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+
+	// Promoting the staff member to admin applies on the next refresh
+	if _, err := f.svc.UpdateStaff(f.ctx, f.staff.ID, f.staff.Name, f.staff.Email, "", appt_booking.RoleAdmin, ""); err != nil {
+		t.Fatalf("update staff: %v", err)
+	}
+	refreshed, err := auth.Refresh(f.ctx, tokens.RefreshToken)
+	checkKind(t, err, "")
+	principal, err = auth.VerifyAccessToken(refreshed.AccessToken)
+	checkKind(t, err, "")
+	if principal.Role != appt_booking.RoleAdmin {
+		t.Errorf("expected admin role after promotion, got %q", principal.Role)
+	}
+
+	// Deleting the staff member removes the account
+	if err := f.svc.DeleteStaff(f.ctx, f.staff.ID); err != nil {
+		t.Fatalf("delete staff: %v", err)
+	}
+	_, err = auth.Refresh(f.ctx, tokens.RefreshToken)
+	checkKind(t, err, KindUnauthorized)
+}
+
+func TestPrincipal_CanAccessAppointment(t *testing.T) {
+	staffID, otherStaffID := 1, 2
+	a := &appt_booking.Appointment{StaffID: staffID, CustomerEmail: "Bob@Example.com"}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		want      bool
+	}{
+		{"anonymous", nil, false},
+		{"admin", &Principal{Role: appt_booking.RoleAdmin}, true},
+		{"own provider", &Principal{Role: appt_booking.RoleProvider, StaffID: &staffID}, true},
+		{"other provider", &Principal{Role: appt_booking.RoleProvider, StaffID: &otherStaffID}, false},
+		{"own customer", &Principal{Role: appt_booking.RoleCustomer, Email: "bob@example.com", EmailVerified: true}, true},
+		{"own customer, unverified", &Principal{Role: appt_booking.RoleCustomer, Email: "bob@example.com"}, false},
+		{"other customer", &Principal{Role: appt_booking.RoleCustomer, Email: "carol@example.com", EmailVerified: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.CanAccessAppointment(a); got != tt.want {
+				t.Errorf("CanAccessAppointment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
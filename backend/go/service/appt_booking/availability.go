@@ -0,0 +1,224 @@
+package appt_booking
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// maxAvailabilityRangeDays caps how far a single availability query may span
+const maxAvailabilityRangeDays = 31
+
+// TimeSlot is a bookable [Start, End) interval
+type TimeSlot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Availability is the result of a slot search for one service
+type Availability struct {
+	ServiceID       int
+	DurationMinutes int
+	Staff           []StaffAvailability
+}
+
+// StaffAvailability lists the open slots for a single staff member
+type StaffAvailability struct {
+	StaffID   int
+	StaffName string
+	Location  *time.Location
+	Slots     []TimeSlot
+}
+
+// GetAvailability returns open slots for a service between from and to.
+// If staffID is 0, availability is computed for every staff member offering the service.
+// Slots follow the service's booking policy: they start on its slot granularity, within its
+// booking window, and keep its buffers clear of other appointments and their buffers.
+func (s *ApptBookingService) GetAvailability(ctx context.Context, serviceID, staffID int, from, to time.Time) (*Availability, error) {
+	// Validation
+	if serviceID <= 0 {
+		return nil, Invalid("service_id", "valid service ID is required")
+	}
+	if !from.Before(to) {
+		return nil, Invalid("to", "from must be before to")
+	}
+	if to.Sub(from) > maxAvailabilityRangeDays*24*time.Hour {
+		return nil, Invalid("to", "date range cannot exceed 31 days")
+	}
+
+	// Validate service exists
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, NotFound("service")
+	}
+
+	// Resolve which staff members to compute availability for
+	providers, err := s.staffServiceRepo.GetStaffForService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if staffID > 0 {
+		var selected []appt_booking.Staff
+		for _, st := range providers {
+			if st.ID == staffID {
+				selected = append(selected, st)
+				break
+			}
+		}
+		if len(selected) == 0 {
+			staff, err := s.staffRepo.GetByID(ctx, staffID)
+			if err != nil {
+				return nil, err
+			}
+			if staff == nil {
+				return nil, NotFound("staff")
+			}
+			return nil, PreconditionFailed("staff member does not offer this service", nil)
+		}
+		providers = selected
+	}
+
+	duration := time.Duration(service.DurationMin) * time.Minute
+	bufferBefore, bufferAfter := service.Buffers()
+	earliest, latest := bookingWindow(service, time.Now())
+	if !latest.IsZero() {
+		to = earlierOf(to, latest)
+	}
+
+	// Buffers of the services already booked, to widen their busy ranges
+	services, err := s.serviceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buffers := make(map[int]appt_booking.BookingPolicy, len(services))
+	for _, svc := range services {
+		buffers[svc.ID] = svc.BookingPolicy
+	}
+	// busyRange is the range a slot must not overlap to keep the blocked times of the
+	// slot and of a booking of serviceID at [start, end) apart
+	busyRange := func(serviceID int, start, end time.Time) timeRange {
+		before, after := buffers[serviceID].Buffers()
+		return timeRange{start: start.Add(-before - bufferAfter), end: end.Add(after + bufferBefore)}
+	}
+	// Bookings that can block a slot starting in [from, to)
+	searchFrom, searchTo := from.Add(-bufferBefore), to.Add(duration+bufferAfter)
+
+	result := &Availability{
+		ServiceID:       service.ID,
+		DurationMinutes: service.DurationMin,
+		Staff:           make([]StaffAvailability, 0, len(providers)),
+	}
+	for i := range providers {
+		st := &providers[i]
+		schedules, err := s.scheduleRepo.GetByStaff(ctx, st.ID)
+		if err != nil {
+			return nil, err
+		}
+		loc := s.staffLocation(st)
+		exceptions, err := s.exceptionRepo.GetApplicable(ctx, st.ID, from.In(loc).Format("2006-01-02"), to.In(loc).Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		appointments, err := s.appointmentRepo.GetActiveByStaffBetween(ctx, st.ID, searchFrom, searchTo)
+		if err != nil {
+			return nil, err
+		}
+
+		holds, err := s.holdRepo.GetActiveByStaffBetween(ctx, st.ID, searchFrom, searchTo)
+		if err != nil {
+			return nil, err
+		}
+
+		external, err := s.externalRepo.GetBusyByStaffBetween(ctx, st.ID, searchFrom, searchTo)
+		if err != nil {
+			return nil, err
+		}
+
+		busy := make([]timeRange, 0, len(appointments)+len(holds)+len(external))
+		for _, a := range appointments {
+			busy = append(busy, busyRange(a.ServiceID, a.AppointmentDatetime, a.AppointmentDatetime.Add(time.Duration(a.DurationMinutes)*time.Minute)))
+		}
+		// Held slots are taken until the hold expires
+		for _, h := range holds {
+			busy = append(busy, busyRange(h.ServiceID, h.StartsAt, h.StartsAt.Add(time.Duration(h.DurationMinutes)*time.Minute)))
+		}
+		// Busy times from the staff member's external calendars have no buffers of their own
+		for _, b := range external {
+			busy = append(busy, busyRange(0, b.StartTime, b.EndTime))
+		}
+
+		result.Staff = append(result.Staff, StaffAvailability{
+			StaffID:   st.ID,
+			StaffName: st.Name,
+			Location:  loc,
+			Slots:     generateSlots(schedules, exceptions, busy, loc, from, to, earliest, duration, slotGranularity(service)),
+		})
+	}
+
+	return result, nil
+}
+
+// timeRange is a half-open [start, end) interval
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// overlaps uses the same condition as AppointmentRepository.CheckConflict
+func (r timeRange) overlaps(other timeRange) bool {
+	return r.start.Before(other.end) && other.start.Before(r.end)
+}
+
+// generateSlots expands weekly schedules (with schedule exceptions applied) into concrete
+// slots of the given duration starting within [from, to), skipping slots before now or
+// overlapping a busy range. Schedules are interpreted as wall-clock times in loc; slots start
+// on multiples of step past local midnight and step in absolute time, so a window spanning
+// a DST change yields slots for its real length.
+func generateSlots(schedules []appt_booking.Schedule, exceptions []appt_booking.ScheduleException, busy []timeRange, loc *time.Location, from, to, now time.Time, duration, step time.Duration) []TimeSlot {
+	slots := []TimeSlot{}
+	if duration <= 0 || step <= 0 {
+		return slots
+	}
+
+	// Walk each local calendar day touched by the range
+	fromLocal := from.In(loc)
+	day := time.Date(fromLocal.Year(), fromLocal.Month(), fromLocal.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		for _, window := range workingWindows(schedules, exceptions, day, loc) {
+			for start := alignToStep(window.start, step); !start.Add(duration).After(window.end); start = start.Add(step) {
+				if start.Before(from) || !start.Before(to) || start.Before(now) {
+					continue
+				}
+				candidate := timeRange{start: start, end: start.Add(duration)}
+				free := true
+				for _, b := range busy {
+					if candidate.overlaps(b) {
+						free = false
+						break
+					}
+				}
+				if free {
+					slots = append(slots, TimeSlot{Start: candidate.start, End: candidate.end})
+				}
+			}
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Start.Before(slots[j].Start)
+	})
+	return slots
+}
+
+// alignToStep rounds t up to the next wall-clock multiple of step past midnight
+func alignToStep(t time.Time, step time.Duration) time.Time {
+	if r := wallClockSinceMidnight(t) % step; r != 0 {
+		return t.Add(step - r)
+	}
+	return t
+}
@@ -0,0 +1,175 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func clock(hhmm string) time.Time {
+	t, _ := time.Parse("15:04", hhmm)
+	return t
+}
+
+func TestGenerateSlots(t *testing.T) {
+	loc := time.UTC
+	// Monday 2024-06-03
+	from := time.Date(2024, 6, 3, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 1)
+	past := from.AddDate(0, 0, -1)
+	schedules := []appt_booking.Schedule{
+		{StaffID: 1, DayOfWeek: 1, StartTime: clock("09:00"), EndTime: clock("10:30")},
+	}
+
+	tests := []struct {
+		name     string
+		busy     []timeRange
+		now      time.Time
+		duration time.Duration
+		want     []string
+	}{
+		{
+			name:     "empty day",
+			now:      past,
+			duration: 30 * time.Minute,
+			want:     []string{"09:00", "09:15", "09:30", "09:45", "10:00"},
+		},
+		{
+			name:     "duration must fit in window",
+			now:      past,
+			duration: 60 * time.Minute,
+			want:     []string{"09:00", "09:15", "09:30"},
+		},
+		{
+			name: "busy appointment removes overlapping slots",
+			busy: []timeRange{
+				{start: time.Date(2024, 6, 3, 9, 30, 0, 0, loc), end: time.Date(2024, 6, 3, 10, 0, 0, 0, loc)},
+			},
+			now:      past,
+			duration: 30 * time.Minute,
+			want:     []string{"09:00", "10:00"},
+		},
+		{
+			name:     "slots before now are skipped",
+			now:      time.Date(2024, 6, 3, 9, 40, 0, 0, loc),
+			duration: 30 * time.Minute,
+			want:     []string{"09:45", "10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := generateSlots(schedules, nil, tt.busy, loc, from, to, tt.now, tt.duration, 15*time.Minute)
+			if len(slots) != len(tt.want) {
+				t.Fatalf("expected %d slots, got %d: %v", len(tt.want), len(slots), slots)
+			}
+			for i, slot := range slots {
+				if got := slot.Start.Format("15:04"); got != tt.want[i] {
+					t.Errorf("slot %d: expected %s, got %s", i, tt.want[i], got)
+				}
+				if slot.End.Sub(slot.Start) != tt.duration {
+					t.Errorf("slot %d: expected duration %s, got %s", i, tt.duration, slot.End.Sub(slot.Start))
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSlots_SkipsOtherWeekdays(t *testing.T) {
+	// Tuesday 2024-06-04 only, schedule is Monday
+	from := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	schedules := []appt_booking.Schedule{
+		{StaffID: 1, DayOfWeek: 1, StartTime: clock("09:00"), EndTime: clock("17:00")},
+	}
+
+	slots := generateSlots(schedules, nil, nil, time.UTC, from, from.AddDate(0, 0, 1), from.AddDate(0, 0, -1), 30*time.Minute, 15*time.Minute)
+	if len(slots) != 0 {
+		t.Errorf("expected no slots, got %d", len(slots))
+	}
+}
+
+func TestGenerateSlots_DSTTransitions(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		day       time.Time
+		dayOfWeek int
+		start     string
+		end       string
+		want      []string
+	}{
+		{
+			// 2024-03-10: clocks jump from 02:00 to 03:00, so the window is only 2 real hours
+			name:      "spring forward",
+			day:       time.Date(2024, 3, 10, 0, 0, 0, 0, ny),
+			dayOfWeek: 0,
+			start:     "01:00",
+			end:       "04:00",
+			want:      []string{"01:00-05:00", "01:15-05:00", "01:30-05:00", "01:45-05:00", "03:00-04:00", "03:15-04:00", "03:30-04:00"},
+		},
+		{
+			// 2024-11-03: 01:00-02:00 happens twice, so the window is 3 real hours
+			name:      "fall back",
+			day:       time.Date(2024, 11, 3, 0, 0, 0, 0, ny),
+			dayOfWeek: 0,
+			start:     "01:00",
+			end:       "03:00",
+			want: []string{
+				"01:00-04:00", "01:15-04:00", "01:30-04:00", "01:45-04:00",
+				"01:00-05:00", "01:15-05:00", "01:30-05:00", "01:45-05:00",
+				"02:00-05:00", "02:15-05:00", "02:30-05:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules := []appt_booking.Schedule{
+				{StaffID: 1, DayOfWeek: tt.dayOfWeek, StartTime: clock(tt.start), EndTime: clock(tt.end)},
+			}
+			slots := generateSlots(schedules, nil, nil, ny, tt.day, tt.day.AddDate(0, 0, 1), tt.day.AddDate(0, 0, -1), 30*time.Minute, 15*time.Minute)
+			if len(slots) != len(tt.want) {
+				t.Fatalf("expected %d slots, got %d: %v", len(tt.want), len(slots), slots)
+			}
+			for i, slot := range slots {
+				if got := slot.Start.In(ny).Format("15:04-07:00"); got != tt.want[i] {
+					t.Errorf("slot %d: expected %s, got %s", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSlots_KeepsLocalHoursAcrossDST(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	schedules := []appt_booking.Schedule{
+		{StaffID: 1, DayOfWeek: 5, StartTime: clock("09:00"), EndTime: clock("09:30")},
+		{StaffID: 1, DayOfWeek: 1, StartTime: clock("09:00"), EndTime: clock("09:30")},
+	}
+
+	// Friday 2024-03-08 (PST) through Monday 2024-03-11 (PDT)
+	from := time.Date(2024, 3, 8, 0, 0, 0, 0, la)
+	to := time.Date(2024, 3, 12, 0, 0, 0, 0, la)
+	slots := generateSlots(schedules, nil, nil, la, from, to, from.AddDate(0, 0, -1), 30*time.Minute, 15*time.Minute)
+	if len(slots) != 2 {
+		t.Fatalf("expected 2 slots, got %d: %v", len(slots), slots)
+	}
+
+	want := []string{"2024-03-08T17:00:00Z", "2024-03-11T16:00:00Z"}
+	for i, slot := range slots {
+		if got := slot.Start.In(la).Format("15:04"); got != "09:00" {
+			t.Errorf("slot %d: expected local 09:00, got %s", i, got)
+		}
+		if got := slot.Start.UTC().Format(time.RFC3339); got != want[i] {
+			t.Errorf("slot %d: expected %s, got %s", i, want[i], got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package appt_booking
+
+import (
+	"fmt"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// validateBookingPolicy checks a service's booking policy, defaulting a zero slot granularity
+func validateBookingPolicy(policy appt_booking.BookingPolicy) (appt_booking.BookingPolicy, error) {
+	if policy.BufferBeforeMin < 0 {
+		return policy, Invalid("buffer_before_minutes", "buffer cannot be negative")
+	}
+	if policy.BufferAfterMin < 0 {
+		return policy, Invalid("buffer_after_minutes", "buffer cannot be negative")
+	}
+	if policy.MinNoticeMin < 0 {
+		return policy, Invalid("min_notice_minutes", "minimum notice cannot be negative")
+	}
+	if policy.MaxAdvanceDays < 0 {
+		return policy, Invalid("max_advance_days", "maximum advance cannot be negative")
+	}
+	if policy.CancelNoticeMin < 0 {
+		return policy, Invalid("cancel_notice_minutes", "cancellation notice cannot be negative")
+	}
+	if policy.SlotGranularityMin < 0 || policy.SlotGranularityMin > 24*60 {
+		return policy, Invalid("slot_granularity_minutes", "slot granularity must be between 1 and 1440 minutes")
+	}
+	if policy.SlotGranularityMin == 0 {
+		policy.SlotGranularityMin = appt_booking.DefaultSlotGranularityMinutes
+	}
+	return policy, nil
+}
+
+// bookingWindow returns the earliest start time service can be booked for at now and the
+// time bookings must start before; latest is zero if the service has no maximum advance window
+func bookingWindow(service *appt_booking.Service, now time.Time) (earliest, latest time.Time) {
+	earliest = now.Add(time.Duration(service.MinNoticeMin) * time.Minute)
+	if service.MaxAdvanceDays > 0 {
+		latest = now.AddDate(0, 0, service.MaxAdvanceDays)
+	}
+	return earliest, latest
+}
+
+// slotGranularity returns the spacing of the service's bookable start times
+func slotGranularity(service *appt_booking.Service) time.Duration {
+	if service.SlotGranularityMin <= 0 {
+		return appt_booking.DefaultSlotGranularityMinutes * time.Minute
+	}
+	return time.Duration(service.SlotGranularityMin) * time.Minute
+}
+
+// checkBookingPolicy verifies that start is in the future, within the service's booking
+// window at now and on its slot granularity in the staff member's timezone
+func (s *ApptBookingService) checkBookingPolicy(service *appt_booking.Service, staff *appt_booking.Staff, start, now time.Time) error {
+	if !start.After(now) {
+		return Invalid("appointment_datetime", "appointment time must be in the future")
+	}
+	if !onGranularity(start.In(s.staffLocation(staff)), slotGranularity(service)) {
+		return Invalid("appointment_datetime", fmt.Sprintf("appointments for this service start on %d-minute boundaries", slotGranularity(service)/time.Minute))
+	}
+	earliest, latest := bookingWindow(service, now)
+	if start.Before(earliest) {
+		return PreconditionFailed(fmt.Sprintf("this service must be booked at least %d minutes in advance", service.MinNoticeMin), nil)
+	}
+	if !latest.IsZero() && !start.Before(latest) {
+		return PreconditionFailed(fmt.Sprintf("this service cannot be booked more than %d days in advance", service.MaxAdvanceDays), nil)
+	}
+	return nil
+}
+
+// onGranularity reports whether the wall-clock time of t is a whole multiple of step past midnight
+func onGranularity(t time.Time, step time.Duration) bool {
+	return wallClockSinceMidnight(t)%step == 0
+}
+
+// wallClockSinceMidnight returns the wall-clock time of t as a duration since midnight
+func wallClockSinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
@@ -0,0 +1,134 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// setPolicy replaces the booking policy of the fixture's service
+func (f *testFixture) setPolicy(t *testing.T, policy appt_booking.BookingPolicy) {
+	t.Helper()
+	updated, err := f.svc.UpdateService(f.ctx, f.service.ID, f.service.Name, f.service.Description, f.service.DurationMin, f.service.PriceCents, policy)
+	if err != nil {
+		t.Fatalf("update service: %v", err)
+	}
+	f.service = updated
+}
+
+func TestCreateService_BookingPolicy(t *testing.T) {
+	f := newTestFixture(t)
+	if f.service.SlotGranularityMin != appt_booking.DefaultSlotGranularityMinutes {
+		t.Fatalf("expected default granularity %d, got %d", appt_booking.DefaultSlotGranularityMinutes, f.service.SlotGranularityMin)
+	}
+
+	for _, policy := range []appt_booking.BookingPolicy{
+		{BufferBeforeMin: -1},
+		{BufferAfterMin: -1},
+		{MinNoticeMin: -1},
+		{MaxAdvanceDays: -1},
+		{SlotGranularityMin: -15},
+		{SlotGranularityMin: 24*60 + 1},
+	} {
+		_, err := f.svc.CreateService(f.ctx, "Trim", "", 30, 1500, policy)
+		checkKind(t, err, KindValidation)
+	}
+}
+
+func TestBookAppointment_Buffers(t *testing.T) {
+	f := newTestFixture(t)
+	f.setPolicy(t, appt_booking.BookingPolicy{BufferAfterMin: 15})
+	f.book(t, 10*time.Hour)
+
+	// 11:00 falls in the cleanup after the 10:00 appointment
+	_, err := f.svc.BookAppointment(f.ctx, "Dave", "dave@example.com", "", f.staff.ID, f.service.ID, monday.Add(11*time.Hour), "")
+	checkKind(t, err, KindConflict)
+	// 09:00's cleanup would run into the 10:00 appointment
+	_, err = f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, monday.Add(9*time.Hour))
+	checkKind(t, err, KindConflict)
+
+	availability, err := f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	slots := availability.Staff[0].Slots
+	if len(slots) == 0 || !slots[0].Start.Equal(monday.Add(11*time.Hour+15*time.Minute)) {
+		t.Fatalf("expected the first slot at 11:15, got %v", slots)
+	}
+	f.book(t, 11*time.Hour+15*time.Minute)
+}
+
+func TestCheckBookingPolicy(t *testing.T) {
+	f := newTestFixture(t)
+	now := monday.Add(9 * time.Hour)
+	service := &appt_booking.Service{DurationMin: 60, BookingPolicy: appt_booking.BookingPolicy{
+		MinNoticeMin:       120,
+		MaxAdvanceDays:     7,
+		SlotGranularityMin: 30,
+	}}
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		wantKind ErrorKind
+	}{
+		{"in the past", now.Add(-time.Hour), KindValidation},
+		{"within minimum notice", now.Add(time.Hour), KindPreconditionFailed},
+		{"at minimum notice", now.Add(2 * time.Hour), ""},
+		{"off granularity", now.Add(2*time.Hour + 15*time.Minute), KindValidation},
+		{"last day of window", now.AddDate(0, 0, 7).Add(-30 * time.Minute), ""},
+		{"beyond maximum advance", now.AddDate(0, 0, 7), KindPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkKind(t, f.svc.checkBookingPolicy(service, f.staff, tt.start, now), tt.wantKind)
+		})
+	}
+}
+
+func TestBookAppointment_BookingPolicy(t *testing.T) {
+	f := newTestFixture(t)
+	f.setPolicy(t, appt_booking.BookingPolicy{SlotGranularityMin: 30})
+
+	_, err := f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(10*time.Hour+15*time.Minute), "")
+	checkKind(t, err, KindValidation)
+	_, err = f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, time.Now().Add(-time.Hour), "")
+	checkKind(t, err, KindValidation)
+	a := f.book(t, 10*time.Hour+30*time.Minute)
+
+	// monday is years ahead
+	f.setPolicy(t, appt_booking.BookingPolicy{MaxAdvanceDays: 30})
+	_, err = f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(14*time.Hour), "")
+	checkKind(t, err, KindPreconditionFailed)
+	_, err = f.svc.RescheduleAppointment(f.ctx, a.ID, 0, monday.Add(14*time.Hour))
+	checkKind(t, err, KindPreconditionFailed)
+}
+
+func TestGetAvailability_BookingPolicy(t *testing.T) {
+	f := newTestFixture(t)
+	f.setPolicy(t, appt_booking.BookingPolicy{SlotGranularityMin: 45})
+
+	availability, err := f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	slots := availability.Staff[0].Slots
+	if len(slots) == 0 {
+		t.Fatal("expected slots")
+	}
+	for _, slot := range slots {
+		if !onGranularity(slot.Start, 45*time.Minute) {
+			t.Fatalf("slot %v is not on a 45-minute boundary", slot.Start)
+		}
+	}
+
+	f.setPolicy(t, appt_booking.BookingPolicy{MaxAdvanceDays: 30})
+	availability, err = f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	if n := len(availability.Staff[0].Slots); n != 0 {
+		t.Fatalf("expected no slots beyond the booking window, got %d", n)
+	}
+}
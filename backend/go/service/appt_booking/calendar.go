@@ -0,0 +1,213 @@
+package appt_booking
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// CalendarUIDDomain qualifies the UIDs of appointment events, which must be globally unique
+const CalendarUIDDomain = "appt-booking.k8s-fullstack-blueprint"
+
+// calendarFeedHistory is how long past appointments stay in a staff calendar feed
+const calendarFeedHistory = 30 * 24 * time.Hour
+
+// AppointmentCalendar returns an appointment as a calendar for its customer, in the staff
+// member's timezone. Its event replaces those sent for earlier versions of the appointment.
+func (s *ApptBookingService) AppointmentCalendar(ctx context.Context, appt *appt_booking.Appointment) (Calendar, error) {
+	service, err := s.GetServiceByID(ctx, appt.ServiceID)
+	if err != nil {
+		return Calendar{}, err
+	}
+	staff, err := s.GetStaffByID(ctx, appt.StaffID)
+	if err != nil {
+		return Calendar{}, err
+	}
+	reschedules, err := s.appointmentRepo.CountReschedules(ctx, []int{appt.ID})
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	event := appointmentEvent(appt, reschedules[appt.ID])
+	event.Summary = service.Name + " with " + staff.Name
+	event.Description = fmt.Sprintf("%s (%d minutes) with %s", service.Name, appt.DurationMinutes, staff.Name)
+	return Calendar{Method: "PUBLISH", Location: s.staffLocation(staff), Events: []CalendarEvent{event}}, nil
+}
+
+// appointmentEvent returns the event for appt without a summary or description. Its
+// sequence counts the changes calendar apps must pick up: each reschedule and the
+// cancellation.
+func appointmentEvent(appt *appt_booking.Appointment, reschedules int) CalendarEvent {
+	e := CalendarEvent{
+		UID:      fmt.Sprintf("appointment-%d@%s", appt.ID, CalendarUIDDomain),
+		Sequence: reschedules,
+		Status:   CalendarStatusConfirmed,
+		Start:    appt.AppointmentDatetime,
+		End:      appt.AppointmentDatetime.Add(time.Duration(appt.DurationMinutes) * time.Minute),
+		Created:  appt.CreatedAt,
+		Modified: appt.UpdatedAt,
+	}
+	switch appt.Status {
+	case appt_booking.AppointmentStatusPending:
+		e.Status = CalendarStatusTentative
+	case appt_booking.AppointmentStatusCancelled:
+		e.Status = CalendarStatusCancelled
+		e.Sequence++
+	}
+	return e
+}
+
+// CalendarService publishes staff members' appointments as iCalendar feeds that calendar
+// apps subscribe to. A feed URL carries a secret token in place of a bearer token.
+type CalendarService struct {
+	booking *ApptBookingService
+	feeds   CalendarFeedRepository
+}
+
+// NewCalendarService creates a calendar service for the appointments of booking
+func NewCalendarService(booking *ApptBookingService, feeds CalendarFeedRepository) *CalendarService {
+	return &CalendarService{booking: booking, feeds: feeds}
+}
+
+// GetFeed returns a staff member's calendar feed, creating it on first use
+func (s *CalendarService) GetFeed(ctx context.Context, staffID int) (*appt_booking.StaffCalendarFeed, error) {
+	if _, err := s.booking.GetStaffByID(ctx, staffID); err != nil {
+		return nil, err
+	}
+	feed, err := s.feeds.GetByStaff(ctx, staffID)
+	if err != nil || feed != nil {
+		return feed, err
+	}
+	token, err := newCalendarFeedToken()
+	if err != nil {
+		return nil, err
+	}
+	return s.feeds.Create(ctx, staffID, token)
+}
+
+// RotateFeed gives a staff member's calendar feed a new token. Subscriptions with the
+// old one stop working.
+func (s *CalendarService) RotateFeed(ctx context.Context, staffID int) (*appt_booking.StaffCalendarFeed, error) {
+	if _, err := s.booking.GetStaffByID(ctx, staffID); err != nil {
+		return nil, err
+	}
+	token, err := newCalendarFeedToken()
+	if err != nil {
+		return nil, err
+	}
+	return s.feeds.Replace(ctx, staffID, token)
+}
+
+// StaffFeed returns a staff member's appointments from the last 30 days on as a calendar,
+// if token is the feed's token. Cancelled appointments are included so that subscribed
+// calendars remove them.
+func (s *CalendarService) StaffFeed(ctx context.Context, staffID int, token string, now time.Time) (Calendar, error) {
+	feed, err := s.feeds.GetByStaff(ctx, staffID)
+	if err != nil {
+		return Calendar{}, err
+	}
+	if feed == nil || subtle.ConstantTimeCompare([]byte(feed.Token), []byte(token)) != 1 {
+		return Calendar{}, NotFound("calendar")
+	}
+	staff, err := s.booking.GetStaffByID(ctx, staffID)
+	if err != nil {
+		return Calendar{}, err
+	}
+	services, err := s.booking.GetAllServices(ctx)
+	if err != nil {
+		return Calendar{}, err
+	}
+	serviceNames := make(map[int]string, len(services))
+	for _, service := range services {
+		serviceNames[service.ID] = service.Name
+	}
+
+	appointments, err := s.staffAppointments(ctx, staffID, now.Add(-calendarFeedHistory))
+	if err != nil {
+		return Calendar{}, err
+	}
+	ids := make([]int, len(appointments))
+	for i, a := range appointments {
+		ids[i] = a.ID
+	}
+	reschedules, err := s.booking.appointmentRepo.CountReschedules(ctx, ids)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	calendar := Calendar{Name: staff.Name + " – Appointments", Location: s.booking.staffLocation(staff)}
+	for i := range appointments {
+		a := &appointments[i]
+		event := appointmentEvent(a, reschedules[a.ID])
+		serviceName := serviceNames[a.ServiceID]
+		if serviceName == "" {
+			serviceName = "Appointment"
+		}
+		event.Summary = serviceName + ": " + a.CustomerName
+		event.Description = staffEventDescription(a)
+		calendar.Events = append(calendar.Events, event)
+	}
+	return calendar, nil
+}
+
+// staffAppointments retrieves all of a staff member's appointments starting from from on
+func (s *CalendarService) staffAppointments(ctx context.Context, staffID int, from time.Time) ([]appt_booking.Appointment, error) {
+	filter := appt_booking.AppointmentFilter{StaffID: staffID, From: &from}
+	opts := appt_booking.ListOptions{Limit: MaxPageSize, Sort: []appt_booking.SortField{{Field: "appointment_datetime"}}}
+	var appointments []appt_booking.Appointment
+	for {
+		page, total, err := s.booking.appointmentRepo.ListWithServiceDetails(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page {
+			appointments = append(appointments, appt_booking.Appointment{
+				ID:                  a.ID,
+				CustomerID:          a.CustomerID,
+				CustomerName:        a.CustomerName,
+				CustomerEmail:       a.CustomerEmail,
+				CustomerPhone:       a.CustomerPhone,
+				StaffID:             a.StaffID,
+				ServiceID:           a.ServiceID,
+				AppointmentDatetime: a.AppointmentDatetime,
+				DurationMinutes:     a.DurationMinutes,
+				Status:              a.Status,
+				Notes:               a.Notes,
+				CreatedAt:           a.CreatedAt,
+				UpdatedAt:           a.UpdatedAt,
+			})
+		}
+		opts.Offset += len(page)
+		if len(page) == 0 || opts.Offset >= total {
+			return appointments, nil
+		}
+	}
+}
+
+// staffEventDescription describes an appointment for the staff member's calendar
+func staffEventDescription(a *appt_booking.Appointment) string {
+	lines := []string{"Customer: " + a.CustomerName, "Email: " + a.CustomerEmail}
+	if a.CustomerPhone != "" {
+		lines = append(lines, "Phone: "+a.CustomerPhone)
+	}
+	lines = append(lines, "Status: "+a.Status)
+	if a.Notes != "" {
+		lines = append(lines, "", a.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newCalendarFeedToken returns a random token for a calendar feed URL
+func newCalendarFeedToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package appt_booking
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// calendarProperty returns the value of the first unfolded content line of ics starting
+// with name, or "" if there is none
+func calendarProperty(ics, name string) string {
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if strings.HasPrefix(line, name) {
+			return strings.TrimPrefix(line, name)
+		}
+	}
+	return ""
+}
+
+func TestCalendarEncode_TimezoneFoldingAndEscaping(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	start := time.Date(2030, 7, 1, 14, 0, 0, 0, time.UTC)
+	description := strings.Repeat("Long notes; with, special characters – ", 4) + "\nsecond line"
+	ics := string(Calendar{Location: newYork, Events: []CalendarEvent{{
+		UID: "appointment-1@example.com", Status: CalendarStatusConfirmed,
+		Start: start, End: start.Add(time.Hour), Summary: "Haircut", Description: description,
+		Created: start, Modified: start,
+	}}}.Encode())
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 || !utf8.ValidString(line) {
+			t.Errorf("line not folded at a character boundary within 75 octets: %q", line)
+		}
+	}
+	if got := calendarProperty(ics, "DTSTART;TZID=America/New_York:"); got != "20300701T100000" {
+		t.Errorf("expected the start in New York time, got %q", got)
+	}
+	if got := calendarProperty(ics, "DESCRIPTION:"); !strings.HasPrefix(got, `Long notes\; with\, special`) || !strings.HasSuffix(got, `\nsecond line`) {
+		t.Errorf("unexpected escaping %q", got)
+	}
+
+	// Daylight saving time starts on 10 March 2030 at 02:00 and ends on 3 November
+	for _, want := range []string{
+		"BEGIN:DAYLIGHT\r\nDTSTART:20300310T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20301103T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("VTIMEZONE lacks %q:\n%s", want, ics)
+		}
+	}
+}
+
+func TestCalendarEncode_UTC(t *testing.T) {
+	start := monday.Add(10 * time.Hour)
+	ics := string(Calendar{Events: []CalendarEvent{{UID: "a@example.com", Start: start, End: start.Add(time.Hour)}}}.Encode())
+	if strings.Contains(ics, "VTIMEZONE") || calendarProperty(ics, "DTSTART:") != "20300107T100000Z" {
+		t.Errorf("expected UTC times without a VTIMEZONE:\n%s", ics)
+	}
+}
+
+func TestStaffFeed(t *testing.T) {
+	f := newTestFixture(t)
+	calendars := NewCalendarService(f.svc, f.store.CalendarFeeds())
+	a := f.book(t, 10*time.Hour)
+	now := monday
+
+	feed, err := calendars.GetFeed(f.ctx, f.staff.ID)
+	if err != nil {
+		t.Fatalf("get feed: %v", err)
+	}
+	if again, _ := calendars.GetFeed(f.ctx, f.staff.ID); again.Token != feed.Token {
+		t.Errorf("expected the same feed on every get")
+	}
+	_, err = calendars.StaffFeed(f.ctx, f.staff.ID, "guess", now)
+	checkKind(t, err, KindNotFound)
+
+	calendar, err := calendars.StaffFeed(f.ctx, f.staff.ID, feed.Token, now)
+	if err != nil {
+		t.Fatalf("staff feed: %v", err)
+	}
+	ics := string(calendar.Encode())
+	uid := calendarProperty(ics, "UID:")
+	if uid == "" || calendarProperty(ics, "SUMMARY:") != "Haircut: Bob" || calendarProperty(ics, "SEQUENCE:") != "0" ||
+		calendarProperty(ics, "X-WR-CALNAME:") != "Alice – Appointments" {
+		t.Fatalf("unexpected feed:\n%s", ics)
+	}
+
+	// Rescheduling and cancelling update the same event with a higher sequence
+	if _, err := f.svc.RescheduleAppointment(f.ctx, a.ID, 0, monday.Add(11*time.Hour)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	calendar, _ = calendars.StaffFeed(f.ctx, f.staff.ID, feed.Token, now)
+	ics = string(calendar.Encode())
+	if calendarProperty(ics, "UID:") != uid || calendarProperty(ics, "SEQUENCE:") != "1" || calendarProperty(ics, "DTSTART:") != "20300107T110000Z" {
+		t.Errorf("unexpected rescheduled event:\n%s", ics)
+	}
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	calendar, _ = calendars.StaffFeed(f.ctx, f.staff.ID, feed.Token, now)
+	ics = string(calendar.Encode())
+	if calendarProperty(ics, "UID:") != uid || calendarProperty(ics, "SEQUENCE:") != "2" || calendarProperty(ics, "STATUS:") != CalendarStatusCancelled {
+		t.Errorf("unexpected cancelled event:\n%s", ics)
+	}
+
+	// Appointments long past drop out of the feed
+	calendar, _ = calendars.StaffFeed(f.ctx, f.staff.ID, feed.Token, monday.Add(31*24*time.Hour))
+	if len(calendar.Events) != 0 {
+		t.Errorf("expected no events a month later, got %d", len(calendar.Events))
+	}
+
+	// Rotating the token revokes the old feed URL
+	rotated, err := calendars.RotateFeed(f.ctx, f.staff.ID)
+	if err != nil || rotated.Token == feed.Token {
+		t.Fatalf("expected a new token, got %+v, %v", rotated, err)
+	}
+	_, err = calendars.StaffFeed(f.ctx, f.staff.ID, feed.Token, now)
+	checkKind(t, err, KindNotFound)
+	_, err = calendars.GetFeed(f.ctx, 9999)
+	checkKind(t, err, KindNotFound)
+}
+
+func TestNotifications_AttachAppointmentCalendar(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	_, dispatcher := f.newNotificationService(notifier)
+	a := f.book(t, 10*time.Hour)
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if len(notifier.sent) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(notifier.sent))
+	}
+	for i, wantStatus := range []string{CalendarStatusConfirmed, CalendarStatusCancelled} {
+		msg := notifier.sent[i]
+		if len(msg.Attachments) != 1 || !strings.HasPrefix(msg.Attachments[0].ContentType, "text/calendar") {
+			t.Fatalf("expected a calendar attachment, got %+v", msg.Attachments)
+		}
+		ics := string(msg.Attachments[0].Data)
+		if calendarProperty(ics, "STATUS:") != wantStatus || calendarProperty(ics, "SUMMARY:") != "Haircut with Alice" {
+			t.Errorf("unexpected attachment:\n%s", ics)
+		}
+	}
+	// Composed into a multipart/mixed message
+	body, err := composeEmail("bookings@example.com", notifier.sent[0], time.Now())
+	if err != nil || !strings.Contains(string(body), "multipart/mixed") || !strings.Contains(string(body), `filename=appointment-`) {
+		t.Errorf("unexpected message %s, %v", body, err)
+	}
+}
@@ -0,0 +1,90 @@
+package appt_booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// CancellationConfig configures the consequences of late cancellations and no-shows
+type CancellationConfig struct {
+	NoShowLimit int // customers with this many no-shows cannot book for themselves; 0 = no limit
+}
+
+// CancelAppointment cancels an appointment with an optional reason. Customers cancelling
+// for themselves within the service's cancellation notice cancel late, which counts
+// against them; cancellations by staff never do.
+func (s *ApptBookingService) CancelAppointment(ctx context.Context, id int, reason string) (*appt_booking.AppointmentCancellation, error) {
+	if _, err := s.TransitionAppointment(ctx, id, appt_booking.AppointmentStatusCancelled, principalEmail(ctx), reason); err != nil {
+		return nil, err
+	}
+	return s.GetAppointmentCancellation(ctx, id)
+}
+
+// MarkNoShow records that the customer did not turn up to an appointment that has started
+func (s *ApptBookingService) MarkNoShow(ctx context.Context, id int, reason string) (*appt_booking.Appointment, error) {
+	return s.TransitionAppointment(ctx, id, appt_booking.AppointmentStatusNoShow, principalEmail(ctx), reason)
+}
+
+// GetAppointmentCancellation retrieves who cancelled an appointment and why, returning a
+// KindNotFound error if it has not been cancelled
+func (s *ApptBookingService) GetAppointmentCancellation(ctx context.Context, id int) (*appt_booking.AppointmentCancellation, error) {
+	cancellation, err := s.appointmentRepo.GetCancellation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation == nil {
+		return nil, NotFound("cancellation")
+	}
+	return cancellation, nil
+}
+
+// newCancellation describes the cancellation of appt by the request's principal. Requests
+// without a customer principal are treated as staff cancellations.
+func (s *ApptBookingService) newCancellation(ctx context.Context, appt *appt_booking.Appointment, reason string) (*appt_booking.AppointmentCancellation, error) {
+	cancellation := &appt_booking.AppointmentCancellation{
+		AppointmentID: appt.ID,
+		CancelledBy:   appt_booking.CancelledByStaff,
+		Reason:        reason,
+	}
+	if !PrincipalFrom(ctx).HasRole(appt_booking.RoleCustomer) {
+		return cancellation, nil
+	}
+
+	cancellation.CancelledBy = appt_booking.CancelledByCustomer
+	service, err := s.serviceRepo.GetByID(ctx, appt.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	if service != nil && service.CancelNoticeMin > 0 {
+		deadline := appt.AppointmentDatetime.Add(-time.Duration(service.CancelNoticeMin) * time.Minute)
+		cancellation.Late = time.Now().After(deadline)
+	}
+	return cancellation, nil
+}
+
+// checkNoShowLimit refuses customers booking for themselves once they reach the no-show limit.
+// Staff can still book for them.
+func (s *ApptBookingService) checkNoShowLimit(ctx context.Context, customer *appt_booking.Customer) error {
+	if s.cancellation.NoShowLimit <= 0 || !PrincipalFrom(ctx).HasRole(appt_booking.RoleCustomer) {
+		return nil
+	}
+	stats, err := s.customerRepo.GetStats(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+	if stats.NoShowCount >= s.cancellation.NoShowLimit {
+		return PreconditionFailed(fmt.Sprintf("online booking is blocked after %d missed appointments; please contact us to book", stats.NoShowCount), nil)
+	}
+	return nil
+}
+
+// principalEmail returns the email of the request's principal, or "" for anonymous requests
+func principalEmail(ctx context.Context) string {
+	if p := PrincipalFrom(ctx); p != nil {
+		return p.Email
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// asCustomer returns a copy of the fixture acting as Bob signed in as a customer
+func (f *testFixture) asCustomer() *testFixture {
+	g := *f
+	g.ctx = WithPrincipal(f.ctx, &Principal{Role: appt_booking.RoleCustomer, Email: "bob@example.com"})
+	return &g
+}
+
+// bookPast inserts a confirmed appointment for Bob that started ago, bypassing the booking policy
+func (f *testFixture) bookPast(t *testing.T, customerID int, ago time.Duration) *appt_booking.Appointment {
+	t.Helper()
+	a, err := f.store.Appointments().CreateExclusive(f.ctx, customerID, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID,
+		f.service.DurationMin, time.Now().Add(-ago).Truncate(time.Minute), appt_booking.AppointmentStatusConfirmed, "", nil)
+	if err != nil {
+		t.Fatalf("create appointment: %v", err)
+	}
+	return a
+}
+
+func TestCancelAppointment_LateCancellation(t *testing.T) {
+	f := newTestFixture(t)
+	// monday is years ahead, so any cancellation falls within a ten-year notice
+	f.setPolicy(t, appt_booking.BookingPolicy{CancelNoticeMin: 10 * 365 * 24 * 60})
+	customer := f.asCustomer()
+
+	a := f.book(t, 10*time.Hour)
+	cancellation, err := customer.svc.CancelAppointment(customer.ctx, a.ID, "Feeling unwell")
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancellation.CancelledBy != appt_booking.CancelledByCustomer || !cancellation.Late || cancellation.Reason != "Feeling unwell" {
+		t.Fatalf("expected a late customer cancellation, got %+v", cancellation)
+	}
+
+	b := f.book(t, 11*time.Hour)
+	if cancellation, err = f.svc.CancelAppointment(f.ctx, b.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancellation.CancelledBy != appt_booking.CancelledByStaff || cancellation.Late {
+		t.Fatalf("expected an on-time staff cancellation, got %+v", cancellation)
+	}
+
+	stats, err := f.svc.GetCustomerStats(f.ctx, a.CustomerID)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.LateCancelCount != 1 {
+		t.Fatalf("expected 1 late cancellation, got %d", stats.LateCancelCount)
+	}
+
+	_, err = f.svc.GetAppointmentCancellation(f.ctx, f.book(t, 12*time.Hour).ID)
+	checkKind(t, err, KindNotFound)
+}
+
+func TestCancelAppointment_OutsideNotice(t *testing.T) {
+	f := newTestFixture(t)
+	f.setPolicy(t, appt_booking.BookingPolicy{CancelNoticeMin: 24 * 60})
+	customer := f.asCustomer()
+
+	a := f.book(t, 10*time.Hour)
+	cancellation, err := customer.svc.CancelAppointment(customer.ctx, a.ID, "")
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancellation.Late {
+		t.Fatal("expected a cancellation well ahead of the notice to be on time")
+	}
+}
+
+func TestMarkNoShow(t *testing.T) {
+	f := newTestFixture(t)
+	future := f.book(t, 10*time.Hour)
+	_, err := f.svc.MarkNoShow(f.ctx, future.ID, "")
+	checkKind(t, err, KindPreconditionFailed)
+
+	past := f.bookPast(t, future.CustomerID, time.Hour)
+	updated, err := f.svc.MarkNoShow(f.ctx, past.ID, "Did not arrive")
+	if err != nil {
+		t.Fatalf("no-show: %v", err)
+	}
+	if updated.Status != appt_booking.AppointmentStatusNoShow {
+		t.Fatalf("expected status no_show, got %s", updated.Status)
+	}
+
+	stats, err := f.svc.GetCustomerStats(f.ctx, future.CustomerID)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.NoShowCount != 1 {
+		t.Fatalf("expected 1 no-show, got %d", stats.NoShowCount)
+	}
+}
+
+func TestBookAppointment_NoShowLimit(t *testing.T) {
+	f := newTestFixture(t)
+	f.svc.cancellation = CancellationConfig{NoShowLimit: 2}
+	customer := f.asCustomer()
+
+	first := f.book(t, 10*time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, err := f.svc.MarkNoShow(f.ctx, f.bookPast(t, first.CustomerID, time.Duration(i+1)*2*time.Hour).ID, ""); err != nil {
+			t.Fatalf("no-show: %v", err)
+		}
+	}
+
+	_, err := customer.svc.BookAppointment(customer.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(11*time.Hour), "")
+	checkKind(t, err, KindPreconditionFailed)
+	// staff can still book on the customer's behalf
+	f.book(t, 11*time.Hour)
+}
@@ -0,0 +1,140 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// ========== Customer Operations ==========
+
+// CreateCustomer creates a new customer
+func (s *ApptBookingService) CreateCustomer(ctx context.Context, name, email, phone string) (*appt_booking.Customer, error) {
+	email = normalizeEmail(email)
+	if err := validateCustomer(name, email); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.Create(ctx, name, email, phone)
+	if errors.Is(err, appt_booking.ErrCustomerEmailTaken) {
+		return nil, Conflict("customer with this email already exists", err)
+	}
+	return customer, err
+}
+
+// UpdateCustomer modifies an existing customer. Appointments keep the contact details
+// they were booked with but stay linked to the customer.
+func (s *ApptBookingService) UpdateCustomer(ctx context.Context, id int, name, email, phone string) (*appt_booking.Customer, error) {
+	email = normalizeEmail(email)
+	if err := validateCustomer(name, email); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.customerRepo.Update(ctx, id, name, email, phone)
+	if errors.Is(err, appt_booking.ErrCustomerEmailTaken) {
+		return nil, Conflict("email is already used by another customer", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("customer")
+	}
+	return updated, nil
+}
+
+// GetCustomerByID retrieves a customer by ID
+func (s *ApptBookingService) GetCustomerByID(ctx context.Context, id int) (*appt_booking.Customer, error) {
+	found, err := s.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, NotFound("customer")
+	}
+	return found, nil
+}
+
+// GetCustomerStats retrieves a customer's visit count, lifetime spend and last visit
+func (s *ApptBookingService) GetCustomerStats(ctx context.Context, id int) (*appt_booking.CustomerStats, error) {
+	if _, err := s.GetCustomerByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.customerRepo.GetStats(ctx, id)
+}
+
+// DeleteCustomer removes a customer without appointments
+func (s *ApptBookingService) DeleteCustomer(ctx context.Context, id int) error {
+	stats, err := s.GetCustomerStats(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stats.AppointmentCount > 0 {
+		return PreconditionFailed("cannot delete customer with existing appointments", nil)
+	}
+	return s.customerRepo.Delete(ctx, id)
+}
+
+// IsCustomerAppointment reports whether a belongs to the customer with email, including
+// appointments booked under the customer's previous email
+func (s *ApptBookingService) IsCustomerAppointment(ctx context.Context, email string, a *appt_booking.Appointment) (bool, error) {
+	customer, err := s.customerRepo.GetByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return false, err
+	}
+	return customer != nil && customer.ID == a.CustomerID, nil
+}
+
+// CustomerIDForEmail returns the ID of the customer with email, or 0 if there is none
+func (s *ApptBookingService) CustomerIDForEmail(ctx context.Context, email string) (int, error) {
+	customer, err := s.customerRepo.GetByEmail(ctx, normalizeEmail(email))
+	if err != nil || customer == nil {
+		return 0, err
+	}
+	return customer.ID, nil
+}
+
+// findOrCreateCustomer returns the customer with email, creating them from the booking's
+// contact details if there is none. An existing customer's details are left unchanged.
+func (s *ApptBookingService) findOrCreateCustomer(ctx context.Context, name, email, phone string) (*appt_booking.Customer, error) {
+	email = normalizeEmail(email)
+	existing, err := s.customerRepo.GetByEmail(ctx, email)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	customer, err := s.customerRepo.Create(ctx, name, email, phone)
+	if errors.Is(err, appt_booking.ErrCustomerEmailTaken) {
+		// Created by a concurrent booking
+		return s.customerRepo.GetByEmail(ctx, email)
+	}
+	return customer, err
+}
+
+// bookingCustomer returns the customer a booking is for like findOrCreateCustomer, refusing
+// customers who book for themselves while over the no-show limit
+func (s *ApptBookingService) bookingCustomer(ctx context.Context, name, email, phone string) (*appt_booking.Customer, error) {
+	customer, err := s.findOrCreateCustomer(ctx, name, email, phone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoShowLimit(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// validateCustomer checks the required customer fields; email is already normalized
+func validateCustomer(name, email string) error {
+	if name == "" {
+		return Invalid("name", "customer name is required")
+	}
+	if email == "" {
+		return Invalid("email", "customer email is required")
+	}
+	if !contains(email, "@") {
+		return Invalid("email", "invalid email format")
+	}
+	return nil
+}
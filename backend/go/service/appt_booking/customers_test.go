@@ -0,0 +1,95 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBookAppointment_LinksCustomer(t *testing.T) {
+	f := newTestFixture(t)
+	first := f.book(t, 9*time.Hour)
+
+	// Same person, different casing and a new phone number
+	second, err := f.svc.BookAppointment(f.ctx, "Bobby", " BOB@example.com", "555-0199", f.staff.ID, f.service.ID, monday.Add(11*time.Hour), "")
+	checkKind(t, err, "")
+	if first.CustomerID == 0 || second.CustomerID != first.CustomerID {
+		t.Fatalf("expected both appointments to link to one customer, got %d and %d", first.CustomerID, second.CustomerID)
+	}
+	if second.CustomerName != "Bobby" || second.CustomerPhone != "555-0199" {
+		t.Errorf("expected the appointment to keep the details it was booked with, got %+v", second)
+	}
+
+	customer, err := f.svc.GetCustomerByID(f.ctx, first.CustomerID)
+	checkKind(t, err, "")
+	if customer.Email != "bob@example.com" || customer.Name != "Bob" {
+		t.Errorf("unexpected customer: %+v", customer)
+	}
+
+	// After an email change the history stays with the customer
+	if _, err := f.svc.UpdateCustomer(f.ctx, customer.ID, "Bob", "robert@example.com", "555-0199"); err != nil {
+		t.Fatalf("update customer: %v", err)
+	}
+	appointments, err := f.svc.GetAppointmentsByCustomer(f.ctx, "Robert@example.com")
+	checkKind(t, err, "")
+	if len(appointments) != 2 {
+		t.Errorf("expected 2 appointments under the new email, got %d", len(appointments))
+	}
+	owned, err := f.svc.IsCustomerAppointment(f.ctx, "robert@example.com", first)
+	checkKind(t, err, "")
+	if !owned {
+		t.Error("expected the appointment to belong to the customer after the email change")
+	}
+}
+
+func TestGetCustomerStats(t *testing.T) {
+	f := newTestFixture(t)
+	visit := f.book(t, 9*time.Hour)
+	laterVisit := f.book(t, 10*time.Hour)
+	f.book(t, 11*time.Hour) // still upcoming
+	for _, a := range []int{visit.ID, laterVisit.ID} {
+		if err := f.svc.CompleteAppointment(f.ctx, a); err != nil {
+			t.Fatalf("complete appointment: %v", err)
+		}
+	}
+
+	stats, err := f.svc.GetCustomerStats(f.ctx, visit.CustomerID)
+	checkKind(t, err, "")
+	if stats.AppointmentCount != 3 || stats.VisitCount != 2 || stats.LifetimeSpendCents != 6000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.LastVisit == nil || !stats.LastVisit.Equal(laterVisit.AppointmentDatetime) {
+		t.Errorf("expected last visit %s, got %v", laterVisit.AppointmentDatetime, stats.LastVisit)
+	}
+
+	_, err = f.svc.GetCustomerStats(f.ctx, 9999)
+	checkKind(t, err, KindNotFound)
+}
+
+func TestCustomerCRUD(t *testing.T) {
+	f := newTestFixture(t)
+
+	_, err := f.svc.CreateCustomer(f.ctx, "", "carol@example.com", "")
+	checkKind(t, err, KindValidation)
+	_, err = f.svc.CreateCustomer(f.ctx, "Carol", "carol", "")
+	checkKind(t, err, KindValidation)
+
+	carol, err := f.svc.CreateCustomer(f.ctx, "Carol", "Carol@Example.com", "555-0100")
+	checkKind(t, err, "")
+	if carol.Email != "carol@example.com" {
+		t.Errorf("expected normalized email, got %q", carol.Email)
+	}
+	_, err = f.svc.CreateCustomer(f.ctx, "Carol Again", "carol@example.com", "")
+	checkKind(t, err, KindConflict)
+
+	booked := f.book(t, 9*time.Hour)
+	_, err = f.svc.UpdateCustomer(f.ctx, booked.CustomerID, "Bob", "carol@example.com", "")
+	checkKind(t, err, KindConflict)
+	_, err = f.svc.UpdateCustomer(f.ctx, 9999, "Nobody", "nobody@example.com", "")
+	checkKind(t, err, KindNotFound)
+
+	// Customers with appointments cannot be deleted
+	checkKind(t, f.svc.DeleteCustomer(f.ctx, booked.CustomerID), KindPreconditionFailed)
+	checkKind(t, f.svc.DeleteCustomer(f.ctx, carol.ID), "")
+	_, err = f.svc.GetCustomerByID(f.ctx, carol.ID)
+	checkKind(t, err, KindNotFound)
+}
@@ -0,0 +1,87 @@
+package appt_booking
+
+import "errors"
+
+// ErrorKind classifies domain errors so callers can react without matching on messages
+type ErrorKind string
+
+const (
+	// KindNotFound means a referenced resource does not exist
+	KindNotFound ErrorKind = "not_found"
+	// KindValidation means the input is malformed or incomplete
+	KindValidation ErrorKind = "validation"
+	// KindConflict means the request collides with existing data, e.g. an overlapping booking
+	KindConflict ErrorKind = "conflict"
+	// KindPreconditionFailed means the resource is not in a state that allows the operation
+	KindPreconditionFailed ErrorKind = "precondition_failed"
+	// KindUnauthorized means the caller is not authenticated or the credentials are invalid
+	KindUnauthorized ErrorKind = "unauthorized"
+	// KindForbidden means the caller is authenticated but not allowed to perform the operation
+	KindForbidden ErrorKind = "forbidden"
+)
+
+// FieldError describes a problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error returned by ApptBookingService.
+// Errors that are not of this type are unexpected (database failures and the like).
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Fields  []FieldError
+	Err     error // underlying cause, if any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns a KindNotFound error for the named resource, e.g. NotFound("staff")
+func NotFound(resource string) *Error {
+	return &Error{Kind: KindNotFound, Message: resource + " not found"}
+}
+
+// Invalid returns a KindValidation error for a single input field
+func Invalid(field, message string) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// Conflict returns a KindConflict error; cause may be nil
+func Conflict(message string, cause error) *Error {
+	return &Error{Kind: KindConflict, Message: message, Err: cause}
+}
+
+// PreconditionFailed returns a KindPreconditionFailed error; cause may be nil
+func PreconditionFailed(message string, cause error) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message, Err: cause}
+}
+
+// Unauthorized returns a KindUnauthorized error
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// Forbidden returns a KindForbidden error
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// KindOf returns the kind of a domain error, or "" if err is not one
+func KindOf(err error) ErrorKind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return ""
+}
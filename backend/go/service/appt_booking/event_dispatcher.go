@@ -0,0 +1,198 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Dispatcher defaults, used for zero DispatcherConfig fields
+const (
+	defaultDispatchPollInterval = 2 * time.Second
+	defaultDispatchBatchSize    = 50
+	defaultDispatchMaxAttempts  = 10
+	defaultDispatchRetryBackoff = 5 * time.Second
+	defaultDispatchMaxBackoff   = time.Hour
+	defaultDispatchLease        = time.Minute
+)
+
+// DispatcherConfig configures event delivery
+type DispatcherConfig struct {
+	PollInterval time.Duration // how often Run looks for due events
+	BatchSize    int           // events claimed per poll
+	MaxAttempts  int           // attempts before an event is marked failed
+	RetryBackoff time.Duration // delay after the first failed attempt; doubles with each further one
+	MaxBackoff   time.Duration // upper bound on the delay between attempts
+	Lease        time.Duration // how long a claimed event is hidden from other dispatchers
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c DispatcherConfig) withDefaults() DispatcherConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultDispatchPollInterval
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultDispatchBatchSize
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultDispatchMaxAttempts
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaultDispatchRetryBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultDispatchMaxBackoff
+	}
+	if c.Lease <= 0 {
+		c.Lease = defaultDispatchLease
+	}
+	return c
+}
+
+// EventHandler reacts to a domain event. Delivery is at least once: an event is handed to
+// every subscribed handler again when any of them fails, and after a crash, so handlers
+// must be idempotent, e.g. by remembering the IDs of events they have processed.
+type EventHandler func(ctx context.Context, event appt_booking.OutboxEvent) error
+
+// subscription is a handler registered for one event type, or all of them
+type subscription struct {
+	name      string
+	eventType string // "" matches every event
+	handle    EventHandler
+}
+
+// EventDispatcher delivers the domain events recorded in the outbox to the handlers
+// subscribed to them, retrying failed deliveries with exponential backoff. Each poll
+// claims the oldest due events first, but an event whose delivery failed is retried after
+// later ones, and concurrent dispatchers deliver in parallel, so handlers must not rely on
+// events arriving in the order they were recorded.
+type EventDispatcher struct {
+	outbox OutboxRepository
+	config DispatcherConfig
+
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+// NewEventDispatcher creates a dispatcher for the events in outbox. Zero config fields
+// take their defaults.
+func NewEventDispatcher(outbox OutboxRepository, config DispatcherConfig) *EventDispatcher {
+	return &EventDispatcher{outbox: outbox, config: config.withDefaults()}
+}
+
+// Subscribe registers handler for events of eventType, or for every event if eventType is
+// empty. name identifies the handler in delivery errors.
+func (d *EventDispatcher) Subscribe(name, eventType string, handler EventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscriptions = append(d.subscriptions, subscription{name: name, eventType: eventType, handle: handler})
+}
+
+// DispatchDue delivers the events that are due now. Returns the number delivered.
+func (d *EventDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	events, err := d.outbox.ClaimDue(ctx, now, d.config.Lease, d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			if err := d.recordFailure(ctx, event, err); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+		if err := d.outbox.MarkDelivered(ctx, event.ID, time.Now()); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// deliver hands event to every handler subscribed to it, returning the failures of all of them
+func (d *EventDispatcher) deliver(ctx context.Context, event appt_booking.OutboxEvent) error {
+	d.mu.RLock()
+	subscriptions := append([]subscription(nil), d.subscriptions...)
+	d.mu.RUnlock()
+
+	var failures []string
+	for _, sub := range subscriptions {
+		if sub.eventType != "" && sub.eventType != event.EventType {
+			continue
+		}
+		if err := sub.handle(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// recordFailure schedules another attempt at event, or marks it failed once it has used
+// up its attempts
+func (d *EventDispatcher) recordFailure(ctx context.Context, event appt_booking.OutboxEvent, deliveryErr error) error {
+	if event.Attempts >= d.config.MaxAttempts {
+		log.Printf("giving up on event %d (%s) after %d attempts: %v", event.ID, event.EventType, event.Attempts, deliveryErr)
+		return d.outbox.MarkFailed(ctx, event.ID, deliveryErr.Error())
+	}
+	return d.outbox.RetryLater(ctx, event.ID, time.Now().Add(d.backoff(event.Attempts)), deliveryErr.Error())
+}
+
+// backoff returns the delay before the attempt following attempt number attempts
+func (d *EventDispatcher) backoff(attempts int) time.Duration {
+	return retryBackoff(d.config.RetryBackoff, d.config.MaxBackoff, attempts)
+}
+
+// retryBackoff returns the delay before the attempt following attempt number attempts:
+// base after the first, doubling with each further one up to max
+func retryBackoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// Run dispatches due events every DispatcherConfig.PollInterval until ctx is done
+func (d *EventDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep going while full batches come back
+			for {
+				n, err := d.DispatchDue(ctx)
+				if err != nil {
+					log.Printf("dispatch events: %v", err)
+					break
+				}
+				if n < d.config.BatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// LogEventHandler writes events to the standard logger
+func LogEventHandler(ctx context.Context, event appt_booking.OutboxEvent) error {
+	log.Printf("event %d %s %s/%d: %s", event.ID, event.EventType, event.AggregateType, event.AggregateID, event.Payload)
+	return nil
+}
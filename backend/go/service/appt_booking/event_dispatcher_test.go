@@ -0,0 +1,133 @@
+package appt_booking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// outboxTypes returns the types of the events recorded in the fixture's outbox, oldest first
+func (f *testFixture) outboxTypes(t *testing.T) []string {
+	t.Helper()
+	events, err := f.store.Outbox().GetAll(f.ctx)
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.EventType)
+	}
+	return types
+}
+
+func TestDomainEvents_RecordedWithChanges(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	if _, err := f.svc.RescheduleAppointment(f.ctx, a.ID, 0, monday.Add(11*time.Hour)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if err := f.svc.CompleteAppointment(f.ctx, a.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	// Failed changes record nothing
+	_, err := f.svc.BookAppointment(f.ctx, "Dave", "dave@example.com", "", f.staff.ID, f.service.ID, monday.Add(11*time.Hour), "")
+	checkKind(t, err, KindConflict)
+
+	want := []string{EventStaffCreated, EventServiceCreated, EventAppointmentBooked, EventAppointmentRescheduled, EventAppointmentCompleted}
+	got := f.outboxTypes(t)
+	if len(got) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, got)
+		}
+	}
+
+	events, _ := f.store.Outbox().GetAll(f.ctx)
+	completed := events[len(events)-1]
+	var payload appt_booking.Appointment
+	if err := json.Unmarshal(completed.Payload, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if completed.AggregateType != appt_booking.AggregateAppointment || completed.AggregateID != a.ID ||
+		payload.ID != a.ID || payload.Status != appt_booking.AppointmentStatusCompleted {
+		t.Errorf("unexpected event %+v with payload %+v", completed, payload)
+	}
+}
+
+func TestEventDispatcher_DeliversToSubscribers(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	d := NewEventDispatcher(f.store.Outbox(), DispatcherConfig{})
+	var all, cancelled []string
+	d.Subscribe("all", "", func(ctx context.Context, e appt_booking.OutboxEvent) error {
+		all = append(all, e.EventType)
+		return nil
+	})
+	d.Subscribe("cancellations", EventAppointmentCancelled, func(ctx context.Context, e appt_booking.OutboxEvent) error {
+		cancelled = append(cancelled, e.EventType)
+		return nil
+	})
+
+	n, err := d.DispatchDue(f.ctx)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if n != 4 || len(all) != 4 || len(cancelled) != 1 {
+		t.Fatalf("expected 4 events delivered and 1 cancellation, got %d: %v and %v", n, all, cancelled)
+	}
+
+	// Delivered events are not handed out again
+	if n, err := d.DispatchDue(f.ctx); err != nil || n != 0 {
+		t.Fatalf("expected nothing left to dispatch, got %d, %v", n, err)
+	}
+}
+
+func TestEventDispatcher_RetriesThenFails(t *testing.T) {
+	f := newTestFixture(t)
+	d := NewEventDispatcher(f.store.Outbox(), DispatcherConfig{MaxAttempts: 2, RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	calls := 0
+	d.Subscribe("flaky", EventStaffCreated, func(ctx context.Context, e appt_booking.OutboxEvent) error {
+		calls++
+		return errors.New("unavailable")
+	})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if _, err := d.DispatchDue(f.ctx); err != nil {
+			t.Fatalf("dispatch %d: %v", attempt, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+
+	events, _ := f.store.Outbox().GetAll(f.ctx)
+	for _, e := range events {
+		want := appt_booking.OutboxStatusDelivered
+		if e.EventType == EventStaffCreated {
+			want = appt_booking.OutboxStatusFailed
+		}
+		if e.Status != want {
+			t.Errorf("expected %s event to be %s, got %s (%s)", e.EventType, want, e.Status, e.LastError)
+		}
+	}
+}
+
+func TestEventDispatcher_Backoff(t *testing.T) {
+	d := NewEventDispatcher(nil, DispatcherConfig{RetryBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 20: 5 * time.Second} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
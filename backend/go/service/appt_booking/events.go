@@ -0,0 +1,61 @@
+package appt_booking
+
+import "k8s-fullstack-blueprint-backend/db/appt_booking"
+
+// Domain event types, recorded in the outbox in the same transaction as the change they
+// describe and delivered by EventDispatcher. The payload is the changed row as JSON (an
+// appt_booking.Appointment, Service, Staff or WaitlistEntry), or an empty object for
+// deletions.
+const (
+	EventAppointmentBooked      = "appointment.booked"
+	EventAppointmentRescheduled = "appointment.rescheduled"
+	// Status changes are "appointment." followed by the new status
+	EventAppointmentConfirmed  = "appointment.confirmed"
+	EventAppointmentCheckedIn  = "appointment.checked_in"
+	EventAppointmentInProgress = "appointment.in_progress"
+	EventAppointmentCompleted  = "appointment.completed"
+	EventAppointmentCancelled  = "appointment.cancelled"
+	EventAppointmentNoShow     = "appointment.no_show"
+
+	EventServiceCreated = "service.created"
+	EventServiceUpdated = "service.updated"
+	EventServiceDeleted = "service.deleted"
+
+	EventStaffCreated = "staff.created"
+	EventStaffUpdated = "staff.updated"
+	EventStaffDeleted = "staff.deleted"
+
+	// EventWaitlistSlotOffered is recorded when a freed slot is held for a waitlist entry.
+	// The hold is looked up by entry; its token is not part of the payload.
+	EventWaitlistSlotOffered = "waitlist.slot_offered"
+)
+
+// domainEventTypes lists every domain event type, e.g. for validating webhook subscriptions
+var domainEventTypes = []string{
+	EventAppointmentBooked, EventAppointmentRescheduled,
+	EventAppointmentConfirmed, EventAppointmentCheckedIn, EventAppointmentInProgress,
+	EventAppointmentCompleted, EventAppointmentCancelled, EventAppointmentNoShow,
+	EventServiceCreated, EventServiceUpdated, EventServiceDeleted,
+	EventStaffCreated, EventStaffUpdated, EventStaffDeleted,
+	EventWaitlistSlotOffered,
+}
+
+// IsDomainEventType reports whether eventType is one of the domain event types
+func IsDomainEventType(eventType string) bool {
+	for _, t := range domainEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// appointmentStatusEvent returns the type of the event recorded when an appointment moves to status
+func appointmentStatusEvent(status string) string {
+	return "appointment." + status
+}
+
+// domainEvent returns an outbox event of eventType about a row of aggregateType
+func domainEvent(eventType, aggregateType string) *appt_booking.OutboxEvent {
+	return &appt_booking.OutboxEvent{EventType: eventType, AggregateType: aggregateType}
+}
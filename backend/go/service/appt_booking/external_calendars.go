@@ -0,0 +1,377 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// External calendar defaults, used for zero ExternalCalendarConfig fields
+const (
+	defaultExternalCalendarRefreshInterval = 15 * time.Minute
+	defaultExternalCalendarHorizon         = 180 * 24 * time.Hour
+	defaultExternalCalendarFetchTimeout    = 30 * time.Second
+	defaultExternalCalendarMaxSize         = 5 << 20
+)
+
+// externalCalendarHistory is how far back busy times are kept, so an appointment being
+// rescheduled today still sees events that began yesterday
+const externalCalendarHistory = 24 * time.Hour
+
+// errBlockedCalendarAddress is returned when a calendar URL leads to an address that
+// calendars must not be fetched from
+var errBlockedCalendarAddress = errors.New("calendar URL leads to a loopback, private or link-local address")
+
+// sharedAddressSpace is 100.64.0.0/10, used inside some clusters and carrier networks
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ExternalCalendarJobName is the name the external calendar refresh is scheduled under
+const ExternalCalendarJobName = "external-calendars"
+
+// ExternalCalendarConfig configures the import of staff members' external calendars
+type ExternalCalendarConfig struct {
+	RefreshInterval time.Duration // how often calendars are fetched and re-expanded
+	Horizon         time.Duration // how far ahead recurring events are expanded into busy times
+	FetchTimeout    time.Duration // limit on each fetch of a calendar URL
+	MaxSize         int64         // largest calendar accepted, in bytes
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c ExternalCalendarConfig) withDefaults() ExternalCalendarConfig {
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultExternalCalendarRefreshInterval
+	}
+	if c.Horizon <= 0 {
+		c.Horizon = defaultExternalCalendarHorizon
+	}
+	if c.FetchTimeout <= 0 {
+		c.FetchTimeout = defaultExternalCalendarFetchTimeout
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultExternalCalendarMaxSize
+	}
+	return c
+}
+
+// ExternalCalendarService imports the events of staff members' calendars kept elsewhere,
+// from an ICS URL or an uploaded ICS file, as busy times that block bookings and
+// availability. Calendars are refreshed periodically; a failed refresh keeps the busy times
+// of the last successful one.
+type ExternalCalendarService struct {
+	booking   *ApptBookingService
+	calendars ExternalCalendarRepository
+	client    *http.Client
+	config    ExternalCalendarConfig
+}
+
+// NewExternalCalendarService creates an external calendar service for the staff members
+// of booking. A nil client is replaced by one with the configured fetch timeout that only
+// connects to public addresses; zero config fields take their defaults.
+func NewExternalCalendarService(booking *ApptBookingService, calendars ExternalCalendarRepository, client *http.Client, config ExternalCalendarConfig) *ExternalCalendarService {
+	config = config.withDefaults()
+	if client == nil {
+		client = newExternalCalendarClient(config.FetchTimeout)
+	}
+	return &ExternalCalendarService{booking: booking, calendars: calendars, client: client, config: config}
+}
+
+// Job returns the periodic job that refreshes every external calendar
+func (s *ExternalCalendarService) Job() Job {
+	return Job{
+		Name:     ExternalCalendarJobName,
+		Interval: s.config.RefreshInterval,
+		Run:      s.SyncAll,
+	}
+}
+
+// AddURLCalendar subscribes a staff member to the calendar at rawURL. webcal:// URLs are
+// fetched over https. The calendar is fetched straight away, and rejected if it cannot be
+// fetched or is not an iCalendar file.
+func (s *ExternalCalendarService) AddURLCalendar(ctx context.Context, staffID int, name, rawURL string) (*appt_booking.ExternalCalendar, error) {
+	fetchURL, err := externalCalendarFetchURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := s.validateCalendar(ctx, staffID, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.fetch(ctx, fetchURL)
+	if err != nil {
+		return nil, Invalid("url", fmt.Sprintf("could not fetch calendar: %v", err))
+	}
+	busy, err := s.expand(data, loc, time.Now())
+	if err != nil {
+		return nil, Invalid("url", fmt.Sprintf("could not read calendar: %v", err))
+	}
+	calendar, err := s.calendars.Create(ctx, staffID, strings.TrimSpace(name), strings.TrimSpace(rawURL), "")
+	if err != nil {
+		return nil, err
+	}
+	return s.store(ctx, calendar, busy, time.Now())
+}
+
+// AddUploadedCalendar adds an ICS file as a staff member's calendar. The file is kept and
+// expanded again on every refresh, so its recurring events stay blocked as time passes.
+func (s *ExternalCalendarService) AddUploadedCalendar(ctx context.Context, staffID int, name string, data []byte) (*appt_booking.ExternalCalendar, error) {
+	loc, err := s.validateCalendar(ctx, staffID, name)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.config.MaxSize {
+		return nil, Invalid("file", fmt.Sprintf("calendar must not exceed %d bytes", s.config.MaxSize))
+	}
+	busy, err := s.expand(data, loc, time.Now())
+	if err != nil {
+		return nil, Invalid("file", fmt.Sprintf("could not read calendar: %v", err))
+	}
+	calendar, err := s.calendars.Create(ctx, staffID, strings.TrimSpace(name), "", string(data))
+	if err != nil {
+		return nil, err
+	}
+	return s.store(ctx, calendar, busy, time.Now())
+}
+
+// validateCalendar checks the settings of a new calendar and returns the timezone of its
+// staff member, which its floating times are taken to be in
+func (s *ExternalCalendarService) validateCalendar(ctx context.Context, staffID int, name string) (*time.Location, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, Invalid("name", "name is required")
+	}
+	if len(name) > 255 {
+		return nil, Invalid("name", "name must not exceed 255 characters")
+	}
+	staff, err := s.booking.GetStaffByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+	return s.booking.staffLocation(staff), nil
+}
+
+// ListCalendars returns a staff member's external calendars
+func (s *ExternalCalendarService) ListCalendars(ctx context.Context, staffID int) ([]appt_booking.ExternalCalendar, error) {
+	if _, err := s.booking.GetStaffByID(ctx, staffID); err != nil {
+		return nil, err
+	}
+	calendars, err := s.calendars.GetByStaff(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if calendars == nil {
+		calendars = []appt_booking.ExternalCalendar{}
+	}
+	return calendars, nil
+}
+
+// GetCalendar retrieves one of a staff member's external calendars
+func (s *ExternalCalendarService) GetCalendar(ctx context.Context, staffID, id int) (*appt_booking.ExternalCalendar, error) {
+	calendar, err := s.calendars.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if calendar == nil || calendar.StaffID != staffID {
+		return nil, NotFound("external calendar")
+	}
+	return calendar, nil
+}
+
+// DeleteCalendar removes one of a staff member's external calendars; its events stop
+// blocking them
+func (s *ExternalCalendarService) DeleteCalendar(ctx context.Context, staffID, id int) error {
+	if _, err := s.GetCalendar(ctx, staffID, id); err != nil {
+		return err
+	}
+	return s.calendars.Delete(ctx, id)
+}
+
+// SyncCalendar refreshes one of a staff member's external calendars now. A failed refresh
+// is not an error; it is reported in the returned calendar's LastError.
+func (s *ExternalCalendarService) SyncCalendar(ctx context.Context, staffID, id int) (*appt_booking.ExternalCalendar, error) {
+	calendar, err := s.GetCalendar(ctx, staffID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sync(ctx, calendar, time.Now()); err != nil && !isExternalCalendarError(err) {
+		return nil, err
+	}
+	return s.GetCalendar(ctx, staffID, id)
+}
+
+// SyncAll refreshes every external calendar as of now. Calendars that fail to refresh
+// keep their busy times and record the failure; the others are refreshed regardless.
+func (s *ExternalCalendarService) SyncAll(ctx context.Context, now time.Time) error {
+	calendars, err := s.calendars.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i := range calendars {
+		if err := s.sync(ctx, &calendars[i], now); err != nil {
+			if !isExternalCalendarError(err) {
+				return err
+			}
+			log.Printf("refresh external calendar %d: %v", calendars[i].ID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d external calendars failed to refresh", failed, len(calendars))
+	}
+	return nil
+}
+
+// externalCalendarError is a failure to fetch or read a calendar, as opposed to a failure
+// to store the result
+type externalCalendarError struct {
+	err error
+}
+
+func (e *externalCalendarError) Error() string { return e.err.Error() }
+func (e *externalCalendarError) Unwrap() error { return e.err }
+
+func isExternalCalendarError(err error) bool {
+	var target *externalCalendarError
+	return errors.As(err, &target)
+}
+
+// sync fetches or re-reads a calendar and replaces its busy times. A failure to fetch or
+// read it is recorded on the calendar and returned as an *externalCalendarError.
+func (s *ExternalCalendarService) sync(ctx context.Context, calendar *appt_booking.ExternalCalendar, now time.Time) error {
+	loc, err := s.booking.LocationForStaff(ctx, calendar.StaffID)
+	if err != nil {
+		return err
+	}
+	data := []byte(calendar.Data)
+	if calendar.URL != "" {
+		fetchURL, err := externalCalendarFetchURL(calendar.URL)
+		if err == nil {
+			data, err = s.fetch(ctx, fetchURL)
+		}
+		if err != nil {
+			return s.recordError(ctx, calendar, fmt.Errorf("fetch: %w", err), now)
+		}
+	}
+	busy, err := s.expand(data, loc, now)
+	if err != nil {
+		return s.recordError(ctx, calendar, fmt.Errorf("read: %w", err), now)
+	}
+	_, err = s.store(ctx, calendar, busy, now)
+	return err
+}
+
+// recordError records a failed refresh of calendar and returns it as an
+// *externalCalendarError
+func (s *ExternalCalendarService) recordError(ctx context.Context, calendar *appt_booking.ExternalCalendar, err error, now time.Time) error {
+	if recordErr := s.calendars.RecordSyncError(ctx, calendar.ID, err.Error(), now); recordErr != nil {
+		return recordErr
+	}
+	return &externalCalendarError{err: err}
+}
+
+// store replaces calendar's busy times with busy and returns the updated calendar
+func (s *ExternalCalendarService) store(ctx context.Context, calendar *appt_booking.ExternalCalendar, busy []TimeSlot, now time.Time) (*appt_booking.ExternalCalendar, error) {
+	times := make([]appt_booking.ExternalBusyTime, len(busy))
+	for i, b := range busy {
+		times[i] = appt_booking.ExternalBusyTime{CalendarID: calendar.ID, StaffID: calendar.StaffID, StartTime: b.Start, EndTime: b.End}
+	}
+	if err := s.calendars.ReplaceBusyTimes(ctx, calendar.ID, times, now); err != nil {
+		return nil, err
+	}
+	updated, err := s.calendars.GetByID(ctx, calendar.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, NotFound("external calendar")
+	}
+	return updated, nil
+}
+
+// expand returns the busy times of a calendar from a day before now to the horizon
+func (s *ExternalCalendarService) expand(data []byte, loc *time.Location, now time.Time) ([]TimeSlot, error) {
+	return ParseCalendarBusyTimes(data, loc, now.Add(-externalCalendarHistory), now.Add(s.config.Horizon))
+}
+
+// fetch downloads the calendar at fetchURL
+func (s *ExternalCalendarService) fetch(ctx context.Context, fetchURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fetchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar, */*;q=0.5")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.config.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.config.MaxSize {
+		return nil, fmt.Errorf("calendar exceeds %d bytes", s.config.MaxSize)
+	}
+	return data, nil
+}
+
+// newExternalCalendarClient returns a client that refuses to connect to loopback, private,
+// link-local and unspecified addresses. Providers choose the URLs fetched, so without this
+// they could probe the host and the cluster. The check runs on each connection, after DNS
+// resolution, so neither hostnames nor redirects get around it; requests are not proxied,
+// as the proxy's address would be the one checked.
+func newExternalCalendarClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseInternalAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// refuseInternalAddress is a net.Dialer Control hook that rejects connections to
+// addresses calendars must not be fetched from
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return errBlockedCalendarAddress
+	}
+	return nil
+}
+
+// externalCalendarFetchURL validates a calendar URL and returns the URL to fetch it from
+func externalCalendarFetchURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return "", Invalid("url", "url must be an absolute http, https or webcal URL")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "webcal", "webcals":
+		u.Scheme = "https"
+	default:
+		return "", Invalid("url", "url must be an absolute http, https or webcal URL")
+	}
+	return u.String(), nil
+}
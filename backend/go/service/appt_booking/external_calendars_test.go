@@ -0,0 +1,298 @@
+package appt_booking
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// icsCalendar wraps content lines in a VCALENDAR with CRLF line endings
+func icsCalendar(lines ...string) []byte {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//EN"}, lines...)
+	all = append(all, "END:VCALENDAR", "")
+	return []byte(strings.Join(all, "\r\n"))
+}
+
+// checkBusy fails the test unless busy is exactly want
+func checkBusy(t *testing.T, busy []TimeSlot, want ...TimeSlot) {
+	t.Helper()
+	if len(busy) != len(want) {
+		t.Fatalf("expected %d busy times, got %v", len(want), busy)
+	}
+	for i := range want {
+		if !busy[i].Start.Equal(want[i].Start) || !busy[i].End.Equal(want[i].End) {
+			t.Errorf("busy time %d: expected %v-%v, got %v-%v", i, want[i].Start, want[i].End, busy[i].Start, busy[i].End)
+		}
+	}
+}
+
+func TestParseCalendarBusyTimes_Recurrence(t *testing.T) {
+	data := icsCalendar(
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"DTSTART:20300107T100000Z",
+		"DTEND:20300107T110000Z",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+		"EXDATE:20300109T100000Z",
+		"SUMMARY:A summary long enough that the producer folded it over",
+		"  two lines",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"DTSTART:20300101T000000Z",
+		"END:VALARM",
+		"END:VEVENT",
+		// Moves the second Monday's occurrence to the afternoon
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"RECURRENCE-ID:20300114T100000Z",
+		"DTSTART:20300114T150000Z",
+		"DURATION:PT1H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:cancelled",
+		"DTSTART:20300108T100000Z",
+		"DTEND:20300108T110000Z",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:free",
+		"DTSTART:20300108T120000Z",
+		"DTEND:20300108T130000Z",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseCalendarBusyTimes(data, time.UTC, monday, monday.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// COUNT includes the excluded Wednesday and the moved Monday
+	checkBusy(t, busy,
+		TimeSlot{Start: monday.Add(10 * time.Hour), End: monday.Add(11 * time.Hour)},
+		TimeSlot{Start: monday.AddDate(0, 0, 7).Add(15 * time.Hour), End: monday.AddDate(0, 0, 7).Add(16 * time.Hour)},
+		TimeSlot{Start: monday.AddDate(0, 0, 9).Add(10 * time.Hour), End: monday.AddDate(0, 0, 9).Add(11 * time.Hour)},
+	)
+}
+
+func TestParseCalendarBusyTimes_MonthlyAllDayAndMerging(t *testing.T) {
+	data := icsCalendar(
+		"BEGIN:VEVENT",
+		"UID:review",
+		"DTSTART:20300125T160000",
+		"DTEND:20300125T170000",
+		"RRULE:FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20300331",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:holiday",
+		"DTSTART;VALUE=DATE:20300301",
+		"DTEND;VALUE=DATE:20300302",
+		"END:VEVENT",
+		// Overlaps the holiday, so is merged into it
+		"BEGIN:VEVENT",
+		"UID:late",
+		"DTSTART:20300301T230000",
+		"DTEND:20300302T010000",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseCalendarBusyTimes(data, time.UTC, monday, monday.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	day := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2030, month, d, hour, 0, 0, 0, time.UTC)
+	}
+	checkBusy(t, busy,
+		TimeSlot{Start: day(time.January, 25, 16), End: day(time.January, 25, 17)},
+		TimeSlot{Start: day(time.February, 22, 16), End: day(time.February, 22, 17)},
+		TimeSlot{Start: day(time.March, 1, 0), End: day(time.March, 2, 1)},
+		TimeSlot{Start: day(time.March, 29, 16), End: day(time.March, 29, 17)},
+	)
+}
+
+func TestParseCalendarBusyTimes_TZID(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	data := icsCalendar(
+		"BEGIN:VEVENT",
+		"UID:gym",
+		`DTSTART;TZID="America/New_York":20300308T090000`,
+		"DTEND;TZID=America/New_York:20300308T100000",
+		"RRULE:FREQ=DAILY;INTERVAL=3;COUNT=2",
+		"END:VEVENT",
+		// Floating times are in the given location
+		"BEGIN:VEVENT",
+		"UID:lunch",
+		"DTSTART:20300320T120000",
+		"DTEND:20300320T130000",
+		"END:VEVENT",
+	)
+
+	busy, err := ParseCalendarBusyTimes(data, newYork, monday, monday.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// Daylight saving time starts on 10 March, between the occurrences
+	checkBusy(t, busy,
+		TimeSlot{Start: time.Date(2030, 3, 8, 14, 0, 0, 0, time.UTC), End: time.Date(2030, 3, 8, 15, 0, 0, 0, time.UTC)},
+		TimeSlot{Start: time.Date(2030, 3, 11, 13, 0, 0, 0, time.UTC), End: time.Date(2030, 3, 11, 14, 0, 0, 0, time.UTC)},
+		TimeSlot{Start: time.Date(2030, 3, 20, 16, 0, 0, 0, time.UTC), End: time.Date(2030, 3, 20, 17, 0, 0, 0, time.UTC)},
+	)
+}
+
+func TestParseCalendarBusyTimes_Invalid(t *testing.T) {
+	if _, err := ParseCalendarBusyTimes([]byte("<html></html>"), time.UTC, monday, monday.AddDate(0, 1, 0)); !errors.Is(err, ErrNotCalendar) {
+		t.Errorf("expected ErrNotCalendar, got %v", err)
+	}
+	data := icsCalendar("BEGIN:VEVENT", "UID:bad", "DTSTART:tomorrow", "END:VEVENT")
+	if _, err := ParseCalendarBusyTimes(data, time.UTC, monday, monday.AddDate(0, 1, 0)); err == nil {
+		t.Error("expected an error for an invalid DTSTART")
+	}
+}
+
+// calendarServer serves an ICS body that tests can change, or an error status
+type calendarServer struct {
+	mu     sync.Mutex
+	body   []byte
+	status int
+}
+
+func (cs *calendarServer) set(status int, body []byte) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.status, cs.body = status, body
+}
+
+func (cs *calendarServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	w.Header().Set("Content-Type", "text/calendar")
+	w.WriteHeader(cs.status)
+	w.Write(cs.body)
+}
+
+func TestExternalCalendarService_URLBlocksBookingAndAvailability(t *testing.T) {
+	f := newTestFixture(t)
+	feed := &calendarServer{}
+	feed.set(http.StatusOK, icsCalendar(
+		"BEGIN:VEVENT",
+		"UID:dentist",
+		"DTSTART:20300107T120000Z",
+		"DTEND:20300107T130000Z",
+		"END:VEVENT",
+	))
+	server := httptest.NewServer(feed)
+	defer server.Close()
+	// The fixture's Monday is years away, past the default horizon
+	external := NewExternalCalendarService(f.svc, f.store.ExternalCalendars(), server.Client(), ExternalCalendarConfig{Horizon: 10 * 365 * 24 * time.Hour})
+
+	calendar, err := external.AddURLCalendar(f.ctx, f.staff.ID, "Personal", server.URL+"/personal.ics")
+	checkKind(t, err, "")
+	if calendar.LastSyncedAt == nil || calendar.LastError != "" {
+		t.Fatalf("expected a successful first sync, got %+v", calendar)
+	}
+
+	_, err = f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(12*time.Hour+30*time.Minute), "")
+	checkKind(t, err, KindConflict)
+	availability, err := f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	checkKind(t, err, "")
+	for _, slot := range availability.Staff[0].Slots {
+		if slot.Start.Before(monday.Add(13*time.Hour)) && slot.End.After(monday.Add(12*time.Hour)) {
+			t.Errorf("slot %v-%v overlaps the busy time", slot.Start, slot.End)
+		}
+	}
+	f.book(t, 11*time.Hour) // ends as the busy time starts
+
+	// A failed refresh keeps the busy times
+	feed.set(http.StatusInternalServerError, nil)
+	if err := external.SyncAll(f.ctx, time.Now()); err == nil {
+		t.Error("expected the refresh job to report the failure")
+	}
+	calendar, err = external.SyncCalendar(f.ctx, f.staff.ID, calendar.ID)
+	checkKind(t, err, "")
+	if !strings.Contains(calendar.LastError, "500") {
+		t.Errorf("expected the failure to be recorded, got %q", calendar.LastError)
+	}
+	_, err = f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(12*time.Hour), "")
+	checkKind(t, err, KindConflict)
+
+	// Once the event is gone from the calendar the time can be booked
+	feed.set(http.StatusOK, icsCalendar())
+	if err := external.SyncAll(f.ctx, time.Now()); err != nil {
+		t.Fatalf("sync all: %v", err)
+	}
+	f.book(t, 12*time.Hour)
+}
+
+func TestExternalCalendarService_RefusesInternalAddresses(t *testing.T) {
+	f := newTestFixture(t)
+	feed := &calendarServer{}
+	feed.set(http.StatusOK, icsCalendar())
+	server := httptest.NewServer(feed)
+	defer server.Close()
+
+	// The default client does not connect to the loopback test server
+	external := NewExternalCalendarService(f.svc, f.store.ExternalCalendars(), nil, ExternalCalendarConfig{})
+	_, err := external.AddURLCalendar(f.ctx, f.staff.ID, "Personal", server.URL+"/personal.ics")
+	checkKind(t, err, KindValidation)
+	if err == nil || !strings.Contains(err.Error(), errBlockedCalendarAddress.Error()) {
+		t.Errorf("expected the address to be refused, got %v", err)
+	}
+
+	// An injected client is used as is
+	external = NewExternalCalendarService(f.svc, f.store.ExternalCalendars(), server.Client(), ExternalCalendarConfig{})
+	_, err = external.AddURLCalendar(f.ctx, f.staff.ID, "Personal", server.URL+"/personal.ics")
+	checkKind(t, err, "")
+
+	for address, refused := range map[string]bool{
+		"127.0.0.1:80":          true,
+		"[::1]:443":             true,
+		"10.0.0.5:80":           true,
+		"172.16.3.4:80":         true,
+		"192.168.1.1:80":        true,
+		"169.254.169.254:80":    true,
+		"100.64.0.1:80":         true,
+		"0.0.0.0:80":            true,
+		"[::ffff:127.0.0.1]:80": true,
+		"[fe80::1]:80":          true,
+		"[fd00::1]:80":          true,
+		"93.184.216.34:443":     false,
+		"[2606:4700::1]:443":    false,
+	} {
+		if err := refuseInternalAddress("tcp", address, nil); (err != nil) != refused {
+			t.Errorf("refuseInternalAddress(%s) = %v, want refused %v", address, err, refused)
+		}
+	}
+}
+
+func TestExternalCalendarService_Upload(t *testing.T) {
+	f := newTestFixture(t)
+	external := NewExternalCalendarService(f.svc, f.store.ExternalCalendars(), nil, ExternalCalendarConfig{Horizon: 10 * 365 * 24 * time.Hour})
+
+	_, err := external.AddUploadedCalendar(f.ctx, f.staff.ID, "Holidays", []byte("not a calendar"))
+	checkKind(t, err, KindValidation)
+	_, err = external.AddURLCalendar(f.ctx, f.staff.ID, "Holidays", "ftp://example.com/holidays.ics")
+	checkKind(t, err, KindValidation)
+
+	calendar, err := external.AddUploadedCalendar(f.ctx, f.staff.ID, "Holidays", icsCalendar(
+		"BEGIN:VEVENT",
+		"UID:new-year",
+		"DTSTART;VALUE=DATE:20300107",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+	))
+	checkKind(t, err, "")
+	_, err = f.svc.BookAppointment(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, monday.Add(9*time.Hour), "")
+	checkKind(t, err, KindConflict)
+
+	// Other staff members cannot reach the calendar through their own ID
+	checkKind(t, external.DeleteCalendar(f.ctx, f.staff.ID+1000, calendar.ID), KindNotFound)
+	checkKind(t, external.DeleteCalendar(f.ctx, f.staff.ID, calendar.ID), "")
+	f.book(t, 9*time.Hour)
+}
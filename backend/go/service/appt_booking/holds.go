@@ -0,0 +1,244 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Hold defaults, used for zero HoldConfig fields
+const (
+	defaultHoldTTL           = 10 * time.Minute
+	defaultWaitlistOfferTTL  = 2 * time.Hour
+	defaultHoldSweepInterval = time.Minute
+	defaultMaxActiveHolds    = 3
+)
+
+// HoldConfig configures slot holds
+type HoldConfig struct {
+	HoldTTL          time.Duration // how long a slot picked during checkout stays held
+	WaitlistOfferTTL time.Duration // how long a slot offered to a waitlist entry stays held
+	SweepInterval    time.Duration // how often RunHoldSweeper deletes expired holds
+	MaxActiveHolds   int           // unexpired checkout holds one account may have at once
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c HoldConfig) withDefaults() HoldConfig {
+	if c.HoldTTL <= 0 {
+		c.HoldTTL = defaultHoldTTL
+	}
+	if c.WaitlistOfferTTL <= 0 {
+		c.WaitlistOfferTTL = defaultWaitlistOfferTTL
+	}
+	if c.SweepInterval <= 0 {
+		c.SweepInterval = defaultHoldSweepInterval
+	}
+	if c.MaxActiveHolds <= 0 {
+		c.MaxActiveHolds = defaultMaxActiveHolds
+	}
+	return c
+}
+
+// CreateHold reserves a slot while the customer completes the booking form. Until the hold
+// expires the slot is excluded from availability and other bookings conflict with it; Book
+// with the hold's token converts it into an appointment. The hold belongs to the request's
+// principal, who may have at most HoldConfig.MaxActiveHolds unexpired holds.
+func (s *ApptBookingService) CreateHold(ctx context.Context, staffID, serviceID int, appointmentDatetime time.Time) (*appt_booking.SlotHold, error) {
+	if staffID <= 0 {
+		return nil, Invalid("staff_id", "valid staff ID is required")
+	}
+	if serviceID <= 0 {
+		return nil, Invalid("service_id", "valid service ID is required")
+	}
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, NotFound("staff")
+	}
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, NotFound("service")
+	}
+	if err := s.checkBookingPolicy(service, staff, appointmentDatetime, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.checkStaffAvailableFor(ctx, staff, serviceID, appointmentDatetime, service.DurationMin); err != nil {
+		return nil, err
+	}
+
+	var userID *int
+	if p := PrincipalFrom(ctx); p != nil {
+		userID = &p.UserID
+	}
+	token, err := newHoldToken()
+	if err != nil {
+		return nil, err
+	}
+	hold, err := s.holdRepo.CreateExclusive(ctx,
+		token,
+		staffID,
+		serviceID,
+		appointmentDatetime,
+		service.DurationMin,
+		nil,
+		userID,
+		s.holdConfig.MaxActiveHolds,
+		time.Now().Add(s.holdConfig.HoldTTL),
+	)
+	if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict("slot is already booked or held", err)
+	}
+	if errors.Is(err, appt_booking.ErrHoldLimitReached) {
+		return nil, Conflict(fmt.Sprintf("you already hold %d slots; book or release one first", s.holdConfig.MaxActiveHolds), err)
+	}
+	return hold, err
+}
+
+// ReleaseHold gives up a checkout hold before it expires, freeing the slot
+func (s *ApptBookingService) ReleaseHold(ctx context.Context, token string) error {
+	hold, err := s.checkoutHold(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := s.holdRepo.Delete(ctx, hold.ID); err != nil {
+		return err
+	}
+	s.offerFreedSlots(ctx, hold.StaffID)
+	return nil
+}
+
+// BookHeldAppointment books the slot reserved by the checkout hold with token. The booking
+// must be for the held staff member, service and time; the hold is released on success.
+func (s *ApptBookingService) BookHeldAppointment(
+	ctx context.Context,
+	token string,
+	customerName, customerEmail, customerPhone string,
+	staffID, serviceID int,
+	appointmentDatetime time.Time,
+	notes string,
+) (*appt_booking.Appointment, error) {
+	staff, service, err := s.validateBooking(ctx, customerName, customerEmail, staffID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	hold, err := s.checkoutHold(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if hold.StaffID != staff.ID || hold.ServiceID != service.ID || !hold.StartsAt.Equal(appointmentDatetime) {
+		return nil, PreconditionFailed("hold is for a different slot", nil)
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		return nil, PreconditionFailed("hold has expired", appt_booking.ErrHoldNotFound)
+	}
+
+	// Working hours may have changed since the slot was held
+	if err := s.checkStaffAvailableFor(ctx, staff, serviceID, appointmentDatetime, hold.DurationMinutes); err != nil {
+		return nil, err
+	}
+	customer, err := s.bookingCustomer(ctx, customerName, customerEmail, customerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment, err := s.appointmentRepo.CreateFromHold(ctx,
+		hold.ID,
+		customer.ID,
+		customerName,
+		customerEmail,
+		customerPhone,
+		appt_booking.AppointmentStatusConfirmed,
+		notes,
+		domainEvent(EventAppointmentBooked, appt_booking.AggregateAppointment),
+	)
+	if errors.Is(err, appt_booking.ErrHoldNotFound) {
+		return nil, PreconditionFailed("hold has expired", err)
+	}
+	if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict(err.Error(), err)
+	}
+	return appointment, err
+}
+
+// checkoutHold loads the hold with token, rejecting waitlist offers, which are accepted
+// through the waitlist. Holds of other accounts are reported as not found.
+func (s *ApptBookingService) checkoutHold(ctx context.Context, token string) (*appt_booking.SlotHold, error) {
+	if token == "" {
+		return nil, Invalid("hold_token", "hold token is required")
+	}
+	hold, err := s.holdRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || !ownsHold(PrincipalFrom(ctx), hold) {
+		return nil, NotFound("hold")
+	}
+	if hold.WaitlistEntryID != nil {
+		return nil, PreconditionFailed("hold is a waitlist offer; accept it through the waitlist", nil)
+	}
+	return hold, nil
+}
+
+// ownsHold reports whether hold may be used by p: holds are used by the account that placed
+// them. Holds without an owner are not limited to anyone.
+func ownsHold(p *Principal, hold *appt_booking.SlotHold) bool {
+	if hold.UserID == nil {
+		return true
+	}
+	return p != nil && p.UserID == *hold.UserID
+}
+
+// SweepExpiredHolds expires lapsed waitlist offers, deletes expired holds and offers the
+// time they held to the waitlist. Returns the number of holds deleted.
+func (s *ApptBookingService) SweepExpiredHolds(ctx context.Context) (int, error) {
+	// Offers must be expired before their holds are deleted, with the same cut-off
+	now := time.Now()
+	expiredOffers, err := s.waitlistRepo.ExpireOffers(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	expired, err := s.holdRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(expiredOffers) > 0 {
+		s.offerFreedSlots(ctx, 0)
+		return len(expired), nil
+	}
+	freed := make(map[int]bool)
+	for _, h := range expired {
+		if !freed[h.StaffID] {
+			freed[h.StaffID] = true
+			s.offerFreedSlots(ctx, h.StaffID)
+		}
+	}
+	return len(expired), nil
+}
+
+// RunHoldSweeper sweeps expired holds every HoldConfig.SweepInterval until ctx is done
+func (s *ApptBookingService) RunHoldSweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.holdConfig.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.SweepExpiredHolds(ctx); err != nil {
+				log.Printf("sweep expired holds: %v", err)
+			} else if n > 0 {
+				log.Printf("swept %d expired holds", n)
+			}
+		}
+	}
+}
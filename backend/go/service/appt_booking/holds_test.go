@@ -0,0 +1,156 @@
+package appt_booking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func TestCreateHold_BlocksSlot(t *testing.T) {
+	f := newTestFixture(t)
+	at := monday.Add(10 * time.Hour)
+	hold, err := f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, at)
+	if err != nil {
+		t.Fatalf("create hold: %v", err)
+	}
+	if hold.Token == "" || !hold.ExpiresAt.After(time.Now()) {
+		t.Fatalf("unexpected hold: %+v", hold)
+	}
+
+	_, err = f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, at.Add(30*time.Minute))
+	checkKind(t, err, KindConflict)
+	_, err = f.svc.BookAppointment(f.ctx, "Dave", "dave@example.com", "", f.staff.ID, f.service.ID, at, "")
+	checkKind(t, err, KindConflict)
+
+	availability, err := f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	for _, slot := range availability.Staff[0].Slots {
+		if slot.Start.Before(at.Add(time.Hour)) && slot.End.After(at) {
+			t.Fatalf("held slot %v is offered as available", slot.Start)
+		}
+	}
+
+	// Outside working hours
+	_, err = f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, monday.Add(20*time.Hour))
+	checkKind(t, err, KindPreconditionFailed)
+}
+
+func TestCreateHold_OwnedAndLimitedPerAccount(t *testing.T) {
+	f := newTestFixture(t)
+	f.svc.holdConfig.MaxActiveHolds = 2
+	principal := func(email string) context.Context {
+		t.Helper()
+		u, err := f.store.Users().Create(f.ctx, email, "hash", appt_booking.RoleCustomer, nil)
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return WithPrincipal(f.ctx, &Principal{UserID: u.ID, Email: email, Role: appt_booking.RoleCustomer})
+	}
+	bob, carol := principal("bob@example.com"), principal("carol@example.com")
+
+	var holds []*appt_booking.SlotHold
+	for _, hour := range []time.Duration{9, 10} {
+		hold, err := f.svc.CreateHold(bob, f.staff.ID, f.service.ID, monday.Add(hour*time.Hour))
+		if err != nil {
+			t.Fatalf("create hold: %v", err)
+		}
+		if hold.UserID == nil || *hold.UserID != PrincipalFrom(bob).UserID {
+			t.Fatalf("expected hold owned by user %d, got %+v", PrincipalFrom(bob).UserID, hold)
+		}
+		holds = append(holds, hold)
+	}
+	_, err := f.svc.CreateHold(bob, f.staff.ID, f.service.ID, monday.Add(11*time.Hour))
+	checkKind(t, err, KindConflict)
+	if _, err := f.svc.CreateHold(carol, f.staff.ID, f.service.ID, monday.Add(11*time.Hour)); err != nil {
+		t.Fatalf("create hold for another account: %v", err)
+	}
+
+	// Only the owner can use a hold
+	checkKind(t, f.svc.ReleaseHold(carol, holds[0].Token), KindNotFound)
+	_, err = f.svc.BookHeldAppointment(carol, holds[0].Token, "Carol", "carol@example.com", "", f.staff.ID, f.service.ID, holds[0].StartsAt, "")
+	checkKind(t, err, KindNotFound)
+
+	// Releasing a hold makes room for another
+	if err := f.svc.ReleaseHold(bob, holds[0].Token); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, err := f.svc.CreateHold(bob, f.staff.ID, f.service.ID, monday.Add(12*time.Hour)); err != nil {
+		t.Fatalf("create hold after release: %v", err)
+	}
+}
+
+func TestBookHeldAppointment(t *testing.T) {
+	f := newTestFixture(t)
+	at := monday.Add(10 * time.Hour)
+	hold, err := f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, at)
+	if err != nil {
+		t.Fatalf("create hold: %v", err)
+	}
+
+	_, err = f.svc.BookHeldAppointment(f.ctx, hold.Token, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at.Add(time.Hour), "")
+	checkKind(t, err, KindPreconditionFailed)
+	_, err = f.svc.BookHeldAppointment(f.ctx, "unknown", "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at, "")
+	checkKind(t, err, KindNotFound)
+
+	a, err := f.svc.BookHeldAppointment(f.ctx, hold.Token, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at, "")
+	if err != nil {
+		t.Fatalf("book held appointment: %v", err)
+	}
+	if !a.AppointmentDatetime.Equal(at) || a.Status != appt_booking.AppointmentStatusConfirmed {
+		t.Fatalf("unexpected appointment: %+v", a)
+	}
+
+	// The hold was converted and cannot be used again
+	_, err = f.svc.BookHeldAppointment(f.ctx, hold.Token, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at, "")
+	checkKind(t, err, KindNotFound)
+}
+
+func TestHold_ExpiryAndSweep(t *testing.T) {
+	f := newTestFixture(t)
+	f.svc.holdConfig.HoldTTL = 10 * time.Millisecond
+	at := monday.Add(10 * time.Hour)
+	hold, err := f.svc.CreateHold(f.ctx, f.staff.ID, f.service.ID, at)
+	if err != nil {
+		t.Fatalf("create hold: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = f.svc.BookHeldAppointment(f.ctx, hold.Token, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at, "")
+	checkKind(t, err, KindPreconditionFailed)
+
+	n, err := f.svc.SweepExpiredHolds(f.ctx)
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 swept hold, got %d", n)
+	}
+	// Expired holds no longer block the slot
+	f.book(t, 10*time.Hour)
+}
+
+func TestSweepExpiredHolds_ExpiresWaitlistOffers(t *testing.T) {
+	f := newTestFixture(t)
+	f.svc.holdConfig.WaitlistOfferTTL = 10 * time.Millisecond
+	a := f.book(t, 10*time.Hour)
+	entry := f.joinWaitlist(t, "carol", 0)
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	f.checkWaitlistStatus(t, entry.ID, appt_booking.WaitlistStatusOffered)
+	token := f.offeredHold(t, entry.ID).Token
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := f.svc.SweepExpiredHolds(f.ctx); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	f.checkWaitlistStatus(t, entry.ID, appt_booking.WaitlistStatusExpired)
+
+	// The offer's hold was deleted once the entry had expired
+	err := f.svc.ReleaseHold(f.ctx, token)
+	checkKind(t, err, KindNotFound)
+}
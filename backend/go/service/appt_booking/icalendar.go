@@ -0,0 +1,206 @@
+package appt_booking
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CalendarProductID identifies this application in the iCalendar files it writes
+const CalendarProductID = "-//k8s-fullstack-blueprint//Appointment Booking//EN"
+
+// iCalendar event statuses
+const (
+	CalendarStatusTentative = "TENTATIVE"
+	CalendarStatusConfirmed = "CONFIRMED"
+	CalendarStatusCancelled = "CANCELLED"
+)
+
+// CalendarEvent is a VEVENT. Its UID must stay the same across updates, and Sequence
+// must grow with each change of its time or status, so calendar apps replace the copy
+// they have.
+type CalendarEvent struct {
+	UID         string
+	Sequence    int
+	Status      string // one of the CalendarStatus* constants
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Created     time.Time
+	Modified    time.Time // also the DTSTAMP
+}
+
+// Calendar is an iCalendar object (RFC 5545) whose events are written in one timezone
+type Calendar struct {
+	Name     string         // shown by calendar apps for subscriptions; optional
+	Method   string         // iTIP method such as "PUBLISH"; optional
+	Location *time.Location // timezone of the events' local times; nil means UTC
+	Events   []CalendarEvent
+}
+
+// Encode renders c in the iCalendar format. Times are local to c.Location, which is
+// described by a VTIMEZONE covering the events.
+func (c Calendar) Encode() []byte {
+	w := &icalWriter{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + CalendarProductID)
+	w.line("CALSCALE:GREGORIAN")
+	if c.Method != "" {
+		w.line("METHOD:" + c.Method)
+	}
+	if c.Name != "" {
+		w.line("X-WR-CALNAME:" + escapeCalendarText(c.Name))
+	}
+
+	loc := c.Location
+	if loc == nil || loc.String() == "UTC" {
+		loc = nil
+	} else {
+		w.line("X-WR-TIMEZONE:" + loc.String())
+		from, to := c.eventRange()
+		w.timezone(loc, from, to)
+	}
+
+	for _, e := range c.Events {
+		w.line("BEGIN:VEVENT")
+		w.line("UID:" + e.UID)
+		w.line("DTSTAMP:" + formatCalendarUTC(e.Modified))
+		w.line("CREATED:" + formatCalendarUTC(e.Created))
+		w.line("LAST-MODIFIED:" + formatCalendarUTC(e.Modified))
+		w.line("SEQUENCE:" + fmt.Sprint(e.Sequence))
+		w.line("DTSTART" + formatCalendarLocal(e.Start, loc))
+		w.line("DTEND" + formatCalendarLocal(e.End, loc))
+		w.line("SUMMARY:" + escapeCalendarText(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION:" + escapeCalendarText(e.Description))
+		}
+		w.line("STATUS:" + e.Status)
+		if e.Status == CalendarStatusCancelled {
+			w.line("TRANSP:TRANSPARENT")
+		} else {
+			w.line("TRANSP:OPAQUE")
+		}
+		w.line("END:VEVENT")
+	}
+	w.line("END:VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// eventRange returns the span of the events' times, or a zero span if there are none
+func (c Calendar) eventRange() (from, to time.Time) {
+	for i, e := range c.Events {
+		if i == 0 || e.Start.Before(from) {
+			from = e.Start
+		}
+		if i == 0 || e.End.After(to) {
+			to = e.End
+		}
+	}
+	return from, to
+}
+
+// icalWriter writes content lines folded to 75 octets and terminated by CRLF
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *icalWriter) line(content string) {
+	limit := 75
+	for len(content) > limit {
+		// Fold before the last whole character that fits
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.buf.WriteString(content[:cut])
+		w.buf.WriteString("\r\n ")
+		content = content[cut:]
+		limit = 74 // continuation lines begin with a space
+	}
+	w.buf.WriteString(content)
+	w.buf.WriteString("\r\n")
+}
+
+// timezone writes a VTIMEZONE for loc with an observance for each offset change from a
+// year before from to a year after to, so recurring edits near the range stay correct
+func (w *icalWriter) timezone(loc *time.Location, from, to time.Time) {
+	if from.IsZero() {
+		from, to = time.Now(), time.Now()
+	}
+	start := from.AddDate(-1, 0, 0).In(loc)
+	end := to.AddDate(1, 0, 0)
+
+	w.line("BEGIN:VTIMEZONE")
+	w.line("TZID:" + loc.String())
+	// The offset in effect at the start of the range, then each change within it
+	name, offset := start.Zone()
+	w.observance(start.IsDST(), name, offset, offset, start.In(time.FixedZone("", offset)))
+	for t := start; ; {
+		_, zoneEnd := t.ZoneBounds()
+		if zoneEnd.IsZero() || zoneEnd.After(end) {
+			break
+		}
+		next := zoneEnd.In(loc)
+		nextName, nextOffset := next.Zone()
+		// The onset is given in the local time before the change
+		w.observance(next.IsDST(), nextName, offset, nextOffset, zoneEnd.In(time.FixedZone("", offset)))
+		t, offset = next, nextOffset
+	}
+	w.line("END:VTIMEZONE")
+}
+
+// observance writes a STANDARD or DAYLIGHT component starting at the local time onset
+func (w *icalWriter) observance(dst bool, name string, offsetFrom, offsetTo int, onset time.Time) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN:" + kind)
+	w.line("DTSTART:" + onset.Format("20060102T150405"))
+	w.line("TZOFFSETFROM:" + formatCalendarOffset(offsetFrom))
+	w.line("TZOFFSETTO:" + formatCalendarOffset(offsetTo))
+	if name != "" {
+		w.line("TZNAME:" + escapeCalendarText(name))
+	}
+	w.line("END:" + kind)
+}
+
+// formatCalendarUTC formats t as an iCalendar UTC date-time
+func formatCalendarUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatCalendarLocal formats t as the parameters and value of a date-time property:
+// local to loc with a TZID, or UTC if loc is nil
+func formatCalendarLocal(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		return ":" + formatCalendarUTC(t)
+	}
+	return ";TZID=" + loc.String() + ":" + t.In(loc).Format("20060102T150405")
+}
+
+// formatCalendarOffset formats a UTC offset in seconds as ±HHMM, or ±HHMMSS when it has
+// seconds
+func formatCalendarOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// calendarTextEscaper escapes the characters with special meaning in TEXT values
+var calendarTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// escapeCalendarText escapes s for use as a TEXT value
+func escapeCalendarText(s string) string {
+	return calendarTextEscaper.Replace(s)
+}
@@ -0,0 +1,731 @@
+package appt_booking
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrencePeriods bounds the periods (days, weeks, months or years) a recurrence
+// rule is expanded over, so a rule that rarely or never matches cannot loop for long
+const maxRecurrencePeriods = 50000
+
+// ErrNotCalendar is returned when parsing data that is not an iCalendar object
+var ErrNotCalendar = errors.New("not an iCalendar file")
+
+// ParseCalendarBusyTimes returns the times the events of an iCalendar object keep busy
+// within [from, to), sorted and with overlapping times merged. Recurring events are
+// expanded, with their EXDATEs, RDATEs and overridden occurrences (RECURRENCE-ID) applied.
+// Cancelled events and events marked free (TRANSP:TRANSPARENT) are left out.
+//
+// Times with a TZID the system does not know, floating times and all-day dates are taken
+// to be in loc. Recurrence rules of the DAILY, WEEKLY, MONTHLY and YEARLY frequencies are
+// expanded with their BYMONTH, BYMONTHDAY, BYDAY and BYSETPOS parts; events recurring
+// more often than daily count their first occurrence only.
+func ParseCalendarBusyTimes(data []byte, loc *time.Location, from, to time.Time) ([]TimeSlot, error) {
+	events, err := parseCalendarEvents(data, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Occurrences replaced by an override are expanded from the override instead
+	overridden := make(map[string]map[int64]bool)
+	for _, e := range events {
+		if !e.recurrenceID.IsZero() {
+			if overridden[e.uid] == nil {
+				overridden[e.uid] = make(map[int64]bool)
+			}
+			overridden[e.uid][e.recurrenceID.Unix()] = true
+		}
+	}
+
+	var busy []TimeSlot
+	for _, e := range events {
+		if e.cancelled || e.transparent {
+			continue
+		}
+		var skip map[int64]bool
+		if e.recurrenceID.IsZero() {
+			skip = overridden[e.uid]
+		}
+		occurrences, err := e.occurrences(from, to, skip)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %w", e.uid, err)
+		}
+		busy = append(busy, occurrences...)
+	}
+	return mergeTimeSlots(busy), nil
+}
+
+// calendarEvent is the part of a VEVENT that decides when it is busy
+type calendarEvent struct {
+	uid          string
+	start        time.Time
+	allDay       bool
+	days         int           // nominal part of its length, for all-day events and DURATION:P1D
+	length       time.Duration // exact part of its length
+	rrule        string
+	exdates      []time.Time
+	rdates       []time.Time
+	recurrenceID time.Time
+	cancelled    bool
+	transparent  bool
+}
+
+// end returns the end of the occurrence starting at start
+func (e *calendarEvent) end(start time.Time) time.Time {
+	return start.AddDate(0, 0, e.days).Add(e.length)
+}
+
+// occurrences returns e's occurrences overlapping [from, to), except those starting at the
+// Unix times in skip
+func (e *calendarEvent) occurrences(from, to time.Time, skip map[int64]bool) ([]TimeSlot, error) {
+	starts := []time.Time{e.start}
+	if e.rrule != "" {
+		rule, err := parseRecurrenceRule(e.rrule, e.start.Location())
+		if err != nil {
+			return nil, err
+		}
+		// Occurrences starting before from can still reach into it
+		starts = rule.expand(e.start, to)
+	}
+	starts = append(starts, e.rdates...)
+
+	excluded := make(map[int64]bool, len(e.exdates))
+	for _, t := range e.exdates {
+		excluded[t.Unix()] = true
+	}
+	seen := make(map[int64]bool, len(starts))
+	var slots []TimeSlot
+	for _, start := range starts {
+		key := start.Unix()
+		if excluded[key] || skip[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		end := e.end(start)
+		if end.After(start) && start.Before(to) && end.After(from) {
+			slots = append(slots, TimeSlot{Start: start.UTC(), End: end.UTC()})
+		}
+	}
+	return slots, nil
+}
+
+// parseCalendarEvents reads the VEVENTs of an iCalendar object
+func parseCalendarEvents(data []byte, loc *time.Location) ([]calendarEvent, error) {
+	var (
+		events   []calendarEvent
+		stack    []string
+		inEvent  bool
+		event    calendarEvent
+		end      time.Time
+		hasEnd   bool
+		duration string
+		found    bool
+	)
+	for _, line := range unfoldCalendarLines(data) {
+		name, params, value, ok := parseCalendarContentLine(line)
+		if !ok {
+			continue
+		}
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			if component == "VCALENDAR" {
+				found = true
+			}
+			if component == "VEVENT" && len(stack) > 0 && stack[len(stack)-1] == "VCALENDAR" {
+				inEvent = true
+				event, end, hasEnd, duration = calendarEvent{}, time.Time{}, false, ""
+			}
+			stack = append(stack, component)
+			continue
+		case "END":
+			component := strings.ToUpper(value)
+			if len(stack) == 0 || stack[len(stack)-1] != component {
+				return nil, fmt.Errorf("unexpected END:%s", value)
+			}
+			stack = stack[:len(stack)-1]
+			if component == "VEVENT" && inEvent {
+				inEvent = false
+				if event.start.IsZero() {
+					continue // DTSTART is only optional with METHOD; nothing to block
+				}
+				if err := event.setLength(end, hasEnd, duration); err != nil {
+					return nil, fmt.Errorf("event %q: %w", event.uid, err)
+				}
+				events = append(events, event)
+			}
+			continue
+		}
+		// Properties of components within the event, such as VALARM, are not the event's
+		if !inEvent || stack[len(stack)-1] != "VEVENT" {
+			continue
+		}
+
+		var err error
+		switch name {
+		case "UID":
+			event.uid = value
+		case "DTSTART":
+			event.start, event.allDay, err = parseCalendarTime(value, params, loc)
+		case "DTEND":
+			end, _, err = parseCalendarTime(value, params, loc)
+			hasEnd = true
+		case "DURATION":
+			duration = value
+		case "RRULE":
+			event.rrule = value
+		case "EXDATE":
+			var times []time.Time
+			times, err = parseCalendarTimes(value, params, loc)
+			event.exdates = append(event.exdates, times...)
+		case "RDATE":
+			var times []time.Time
+			times, err = parseCalendarTimes(value, params, loc)
+			event.rdates = append(event.rdates, times...)
+		case "RECURRENCE-ID":
+			event.recurrenceID, _, err = parseCalendarTime(value, params, loc)
+		case "STATUS":
+			event.cancelled = strings.EqualFold(value, CalendarStatusCancelled)
+		case "TRANSP":
+			event.transparent = strings.EqualFold(value, "TRANSPARENT")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %s: %w", event.uid, name, err)
+		}
+	}
+	if !found {
+		return nil, ErrNotCalendar
+	}
+	return events, nil
+}
+
+// setLength sets the length of e's occurrences from its DTEND or DURATION. Without
+// either, an all-day event lasts the day and any other event takes no time.
+func (e *calendarEvent) setLength(end time.Time, hasEnd bool, duration string) error {
+	switch {
+	case hasEnd && e.allDay:
+		// Count days by date so a DST change in between does not shorten the event
+		y1, m1, d1 := e.start.Date()
+		y2, m2, d2 := end.In(e.start.Location()).Date()
+		e.days = int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	case hasEnd:
+		e.length = end.Sub(e.start)
+	case duration != "":
+		var err error
+		if e.days, e.length, err = parseCalendarDuration(duration); err != nil {
+			return fmt.Errorf("DURATION: %w", err)
+		}
+	case e.allDay:
+		e.days = 1
+	}
+	return nil
+}
+
+// unfoldCalendarLines splits data into content lines, joining folded lines back together
+func unfoldCalendarLines(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	var lines []string
+	for _, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		if raw != "" {
+			lines = append(lines, raw)
+		}
+	}
+	return lines
+}
+
+// parseCalendarContentLine splits a content line into its upper-cased name, its
+// parameters and its value. Parameter values may be quoted to contain ':' and ';'.
+func parseCalendarContentLine(line string) (name string, params map[string]string, value string, ok bool) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return "", nil, "", false
+	}
+	name = strings.ToUpper(line[:i])
+	params = make(map[string]string)
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return "", nil, "", false
+		}
+		key := strings.ToUpper(rest[:eq])
+		j := eq + 1
+		var val string
+		if j < len(rest) && rest[j] == '"' {
+			closing := strings.IndexByte(rest[j+1:], '"')
+			if closing < 0 {
+				return "", nil, "", false
+			}
+			val = rest[j+1 : j+1+closing]
+			j += closing + 2
+		} else {
+			k := strings.IndexAny(rest[j:], ";:")
+			if k < 0 {
+				return "", nil, "", false
+			}
+			val = rest[j : j+k]
+			j += k
+		}
+		params[key] = val
+		i += 1 + j
+		if i >= len(line) {
+			return "", nil, "", false
+		}
+	}
+	return name, params, line[i+1:], true
+}
+
+// parseCalendarTime parses a DATE or DATE-TIME value. Dates are midnight in loc, and
+// reported as all-day.
+func parseCalendarTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, calendarLocation(params["TZID"], loc))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// parseCalendarTimes parses a comma-separated list of DATE, DATE-TIME or PERIOD values,
+// returning the start of each period
+func parseCalendarTimes(value string, params map[string]string, loc *time.Location) ([]time.Time, error) {
+	var times []time.Time
+	for _, v := range strings.Split(value, ",") {
+		if i := strings.IndexByte(v, '/'); i >= 0 {
+			v = v[:i]
+		}
+		if v == "" {
+			continue
+		}
+		t, _, err := parseCalendarTime(v, params, loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// calendarLocation returns the location of a TZID, or loc if there is none or it is not
+// an IANA timezone name the system knows
+func calendarLocation(tzid string, loc *time.Location) *time.Location {
+	// Some producers prefix globally unique TZIDs with a slash
+	tzid = strings.TrimPrefix(strings.TrimSpace(tzid), "/")
+	if tzid == "" {
+		return loc
+	}
+	if l, err := time.LoadLocation(tzid); err == nil {
+		return l
+	}
+	return loc
+}
+
+// parseCalendarDuration parses a DURATION value into its nominal days (including weeks)
+// and its exact hours, minutes and seconds
+func parseCalendarDuration(value string) (days int, exact time.Duration, err error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime, s = true, s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n, _ := strconv.Atoi(s[:i])
+		switch unit := s[i]; {
+		case unit == 'W' && !inTime:
+			days += 7 * n
+		case unit == 'D' && !inTime:
+			days += n
+		case unit == 'H' && inTime:
+			exact += time.Duration(n) * time.Hour
+		case unit == 'M' && inTime:
+			exact += time.Duration(n) * time.Minute
+		case unit == 'S' && inTime:
+			exact += time.Duration(n) * time.Second
+		default:
+			return 0, 0, fmt.Errorf("invalid duration %q", value)
+		}
+		s = s[i+1:]
+	}
+	return sign * days, time.Duration(sign) * exact, nil
+}
+
+// mergeTimeSlots sorts slots and merges those that overlap or touch
+func mergeTimeSlots(slots []TimeSlot) []TimeSlot {
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	var merged []TimeSlot
+	for _, s := range slots {
+		if n := len(merged); n > 0 && !s.Start.After(merged[n-1].End) {
+			if s.End.After(merged[n-1].End) {
+				merged[n-1].End = s.End
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// recurrenceRule is the part of an RRULE this package expands
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byMonth    []int
+	byMonthDay []int
+	byDay      []recurrenceWeekday
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// recurrenceWeekday is a BYDAY entry such as MO, 2TU or -1FR; n is 0 for every such
+// weekday of the period
+type recurrenceWeekday struct {
+	n       int
+	weekday time.Weekday
+}
+
+// calendarWeekdays maps iCalendar weekday names to weekdays
+var calendarWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRecurrenceRule parses an RRULE value. A date-only UNTIL is taken to be in loc and
+// includes the whole day.
+func parseRecurrenceRule(value string, loc *time.Location) (*recurrenceRule, error) {
+	rule := &recurrenceRule{interval: 1, weekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		key, val = strings.ToUpper(key), strings.ToUpper(val)
+		var err error
+		switch key {
+		case "FREQ":
+			rule.freq = val
+		case "INTERVAL":
+			if rule.interval, err = strconv.Atoi(val); err == nil && rule.interval < 1 {
+				err = errors.New("must be positive")
+			}
+		case "COUNT":
+			rule.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var allDay bool
+			if rule.until, allDay, err = parseCalendarTime(val, nil, loc); err == nil && allDay {
+				rule.until = rule.until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		case "BYMONTH":
+			rule.byMonth, err = parseRecurrenceInts(val, 1, 12)
+		case "BYMONTHDAY":
+			rule.byMonthDay, err = parseRecurrenceInts(val, -31, 31)
+		case "BYSETPOS":
+			rule.bySetPos, err = parseRecurrenceInts(val, -366, 366)
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				if len(d) < 2 {
+					return nil, fmt.Errorf("RRULE: invalid BYDAY %q", val)
+				}
+				weekday, ok := calendarWeekdays[d[len(d)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("RRULE: invalid BYDAY %q", val)
+				}
+				n := 0
+				if ordinal := d[:len(d)-2]; ordinal != "" {
+					if n, err = strconv.Atoi(ordinal); err != nil || n == 0 {
+						return nil, fmt.Errorf("RRULE: invalid BYDAY %q", val)
+					}
+				}
+				rule.byDay = append(rule.byDay, recurrenceWeekday{n: n, weekday: weekday})
+			}
+		case "WKST":
+			weekday, ok := calendarWeekdays[val]
+			if !ok {
+				err = errors.New("invalid weekday")
+			}
+			rule.weekStart = weekday
+		}
+		if err != nil {
+			return nil, fmt.Errorf("RRULE: invalid %s %q", key, val)
+		}
+	}
+	if rule.freq == "" {
+		return nil, errors.New("RRULE: FREQ is required")
+	}
+	return rule, nil
+}
+
+// parseRecurrenceInts parses a comma-separated list of non-zero integers in [min, max]
+func parseRecurrenceInts(value string, min, max int) ([]int, error) {
+	var ints []int
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q", v)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// expand returns the starts of the occurrences of a rule beginning at start, up to to.
+// Occurrences keep start's wall-clock time in its location.
+func (r *recurrenceRule) expand(start, to time.Time) []time.Time {
+	loc := start.Location()
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, loc)
+	}
+
+	var period func(i int) (first time.Time, dates []time.Time)
+	switch r.freq {
+	case "DAILY":
+		period = func(i int) (time.Time, []time.Time) {
+			d := at(year, month, day+i*r.interval)
+			if r.matchesMonth(d) && r.matchesMonthDay(d) && r.matchesWeekday(d) {
+				return d, []time.Time{d}
+			}
+			return d, nil
+		}
+	case "WEEKLY":
+		// Weeks begin on weekStart; the first is the one containing start
+		weekOffset := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		period = func(i int) (time.Time, []time.Time) {
+			first := at(year, month, day-weekOffset+7*i*r.interval)
+			var dates []time.Time
+			for k := 0; k < 7; k++ {
+				d := at(year, month, day-weekOffset+7*i*r.interval+k)
+				weekly := len(r.byDay) == 0 && d.Weekday() == start.Weekday()
+				if (weekly || r.matchesWeekday(d)) && r.matchesMonth(d) {
+					dates = append(dates, d)
+				}
+			}
+			return first, dates
+		}
+	case "MONTHLY":
+		period = func(i int) (time.Time, []time.Time) {
+			first := at(year, month+time.Month(i*r.interval), 1)
+			if !r.matchesMonth(first) {
+				return first, nil
+			}
+			return first, r.monthDates(first.Year(), first.Month(), day, at)
+		}
+	case "YEARLY":
+		period = func(i int) (time.Time, []time.Time) {
+			y := year + i*r.interval
+			first := at(y, time.January, 1)
+			var dates []time.Time
+			switch {
+			case len(r.byMonth) > 0:
+				for m := time.January; m <= time.December; m++ {
+					if r.matchesMonth(at(y, m, 1)) {
+						dates = append(dates, r.monthDates(y, m, day, at)...)
+					}
+				}
+			case len(r.byDay) > 0 && len(r.byMonthDay) == 0:
+				dates = r.weekdayDates(y, time.January, 12, at)
+			default:
+				dates = r.monthDates(y, month, day, at)
+			}
+			return first, dates
+		}
+	default:
+		return []time.Time{start}
+	}
+
+	var starts []time.Time
+	n := 0
+	for i := 0; i < maxRecurrencePeriods; i++ {
+		first, dates := period(i)
+		if !first.Before(to) {
+			break
+		}
+		for _, d := range r.setPositions(dates) {
+			if d.Before(start) {
+				continue
+			}
+			if (!r.until.IsZero() && d.After(r.until)) || (r.count > 0 && n >= r.count) {
+				return starts
+			}
+			n++
+			starts = append(starts, d)
+		}
+	}
+	return starts
+}
+
+// monthDates returns the dates of a month a MONTHLY rule, or a YEARLY rule with
+// BYMONTH, selects: by BYMONTHDAY and BYDAY, or else the day of the month of DTSTART
+func (r *recurrenceRule) monthDates(year int, month time.Month, day int, at func(int, time.Month, int) time.Time) []time.Time {
+	first := at(year, month, 1)
+	next := at(year, month+1, 1)
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if d := at(year, month, day); d.Month() == month {
+			return []time.Time{d}
+		}
+		return nil // months too short for the day are skipped
+	}
+	var dates []time.Time
+	if len(r.byDay) > 0 {
+		dates = r.weekdayDates(year, month, 1, at)
+		if len(r.byMonthDay) > 0 {
+			var both []time.Time
+			for _, d := range dates {
+				if r.matchesMonthDay(d) {
+					both = append(both, d)
+				}
+			}
+			dates = both
+		}
+		return dates
+	}
+	for d := first; d.Before(next); d = at(year, month, d.Day()+1) {
+		if r.matchesMonthDay(d) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// weekdayDates returns the days of the months months long span from month that BYDAY
+// selects, counting ordinals such as 2TU or -1FR within that span
+func (r *recurrenceRule) weekdayDates(year int, month time.Month, months int, at func(int, time.Month, int) time.Time) []time.Time {
+	var all []time.Time
+	end := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	for k := 1; ; k++ {
+		if !time.Date(year, month, k, 0, 0, 0, 0, time.UTC).Before(end) {
+			break
+		}
+		all = append(all, at(year, month, k))
+	}
+	var dates []time.Time
+	for _, d := range all {
+		for _, wd := range r.byDay {
+			if d.Weekday() != wd.weekday {
+				continue
+			}
+			if wd.n == 0 || wd.n == weekdayOrdinal(all, d, wd.n < 0) {
+				dates = append(dates, d)
+				break
+			}
+		}
+	}
+	return dates
+}
+
+// weekdayOrdinal returns which occurrence of its weekday d is within days, counting from
+// the end as negative numbers if fromEnd
+func weekdayOrdinal(days []time.Time, d time.Time, fromEnd bool) int {
+	n := 0
+	for _, other := range days {
+		if other.Weekday() != d.Weekday() {
+			continue
+		}
+		if fromEnd && !other.Before(d) {
+			n--
+		} else if !fromEnd && !other.After(d) {
+			n++
+		}
+	}
+	return n
+}
+
+// setPositions applies BYSETPOS to the dates of one period, in order
+func (r *recurrenceRule) setPositions(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	if len(r.bySetPos) == 0 {
+		return dates
+	}
+	var selected []time.Time
+	for i, d := range dates {
+		for _, pos := range r.bySetPos {
+			if pos == i+1 || pos == i-len(dates) {
+				selected = append(selected, d)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+func (r *recurrenceRule) matchesMonth(d time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, m := range r.byMonth {
+		if time.Month(m) == d.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recurrenceRule) matchesMonthDay(d time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.byMonthDay {
+		if md == d.Day() || (md < 0 && daysInMonth+1+md == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday reports whether d is one of the BYDAY weekdays, ignoring ordinals
+func (r *recurrenceRule) matchesWeekday(d time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if wd.weekday == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
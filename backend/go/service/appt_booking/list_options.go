@@ -0,0 +1,69 @@
+package appt_booking
+
+import (
+	"context"
+	"fmt"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Page size bounds for list endpoints
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 500
+)
+
+// normalizeListOptions validates paging and sort fields, applying the default page size
+// and defaultSort when none is given
+func normalizeListOptions(opts appt_booking.ListOptions, isSortField func(string) bool, defaultSort ...appt_booking.SortField) (appt_booking.ListOptions, error) {
+	switch {
+	case opts.Limit < 0:
+		return opts, Invalid("limit", "limit must not be negative")
+	case opts.Limit == 0:
+		opts.Limit = DefaultPageSize
+	case opts.Limit > MaxPageSize:
+		return opts, Invalid("limit", fmt.Sprintf("limit must be at most %d", MaxPageSize))
+	}
+	if opts.Offset < 0 {
+		return opts, Invalid("offset", "offset must not be negative")
+	}
+	for _, field := range opts.Sort {
+		if !isSortField(field.Field) {
+			return opts, Invalid("sort", "cannot sort by "+field.Field)
+		}
+	}
+	if len(opts.Sort) == 0 {
+		opts.Sort = defaultSort
+	}
+	return opts, nil
+}
+
+// ListAppointments retrieves one page of the appointments matching filter with service
+// price, and the number of matching appointments. Without a sort order the latest
+// appointments come first.
+func (s *ApptBookingService) ListAppointments(ctx context.Context, filter appt_booking.AppointmentFilter, opts appt_booking.ListOptions) ([]appt_booking.AppointmentWithService, int, error) {
+	opts, err := normalizeListOptions(opts, appt_booking.IsAppointmentSortField,
+		appt_booking.SortField{Field: "appointment_datetime", Desc: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, status := range filter.Statuses {
+		if !IsValidAppointmentStatus(status) {
+			return nil, 0, Invalid("status", "Invalid status: "+status)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, Invalid("to", "to must be after from")
+	}
+	return s.appointmentRepo.ListWithServiceDetails(ctx, filter, opts)
+}
+
+// ListCustomers retrieves one page of customers and the total number of customers.
+// Without a sort order customers are ordered by name.
+func (s *ApptBookingService) ListCustomers(ctx context.Context, opts appt_booking.ListOptions) ([]appt_booking.Customer, int, error) {
+	opts, err := normalizeListOptions(opts, appt_booking.IsCustomerSortField, appt_booking.SortField{Field: "name"})
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.customerRepo.List(ctx, opts)
+}
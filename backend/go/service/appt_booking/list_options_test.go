@@ -0,0 +1,104 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func TestListAppointments_FiltersSortsAndPages(t *testing.T) {
+	f := newTestFixture(t)
+	early := f.book(t, 9*time.Hour)
+	middle := f.book(t, 11*time.Hour)
+	late := f.book(t, 13*time.Hour)
+	if _, err := f.svc.CancelAppointment(f.ctx, middle.ID, ""); err != nil {
+		t.Fatalf("cancel appointment: %v", err)
+	}
+
+	// Default order is latest first
+	all, total, err := f.svc.ListAppointments(f.ctx, appt_booking.AppointmentFilter{}, appt_booking.ListOptions{})
+	checkKind(t, err, "")
+	if total != 3 || len(all) != 3 || all[0].ID != late.ID || all[2].ID != early.ID {
+		t.Fatalf("expected 3 appointments latest first, got total %d: %+v", total, all)
+	}
+	if all[0].PriceCents != 3000 {
+		t.Errorf("expected service price on listed appointments, got %d", all[0].PriceCents)
+	}
+
+	// Combined filters must all match
+	from := monday.Add(10 * time.Hour)
+	filter := appt_booking.AppointmentFilter{
+		StaffID:       f.staff.ID,
+		ServiceID:     f.service.ID,
+		CustomerEmail: "BOB@example.com",
+		Statuses:      []string{appt_booking.AppointmentStatusPending, appt_booking.AppointmentStatusConfirmed},
+		From:          &from,
+	}
+	got, total, err := f.svc.ListAppointments(f.ctx, filter, appt_booking.ListOptions{})
+	checkKind(t, err, "")
+	if total != 1 || len(got) != 1 || got[0].ID != late.ID {
+		t.Errorf("expected only the late appointment, got total %d: %+v", total, got)
+	}
+
+	// Pages report the total across all pages
+	opts := appt_booking.ListOptions{
+		Limit:  2,
+		Offset: 2,
+		Sort:   []appt_booking.SortField{{Field: "appointment_datetime"}},
+	}
+	got, total, err = f.svc.ListAppointments(f.ctx, appt_booking.AppointmentFilter{}, opts)
+	checkKind(t, err, "")
+	if total != 3 || len(got) != 1 || got[0].ID != late.ID {
+		t.Errorf("expected the last of 3 appointments, got total %d: %+v", total, got)
+	}
+}
+
+func TestListAppointments_Validation(t *testing.T) {
+	f := newTestFixture(t)
+	from := monday.Add(10 * time.Hour)
+	to := monday.Add(9 * time.Hour)
+
+	tests := []struct {
+		name   string
+		filter appt_booking.AppointmentFilter
+		opts   appt_booking.ListOptions
+	}{
+		{name: "unknown status", filter: appt_booking.AppointmentFilter{Statuses: []string{"lost"}}},
+		{name: "to before from", filter: appt_booking.AppointmentFilter{From: &from, To: &to}},
+		{name: "negative limit", opts: appt_booking.ListOptions{Limit: -1}},
+		{name: "limit too large", opts: appt_booking.ListOptions{Limit: MaxPageSize + 1}},
+		{name: "negative offset", opts: appt_booking.ListOptions{Offset: -1}},
+		{name: "unknown sort field", opts: appt_booking.ListOptions{Sort: []appt_booking.SortField{{Field: "password"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := f.svc.ListAppointments(f.ctx, tt.filter, tt.opts)
+			checkKind(t, err, KindValidation)
+		})
+	}
+}
+
+func TestListCustomers_SortsAndPages(t *testing.T) {
+	f := newTestFixture(t)
+	for _, name := range []string{"Carol", "Alan", "Bea"} {
+		if _, err := f.svc.CreateCustomer(f.ctx, name, name+"@example.com", ""); err != nil {
+			t.Fatalf("create customer: %v", err)
+		}
+	}
+
+	customers, total, err := f.svc.ListCustomers(f.ctx, appt_booking.ListOptions{Limit: 2})
+	checkKind(t, err, "")
+	if total != 3 || len(customers) != 2 || customers[0].Name != "Alan" || customers[1].Name != "Bea" {
+		t.Errorf("expected the first 2 of 3 customers by name, got total %d: %+v", total, customers)
+	}
+
+	customers, _, err = f.svc.ListCustomers(f.ctx, appt_booking.ListOptions{Sort: []appt_booking.SortField{{Field: "name", Desc: true}}})
+	checkKind(t, err, "")
+	if len(customers) != 3 || customers[0].Name != "Carol" {
+		t.Errorf("expected customers in descending name order, got %+v", customers)
+	}
+
+	_, _, err = f.svc.ListCustomers(f.ctx, appt_booking.ListOptions{Sort: []appt_booking.SortField{{Field: "phone"}}})
+	checkKind(t, err, KindValidation)
+}
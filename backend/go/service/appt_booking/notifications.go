@@ -0,0 +1,416 @@
+package appt_booking
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+//go:embed templates/*.tmpl
+var notificationTemplateFS embed.FS
+
+// notificationTemplates holds the parsed templates of each notification kind. The text
+// template defines "subject" and renders the plain-text body; the HTML one fills in the
+// "content" of layout.html.tmpl.
+var notificationTemplates = map[string]struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}{}
+
+func init() {
+	for _, kind := range []string{appt_booking.NotificationBooked, appt_booking.NotificationRescheduled, appt_booking.NotificationCancelled, appt_booking.NotificationReminder, appt_booking.NotificationWaitlistOffer} {
+		t := notificationTemplates[kind]
+		t.text = texttemplate.Must(texttemplate.ParseFS(notificationTemplateFS, "templates/"+kind+".txt.tmpl"))
+		t.html = htmltemplate.Must(htmltemplate.ParseFS(notificationTemplateFS, "templates/layout.html.tmpl", "templates/"+kind+".html.tmpl"))
+		notificationTemplates[kind] = t
+	}
+}
+
+// Notification defaults, used for zero NotificationConfig fields
+const (
+	defaultNotificationMaxAttempts  = 3
+	defaultNotificationRetryBackoff = 2 * time.Second
+	defaultNotificationMaxBackoff   = 30 * time.Second
+	notificationTimeFormat          = "Monday, January 2, 2006 at 3:04 PM MST"
+)
+
+// NotificationConfig configures how notifications are sent
+type NotificationConfig struct {
+	MaxAttempts  int           // attempts at a send that fails transiently before giving up for now
+	RetryBackoff time.Duration // delay after the first transient failure; doubles with each further one
+	MaxBackoff   time.Duration // upper bound on the delay between attempts
+
+	// Waitlist offers link to WaitlistOfferURL with the entry ID and the offer's token in
+	// its "entry" and "token" query parameters (empty = the bare token is sent)
+	WaitlistOfferURL string
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c NotificationConfig) withDefaults() NotificationConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultNotificationMaxAttempts
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaultNotificationRetryBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultNotificationMaxBackoff
+	}
+	return c
+}
+
+// NotificationData is what the notification templates are rendered with. Times are
+// formatted in the staff member's timezone.
+type NotificationData struct {
+	AppointmentID   int
+	CustomerName    string
+	ServiceName     string
+	StaffName       string
+	DurationMinutes int
+	When            string
+	PreviousWhen    string // rescheduled: the time the appointment moved from
+	Reason          string // cancelled: the reason given, if any
+
+	// Waitlist offers are about a held slot rather than an appointment
+	WaitlistEntryID int
+	ExpiresWhen     string // when the hold lapses and the slot is offered to someone else
+	OfferToken      string // accepts the offer
+	AcceptURL       string // opens the offer in the frontend; empty if not configured
+}
+
+// NotificationService emails customers about their appointments and waitlist offers and
+// keeps a log of what was sent. Each notification has a dedupe key, so a trigger that is handled twice (such
+// as a redelivered domain event) does not notify the customer twice.
+type NotificationService struct {
+	booking       *ApptBookingService
+	notifications NotificationRepository
+	notifier      Notifier
+	config        NotificationConfig
+}
+
+// NewNotificationService creates a notification service that looks appointments up in
+// booking and sends through notifier. Zero config fields take their defaults.
+func NewNotificationService(booking *ApptBookingService, notifications NotificationRepository, notifier Notifier, config NotificationConfig) *NotificationService {
+	return &NotificationService{
+		booking:       booking,
+		notifications: notifications,
+		notifier:      notifier,
+		config:        config.withDefaults(),
+	}
+}
+
+// HandleEvent is an EventHandler that notifies the customer of bookings, reschedules,
+// cancellations and waitlist offers. Transient failures are returned so the event is
+// retried later; permanent ones are only logged.
+func (s *NotificationService) HandleEvent(ctx context.Context, event appt_booking.OutboxEvent) error {
+	dedupeKey := "event:" + strconv.FormatInt(event.ID, 10)
+	var kind string
+	switch event.EventType {
+	case EventAppointmentBooked:
+		kind = appt_booking.NotificationBooked
+	case EventAppointmentRescheduled:
+		kind = appt_booking.NotificationRescheduled
+	case EventAppointmentCancelled:
+		kind = appt_booking.NotificationCancelled
+	case EventWaitlistSlotOffered:
+		// The payload is the entry as it was offered; the hold holds the token
+		var entry appt_booking.WaitlistEntry
+		if err := json.Unmarshal(event.Payload, &entry); err != nil {
+			return fmt.Errorf("decode waitlist entry: %w", err)
+		}
+		n, err := s.notifyWaitlistOffer(ctx, entry.ID, dedupeKey)
+		return eventResult(event, n, err)
+	default:
+		return nil
+	}
+
+	// The payload is the appointment as it was after the change
+	var appt appt_booking.Appointment
+	if err := json.Unmarshal(event.Payload, &appt); err != nil {
+		return fmt.Errorf("decode appointment: %w", err)
+	}
+	n, err := s.notify(ctx, kind, &appt.ID, dedupeKey, func() (EmailMessage, error) {
+		return s.render(ctx, kind, &appt)
+	})
+	return eventResult(event, n, err)
+}
+
+// eventResult is what HandleEvent returns when notifying about event ended with n and err:
+// transient failures are returned so the event is retried, permanent ones only logged
+func eventResult(event appt_booking.OutboxEvent, n *appt_booking.Notification, err error) error {
+	if err == nil || IsTransientSendError(err) {
+		return err
+	}
+	// Retrying cannot help when what the notification is about is gone, or the relay
+	// rejected the message for good
+	if KindOf(err) != "" || (n != nil && n.Status == appt_booking.NotificationStatusFailed) {
+		log.Printf("notify of %s event %d: %v", event.EventType, event.ID, err)
+		return nil
+	}
+	return err
+}
+
+// NotifyAppointment sends a notification of kind about an appointment unless one with
+// dedupeKey has been sent already. It returns the logged notification, and the error of
+// the last attempt if sending failed.
+func (s *NotificationService) NotifyAppointment(ctx context.Context, kind string, appointmentID int, dedupeKey string) (*appt_booking.Notification, error) {
+	appt, err := s.booking.GetAppointment(ctx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.notify(ctx, kind, &appt.ID, dedupeKey, func() (EmailMessage, error) {
+		return s.render(ctx, kind, appt)
+	})
+}
+
+// notifyWaitlistOffer emails the customer of waitlist entry entryID the slot held for it.
+// Nothing is sent once the offer is no longer open.
+func (s *NotificationService) notifyWaitlistOffer(ctx context.Context, entryID int, dedupeKey string) (*appt_booking.Notification, error) {
+	entry, err := s.booking.GetWaitlistEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != appt_booking.WaitlistStatusOffered {
+		return nil, nil
+	}
+	hold, err := s.booking.holdRepo.GetByWaitlistEntry(ctx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || !hold.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return s.notify(ctx, appt_booking.NotificationWaitlistOffer, nil, dedupeKey, func() (EmailMessage, error) {
+		return s.renderWaitlistOffer(ctx, entry, hold)
+	})
+}
+
+// ListNotifications retrieves the latest logged notifications matching filter, newest
+// first. A zero limit means DefaultPageSize.
+func (s *NotificationService) ListNotifications(ctx context.Context, filter appt_booking.NotificationFilter, limit int) ([]appt_booking.Notification, error) {
+	switch {
+	case limit < 0:
+		return nil, Invalid("limit", "limit must not be negative")
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit > MaxPageSize:
+		return nil, Invalid("limit", fmt.Sprintf("limit must be at most %d", MaxPageSize))
+	}
+	switch filter.Status {
+	case "", appt_booking.NotificationStatusPending, appt_booking.NotificationStatusSent, appt_booking.NotificationStatusFailed:
+	default:
+		return nil, Invalid("status", "Invalid status: "+filter.Status)
+	}
+	return s.notifications.List(ctx, filter, limit)
+}
+
+// notify sends the notification of kind that render builds, logging it under dedupeKey
+// against appointmentID, if it is about one. A notification already sent under dedupeKey
+// is not sent again; a pending or failed one is retried.
+func (s *NotificationService) notify(ctx context.Context, kind string, appointmentID *int, dedupeKey string, render func() (EmailMessage, error)) (*appt_booking.Notification, error) {
+	n, err := s.notifications.GetByDedupeKey(ctx, dedupeKey)
+	if err != nil {
+		return nil, err
+	}
+	if n != nil && n.Status == appt_booking.NotificationStatusSent {
+		return n, nil
+	}
+
+	msg, err := render()
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		n, err = s.notifications.Create(ctx, appt_booking.Notification{
+			DedupeKey:     dedupeKey,
+			Kind:          kind,
+			AppointmentID: appointmentID,
+			Channel:       appt_booking.NotificationChannelEmail,
+			Recipient:     msg.To,
+			Subject:       msg.Subject,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			// Logged concurrently by another handler, which sends it
+			return s.notifications.GetByDedupeKey(ctx, dedupeKey)
+		}
+	}
+
+	sendErr := s.send(ctx, msg, n)
+	if err := s.notifications.RecordAttempts(ctx, *n); err != nil {
+		return n, err
+	}
+	return n, sendErr
+}
+
+// send sends msg, retrying transient failures with exponential backoff, and records the
+// outcome in n
+func (s *NotificationService) send(ctx context.Context, msg EmailMessage, n *appt_booking.Notification) error {
+	n.Recipient = msg.To
+	n.Subject = msg.Subject
+	var err error
+	for attempt := 1; attempt <= s.config.MaxAttempts; attempt++ {
+		n.Attempts++
+		if err = s.notifier.Send(ctx, msg); err == nil || !IsTransientSendError(err) || attempt == s.config.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(retryBackoff(s.config.RetryBackoff, s.config.MaxBackoff, attempt)):
+			continue
+		}
+		break
+	}
+
+	if err != nil {
+		n.Status = appt_booking.NotificationStatusFailed
+		n.LastError = err.Error()
+		return err
+	}
+	now := time.Now()
+	n.Status = appt_booking.NotificationStatusSent
+	n.LastError = ""
+	n.SentAt = &now
+	return nil
+}
+
+// render builds the message of kind about appt from the templates
+func (s *NotificationService) render(ctx context.Context, kind string, appt *appt_booking.Appointment) (EmailMessage, error) {
+	data, err := s.templateData(ctx, kind, appt)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	msg, err := renderTemplates(kind, appt.CustomerEmail, data)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+
+	// Confirmations carry the appointment for the customer's calendar; the event's stable
+	// UID makes later versions replace it
+	if kind != appt_booking.NotificationReminder {
+		calendar, err := s.booking.AppointmentCalendar(ctx, appt)
+		if err != nil {
+			return EmailMessage{}, err
+		}
+		msg.Attachments = append(msg.Attachments, EmailAttachment{
+			Filename:    fmt.Sprintf("appointment-%d.ics", appt.ID),
+			ContentType: "text/calendar; charset=utf-8; method=" + calendar.Method,
+			Data:        calendar.Encode(),
+		})
+	}
+	return msg, nil
+}
+
+// renderWaitlistOffer builds the message offering hold to the customer of entry
+func (s *NotificationService) renderWaitlistOffer(ctx context.Context, entry *appt_booking.WaitlistEntry, hold *appt_booking.SlotHold) (EmailMessage, error) {
+	customer, err := s.booking.GetCustomerByID(ctx, entry.CustomerID)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	service, err := s.booking.GetServiceByID(ctx, hold.ServiceID)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	staff, err := s.booking.GetStaffByID(ctx, hold.StaffID)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+	loc := s.booking.staffLocation(staff)
+
+	data := NotificationData{
+		CustomerName:    customer.Name,
+		ServiceName:     service.Name,
+		StaffName:       staff.Name,
+		DurationMinutes: hold.DurationMinutes,
+		When:            hold.StartsAt.In(loc).Format(notificationTimeFormat),
+		WaitlistEntryID: entry.ID,
+		ExpiresWhen:     hold.ExpiresAt.In(loc).Format(notificationTimeFormat),
+		OfferToken:      hold.Token,
+	}
+	if s.config.WaitlistOfferURL != "" {
+		u, err := url.Parse(s.config.WaitlistOfferURL)
+		if err != nil {
+			return EmailMessage{}, fmt.Errorf("invalid waitlist offer URL: %w", err)
+		}
+		query := u.Query()
+		query.Set("entry", strconv.Itoa(entry.ID))
+		query.Set("token", hold.Token)
+		u.RawQuery = query.Encode()
+		data.AcceptURL = u.String()
+	}
+	return renderTemplates(appt_booking.NotificationWaitlistOffer, customer.Email, data)
+}
+
+// renderTemplates renders the templates of kind with data into a message to recipient
+func renderTemplates(kind, recipient string, data NotificationData) (EmailMessage, error) {
+	tmpl, ok := notificationTemplates[kind]
+	if !ok {
+		return EmailMessage{}, fmt.Errorf("unknown notification kind %q", kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html.tmpl", data); err != nil {
+		return EmailMessage{}, err
+	}
+	return EmailMessage{To: recipient, Subject: subject.String(), TextBody: text.String(), HTMLBody: html.String()}, nil
+}
+
+// templateData gathers what the templates of kind show about appt
+func (s *NotificationService) templateData(ctx context.Context, kind string, appt *appt_booking.Appointment) (NotificationData, error) {
+	service, err := s.booking.GetServiceByID(ctx, appt.ServiceID)
+	if err != nil {
+		return NotificationData{}, err
+	}
+	staff, err := s.booking.GetStaffByID(ctx, appt.StaffID)
+	if err != nil {
+		return NotificationData{}, err
+	}
+	loc := s.booking.staffLocation(staff)
+
+	data := NotificationData{
+		AppointmentID:   appt.ID,
+		CustomerName:    appt.CustomerName,
+		ServiceName:     service.Name,
+		StaffName:       staff.Name,
+		DurationMinutes: appt.DurationMinutes,
+		When:            appt.AppointmentDatetime.In(loc).Format(notificationTimeFormat),
+	}
+	switch kind {
+	case appt_booking.NotificationRescheduled:
+		reschedules, err := s.booking.GetAppointmentReschedules(ctx, appt.ID)
+		if err != nil {
+			return data, err
+		}
+		if len(reschedules) > 0 {
+			data.PreviousWhen = reschedules[0].PreviousDatetime.In(loc).Format(notificationTimeFormat)
+		}
+	case appt_booking.NotificationCancelled:
+		cancellation, err := s.booking.GetAppointmentCancellation(ctx, appt.ID)
+		if err != nil && KindOf(err) != KindNotFound {
+			return data, err
+		}
+		if cancellation != nil {
+			data.Reason = cancellation.Reason
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,232 @@
+package appt_booking
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// recordingNotifier records the messages it is asked to send, failing with the queued
+// errors first
+type recordingNotifier struct {
+	mu       sync.Mutex
+	failures []error
+	attempts int
+	sent     []EmailMessage
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg EmailMessage) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attempts++
+	if len(n.failures) > 0 {
+		err := n.failures[0]
+		n.failures = n.failures[1:]
+		return err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// newNotificationService returns a notification service over the fixture that retries
+// after a millisecond, and a dispatcher that hands it the fixture's domain events
+func (f *testFixture) newNotificationService(notifier Notifier) (*NotificationService, *EventDispatcher) {
+	notifications := NewNotificationService(f.svc, f.store.Notifications(), notifier,
+		NotificationConfig{RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	dispatcher := NewEventDispatcher(f.store.Outbox(), DispatcherConfig{RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	dispatcher.Subscribe("notifications", "", notifications.HandleEvent)
+	return notifications, dispatcher
+}
+
+var (
+	errMailboxBusy = &textproto.Error{Code: 451, Msg: "Mailbox busy"}
+	errNoSuchUser  = &textproto.Error{Code: 550, Msg: "No such user"}
+)
+
+func TestNotifications_BookedRescheduledCancelled(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	notifications, dispatcher := f.newNotificationService(notifier)
+
+	a := f.book(t, 10*time.Hour)
+	if _, err := f.svc.RescheduleAppointment(f.ctx, a.ID, 0, monday.Add(11*time.Hour)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, "Feeling unwell"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if len(notifier.sent) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(notifier.sent))
+	}
+	booked, rescheduled, cancelled := notifier.sent[0], notifier.sent[1], notifier.sent[2]
+	for _, msg := range notifier.sent {
+		if msg.To != "bob@example.com" || !strings.Contains(msg.TextBody, "Hi Bob") ||
+			!strings.Contains(msg.HTMLBody, "Alice") || !strings.Contains(msg.TextBody, "Haircut (60 minutes)") {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	}
+	if !strings.Contains(booked.Subject, "booked for Monday, January 7, 2030 at 10:00 AM UTC") {
+		t.Errorf("unexpected booked subject %q", booked.Subject)
+	}
+	if !strings.Contains(rescheduled.Subject, "11:00 AM") || !strings.Contains(rescheduled.TextBody, "from Monday, January 7, 2030 at 10:00 AM UTC") {
+		t.Errorf("unexpected rescheduled message %q: %q", rescheduled.Subject, rescheduled.TextBody)
+	}
+	if !strings.Contains(cancelled.TextBody, "Reason: Feeling unwell") || !strings.Contains(cancelled.HTMLBody, "Reason: Feeling unwell") {
+		t.Errorf("unexpected cancelled message %q", cancelled.TextBody)
+	}
+
+	logged, err := notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{AppointmentID: a.ID}, 0)
+	if err != nil || len(logged) != 3 {
+		t.Fatalf("expected 3 logged notifications, got %v, %v", logged, err)
+	}
+	for _, n := range logged {
+		if n.Status != appt_booking.NotificationStatusSent || n.Attempts != 1 || n.SentAt == nil || n.Recipient != "bob@example.com" {
+			t.Errorf("unexpected log entry %+v", n)
+		}
+	}
+}
+
+func TestNotifications_WaitlistOffer(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	notifications := NewNotificationService(f.svc, f.store.Notifications(), notifier,
+		NotificationConfig{WaitlistOfferURL: "https://example.com/waitlist-offer"})
+	dispatcher := NewEventDispatcher(f.store.Outbox(), DispatcherConfig{})
+	dispatcher.Subscribe("notifications", EventWaitlistSlotOffered, notifications.HandleEvent)
+
+	a := f.book(t, 10*time.Hour)
+	entry := f.joinWaitlist(t, "carol", 0)
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if len(notifier.sent) != 1 {
+		t.Fatalf("expected 1 offer message, got %d", len(notifier.sent))
+	}
+	msg := notifier.sent[0]
+	link := fmt.Sprintf("https://example.com/waitlist-offer?entry=%d&token=%s", entry.ID, f.offeredHold(t, entry.ID).Token)
+	if msg.To != "carol@example.com" || !strings.Contains(msg.Subject, "Monday, January 7, 2030 at 10:00 AM UTC") ||
+		!strings.Contains(msg.TextBody, link) || !strings.Contains(msg.HTMLBody, html.EscapeString(link)) {
+		t.Errorf("unexpected offer message %+v", msg)
+	}
+
+	// Once the offer is accepted there is nothing left to send
+	if _, err := f.svc.AcceptWaitlistOffer(f.ctx, entry.ID, f.offeredHold(t, entry.ID).Token); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	n, err := notifications.notifyWaitlistOffer(f.ctx, entry.ID, "test")
+	if err != nil || n != nil {
+		t.Errorf("expected no notification for an accepted offer, got %+v, %v", n, err)
+	}
+}
+
+func TestNotifications_SentOncePerEvent(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	notifications, _ := f.newNotificationService(notifier)
+	f.book(t, 10*time.Hour)
+
+	event := f.lastOutboxEvent(t)
+	for i := 0; i < 2; i++ {
+		if err := notifications.HandleEvent(f.ctx, event); err != nil {
+			t.Fatalf("handle event: %v", err)
+		}
+	}
+	if len(notifier.sent) != 1 {
+		t.Errorf("expected 1 message for a redelivered event, got %d", len(notifier.sent))
+	}
+}
+
+func TestNotifications_RetriesTransientFailures(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{failures: []error{errMailboxBusy, errMailboxBusy}}
+	notifications, dispatcher := f.newNotificationService(notifier)
+	a := f.book(t, 10*time.Hour)
+
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if len(notifier.sent) != 1 || notifier.attempts != 3 {
+		t.Fatalf("expected 1 message after 3 attempts, got %d after %d", len(notifier.sent), notifier.attempts)
+	}
+	logged, _ := notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{AppointmentID: a.ID}, 0)
+	if len(logged) != 1 || logged[0].Status != appt_booking.NotificationStatusSent || logged[0].Attempts != 3 {
+		t.Errorf("unexpected log %+v", logged)
+	}
+}
+
+func TestNotifications_TransientFailureRetriesEventLater(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{failures: []error{errMailboxBusy, errMailboxBusy, errMailboxBusy}}
+	notifications, dispatcher := f.newNotificationService(notifier)
+	a := f.book(t, 10*time.Hour)
+
+	// Three failed attempts use up the first round; the event stays pending
+	if n, err := dispatcher.DispatchDue(f.ctx); err != nil || n != 2 {
+		t.Fatalf("expected the booking event to be retried later, got %d delivered, %v", n, err)
+	}
+	logged, _ := notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{Status: appt_booking.NotificationStatusFailed}, 0)
+	if len(logged) != 1 || logged[0].LastError == "" {
+		t.Fatalf("expected a failed notification, got %+v", logged)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n, err := dispatcher.DispatchDue(f.ctx); err != nil || n != 1 {
+		t.Fatalf("expected the retried event to be delivered, got %d, %v", n, err)
+	}
+	logged, _ = notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{AppointmentID: a.ID}, 0)
+	if len(logged) != 1 || logged[0].Status != appt_booking.NotificationStatusSent || logged[0].Attempts != 4 {
+		t.Errorf("expected the same notification sent on the 4th attempt, got %+v", logged)
+	}
+}
+
+func TestNotifications_PermanentFailureIsLogged(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{failures: []error{errNoSuchUser}}
+	notifications, dispatcher := f.newNotificationService(notifier)
+	f.book(t, 10*time.Hour)
+
+	if n, err := dispatcher.DispatchDue(f.ctx); err != nil || n != 3 {
+		t.Fatalf("expected every event to be delivered, got %d, %v", n, err)
+	}
+	if notifier.attempts != 1 {
+		t.Errorf("expected no retries of a permanent failure, got %d attempts", notifier.attempts)
+	}
+	logged, _ := notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{}, 0)
+	if len(logged) != 1 || logged[0].Status != appt_booking.NotificationStatusFailed || !strings.Contains(logged[0].LastError, "No such user") {
+		t.Errorf("unexpected log %+v", logged)
+	}
+
+	_, err := notifications.ListNotifications(f.ctx, appt_booking.NotificationFilter{Status: "bounced"}, 0)
+	checkKind(t, err, KindValidation)
+}
+
+func TestNotifications_ReminderTemplate(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	notifications, _ := f.newNotificationService(notifier)
+	a := f.book(t, 10*time.Hour)
+
+	n, err := notifications.NotifyAppointment(f.ctx, appt_booking.NotificationReminder, a.ID, "reminder:test")
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if n.Kind != appt_booking.NotificationReminder || len(notifier.sent) != 1 ||
+		!strings.HasPrefix(notifier.sent[0].Subject, "Reminder: Haircut on Monday, January 7, 2030") {
+		t.Errorf("unexpected reminder %+v: %+v", n, notifier.sent)
+	}
+}
@@ -0,0 +1,284 @@
+package appt_booking
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailMessage is a rendered notification with plain-text and HTML alternatives
+type EmailMessage struct {
+	To          string
+	Subject     string
+	TextBody    string
+	HTMLBody    string
+	Attachments []EmailAttachment
+}
+
+// EmailAttachment is a file attached to an email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string // e.g. "text/calendar; charset=utf-8; method=PUBLISH"
+	Data        []byte
+}
+
+// Notifier sends notifications. Errors for which IsTransientSendError reports true are
+// worth retrying.
+type Notifier interface {
+	Send(ctx context.Context, msg EmailMessage) error
+}
+
+// IsTransientSendError reports whether a send failed for a reason that may go away on its
+// own: a network failure or a 4xx SMTP reply such as "mailbox busy". 5xx replies and
+// malformed messages are permanent.
+func IsTransientSendError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// defaultSMTPTimeout bounds a whole SMTP conversation when SMTPConfig.Timeout is zero
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig configures an SMTP relay
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // empty = no authentication
+	Password string
+	From     string        // envelope and header sender
+	Timeout  time.Duration // limit on connecting and sending one message
+}
+
+// SMTPNotifier sends notifications as email through an SMTP relay, upgrading to TLS when
+// the server offers STARTTLS
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier creates a notifier for the relay in config
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPNotifier{config: config}
+}
+
+// Send delivers msg to the relay
+func (n *SMTPNotifier) Send(ctx context.Context, msg EmailMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, n.config.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+			return err
+		}
+	}
+	if n.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.config.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	body, err := composeEmail(n.config.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// The relay has accepted the message; failing to say goodbye must not get it resent
+	client.Quit()
+	return nil
+}
+
+// LogNotifier writes notifications to the standard logger instead of sending them. It is
+// the default notifier, meant for development.
+type LogNotifier struct{}
+
+// Send logs msg
+func (LogNotifier) Send(ctx context.Context, msg EmailMessage) error {
+	log.Printf("notification to %s: %s\n%s", msg.To, msg.Subject, msg.TextBody)
+	return nil
+}
+
+// FileNotifier writes each notification as an .eml file to a directory instead of sending
+// it, so that development mail can be opened in a mail client
+type FileNotifier struct {
+	Dir  string
+	From string
+}
+
+// Send writes msg to a new file in Dir
+func (n FileNotifier) Send(ctx context.Context, msg EmailMessage) error {
+	now := time.Now()
+	body, err := composeEmail(n.From, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(n.Dir, 0o755); err != nil {
+		return err
+	}
+	recipient := strings.NewReplacer("/", "_", "\\", "_", "@", "_at_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(n.Dir, name), body, 0o644)
+}
+
+// composeEmail renders msg as a MIME message with plain-text and HTML alternatives,
+// wrapped in a multipart/mixed message with the attachments if there are any
+func composeEmail(from string, msg EmailMessage, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if len(msg.Attachments) == 0 {
+		body, boundary, err := composeAlternatives(msg)
+		if err != nil {
+			return nil, err
+		}
+		header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/mixed; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+	body, boundary, err := composeAlternatives(msg)
+	if err != nil {
+		return nil, err
+	}
+	w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {`multipart/alternative; boundary="` + boundary + `"`}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// composeAlternatives renders the plain-text and HTML bodies of msg as the parts of a
+// multipart/alternative body, returning it and its boundary
+func composeAlternatives(msg EmailMessage) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.TextBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, "", err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.Boundary(), nil
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters, as MIME requires
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := 76
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
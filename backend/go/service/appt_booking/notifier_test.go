@@ -0,0 +1,196 @@
+package appt_booking
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server on localhost that records the messages it accepts.
+// rcptReplies are answered to RCPT TO commands in turn; after them RCPT TO succeeds.
+type smtpStandIn struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	rcptReplies []string
+	messages    []string
+}
+
+func newSMTPStandIn(t *testing.T, rcptReplies ...string) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStandIn{listener: l, rcptReplies: rcptReplies}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "bookings@example.com", Timeout: 5 * time.Second}
+}
+
+func (s *smtpStandIn) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			s.mu.Lock()
+			answer := "250 OK"
+			if len(s.rcptReplies) > 0 {
+				answer, s.rcptReplies = s.rcptReplies[0], s.rcptReplies[1:]
+			}
+			s.mu.Unlock()
+			reply(answer)
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK: queued")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier_SendsMultipartMessage(t *testing.T) {
+	server := newSMTPStandIn(t)
+	n := NewSMTPNotifier(server.config())
+
+	err := n.Send(context.Background(), EmailMessage{
+		To:       "carol@example.com",
+		Subject:  "Your appointment – booked",
+		TextBody: "See you soon.",
+		HTMLBody: "<p>See you <strong>soon</strong>.</p>",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0]))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if msg.Header.Get("To") != "carol@example.com" || subject != "Your appointment – booked" {
+		t.Errorf("unexpected headers %v", msg.Header)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q", msg.Header.Get("Content-Type"))
+	}
+	var types []string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		types = append(types, part.Header.Get("Content-Type"))
+		if !strings.Contains(string(body), "soon") {
+			t.Errorf("unexpected %s part %q", part.Header.Get("Content-Type"), body)
+		}
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("expected text and HTML alternatives, got %v", types)
+	}
+}
+
+func TestSMTPNotifier_ClassifiesFailures(t *testing.T) {
+	server := newSMTPStandIn(t, "451 Mailbox busy, try again later", "550 No such user")
+	n := NewSMTPNotifier(server.config())
+	msg := EmailMessage{To: "carol@example.com", Subject: "Hi", TextBody: "Hi"}
+
+	if err := n.Send(context.Background(), msg); err == nil || !IsTransientSendError(err) {
+		t.Errorf("expected a transient error for 451, got %v", err)
+	}
+	if err := n.Send(context.Background(), msg); err == nil || IsTransientSendError(err) {
+		t.Errorf("expected a permanent error for 550, got %v", err)
+	}
+
+	// Nobody listening is transient too
+	config := server.config()
+	server.listener.Close()
+	if err := NewSMTPNotifier(config).Send(context.Background(), msg); err == nil || !IsTransientSendError(err) {
+		t.Errorf("expected a transient error when the relay is down, got %v", err)
+	}
+}
+
+func TestFileNotifier_WritesMessages(t *testing.T) {
+	dir := t.TempDir()
+	n := FileNotifier{Dir: filepath.Join(dir, "mail"), From: "bookings@example.com"}
+	for i := 0; i < 2; i++ {
+		if err := n.Send(context.Background(), EmailMessage{To: "carol@example.com", Subject: "Hi " + strconv.Itoa(i), TextBody: "Hi"}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	files, err := os.ReadDir(n.Dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v, %v", files, err)
+	}
+}
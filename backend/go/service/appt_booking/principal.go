@@ -0,0 +1,63 @@
+package appt_booking
+
+import (
+	"context"
+	"strings"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID        int
+	Email         string
+	EmailVerified bool   // customers are matched to bookings by Email only once verified, as anyone can register an address
+	Role          string // one of the appt_booking.Role* constants
+	StaffID       *int   // set for staff accounts
+}
+
+// principalKey is the context key for the request's Principal
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of ctx, or nil for anonymous requests
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// HasRole reports whether p has one of roles; false for a nil principal
+func (p *Principal) HasRole(roles ...string) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether p is the staff member staffID
+func (p *Principal) IsStaff(staffID int) bool {
+	return p != nil && p.StaffID != nil && *p.StaffID == staffID
+}
+
+// CanAccessAppointment reports whether p may see and act on a: admins on every appointment,
+// providers on their own, customers on those booked under their verified email
+func (p *Principal) CanAccessAppointment(a *appt_booking.Appointment) bool {
+	switch {
+	case p.HasRole(appt_booking.RoleAdmin):
+		return true
+	case p.HasRole(appt_booking.RoleProvider):
+		return p.IsStaff(a.StaffID)
+	case p.HasRole(appt_booking.RoleCustomer):
+		return p.EmailVerified && strings.EqualFold(p.Email, a.CustomerEmail)
+	}
+	return false
+}
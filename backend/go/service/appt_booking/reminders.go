@@ -0,0 +1,160 @@
+package appt_booking
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Reminder defaults, used for zero ReminderConfig fields
+const (
+	defaultReminderInterval  = time.Minute
+	defaultReminderBatchSize = 100
+)
+
+// defaultReminderOffsets are how long before an appointment its reminders are sent
+var defaultReminderOffsets = []time.Duration{24 * time.Hour, 2 * time.Hour}
+
+// ReminderJobName is the name the reminder job is scheduled under
+const ReminderJobName = "appointment-reminders"
+
+// ReminderConfig configures appointment reminders
+type ReminderConfig struct {
+	Offsets   []time.Duration // how long before an appointment to remind the customer, to the minute
+	Interval  time.Duration   // how often the reminder job runs
+	BatchSize int             // appointments reminded per query
+}
+
+// withDefaults returns c with zero fields set to their defaults and Offsets truncated to
+// whole minutes, longest first, without duplicates
+func (c ReminderConfig) withDefaults() ReminderConfig {
+	if len(c.Offsets) == 0 {
+		c.Offsets = defaultReminderOffsets
+	}
+	var offsets []time.Duration
+	for _, offset := range c.Offsets {
+		if offset = offset.Truncate(time.Minute); offset > 0 {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	c.Offsets = nil
+	for i, offset := range offsets {
+		if i == 0 || offset != offsets[i-1] {
+			c.Offsets = append(c.Offsets, offset)
+		}
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultReminderInterval
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultReminderBatchSize
+	}
+	return c
+}
+
+// ReminderService reminds customers of their upcoming appointments at each of the
+// configured offsets before them. Each reminder is recorded against the appointment's
+// time, so it is sent once however often the job runs, and again if the appointment is
+// rescheduled.
+type ReminderService struct {
+	reminders     ReminderRepository
+	notifications *NotificationService
+	config        ReminderConfig
+}
+
+// NewReminderService creates a reminder service that sends reminders through
+// notifications. Zero config fields take their defaults.
+func NewReminderService(reminders ReminderRepository, notifications *NotificationService, config ReminderConfig) *ReminderService {
+	return &ReminderService{reminders: reminders, notifications: notifications, config: config.withDefaults()}
+}
+
+// Job returns the periodic job that sends due reminders
+func (s *ReminderService) Job() Job {
+	return Job{
+		Name:     ReminderJobName,
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context, now time.Time) error {
+			n, err := s.SendDue(ctx, now)
+			if n > 0 {
+				log.Printf("sent %d appointment reminders", n)
+			}
+			return err
+		},
+	}
+}
+
+// SendDue sends the reminders due at now and returns the number sent. An appointment
+// within an offset of now is reminded at the shortest such offset only, so a customer
+// who books late, or whose reminders fell due while the job was not running, gets one
+// reminder rather than several at once. Reminders that fail transiently are left for the
+// next run.
+func (s *ReminderService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for i, offset := range s.config.Offsets {
+		var skipWithin time.Duration
+		if i+1 < len(s.config.Offsets) {
+			skipWithin = s.config.Offsets[i+1]
+		}
+		for {
+			due, err := s.reminders.GetDue(ctx, offset, skipWithin, now, s.config.BatchSize)
+			if err != nil {
+				return sent, err
+			}
+			deferred := false
+			for _, appt := range due {
+				ok, err := s.remind(ctx, appt, offset)
+				if err != nil {
+					return sent, err
+				}
+				if ok {
+					sent++
+				} else {
+					deferred = true
+				}
+			}
+			// Deferred reminders would come back in the next batch
+			if len(due) < s.config.BatchSize || deferred {
+				break
+			}
+		}
+	}
+	return sent, nil
+}
+
+// remind sends the reminder offset before appt and records it. Returns false if sending
+// failed transiently and should be tried again.
+func (s *ReminderService) remind(ctx context.Context, appt appt_booking.Appointment, offset time.Duration) (bool, error) {
+	offsetMinutes := int(offset / time.Minute)
+	dedupeKey := fmt.Sprintf("reminder:%d:%d:%d", appt.ID, offsetMinutes, appt.AppointmentDatetime.Unix())
+	n, err := s.notifications.NotifyAppointment(ctx, appt_booking.NotificationReminder, appt.ID, dedupeKey)
+	switch {
+	case err == nil:
+	case IsTransientSendError(err):
+		log.Printf("remind %s of appointment %d: %v; will retry", appt.CustomerEmail, appt.ID, err)
+		return false, nil
+	case KindOf(err) != "" || (n != nil && n.Status == appt_booking.NotificationStatusFailed):
+		// Retrying cannot help; record the reminder so it is not attempted again
+		log.Printf("remind %s of appointment %d: %v", appt.CustomerEmail, appt.ID, err)
+	default:
+		return false, err
+	}
+
+	reminder := appt_booking.AppointmentReminder{
+		AppointmentID:       appt.ID,
+		OffsetMinutes:       offsetMinutes,
+		AppointmentDatetime: appt.AppointmentDatetime,
+		SentAt:              time.Now(),
+	}
+	if n != nil {
+		reminder.NotificationID = &n.ID
+	}
+	if _, err := s.reminders.Record(ctx, reminder); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package appt_booking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// newReminderService returns a reminder service over the fixture sending 24h and 2h reminders
+func (f *testFixture) newReminderService(notifier Notifier) *ReminderService {
+	notifications, _ := f.newNotificationService(notifier)
+	return NewReminderService(f.store.Reminders(), notifications, ReminderConfig{Offsets: []time.Duration{2 * time.Hour, 24 * time.Hour}})
+}
+
+func TestReminders_SentOncePerOffset(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	reminders := f.newReminderService(notifier)
+	a := f.book(t, 10*time.Hour)
+	start := a.AppointmentDatetime
+
+	for _, step := range []struct {
+		before time.Duration
+		want   int
+	}{
+		{25 * time.Hour, 0},
+		{24 * time.Hour, 1},
+		{23 * time.Hour, 0}, // already reminded
+		{2 * time.Hour, 1},
+		{90 * time.Minute, 0},
+		{-time.Hour, 0}, // started
+	} {
+		if n, err := reminders.SendDue(f.ctx, start.Add(-step.before)); err != nil || n != step.want {
+			t.Fatalf("%v before: expected %d reminders, got %d, %v", step.before, step.want, n, err)
+		}
+	}
+	if len(notifier.sent) != 2 || !strings.HasPrefix(notifier.sent[0].Subject, "Reminder: Haircut") {
+		t.Errorf("unexpected messages %+v", notifier.sent)
+	}
+	sent, _ := f.store.Reminders().GetByAppointment(f.ctx, a.ID)
+	if len(sent) != 2 || sent[0].OffsetMinutes != 24*60 || sent[1].OffsetMinutes != 2*60 || sent[1].NotificationID == nil {
+		t.Errorf("unexpected reminder records %+v", sent)
+	}
+}
+
+func TestReminders_LateRunSendsOnlyClosestReminder(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	reminders := f.newReminderService(notifier)
+	a := f.book(t, 10*time.Hour)
+
+	if n, err := reminders.SendDue(f.ctx, a.AppointmentDatetime.Add(-time.Hour)); err != nil || n != 1 {
+		t.Fatalf("expected 1 reminder, got %d, %v", n, err)
+	}
+	sent, _ := f.store.Reminders().GetByAppointment(f.ctx, a.ID)
+	if len(sent) != 1 || sent[0].OffsetMinutes != 2*60 {
+		t.Errorf("expected only the 2h reminder, got %+v", sent)
+	}
+}
+
+func TestReminders_RescheduledAndCancelledAppointments(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{}
+	reminders := f.newReminderService(notifier)
+	a := f.book(t, 10*time.Hour)
+	cancelled := f.book(t, 14*time.Hour)
+	if _, err := f.svc.CancelAppointment(f.ctx, cancelled.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	if n, err := reminders.SendDue(f.ctx, monday.Add(-13*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("expected only the active appointment to be reminded, got %d, %v", n, err)
+	}
+	// Moved an hour later: the customer is reminded of the new time
+	if _, err := f.svc.RescheduleAppointment(f.ctx, a.ID, 0, monday.Add(11*time.Hour)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if n, err := reminders.SendDue(f.ctx, monday.Add(-13*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("expected a reminder of the new time, got %d, %v", n, err)
+	}
+	if len(notifier.sent) != 2 || !strings.Contains(notifier.sent[1].Subject, "11:00 AM") {
+		t.Errorf("unexpected messages %+v", notifier.sent)
+	}
+}
+
+func TestReminders_TransientFailureRetriedNextRun(t *testing.T) {
+	f := newTestFixture(t)
+	notifier := &recordingNotifier{failures: []error{errMailboxBusy, errMailboxBusy, errMailboxBusy}}
+	reminders := f.newReminderService(notifier)
+	a := f.book(t, 10*time.Hour)
+	now := a.AppointmentDatetime.Add(-24 * time.Hour)
+
+	if n, err := reminders.SendDue(f.ctx, now); err != nil || n != 0 {
+		t.Fatalf("expected the reminder to be deferred, got %d, %v", n, err)
+	}
+	if n, err := reminders.SendDue(f.ctx, now.Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("expected the reminder to be sent on the next run, got %d, %v", n, err)
+	}
+	logged, _ := f.store.Notifications().List(f.ctx, appt_booking.NotificationFilter{AppointmentID: a.ID}, 0)
+	reminderCount := 0
+	for _, n := range logged {
+		if n.Kind == appt_booking.NotificationReminder {
+			reminderCount++
+		}
+	}
+	if reminderCount != 1 || len(notifier.sent) != 1 {
+		t.Errorf("expected one reminder notification, got %d logged and %d sent", reminderCount, len(notifier.sent))
+	}
+}
+
+func TestReminders_JobRunsThroughScheduler(t *testing.T) {
+	f := newTestFixture(t)
+	reminders := f.newReminderService(&recordingNotifier{})
+	scheduler := NewScheduler(f.store.Jobs(), SchedulerConfig{})
+	scheduler.Register(reminders.Job())
+
+	if n, err := scheduler.RunDue(f.ctx); err != nil || n != 1 {
+		t.Fatalf("expected the reminder job to run, got %d, %v", n, err)
+	}
+	jobs, _ := f.store.Jobs().GetAll(f.ctx)
+	if len(jobs) != 1 || jobs[0].Name != ReminderJobName || jobs[0].LastError != "" {
+		t.Errorf("unexpected schedule %+v", jobs)
+	}
+}
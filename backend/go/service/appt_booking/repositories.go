@@ -0,0 +1,254 @@
+package appt_booking
+
+import (
+	"context"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// The interfaces below describe what ApptBookingService needs from storage. The Postgres
+// repositories in db/appt_booking implement them; db/appt_booking/memory provides an
+// in-memory implementation for tests. Lookups by ID return nil, nil when nothing matches.
+// Methods taking an *appt_booking.OutboxEvent record it in the outbox atomically with their
+// change, against the changed row; a nil event records nothing.
+
+// ServiceRepository stores bookable services. Delete records its event only if the
+// service existed.
+type ServiceRepository interface {
+	Create(ctx context.Context, name, description string, duration, priceCents int, policy appt_booking.BookingPolicy, event *appt_booking.OutboxEvent) (*appt_booking.Service, error)
+	Update(ctx context.Context, id int, name, description string, duration, priceCents int, policy appt_booking.BookingPolicy, event *appt_booking.OutboxEvent) (*appt_booking.Service, error)
+	GetAll(ctx context.Context) ([]appt_booking.Service, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.Service, error)
+	Delete(ctx context.Context, id int, event *appt_booking.OutboxEvent) error
+}
+
+// StaffRepository stores staff members. Delete records its event only if the staff member existed.
+type StaffRepository interface {
+	Create(ctx context.Context, name, email, phone, role, timezone string, event *appt_booking.OutboxEvent) (*appt_booking.Staff, error)
+	Update(ctx context.Context, id int, name, email, phone, role, timezone string, event *appt_booking.OutboxEvent) (*appt_booking.Staff, error)
+	GetAll(ctx context.Context) ([]appt_booking.Staff, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.Staff, error)
+	GetByEmail(ctx context.Context, email string) (*appt_booking.Staff, error)
+	// Delete also removes the staff member's schedules, exceptions, service assignments, series and login account
+	Delete(ctx context.Context, id int, event *appt_booking.OutboxEvent) error
+}
+
+// StaffServiceRepository stores which staff members offer which services
+type StaffServiceRepository interface {
+	Assign(ctx context.Context, staffID, serviceID int) error
+	Unassign(ctx context.Context, staffID, serviceID int) error
+	GetServicesForStaff(ctx context.Context, staffID int) ([]appt_booking.Service, error)
+	GetStaffForService(ctx context.Context, serviceID int) ([]appt_booking.Staff, error)
+}
+
+// ScheduleRepository stores weekly working hours. Times are HH:MM strings.
+type ScheduleRepository interface {
+	Create(ctx context.Context, staffID, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error)
+	Update(ctx context.Context, id int, dayOfWeek int, startTime, endTime string) (*appt_booking.Schedule, error)
+	GetAll(ctx context.Context) ([]appt_booking.Schedule, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.Schedule, error)
+	GetByStaff(ctx context.Context, staffID int) ([]appt_booking.Schedule, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// ScheduleExceptionRepository stores time off, holidays and one-off hours.
+// Dates are YYYY-MM-DD and times HH:MM strings.
+type ScheduleExceptionRepository interface {
+	Create(ctx context.Context, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*appt_booking.ScheduleException, error)
+	Update(ctx context.Context, id int, staffID *int, startDate, endDate string, isClosed bool, startTime, endTime *string, reason string) (*appt_booking.ScheduleException, error)
+	GetAll(ctx context.Context) ([]appt_booking.ScheduleException, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.ScheduleException, error)
+	GetByStaff(ctx context.Context, staffID int) ([]appt_booking.ScheduleException, error)
+	GetApplicable(ctx context.Context, staffID int, fromDate, toDate string) ([]appt_booking.ScheduleException, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// AppointmentRepository stores appointments with their reschedule and status history.
+// CreateExclusive and RescheduleExclusive must check for overlaps atomically with the
+// write and return appt_booking.ErrAppointmentConflict; unexpired holds count as overlaps.
+// CreateFromHold books a hold's slot and releases the hold, returning
+// appt_booking.ErrHoldNotFound if it has expired. TransitionStatus and Cancel must return
+// appt_booking.ErrAppointmentStatusChanged when the current status is not fromStatus;
+// Cancel records the cancellation atomically with the status change. RescheduleExclusive
+// must recheck the status atomically with the move and return
+// appt_booking.ErrAppointmentStatusChanged unless it is one of
+// appt_booking.ReschedulableStatuses.
+type AppointmentRepository interface {
+	CreateExclusive(ctx context.Context, customerID int, customerName, customerEmail, customerPhone string, staffID, serviceID, durationMinutes int, appointmentDatetime time.Time, status, notes string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error)
+	CreateFromHold(ctx context.Context, holdID, customerID int, customerName, customerEmail, customerPhone, status, notes string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error)
+	RescheduleExclusive(ctx context.Context, id, staffID int, appointmentDatetime time.Time, durationMinutes int, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error)
+	TransitionStatus(ctx context.Context, id int, fromStatus, toStatus, changedBy, reason string, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error)
+	Cancel(ctx context.Context, id int, fromStatus, changedBy string, cancellation appt_booking.AppointmentCancellation, event *appt_booking.OutboxEvent) (*appt_booking.Appointment, error)
+	GetCancellation(ctx context.Context, appointmentID int) (*appt_booking.AppointmentCancellation, error)
+	GetAll(ctx context.Context) ([]appt_booking.Appointment, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.Appointment, error)
+	GetByStaff(ctx context.Context, staffID int) ([]appt_booking.Appointment, error)
+	GetByCustomerEmail(ctx context.Context, email string) ([]appt_booking.Appointment, error)
+	GetUpcoming(ctx context.Context, limit int) ([]appt_booking.Appointment, error)
+	GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.Appointment, error)
+	GetReschedules(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentReschedule, error)
+	CountReschedules(ctx context.Context, appointmentIDs []int) (map[int]int, error)
+	GetStatusHistory(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentStatusChange, error)
+	GetAllWithServiceDetails(ctx context.Context) ([]appt_booking.AppointmentWithService, error)
+	GetByStaffWithServiceDetails(ctx context.Context, staffID int) ([]appt_booking.AppointmentWithService, error)
+	GetByCustomerEmailWithServiceDetails(ctx context.Context, email string) ([]appt_booking.AppointmentWithService, error)
+	GetUpcomingWithServiceDetails(ctx context.Context, limit int) ([]appt_booking.AppointmentWithService, error)
+	ListWithServiceDetails(ctx context.Context, filter appt_booking.AppointmentFilter, opts appt_booking.ListOptions) ([]appt_booking.AppointmentWithService, int, error)
+}
+
+// CustomerRepository stores customers. Emails are lower-cased by the service; Create and
+// Update return appt_booking.ErrCustomerEmailTaken if another customer has the email.
+type CustomerRepository interface {
+	Create(ctx context.Context, name, email, phone string) (*appt_booking.Customer, error)
+	Update(ctx context.Context, id int, name, email, phone string) (*appt_booking.Customer, error)
+	List(ctx context.Context, opts appt_booking.ListOptions) ([]appt_booking.Customer, int, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*appt_booking.Customer, error)
+	GetStats(ctx context.Context, id int) (*appt_booking.CustomerStats, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// SeriesRepository stores appointment series and links their occurrences to appointments.
+// Delete keeps the appointments; GetOccurrences returns occurrences in index order.
+type SeriesRepository interface {
+	Create(ctx context.Context, customerID, staffID, serviceID int, frequency string, interval int, count *int, until *time.Time, startsAt time.Time, notes string) (*appt_booking.AppointmentSeries, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.AppointmentSeries, error)
+	Delete(ctx context.Context, id int) error
+	AddOccurrence(ctx context.Context, seriesID, appointmentID, index int) error
+	GetOccurrences(ctx context.Context, seriesID int) ([]appt_booking.SeriesOccurrence, error)
+	GetOccurrence(ctx context.Context, appointmentID int) (*appt_booking.SeriesOccurrence, error)
+}
+
+// WaitlistRepository stores waitlist entries. GetWaiting and List return entries in the
+// order they are offered slots; UpdateStatus returns nil when the entry is no longer in
+// fromStatus, and records its event in the outbox along with the change. ExpireOffers
+// expires offered entries whose hold has expired.
+type WaitlistRepository interface {
+	Create(ctx context.Context, customerID, serviceID int, staffID *int, windowStart, windowEnd time.Time, priority int, notes string) (*appt_booking.WaitlistEntry, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.WaitlistEntry, error)
+	List(ctx context.Context, filter appt_booking.WaitlistFilter) ([]appt_booking.WaitlistEntry, error)
+	GetWaiting(ctx context.Context, staffID int, now time.Time) ([]appt_booking.WaitlistEntry, error)
+	UpdateStatus(ctx context.Context, id int, fromStatus, toStatus string, event *appt_booking.OutboxEvent) (*appt_booking.WaitlistEntry, error)
+	ExpireOffers(ctx context.Context, now time.Time) ([]appt_booking.WaitlistEntry, error)
+}
+
+// HoldRepository stores slot holds. CreateExclusive must check for overlaps and the
+// owner's limit on unexpired holds atomically with the write, like
+// AppointmentRepository.CreateExclusive. Holds offered to a waitlist
+// entry must only be deleted by DeleteExpired once WaitlistRepository.ExpireOffers has run
+// with the same now.
+type HoldRepository interface {
+	CreateExclusive(ctx context.Context, token string, staffID, serviceID int, start time.Time, durationMinutes int, waitlistEntryID, userID *int, maxActive int, expiresAt time.Time) (*appt_booking.SlotHold, error)
+	GetByToken(ctx context.Context, token string) (*appt_booking.SlotHold, error)
+	GetByWaitlistEntry(ctx context.Context, entryID int) (*appt_booking.SlotHold, error)
+	GetActiveByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.SlotHold, error)
+	Delete(ctx context.Context, id int) error
+	DeleteExpired(ctx context.Context, now time.Time) ([]appt_booking.SlotHold, error)
+}
+
+// UserRepository stores login accounts. Create returns appt_booking.ErrUserEmailTaken
+// if the email or staff member already has an account. MarkEmailVerified returns nil if
+// the account's email is no longer the one given.
+type UserRepository interface {
+	Create(ctx context.Context, email, passwordHash, role string, staffID *int) (*appt_booking.User, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.User, error)
+	GetByEmail(ctx context.Context, email string) (*appt_booking.User, error)
+	MarkEmailVerified(ctx context.Context, id int, email string, verifiedAt time.Time) (*appt_booking.User, error)
+}
+
+// OutboxRepository delivers the events the other repositories record. ClaimDue counts an
+// attempt at each event it returns and hides it from other claims until now+lease, so an
+// event whose dispatcher dies is claimed again once the lease runs out.
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]appt_booking.OutboxEvent, error)
+	MarkDelivered(ctx context.Context, id int64, now time.Time) error
+	RetryLater(ctx context.Context, id int64, at time.Time, lastError string) error
+	MarkFailed(ctx context.Context, id int64, lastError string) error
+	GetByID(ctx context.Context, id int64) (*appt_booking.OutboxEvent, error)
+	GetAll(ctx context.Context) ([]appt_booking.OutboxEvent, error)
+}
+
+// WebhookRepository persists webhook subscriptions and the log of their deliveries.
+// EnqueueDeliveries skips subscriptions that already have a delivery of the event, and
+// ClaimDueDeliveries leases deliveries the way OutboxRepository.ClaimDue leases events.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, url string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id int, url string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id int) (*appt_booking.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]appt_booking.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	EnqueueDeliveries(ctx context.Context, event appt_booking.OutboxEvent, subscriptionIDs []int) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]appt_booking.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery appt_booking.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*appt_booking.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]appt_booking.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64, now time.Time) (*appt_booking.WebhookDelivery, error)
+}
+
+// NotificationRepository persists the notification log. Create returns nil when a
+// notification with the same dedupe key exists, which is how notifications are sent once.
+type NotificationRepository interface {
+	Create(ctx context.Context, n appt_booking.Notification) (*appt_booking.Notification, error)
+	GetByDedupeKey(ctx context.Context, key string) (*appt_booking.Notification, error)
+	RecordAttempts(ctx context.Context, n appt_booking.Notification) error
+	List(ctx context.Context, filter appt_booking.NotificationFilter, limit int) ([]appt_booking.Notification, error)
+}
+
+// JobRepository persists the schedules of periodic jobs. Claim succeeds for one caller
+// at a time, which is how a job runs on a single replica.
+type JobRepository interface {
+	Register(ctx context.Context, name string, firstRun time.Time) error
+	Claim(ctx context.Context, name string, now time.Time, lease time.Duration) (bool, error)
+	Complete(ctx context.Context, name string, nextRun, finishedAt time.Time, lastError string) error
+	GetAll(ctx context.Context) ([]appt_booking.ScheduledJob, error)
+}
+
+// ReminderRepository persists which appointment reminders have been sent
+type ReminderRepository interface {
+	GetDue(ctx context.Context, offset, skipWithin time.Duration, now time.Time, limit int) ([]appt_booking.Appointment, error)
+	Record(ctx context.Context, reminder appt_booking.AppointmentReminder) (bool, error)
+	GetByAppointment(ctx context.Context, appointmentID int) ([]appt_booking.AppointmentReminder, error)
+}
+
+// CalendarFeedRepository stores the tokens of staff calendar feeds
+type CalendarFeedRepository interface {
+	GetByStaff(ctx context.Context, staffID int) (*appt_booking.StaffCalendarFeed, error)
+	Create(ctx context.Context, staffID int, token string) (*appt_booking.StaffCalendarFeed, error)
+	Replace(ctx context.Context, staffID int, token string) (*appt_booking.StaffCalendarFeed, error)
+}
+
+// ExternalCalendarRepository stores staff members' external calendars and the busy times
+// expanded from them
+type ExternalCalendarRepository interface {
+	Create(ctx context.Context, staffID int, name, url, data string) (*appt_booking.ExternalCalendar, error)
+	GetByID(ctx context.Context, id int) (*appt_booking.ExternalCalendar, error)
+	GetByStaff(ctx context.Context, staffID int) ([]appt_booking.ExternalCalendar, error)
+	GetAll(ctx context.Context) ([]appt_booking.ExternalCalendar, error)
+	Delete(ctx context.Context, id int) error
+	ReplaceBusyTimes(ctx context.Context, calendarID int, busy []appt_booking.ExternalBusyTime, syncedAt time.Time) error
+	RecordSyncError(ctx context.Context, calendarID int, message string, at time.Time) error
+	GetBusyByStaffBetween(ctx context.Context, staffID int, from, to time.Time) ([]appt_booking.ExternalBusyTime, error)
+}
+
+// The Postgres repositories must keep satisfying the interfaces above
+var (
+	_ ServiceRepository           = (*appt_booking.ServiceRepository)(nil)
+	_ StaffRepository             = (*appt_booking.StaffRepository)(nil)
+	_ StaffServiceRepository      = (*appt_booking.StaffServiceRepository)(nil)
+	_ ScheduleRepository          = (*appt_booking.ScheduleRepository)(nil)
+	_ ScheduleExceptionRepository = (*appt_booking.ScheduleExceptionRepository)(nil)
+	_ AppointmentRepository       = (*appt_booking.AppointmentRepository)(nil)
+	_ CustomerRepository          = (*appt_booking.CustomerRepository)(nil)
+	_ SeriesRepository            = (*appt_booking.SeriesRepository)(nil)
+	_ WaitlistRepository          = (*appt_booking.WaitlistRepository)(nil)
+	_ HoldRepository              = (*appt_booking.HoldRepository)(nil)
+	_ UserRepository              = (*appt_booking.UserRepository)(nil)
+	_ OutboxRepository            = (*appt_booking.OutboxRepository)(nil)
+	_ WebhookRepository           = (*appt_booking.WebhookRepository)(nil)
+	_ NotificationRepository      = (*appt_booking.NotificationRepository)(nil)
+	_ JobRepository               = (*appt_booking.JobRepository)(nil)
+	_ ReminderRepository          = (*appt_booking.ReminderRepository)(nil)
+	_ CalendarFeedRepository      = (*appt_booking.CalendarFeedRepository)(nil)
+	_ ExternalCalendarRepository  = (*appt_booking.ExternalCalendarRepository)(nil)
+)
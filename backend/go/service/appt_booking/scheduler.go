@@ -0,0 +1,137 @@
+package appt_booking
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler defaults, used for zero SchedulerConfig fields
+const (
+	defaultSchedulerPollInterval = 15 * time.Second
+	defaultSchedulerLease        = 10 * time.Minute
+)
+
+// SchedulerConfig configures the job scheduler
+type SchedulerConfig struct {
+	PollInterval time.Duration // how often Run looks for due jobs
+	Lease        time.Duration // how long a claimed job is locked against other replicas; longer than any run
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c SchedulerConfig) withDefaults() SchedulerConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultSchedulerPollInterval
+	}
+	if c.Lease <= 0 {
+		c.Lease = defaultSchedulerLease
+	}
+	return c
+}
+
+// Job is a periodic task. Run is passed the time the run was due to start from.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+// Scheduler runs periodic jobs on exactly one replica at a time. Every replica polls the
+// shared job schedules; a replica runs a due job only after claiming it, and a claim
+// succeeds for a single replica. The claim lapses after SchedulerConfig.Lease so a job
+// whose runner died is picked up again.
+type Scheduler struct {
+	jobs   JobRepository
+	config SchedulerConfig
+
+	mu         sync.Mutex
+	registered []Job
+	stored     map[string]bool // jobs known to be in the repository
+}
+
+// NewScheduler creates a scheduler for the job schedules in jobs. Zero config fields take
+// their defaults.
+func NewScheduler(jobs JobRepository, config SchedulerConfig) *Scheduler {
+	return &Scheduler{jobs: jobs, config: config.withDefaults(), stored: make(map[string]bool)}
+}
+
+// Register adds a job, first due as soon as it is registered
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registered = append(s.registered, job)
+}
+
+// RunDue runs the registered jobs that are due and not running elsewhere. Returns the
+// number run. A job's failure is logged and recorded on its schedule; it does not stop
+// the others.
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.registered...)
+	s.mu.Unlock()
+
+	ran := 0
+	for _, job := range jobs {
+		now := time.Now()
+		if err := s.store(ctx, job, now); err != nil {
+			return ran, err
+		}
+		claimed, err := s.jobs.Claim(ctx, job.Name, now, s.config.Lease)
+		if err != nil {
+			return ran, err
+		}
+		if !claimed {
+			continue
+		}
+
+		var lastError string
+		if err := job.Run(ctx, now); err != nil {
+			log.Printf("job %s: %v", job.Name, err)
+			lastError = err.Error()
+		}
+		finishedAt := time.Now()
+		nextRun := now.Add(job.Interval)
+		if nextRun.Before(finishedAt) {
+			nextRun = finishedAt
+		}
+		if err := s.jobs.Complete(ctx, job.Name, nextRun, finishedAt, lastError); err != nil {
+			return ran, err
+		}
+		ran++
+	}
+	return ran, nil
+}
+
+// store adds job's schedule to the repository the first time it is seen
+func (s *Scheduler) store(ctx context.Context, job Job, now time.Time) error {
+	s.mu.Lock()
+	stored := s.stored[job.Name]
+	s.mu.Unlock()
+	if stored {
+		return nil
+	}
+	if err := s.jobs.Register(ctx, job.Name, now); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.stored[job.Name] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Run runs due jobs every SchedulerConfig.PollInterval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunDue(ctx); err != nil {
+				log.Printf("run scheduled jobs: %v", err)
+			}
+		}
+	}
+}
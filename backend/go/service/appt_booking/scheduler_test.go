@@ -0,0 +1,80 @@
+package appt_booking
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking/memory"
+)
+
+func TestScheduler_RunsJobOnOneReplica(t *testing.T) {
+	store := memory.NewStore()
+	var runs int32
+	job := Job{Name: "count", Interval: time.Hour, Run: func(ctx context.Context, now time.Time) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}}
+
+	// Replicas share the store and poll at the same moment
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		scheduler := NewScheduler(store.Jobs(), SchedulerConfig{})
+		scheduler.Register(job)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := scheduler.RunDue(context.Background()); err != nil {
+				t.Errorf("run due: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Fatalf("expected the job to run once, ran %d times", runs)
+	}
+
+	// Not due again until its interval has passed
+	scheduler := NewScheduler(store.Jobs(), SchedulerConfig{})
+	scheduler.Register(job)
+	if n, err := scheduler.RunDue(context.Background()); err != nil || n != 0 {
+		t.Errorf("expected nothing due, got %d, %v", n, err)
+	}
+	jobs, _ := store.Jobs().GetAll(context.Background())
+	if len(jobs) != 1 || jobs[0].LockedUntil != nil || jobs[0].LastFinishedAt == nil ||
+		jobs[0].NextRunAt.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("unexpected schedule %+v", jobs)
+	}
+}
+
+func TestScheduler_RecordsFailureAndReleasesExpiredClaim(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	scheduler := NewScheduler(store.Jobs(), SchedulerConfig{})
+	scheduler.Register(Job{Name: "broken", Interval: time.Millisecond, Run: func(ctx context.Context, now time.Time) error {
+		return errors.New("out of cheese")
+	}})
+	if n, err := scheduler.RunDue(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the job to run, got %d, %v", n, err)
+	}
+	jobs, _ := store.Jobs().GetAll(ctx)
+	if len(jobs) != 1 || jobs[0].LastError != "out of cheese" {
+		t.Errorf("expected the failure to be recorded, got %+v", jobs)
+	}
+
+	// A replica that died holding a claim blocks the job only until its lease runs out
+	time.Sleep(2 * time.Millisecond)
+	if claimed, _ := store.Jobs().Claim(ctx, "broken", time.Now(), time.Millisecond); !claimed {
+		t.Fatal("expected to claim the due job")
+	}
+	if n, _ := scheduler.RunDue(ctx); n != 0 {
+		t.Errorf("expected a claimed job not to run, ran %d", n)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if n, _ := scheduler.RunDue(ctx); n != 1 {
+		t.Errorf("expected the job to run once the claim lapsed, ran %d", n)
+	}
+}
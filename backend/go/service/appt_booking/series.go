@@ -0,0 +1,349 @@
+package appt_booking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// MaxSeriesOccurrences caps how many appointments one series may book
+const MaxSeriesOccurrences = 52
+
+// Scopes for cancelling or rescheduling an appointment that is part of a series
+const (
+	SeriesScopeThis      = "this"      // only the given appointment
+	SeriesScopeFollowing = "following" // the given appointment and every later occurrence
+)
+
+// Recurrence describes how an appointment series repeats: every Interval weeks or months,
+// at the first occurrence's wall-clock time in the staff member's timezone. The series ends
+// after Count occurrences or on Until (inclusive, a date in the staff member's timezone),
+// whichever comes first; at least one of them is required. Monthly series skip months
+// without the first occurrence's day of month.
+type Recurrence struct {
+	Frequency string // appt_booking.SeriesFrequencyWeekly or appt_booking.SeriesFrequencyMonthly
+	Interval  int    // 0 means 1
+	Count     int    // 0 means until Until
+	Until     *time.Time
+}
+
+// OccurrenceResult reports one occurrence of a series: its appointment, or the domain
+// error that kept it from being booked, cancelled or rescheduled
+type OccurrenceResult struct {
+	Index       int
+	Start       time.Time
+	Appointment *appt_booking.Appointment
+	Err         error
+}
+
+// SeriesBooking is an appointment series with one result per occurrence, in recurrence order
+type SeriesBooking struct {
+	Series      *appt_booking.AppointmentSeries
+	Occurrences []OccurrenceResult
+}
+
+// BookAppointmentSeries books every occurrence of a recurring appointment with the same
+// checks as BookAppointment. Occurrences that conflict or fall outside working hours are
+// reported and skipped; the rest are booked. If none can be booked the series is not
+// created and a conflict is returned.
+func (s *ApptBookingService) BookAppointmentSeries(
+	ctx context.Context,
+	customerName, customerEmail, customerPhone string,
+	staffID, serviceID int,
+	firstDatetime time.Time,
+	notes string,
+	recurrence Recurrence,
+) (*SeriesBooking, error) {
+	recurrence, err := validateRecurrence(recurrence)
+	if err != nil {
+		return nil, err
+	}
+	staff, service, err := s.validateBooking(ctx, customerName, customerEmail, staffID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	starts, err := occurrenceStarts(firstDatetime, s.staffLocation(staff), recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.bookingCustomer(ctx, customerName, customerEmail, customerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	var count *int
+	if recurrence.Count > 0 {
+		count = &recurrence.Count
+	}
+	series, err := s.seriesRepo.Create(ctx, customer.ID, staff.ID, service.ID,
+		recurrence.Frequency, recurrence.Interval, count, recurrence.Until, firstDatetime, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	booking := &SeriesBooking{Series: series, Occurrences: make([]OccurrenceResult, len(starts))}
+	booked := 0
+	for i, start := range starts {
+		result := OccurrenceResult{Index: i, Start: start}
+		if err := s.checkBookingPolicy(service, staff, start, time.Now()); err != nil {
+			result.Err = err
+		} else if err := s.checkStaffAvailableFor(ctx, staff, service.ID, start, service.DurationMin); err != nil {
+			result.Err = err
+		} else {
+			result.Appointment, result.Err = s.createAppointment(ctx, customer.ID, customerName, customerEmail, customerPhone, staff, service, start, notes)
+		}
+		if result.Err != nil && KindOf(result.Err) == "" {
+			return nil, result.Err
+		}
+		if result.Appointment != nil {
+			if err := s.seriesRepo.AddOccurrence(ctx, series.ID, result.Appointment.ID, i); err != nil {
+				return nil, err
+			}
+			booked++
+		}
+		booking.Occurrences[i] = result
+	}
+
+	if booked == 0 {
+		if err := s.seriesRepo.Delete(ctx, series.ID); err != nil {
+			return nil, err
+		}
+		return nil, Conflict(fmt.Sprintf("no occurrence of the series could be booked: %v", booking.Occurrences[0].Err), nil)
+	}
+	return booking, nil
+}
+
+// GetAppointmentSeries retrieves a series with its booked occurrences in recurrence order
+func (s *ApptBookingService) GetAppointmentSeries(ctx context.Context, id int) (*SeriesBooking, error) {
+	series, err := s.seriesRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, NotFound("appointment series")
+	}
+
+	occurrences, err := s.seriesOccurrences(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	booking := &SeriesBooking{Series: series, Occurrences: make([]OccurrenceResult, len(occurrences))}
+	for i, o := range occurrences {
+		booking.Occurrences[i] = OccurrenceResult{Index: o.Index, Start: o.Appointment.AppointmentDatetime, Appointment: o.Appointment}
+	}
+	return booking, nil
+}
+
+// GetAppointmentSeriesID returns the ID of the series an appointment belongs to, or 0
+func (s *ApptBookingService) GetAppointmentSeriesID(ctx context.Context, appointmentID int) (int, error) {
+	occurrence, err := s.seriesRepo.GetOccurrence(ctx, appointmentID)
+	if err != nil || occurrence == nil {
+		return 0, err
+	}
+	return occurrence.SeriesID, nil
+}
+
+// CancelFollowingOccurrences cancels an appointment of a series together with every later
+// occurrence. Later occurrences that can no longer be cancelled (completed, cancelled or
+// no-show) are left out of the results. Each cancellation records reason.
+func (s *ApptBookingService) CancelFollowingOccurrences(ctx context.Context, id int, reason string) ([]OccurrenceResult, error) {
+	appt, following, err := s.followingOccurrences(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransitionAppointment(appt.Status, appt_booking.AppointmentStatusCancelled) {
+		return nil, PreconditionFailed(fmt.Sprintf("cannot cancel a %s appointment", appt.Status), ErrInvalidStatusTransition)
+	}
+
+	var results []OccurrenceResult
+	for _, o := range following {
+		if !CanTransitionAppointment(o.Appointment.Status, appt_booking.AppointmentStatusCancelled) {
+			continue
+		}
+		result := OccurrenceResult{Index: o.Index, Start: o.Appointment.AppointmentDatetime}
+		result.Appointment, result.Err = s.TransitionAppointment(ctx, o.Appointment.ID, appt_booking.AppointmentStatusCancelled, principalEmail(ctx), reason)
+		if result.Err != nil && KindOf(result.Err) == "" {
+			return nil, result.Err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RescheduleFollowingOccurrences reschedules an appointment of a series together with every
+// later occurrence. Each occurrence moves by the same number of days and to the new
+// wall-clock time in the staff member's timezone; staffID 0 keeps each occurrence's staff
+// member. Later occurrences that can no longer be moved are left out; those that conflict
+// or fall outside working hours keep their time and are reported.
+func (s *ApptBookingService) RescheduleFollowingOccurrences(ctx context.Context, id, staffID int, appointmentDatetime time.Time) ([]OccurrenceResult, error) {
+	appt, following, err := s.followingOccurrences(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isReschedulable(appt.Status) {
+		return nil, PreconditionFailed(fmt.Sprintf("cannot reschedule a %s appointment", appt.Status), ErrInvalidStatusTransition)
+	}
+
+	locStaffID := staffID
+	if locStaffID <= 0 {
+		locStaffID = appt.StaffID
+	}
+	loc, err := s.LocationForStaff(ctx, locStaffID)
+	if err != nil {
+		return nil, err
+	}
+	from := appt.AppointmentDatetime.In(loc)
+	to := appointmentDatetime.In(loc)
+	dayShift := int(dateOf(to).Sub(dateOf(from)).Hours() / 24)
+	moveTo := func(start time.Time) time.Time {
+		d := start.In(loc)
+		return time.Date(d.Year(), d.Month(), d.Day()+dayShift, to.Hour(), to.Minute(), to.Second(), 0, loc)
+	}
+
+	var results []OccurrenceResult
+	for _, o := range following {
+		if !isReschedulable(o.Appointment.Status) {
+			continue
+		}
+		results = append(results, OccurrenceResult{Index: o.Index, Start: moveTo(o.Appointment.AppointmentDatetime)})
+	}
+
+	// Move the occurrence furthest in the direction of travel first, so occurrences
+	// shifted onto a neighbour's old slot do not collide with it
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	if to.After(from) {
+		sort.Sort(sort.Reverse(sort.IntSlice(order)))
+	}
+	for _, i := range order {
+		result := &results[i]
+		result.Appointment, result.Err = s.RescheduleAppointment(ctx, appointmentIDAt(following, result.Index), staffID, result.Start)
+		if result.Err != nil && KindOf(result.Err) == "" {
+			return nil, result.Err
+		}
+	}
+	return results, nil
+}
+
+// seriesAppointment is a booked occurrence of a series with its appointment loaded
+type seriesAppointment struct {
+	Index       int
+	Appointment *appt_booking.Appointment
+}
+
+// seriesOccurrences loads the occurrences of a series from fromIndex on, in recurrence order
+func (s *ApptBookingService) seriesOccurrences(ctx context.Context, seriesID, fromIndex int) ([]seriesAppointment, error) {
+	occurrences, err := s.seriesRepo.GetOccurrences(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []seriesAppointment
+	for _, o := range occurrences {
+		if o.Index < fromIndex {
+			continue
+		}
+		appt, err := s.appointmentRepo.GetByID(ctx, o.AppointmentID)
+		if err != nil {
+			return nil, err
+		}
+		if appt != nil {
+			loaded = append(loaded, seriesAppointment{Index: o.Index, Appointment: appt})
+		}
+	}
+	return loaded, nil
+}
+
+// followingOccurrences loads an appointment and the occurrences of its series from it on
+func (s *ApptBookingService) followingOccurrences(ctx context.Context, id int) (*appt_booking.Appointment, []seriesAppointment, error) {
+	appt, err := s.GetAppointment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	occurrence, err := s.seriesRepo.GetOccurrence(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if occurrence == nil {
+		return nil, nil, PreconditionFailed("appointment is not part of a series", nil)
+	}
+	following, err := s.seriesOccurrences(ctx, occurrence.SeriesID, occurrence.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return appt, following, nil
+}
+
+// appointmentIDAt returns the appointment ID of the occurrence with the given index
+func appointmentIDAt(occurrences []seriesAppointment, index int) int {
+	for _, o := range occurrences {
+		if o.Index == index {
+			return o.Appointment.ID
+		}
+	}
+	return 0
+}
+
+// validateRecurrence checks a recurrence and fills in the default interval
+func validateRecurrence(r Recurrence) (Recurrence, error) {
+	if r.Frequency != appt_booking.SeriesFrequencyWeekly && r.Frequency != appt_booking.SeriesFrequencyMonthly {
+		return r, Invalid("frequency", "frequency must be weekly or monthly")
+	}
+	if r.Interval < 0 {
+		return r, Invalid("interval", "interval must be positive")
+	}
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if r.Count < 0 {
+		return r, Invalid("count", "count must be positive")
+	}
+	if r.Count > MaxSeriesOccurrences {
+		return r, Invalid("count", fmt.Sprintf("a series can have at most %d occurrences", MaxSeriesOccurrences))
+	}
+	if r.Count == 0 && r.Until == nil {
+		return r, Invalid("count", "either count or until is required")
+	}
+	return r, nil
+}
+
+// occurrenceStarts expands a recurrence starting at first into the start of each occurrence.
+// Occurrences keep first's wall-clock time in loc, so they stay put across DST changes.
+func occurrenceStarts(first time.Time, loc *time.Location, r Recurrence) ([]time.Time, error) {
+	local := first.In(loc)
+	if r.Until != nil && r.Until.Format("2006-01-02") < local.Format("2006-01-02") {
+		return nil, Invalid("until", "until must not be before the first occurrence")
+	}
+
+	var starts []time.Time
+	for k := 0; r.Count == 0 || len(starts) < r.Count; k++ {
+		var start time.Time
+		switch r.Frequency {
+		case appt_booking.SeriesFrequencyWeekly:
+			start = time.Date(local.Year(), local.Month(), local.Day()+7*r.Interval*k, local.Hour(), local.Minute(), local.Second(), 0, loc)
+		case appt_booking.SeriesFrequencyMonthly:
+			start = time.Date(local.Year(), local.Month()+time.Month(r.Interval*k), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, loc)
+			if start.Day() != local.Day() {
+				continue // e.g. the 31st in a 30-day month
+			}
+		}
+		if r.Until != nil && start.Format("2006-01-02") > r.Until.Format("2006-01-02") {
+			break
+		}
+		if len(starts) == MaxSeriesOccurrences {
+			return nil, Invalid("until", fmt.Sprintf("a series can have at most %d occurrences", MaxSeriesOccurrences))
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// dateOf returns midnight UTC of t's calendar date, for counting days between dates
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
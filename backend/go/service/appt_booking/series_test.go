@@ -0,0 +1,207 @@
+package appt_booking
+
+import (
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+func TestOccurrenceStarts(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	date := func(value string) *time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return &d
+	}
+
+	tests := []struct {
+		name       string
+		first      time.Time
+		loc        *time.Location
+		recurrence Recurrence
+		want       []string
+	}{
+		{
+			name:       "every 4 weeks",
+			first:      monday.Add(10 * time.Hour),
+			loc:        time.UTC,
+			recurrence: Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Interval: 4, Count: 3},
+			want:       []string{"2030-01-07T10:00:00Z", "2030-02-04T10:00:00Z", "2030-03-04T10:00:00Z"},
+		},
+		{
+			name:       "monthly skips months without the day",
+			first:      time.Date(2030, 1, 31, 9, 0, 0, 0, time.UTC),
+			loc:        time.UTC,
+			recurrence: Recurrence{Frequency: appt_booking.SeriesFrequencyMonthly, Interval: 1, Count: 3},
+			want:       []string{"2030-01-31T09:00:00Z", "2030-03-31T09:00:00Z", "2030-05-31T09:00:00Z"},
+		},
+		{
+			name:       "until is inclusive",
+			first:      monday.Add(10 * time.Hour),
+			loc:        time.UTC,
+			recurrence: Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Interval: 1, Until: date("2030-01-21")},
+			want:       []string{"2030-01-07T10:00:00Z", "2030-01-14T10:00:00Z", "2030-01-21T10:00:00Z"},
+		},
+		{
+			name:       "keeps wall-clock time across DST",
+			first:      time.Date(2030, 3, 4, 10, 0, 0, 0, newYork),
+			loc:        newYork,
+			recurrence: Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Interval: 1, Count: 2},
+			want:       []string{"2030-03-04T10:00:00-05:00", "2030-03-11T10:00:00-04:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts, err := occurrenceStarts(tt.first, tt.loc, tt.recurrence)
+			checkKind(t, err, "")
+			got := make([]string, len(starts))
+			for i, s := range starts {
+				got[i] = s.Format(time.RFC3339)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("occurrence %d: expected %s, got %s", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+
+	_, err = occurrenceStarts(monday, time.UTC, Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Interval: 1, Until: date("2031-06-01")})
+	checkKind(t, err, KindValidation)
+	_, err = occurrenceStarts(monday, time.UTC, Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Interval: 1, Until: date("2029-12-31")})
+	checkKind(t, err, KindValidation)
+}
+
+// bookWeeklySeries books a weekly series of count Mondays at 10:00 starting on monday
+func (f *testFixture) bookWeeklySeries(t *testing.T, count int) *SeriesBooking {
+	t.Helper()
+	booking, err := f.svc.BookAppointmentSeries(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID,
+		monday.Add(10*time.Hour), "", Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Count: count})
+	if err != nil {
+		t.Fatalf("book series: %v", err)
+	}
+	return booking
+}
+
+func TestBookAppointmentSeries_ReportsConflictingOccurrences(t *testing.T) {
+	f := newTestFixture(t)
+	// Someone else already has the second Monday at 10:00
+	if _, err := f.svc.BookAppointment(f.ctx, "Carol", "carol@example.com", "", f.staff.ID, f.service.ID, monday.AddDate(0, 0, 7).Add(10*time.Hour), ""); err != nil {
+		t.Fatalf("book appointment: %v", err)
+	}
+
+	booking := f.bookWeeklySeries(t, 3)
+	if len(booking.Occurrences) != 3 {
+		t.Fatalf("expected 3 occurrences, got %d", len(booking.Occurrences))
+	}
+	for i, want := range []ErrorKind{"", KindConflict, ""} {
+		o := booking.Occurrences[i]
+		if KindOf(o.Err) != want || (want == "") != (o.Appointment != nil) {
+			t.Errorf("occurrence %d: expected error kind %q, got %v (appointment %v)", i, want, o.Err, o.Appointment)
+		}
+	}
+
+	got, err := f.svc.GetAppointmentSeries(f.ctx, booking.Series.ID)
+	checkKind(t, err, "")
+	if len(got.Occurrences) != 2 || got.Occurrences[0].Index != 0 || got.Occurrences[1].Index != 2 {
+		t.Errorf("expected booked occurrences 0 and 2, got %+v", got.Occurrences)
+	}
+	seriesID, err := f.svc.GetAppointmentSeriesID(f.ctx, got.Occurrences[1].Appointment.ID)
+	checkKind(t, err, "")
+	if seriesID != booking.Series.ID {
+		t.Errorf("expected appointment to belong to series %d, got %d", booking.Series.ID, seriesID)
+	}
+}
+
+func TestBookAppointmentSeries_Validation(t *testing.T) {
+	f := newTestFixture(t)
+	book := func(at time.Time, r Recurrence) error {
+		_, err := f.svc.BookAppointmentSeries(f.ctx, "Bob", "bob@example.com", "", f.staff.ID, f.service.ID, at, "", r)
+		return err
+	}
+
+	checkKind(t, book(monday.Add(10*time.Hour), Recurrence{Frequency: "daily", Count: 2}), KindValidation)
+	checkKind(t, book(monday.Add(10*time.Hour), Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly}), KindValidation)
+	checkKind(t, book(monday.Add(10*time.Hour), Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Count: MaxSeriesOccurrences + 1}), KindValidation)
+
+	// Tuesdays are outside working hours, so nothing can be booked
+	err := book(monday.Add(34*time.Hour), Recurrence{Frequency: appt_booking.SeriesFrequencyWeekly, Count: 2})
+	checkKind(t, err, KindConflict)
+}
+
+func TestCancelFollowingOccurrences(t *testing.T) {
+	f := newTestFixture(t)
+	booking := f.bookWeeklySeries(t, 3)
+	first := booking.Occurrences[0].Appointment
+	second := booking.Occurrences[1].Appointment
+
+	results, err := f.svc.CancelFollowingOccurrences(f.ctx, second.ID, "")
+	checkKind(t, err, "")
+	if len(results) != 2 || results[0].Index != 1 || results[1].Index != 2 {
+		t.Fatalf("expected occurrences 1 and 2 to be cancelled, got %+v", results)
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Appointment.Status != appt_booking.AppointmentStatusCancelled {
+			t.Errorf("occurrence %d: expected cancelled, got %+v", r.Index, r)
+		}
+	}
+
+	kept, err := f.svc.GetAppointment(f.ctx, first.ID)
+	checkKind(t, err, "")
+	if kept.Status != appt_booking.AppointmentStatusConfirmed {
+		t.Errorf("expected the first occurrence to stay confirmed, got %s", kept.Status)
+	}
+
+	// Cancelling again from the start skips the occurrences already cancelled
+	results, err = f.svc.CancelFollowingOccurrences(f.ctx, first.ID, "")
+	checkKind(t, err, "")
+	if len(results) != 1 || results[0].Index != 0 {
+		t.Errorf("expected only occurrence 0 to be cancelled, got %+v", results)
+	}
+
+	single := f.book(t, 14*time.Hour)
+	_, err = f.svc.CancelFollowingOccurrences(f.ctx, single.ID, "")
+	checkKind(t, err, KindPreconditionFailed)
+}
+
+func TestRescheduleFollowingOccurrences(t *testing.T) {
+	f := newTestFixture(t)
+	booking := f.bookWeeklySeries(t, 3)
+	first := booking.Occurrences[0].Appointment
+	second := booking.Occurrences[1].Appointment
+
+	// Move the second and third occurrences from 10:00 to 14:00
+	results, err := f.svc.RescheduleFollowingOccurrences(f.ctx, second.ID, 0, second.AppointmentDatetime.Add(4*time.Hour))
+	checkKind(t, err, "")
+	if len(results) != 2 {
+		t.Fatalf("expected 2 occurrences to be rescheduled, got %+v", results)
+	}
+	for i, r := range results {
+		want := monday.AddDate(0, 0, 7*(i+1)).Add(14 * time.Hour)
+		if r.Err != nil || !r.Appointment.AppointmentDatetime.Equal(want) {
+			t.Errorf("occurrence %d: expected %s, got %+v", r.Index, want, r)
+		}
+	}
+	kept, err := f.svc.GetAppointment(f.ctx, first.ID)
+	checkKind(t, err, "")
+	if !kept.AppointmentDatetime.Equal(first.AppointmentDatetime) {
+		t.Errorf("expected the first occurrence to keep its time, got %s", kept.AppointmentDatetime)
+	}
+
+	// Shifting a week later moves each occurrence onto the next one's old slot, which
+	// only works when the last occurrence moves first
+	results, err = f.svc.RescheduleFollowingOccurrences(f.ctx, second.ID, 0, monday.AddDate(0, 0, 14).Add(14*time.Hour))
+	checkKind(t, err, "")
+	for i, r := range results {
+		want := monday.AddDate(0, 0, 7*(i+2)).Add(14 * time.Hour)
+		if r.Err != nil || !r.Appointment.AppointmentDatetime.Equal(want) {
+			t.Errorf("occurrence %d: expected %s, got %+v", r.Index, want, r)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package appt_booking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// JoinWaitlist adds the customer with email to the waitlist for a service. staffID 0 accepts
+// any staff member offering the service. Entries are offered freed slots that start within
+// [windowStart, windowEnd), highest priority first, then first come first served.
+func (s *ApptBookingService) JoinWaitlist(
+	ctx context.Context,
+	customerName, customerEmail, customerPhone string,
+	serviceID, staffID int,
+	windowStart, windowEnd time.Time,
+	priority int,
+	notes string,
+) (*appt_booking.WaitlistEntry, error) {
+	if customerName == "" {
+		return nil, Invalid("customer_name", "customer name is required")
+	}
+	if customerEmail == "" {
+		return nil, Invalid("customer_email", "customer email is required")
+	}
+	if !contains(customerEmail, "@") {
+		return nil, Invalid("customer_email", "invalid email format")
+	}
+	if serviceID <= 0 {
+		return nil, Invalid("service_id", "valid service ID is required")
+	}
+	if !windowStart.Before(windowEnd) {
+		return nil, Invalid("window_end", "window start must be before window end")
+	}
+	if !windowEnd.After(time.Now()) {
+		return nil, Invalid("window_end", "window end must be in the future")
+	}
+
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, NotFound("service")
+	}
+	var staffRef *int
+	if staffID > 0 {
+		staff, err := s.staffRepo.GetByID(ctx, staffID)
+		if err != nil {
+			return nil, err
+		}
+		if staff == nil {
+			return nil, NotFound("staff")
+		}
+		offered, err := s.staffOffersService(ctx, staffID, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if !offered {
+			return nil, PreconditionFailed("staff member does not offer this service", nil)
+		}
+		staffRef = &staffID
+	}
+
+	customer, err := s.bookingCustomer(ctx, customerName, customerEmail, customerPhone)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.waitlistRepo.Create(ctx, customer.ID, serviceID, staffRef, windowStart, windowEnd, priority, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	// A slot may already be free
+	s.offerFreedSlots(ctx, staffID)
+	return s.GetWaitlistEntry(ctx, entry.ID)
+}
+
+// GetWaitlistEntry retrieves a waitlist entry by ID
+func (s *ApptBookingService) GetWaitlistEntry(ctx context.Context, id int) (*appt_booking.WaitlistEntry, error) {
+	entry, err := s.waitlistRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, NotFound("waitlist entry")
+	}
+	return entry, nil
+}
+
+// ListWaitlist retrieves the waitlist entries matching filter in the order they are offered slots
+func (s *ApptBookingService) ListWaitlist(ctx context.Context, filter appt_booking.WaitlistFilter) ([]appt_booking.WaitlistEntry, error) {
+	for _, status := range filter.Statuses {
+		if !isValidWaitlistStatus(status) {
+			return nil, Invalid("status", "invalid status: "+status)
+		}
+	}
+	return s.waitlistRepo.List(ctx, filter)
+}
+
+// GetWaitlistOffer retrieves the hold offered to a waitlist entry, or nil if it has none
+func (s *ApptBookingService) GetWaitlistOffer(ctx context.Context, entryID int) (*appt_booking.SlotHold, error) {
+	return s.holdRepo.GetByWaitlistEntry(ctx, entryID)
+}
+
+// LeaveWaitlist cancels a waiting or offered entry. A slot held for the entry is released
+// and offered to the next entry.
+func (s *ApptBookingService) LeaveWaitlist(ctx context.Context, id int) (*appt_booking.WaitlistEntry, error) {
+	entry, err := s.GetWaitlistEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != appt_booking.WaitlistStatusWaiting && entry.Status != appt_booking.WaitlistStatusOffered {
+		return nil, PreconditionFailed("waitlist entry is already "+entry.Status, nil)
+	}
+
+	cancelled, err := s.waitlistRepo.UpdateStatus(ctx, id, entry.Status, appt_booking.WaitlistStatusCancelled, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cancelled == nil {
+		return nil, Conflict("waitlist entry was changed by another request", nil)
+	}
+
+	if entry.Status == appt_booking.WaitlistStatusOffered {
+		hold, err := s.holdRepo.GetByWaitlistEntry(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if hold != nil {
+			if err := s.holdRepo.Delete(ctx, hold.ID); err != nil {
+				return nil, err
+			}
+			s.offerFreedSlots(ctx, hold.StaffID)
+		}
+	}
+	return cancelled, nil
+}
+
+// AcceptWaitlistOffer books the slot held for a waitlist entry. token must be the one sent
+// with the offer; offers that have expired return a KindPreconditionFailed error.
+func (s *ApptBookingService) AcceptWaitlistOffer(ctx context.Context, entryID int, token string) (*appt_booking.Appointment, error) {
+	if token == "" {
+		return nil, Invalid("token", "offer token is required")
+	}
+	entry, err := s.GetWaitlistEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	hold, err := s.holdRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || hold.WaitlistEntryID == nil || *hold.WaitlistEntryID != entry.ID {
+		return nil, NotFound("waitlist offer")
+	}
+	if entry.Status != appt_booking.WaitlistStatusOffered {
+		return nil, PreconditionFailed("waitlist offer is no longer open: entry is "+entry.Status, nil)
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, entry.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, NotFound("customer")
+	}
+
+	appointment, err := s.appointmentRepo.CreateFromHold(ctx,
+		hold.ID,
+		customer.ID,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		appt_booking.AppointmentStatusConfirmed,
+		entry.Notes,
+		domainEvent(EventAppointmentBooked, appt_booking.AggregateAppointment),
+	)
+	if errors.Is(err, appt_booking.ErrHoldNotFound) {
+		// Pass the expired slot on to the next entry
+		s.offerFreedSlots(ctx, hold.StaffID)
+		return nil, PreconditionFailed("waitlist offer has expired", err)
+	}
+	if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+		return nil, Conflict(err.Error(), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.waitlistRepo.UpdateStatus(ctx, entry.ID, appt_booking.WaitlistStatusOffered, appt_booking.WaitlistStatusBooked, nil); err != nil {
+		log.Printf("mark waitlist entry %d booked: %v", entry.ID, err)
+	}
+	return appointment, nil
+}
+
+// offerFreedSlots runs an offer pass after a change that may have freed time for staffID
+// (0 for every staff member). The change has already been stored, so failures are logged
+// rather than returned.
+func (s *ApptBookingService) offerFreedSlots(ctx context.Context, staffID int) {
+	if err := s.offerWaitlist(ctx, staffID); err != nil {
+		log.Printf("waitlist offer pass for staff %d: %v", staffID, err)
+	}
+}
+
+// offerWaitlist expires lapsed offers, then holds the earliest open slot in each waiting
+// entry's window for that entry, in priority order, recording EventWaitlistSlotOffered.
+// Only entries that staffID could serve are considered, unless offers expired.
+func (s *ApptBookingService) offerWaitlist(ctx context.Context, staffID int) error {
+	now := time.Now()
+	expired, err := s.waitlistRepo.ExpireOffers(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		// Expired holds free time for whichever staff members they held
+		staffID = 0
+	}
+
+	entries, err := s.waitlistRepo.GetWaiting(ctx, staffID, now)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := s.offerSlot(ctx, &entries[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offerSlot holds the earliest open slot in entry's window, if there is one
+func (s *ApptBookingService) offerSlot(ctx context.Context, entry *appt_booking.WaitlistEntry, now time.Time) error {
+	from := laterOf(entry.WindowStart, now)
+	to := earlierOf(entry.WindowEnd, from.Add(maxAvailabilityRangeDays*24*time.Hour))
+	if !from.Before(to) {
+		return nil
+	}
+	entryStaffID := 0
+	if entry.StaffID != nil {
+		entryStaffID = *entry.StaffID
+	}
+
+	availability, err := s.GetAvailability(ctx, entry.ServiceID, entryStaffID, from, to)
+	if KindOf(err) != "" {
+		// The staff member stopped offering the service, for example; the entry keeps waiting
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range earliestSlots(availability) {
+		token, err := newHoldToken()
+		if err != nil {
+			return err
+		}
+		hold, err := s.holdRepo.CreateExclusive(ctx,
+			token,
+			candidate.staffID,
+			entry.ServiceID,
+			candidate.slot.Start,
+			availability.DurationMinutes,
+			&entry.ID,
+			nil,
+			0,
+			now.Add(s.holdConfig.WaitlistOfferTTL),
+		)
+		if errors.Is(err, appt_booking.ErrAppointmentConflict) {
+			// Taken since availability was computed
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		offered, err := s.waitlistRepo.UpdateStatus(ctx, entry.ID, appt_booking.WaitlistStatusWaiting, appt_booking.WaitlistStatusOffered,
+			domainEvent(EventWaitlistSlotOffered, appt_booking.AggregateWaitlistEntry))
+		if err != nil {
+			return err
+		}
+		if offered == nil {
+			// Cancelled or offered by a concurrent pass
+			return s.holdRepo.Delete(ctx, hold.ID)
+		}
+		return nil
+	}
+	return nil
+}
+
+// staffSlot is an open slot of one staff member
+type staffSlot struct {
+	staffID int
+	slot    TimeSlot
+}
+
+// earliestSlots returns every open slot in availability, earliest first
+func earliestSlots(availability *Availability) []staffSlot {
+	var slots []staffSlot
+	for _, st := range availability.Staff {
+		for _, slot := range st.Slots {
+			slots = append(slots, staffSlot{staffID: st.StaffID, slot: slot})
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].slot.Start.Before(slots[j].slot.Start) })
+	return slots
+}
+
+// staffOffersService reports whether staffID is assigned serviceID
+func (s *ApptBookingService) staffOffersService(ctx context.Context, staffID, serviceID int) (bool, error) {
+	services, err := s.staffServiceRepo.GetServicesForStaff(ctx, staffID)
+	if err != nil {
+		return false, err
+	}
+	for _, svc := range services {
+		if svc.ID == serviceID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isValidWaitlistStatus reports whether status is one of the appt_booking.WaitlistStatus* constants
+func isValidWaitlistStatus(status string) bool {
+	switch status {
+	case appt_booking.WaitlistStatusWaiting, appt_booking.WaitlistStatusOffered, appt_booking.WaitlistStatusBooked,
+		appt_booking.WaitlistStatusExpired, appt_booking.WaitlistStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// newHoldToken returns a random token identifying a hold
+func newHoldToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
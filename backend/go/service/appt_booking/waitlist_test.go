@@ -0,0 +1,182 @@
+package appt_booking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// offeredHold returns the hold offered to waitlist entry entryID
+func (f *testFixture) offeredHold(t *testing.T, entryID int) *appt_booking.SlotHold {
+	t.Helper()
+	hold, err := f.store.Holds().GetByWaitlistEntry(f.ctx, entryID)
+	if err != nil || hold == nil {
+		t.Fatalf("expected a hold offered to waitlist entry %d, got %v (%v)", entryID, hold, err)
+	}
+	return hold
+}
+
+// joinWaitlist adds name to the waitlist for the fixture's staff member, for a slot starting
+// at 10:00 on Monday
+func (f *testFixture) joinWaitlist(t *testing.T, name string, priority int) *appt_booking.WaitlistEntry {
+	t.Helper()
+	start := monday.Add(10 * time.Hour)
+	e, err := f.svc.JoinWaitlist(f.ctx, name, name+"@example.com", "", f.service.ID, f.staff.ID, start, start.Add(time.Minute), priority, "")
+	if err != nil {
+		t.Fatalf("join waitlist: %v", err)
+	}
+	return e
+}
+
+// checkWaitlistStatus fails the test unless the entry has status want
+func (f *testFixture) checkWaitlistStatus(t *testing.T, id int, want string) {
+	t.Helper()
+	e, err := f.svc.GetWaitlistEntry(f.ctx, id)
+	if err != nil {
+		t.Fatalf("get waitlist entry: %v", err)
+	}
+	if e.Status != want {
+		t.Fatalf("expected waitlist entry %d to be %s, got %s", id, want, e.Status)
+	}
+}
+
+func TestWaitlist_OfferOnCancellation(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	entry := f.joinWaitlist(t, "carol", 0)
+	if entry.Status != appt_booking.WaitlistStatusWaiting {
+		t.Fatalf("expected waiting entry while the slot is booked, got %s", entry.Status)
+	}
+
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	f.checkWaitlistStatus(t, entry.ID, appt_booking.WaitlistStatusOffered)
+
+	offer := f.offeredHold(t, entry.ID)
+	if offer.StaffID != f.staff.ID || !offer.StartsAt.Equal(a.AppointmentDatetime) {
+		t.Fatalf("unexpected offer: %+v", offer)
+	}
+
+	// The offer is recorded for delivery, without the hold's token
+	events, err := f.store.Outbox().GetAll(f.ctx)
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+	var offered []appt_booking.OutboxEvent
+	for _, e := range events {
+		if e.EventType == EventWaitlistSlotOffered {
+			offered = append(offered, e)
+		}
+	}
+	if len(offered) != 1 || offered[0].AggregateType != appt_booking.AggregateWaitlistEntry || offered[0].AggregateID != entry.ID {
+		t.Fatalf("expected 1 offer event for entry %d, got %+v", entry.ID, offered)
+	}
+	if strings.Contains(string(offered[0].Payload), offer.Token) {
+		t.Fatalf("offer event payload contains the hold token: %s", offered[0].Payload)
+	}
+
+	// The held slot is taken until the offer is accepted or expires
+	_, err = f.svc.BookAppointment(f.ctx, "Dave", "dave@example.com", "", f.staff.ID, f.service.ID, a.AppointmentDatetime, "")
+	checkKind(t, err, KindConflict)
+	availability, err := f.svc.GetAvailability(f.ctx, f.service.ID, f.staff.ID, monday, monday.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	for _, slot := range availability.Staff[0].Slots {
+		if slot.Start.Before(a.AppointmentDatetime.Add(time.Hour)) && slot.End.After(a.AppointmentDatetime) {
+			t.Fatalf("held slot %v is offered as available", slot.Start)
+		}
+	}
+
+	_, err = f.svc.AcceptWaitlistOffer(f.ctx, entry.ID, "not-the-token")
+	checkKind(t, err, KindNotFound)
+
+	booked, err := f.svc.AcceptWaitlistOffer(f.ctx, entry.ID, offer.Token)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if !booked.AppointmentDatetime.Equal(a.AppointmentDatetime) || booked.CustomerEmail != "carol@example.com" || booked.Status != appt_booking.AppointmentStatusConfirmed {
+		t.Fatalf("unexpected appointment: %+v", booked)
+	}
+	f.checkWaitlistStatus(t, entry.ID, appt_booking.WaitlistStatusBooked)
+
+	_, err = f.svc.AcceptWaitlistOffer(f.ctx, entry.ID, offer.Token)
+	checkKind(t, err, KindNotFound)
+}
+
+func TestWaitlist_PriorityOrder(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	first := f.joinWaitlist(t, "carol", 0)
+	urgent := f.joinWaitlist(t, "dave", 5)
+
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	f.checkWaitlistStatus(t, urgent.ID, appt_booking.WaitlistStatusOffered)
+	f.checkWaitlistStatus(t, first.ID, appt_booking.WaitlistStatusWaiting)
+}
+
+func TestWaitlist_ExpiredOfferPassesOn(t *testing.T) {
+	f := newTestFixture(t)
+	f.svc.holdConfig.WaitlistOfferTTL = 10 * time.Millisecond
+	a := f.book(t, 10*time.Hour)
+	first := f.joinWaitlist(t, "carol", 0)
+	second := f.joinWaitlist(t, "dave", 0)
+
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	f.checkWaitlistStatus(t, first.ID, appt_booking.WaitlistStatusOffered)
+	token := f.offeredHold(t, first.ID).Token
+
+	time.Sleep(20 * time.Millisecond)
+	_, err := f.svc.AcceptWaitlistOffer(f.ctx, first.ID, token)
+	checkKind(t, err, KindPreconditionFailed)
+	f.checkWaitlistStatus(t, first.ID, appt_booking.WaitlistStatusExpired)
+	f.checkWaitlistStatus(t, second.ID, appt_booking.WaitlistStatusOffered)
+}
+
+func TestWaitlist_LeaveReleasesOffer(t *testing.T) {
+	f := newTestFixture(t)
+	a := f.book(t, 10*time.Hour)
+	first := f.joinWaitlist(t, "carol", 0)
+	second := f.joinWaitlist(t, "dave", 0)
+
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	left, err := f.svc.LeaveWaitlist(f.ctx, first.ID)
+	if err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if left.Status != appt_booking.WaitlistStatusCancelled {
+		t.Fatalf("expected cancelled entry, got %s", left.Status)
+	}
+	f.checkWaitlistStatus(t, second.ID, appt_booking.WaitlistStatusOffered)
+
+	_, err = f.svc.LeaveWaitlist(f.ctx, first.ID)
+	checkKind(t, err, KindPreconditionFailed)
+}
+
+func TestJoinWaitlist_Validation(t *testing.T) {
+	f := newTestFixture(t)
+	start := monday.Add(10 * time.Hour)
+
+	_, err := f.svc.JoinWaitlist(f.ctx, "carol", "carol@example.com", "", f.service.ID, f.staff.ID, start, start, 0, "")
+	checkKind(t, err, KindValidation)
+	_, err = f.svc.JoinWaitlist(f.ctx, "carol", "carol@example.com", "", f.service.ID+100, 0, start, start.Add(time.Hour), 0, "")
+	checkKind(t, err, KindNotFound)
+
+	// A free slot in the window is offered straight away
+	entry, err := f.svc.JoinWaitlist(f.ctx, "carol", "carol@example.com", "", f.service.ID, 0, start, start.Add(time.Hour), 0, "")
+	if err != nil {
+		t.Fatalf("join waitlist: %v", err)
+	}
+	if entry.Status != appt_booking.WaitlistStatusOffered {
+		t.Fatalf("expected offered entry, got %s", entry.Status)
+	}
+}
@@ -0,0 +1,353 @@
+package appt_booking
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// Headers of webhook deliveries. Receivers verify WebhookSignatureHeader against the raw
+// body and WebhookTimestampHeader (see SignWebhook), reject stale timestamps to prevent
+// replays, and use WebhookEventIDHeader to ignore events they have already processed:
+// delivery is at least once.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookEventIDHeader   = "X-Webhook-ID"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// Webhook defaults and limits
+const (
+	defaultWebhookTimeout  = 10 * time.Second
+	minWebhookSecretLength = 16
+	maxWebhookResponseBody = 1024 // bytes of the receiver's response kept in the delivery log
+)
+
+// WebhookConfig configures webhook delivery. The embedded DispatcherConfig sets polling,
+// batch size, attempts and backoff the same way it does for domain events.
+type WebhookConfig struct {
+	DispatcherConfig
+	Timeout time.Duration // limit on each delivery request
+}
+
+// withDefaults returns c with zero fields set to their defaults
+func (c WebhookConfig) withDefaults() WebhookConfig {
+	c.DispatcherConfig = c.DispatcherConfig.withDefaults()
+	if c.Timeout <= 0 {
+		c.Timeout = defaultWebhookTimeout
+	}
+	return c
+}
+
+// WebhookPayload is the JSON body of a webhook delivery
+type WebhookPayload struct {
+	ID            int64           `json:"id"` // outbox event ID; the same across redeliveries
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int             `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// WebhookService manages webhook subscriptions and delivers domain events to them as
+// signed HTTP POSTs, retrying failed deliveries with exponential backoff
+type WebhookService struct {
+	webhooks WebhookRepository
+	outbox   OutboxRepository
+	client   *http.Client
+	config   WebhookConfig
+}
+
+// NewWebhookService creates a webhook service. A nil client is replaced by one with the
+// configured timeout; zero config fields take their defaults.
+func NewWebhookService(webhooks WebhookRepository, outbox OutboxRepository, client *http.Client, config WebhookConfig) *WebhookService {
+	config = config.withDefaults()
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	return &WebhookService{webhooks: webhooks, outbox: outbox, client: client, config: config}
+}
+
+// CreateSubscription subscribes url to eventTypes, or to every event if there are none.
+// An empty secret is replaced by a random one; read it from the returned subscription.
+func (s *WebhookService) CreateSubscription(ctx context.Context, rawURL string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error) {
+	if err := validateWebhook(rawURL, eventTypes); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, Invalid("secret", fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength))
+	}
+	return s.webhooks.CreateSubscription(ctx, rawURL, eventTypes, secret, description, active)
+}
+
+// UpdateSubscription replaces a subscription's settings. An empty secret keeps the current one.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id int, rawURL string, eventTypes []string, secret, description string, active bool) (*appt_booking.WebhookSubscription, error) {
+	if err := validateWebhook(rawURL, eventTypes); err != nil {
+		return nil, err
+	}
+	if secret != "" && len(secret) < minWebhookSecretLength {
+		return nil, Invalid("secret", fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength))
+	}
+	current, err := s.webhooks.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, NotFound("webhook subscription")
+	}
+	if secret == "" {
+		secret = current.Secret
+	}
+	sub, err := s.webhooks.UpdateSubscription(ctx, id, rawURL, eventTypes, secret, description, active)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, NotFound("webhook subscription")
+	}
+	return sub, nil
+}
+
+// GetSubscription retrieves a subscription
+func (s *WebhookService) GetSubscription(ctx context.Context, id int) (*appt_booking.WebhookSubscription, error) {
+	sub, err := s.webhooks.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, NotFound("webhook subscription")
+	}
+	return sub, nil
+}
+
+// ListSubscriptions retrieves every subscription
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]appt_booking.WebhookSubscription, error) {
+	return s.webhooks.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription deletes a subscription and its delivery log
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.webhooks.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries retrieves the latest deliveries to a subscription, newest first. A zero
+// limit means DefaultPageSize.
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID, limit int) ([]appt_booking.WebhookDelivery, error) {
+	switch {
+	case limit < 0:
+		return nil, Invalid("limit", "limit must not be negative")
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit > MaxPageSize:
+		return nil, Invalid("limit", fmt.Sprintf("limit must be at most %d", MaxPageSize))
+	}
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.webhooks.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Redeliver queues a delivery to be sent again straight away with a fresh set of attempts,
+// whatever its status
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID int, deliveryID int64) (*appt_booking.WebhookDelivery, error) {
+	d, err := s.webhooks.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || d.SubscriptionID != subscriptionID {
+		return nil, NotFound("webhook delivery")
+	}
+	d, err = s.webhooks.Redeliver(ctx, deliveryID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, NotFound("webhook delivery")
+	}
+	return d, nil
+}
+
+// HandleEvent is an EventHandler that queues a delivery of event to every active
+// subscription that wants it. Queueing an event twice does not deliver it twice.
+func (s *WebhookService) HandleEvent(ctx context.Context, event appt_booking.OutboxEvent) error {
+	subscriptions, err := s.webhooks.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for _, sub := range subscriptions {
+		if sub.Active && sub.Matches(event.EventType) {
+			ids = append(ids, sub.ID)
+		}
+	}
+	return s.webhooks.EnqueueDeliveries(ctx, event, ids)
+}
+
+// DeliverDue sends the deliveries that are due now. Returns the number that succeeded.
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.webhooks.ClaimDueDeliveries(ctx, time.Now(), s.config.Lease, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, d := range deliveries {
+		status, body, sendErr := s.deliver(ctx, d)
+		now := time.Now()
+		d.ResponseStatus = status
+		d.ResponseBody = body
+		if sendErr == nil {
+			d.Status = appt_booking.WebhookDeliveryDelivered
+			d.DeliveredAt = &now
+			d.LastError = ""
+			delivered++
+		} else {
+			d.LastError = sendErr.Error()
+			if d.Attempts >= s.config.MaxAttempts {
+				log.Printf("giving up on webhook delivery %d (%s) after %d attempts: %v", d.ID, d.EventType, d.Attempts, sendErr)
+				d.Status = appt_booking.WebhookDeliveryFailed
+			} else {
+				d.NextAttemptAt = now.Add(retryBackoff(s.config.RetryBackoff, s.config.MaxBackoff, d.Attempts))
+			}
+		}
+		if err := s.webhooks.RecordAttempt(ctx, d); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliver POSTs the event of d to its subscription. It returns the response status and
+// (truncated) body, if there was a response, and an error unless the status was 2xx.
+func (s *WebhookService) deliver(ctx context.Context, d appt_booking.WebhookDelivery) (*int, string, error) {
+	sub, err := s.webhooks.GetSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		return nil, "", err
+	}
+	event, err := s.outbox.GetByID(ctx, d.EventID)
+	if err != nil {
+		return nil, "", err
+	}
+	if sub == nil || event == nil {
+		return nil, "", fmt.Errorf("subscription %d or event %d no longer exists", d.SubscriptionID, d.EventID)
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.EventType)
+	req.Header.Set(WebhookEventIDHeader, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, string(responseBody), fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return &status, string(responseBody), nil
+}
+
+// RunDeliveryWorker sends due deliveries every WebhookConfig.PollInterval until ctx is done
+func (s *WebhookService) RunDeliveryWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep going while full batches come back
+			for {
+				n, err := s.DeliverDue(ctx)
+				if err != nil {
+					log.Printf("deliver webhooks: %v", err)
+					break
+				}
+				if n < s.config.BatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// SignWebhook returns the signature header value of a delivery with body sent at timestamp
+// (Unix seconds): "sha256=" followed by the hex HMAC-SHA256, keyed with secret, of the
+// timestamp, a dot and the body
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhook checks a subscription's target URL and event types
+func validateWebhook(rawURL string, eventTypes []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Invalid("url", "url must be an absolute http or https URL")
+	}
+	for _, t := range eventTypes {
+		if !IsDomainEventType(t) {
+			return Invalid("event_types", "unknown event type: "+t)
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
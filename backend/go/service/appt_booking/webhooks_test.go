@@ -0,0 +1,249 @@
+package appt_booking
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s-fullstack-blueprint-backend/db/appt_booking"
+)
+
+// webhookReceiver is an httptest server that records the requests it receives and answers
+// with the queued status codes, then 200
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, receivedWebhook{header: req.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte(http.StatusText(status)))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+// newWebhookService returns a webhook service over the fixture's store that retries
+// after a millisecond, and a dispatcher that queues deliveries through it
+func (f *testFixture) newWebhookService(maxAttempts int) (*WebhookService, *EventDispatcher) {
+	config := DispatcherConfig{MaxAttempts: maxAttempts, RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	webhooks := NewWebhookService(f.store.Webhooks(), f.store.Outbox(), nil, WebhookConfig{DispatcherConfig: config})
+	dispatcher := NewEventDispatcher(f.store.Outbox(), DispatcherConfig{})
+	dispatcher.Subscribe("webhooks", "", webhooks.HandleEvent)
+	return webhooks, dispatcher
+}
+
+func TestWebhooks_DeliversSignedPayload(t *testing.T) {
+	f := newTestFixture(t)
+	receiver := newWebhookReceiver(t)
+	webhooks, dispatcher := f.newWebhookService(0)
+
+	sub, err := webhooks.CreateSubscription(f.ctx, receiver.URL, []string{EventAppointmentBooked}, "", "partner", true)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if len(sub.Secret) < minWebhookSecretLength {
+		t.Fatalf("expected a generated secret, got %q", sub.Secret)
+	}
+	a := f.book(t, 10*time.Hour)
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	// Dispatching the same events again must not queue them twice
+	if err := webhooks.HandleEvent(f.ctx, f.lastOutboxEvent(t)); err != nil {
+		t.Fatalf("handle event again: %v", err)
+	}
+
+	n, err := webhooks.DeliverDue(f.ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 delivery, got %d, %v", n, err)
+	}
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected only the booking to be delivered, got %d requests", len(requests))
+	}
+
+	req := requests[0]
+	timestamp, err := strconv.ParseInt(req.header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+		t.Fatalf("bad timestamp header %q", req.header.Get(WebhookTimestampHeader))
+	}
+	if got, want := req.header.Get(WebhookSignatureHeader), SignWebhook(sub.Secret, timestamp, req.body); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if got := req.header.Get(WebhookEventHeader); got != EventAppointmentBooked {
+		t.Errorf("event header %q", got)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	var data appt_booking.Appointment
+	if err := json.Unmarshal(payload.Data, &data); err != nil {
+		t.Fatalf("payload data: %v", err)
+	}
+	if payload.Type != EventAppointmentBooked || payload.AggregateID != a.ID || data.ID != a.ID ||
+		req.header.Get(WebhookEventIDHeader) != strconv.FormatInt(payload.ID, 10) {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	deliveries, err := webhooks.ListDeliveries(f.ctx, sub.ID, 0)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected 1 logged delivery, got %v, %v", deliveries, err)
+	}
+	d := deliveries[0]
+	if d.Status != appt_booking.WebhookDeliveryDelivered || d.ResponseStatus == nil || *d.ResponseStatus != http.StatusOK || d.DeliveredAt == nil {
+		t.Errorf("unexpected delivery log %+v", d)
+	}
+}
+
+func TestWebhooks_RetriesThenFailsAndRedelivers(t *testing.T) {
+	f := newTestFixture(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	webhooks, dispatcher := f.newWebhookService(2)
+
+	sub, err := webhooks.CreateSubscription(f.ctx, receiver.URL, []string{EventAppointmentBooked}, "0123456789abcdef", "", true)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	f.book(t, 10*time.Hour)
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if _, err := webhooks.DeliverDue(f.ctx); err != nil {
+			t.Fatalf("deliver %d: %v", attempt, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := len(receiver.received()); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+	deliveries, _ := webhooks.ListDeliveries(f.ctx, sub.ID, 0)
+	d := deliveries[0]
+	if d.Status != appt_booking.WebhookDeliveryFailed || d.Attempts != 2 ||
+		d.ResponseStatus == nil || *d.ResponseStatus != http.StatusBadGateway || d.ResponseBody != "Bad Gateway" {
+		t.Fatalf("unexpected delivery log %+v", d)
+	}
+
+	// Redelivering starts over; the receiver now answers 200
+	if _, err := webhooks.Redeliver(f.ctx, sub.ID+1, d.ID); KindOf(err) != KindNotFound {
+		t.Errorf("expected not found for another subscription's delivery, got %v", err)
+	}
+	if _, err := webhooks.Redeliver(f.ctx, sub.ID, d.ID); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if n, err := webhooks.DeliverDue(f.ctx); err != nil || n != 1 {
+		t.Fatalf("expected redelivery, got %d, %v", n, err)
+	}
+	deliveries, _ = webhooks.ListDeliveries(f.ctx, sub.ID, 0)
+	if d := deliveries[0]; d.Status != appt_booking.WebhookDeliveryDelivered || d.Attempts != 1 || d.LastError != "" {
+		t.Errorf("unexpected delivery log after redelivery %+v", d)
+	}
+}
+
+func TestWebhooks_FiltersSubscriptions(t *testing.T) {
+	f := newTestFixture(t)
+	receiver := newWebhookReceiver(t)
+	webhooks, dispatcher := f.newWebhookService(0)
+
+	all, err := webhooks.CreateSubscription(f.ctx, receiver.URL+"/all", nil, "", "", true)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	cancellations, err := webhooks.CreateSubscription(f.ctx, receiver.URL+"/cancellations", []string{EventAppointmentCancelled}, "", "", true)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	inactive, err := webhooks.CreateSubscription(f.ctx, receiver.URL+"/inactive", nil, "", "", false)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	a := f.book(t, 10*time.Hour)
+	if _, err := f.svc.CancelAppointment(f.ctx, a.ID, ""); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := dispatcher.DispatchDue(f.ctx); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if _, err := webhooks.DeliverDue(f.ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	for sub, want := range map[int]int{all.ID: 4, cancellations.ID: 1, inactive.ID: 0} {
+		deliveries, err := webhooks.ListDeliveries(f.ctx, sub, 0)
+		if err != nil || len(deliveries) != want {
+			t.Errorf("subscription %d: expected %d deliveries, got %d, %v", sub, want, len(deliveries), err)
+		}
+	}
+}
+
+func TestWebhooks_Validation(t *testing.T) {
+	f := newTestFixture(t)
+	webhooks, _ := f.newWebhookService(0)
+
+	_, err := webhooks.CreateSubscription(f.ctx, "ftp://example.com/hook", nil, "", "", true)
+	checkKind(t, err, KindValidation)
+	_, err = webhooks.CreateSubscription(f.ctx, "/relative", nil, "", "", true)
+	checkKind(t, err, KindValidation)
+	_, err = webhooks.CreateSubscription(f.ctx, "https://example.com/hook", []string{"appointment.exploded"}, "", "", true)
+	checkKind(t, err, KindValidation)
+	_, err = webhooks.CreateSubscription(f.ctx, "https://example.com/hook", nil, "short", "", true)
+	checkKind(t, err, KindValidation)
+
+	sub, err := webhooks.CreateSubscription(f.ctx, "https://example.com/hook", nil, "", "", true)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	updated, err := webhooks.UpdateSubscription(f.ctx, sub.ID, "https://example.com/other", nil, "", "", false)
+	if err != nil {
+		t.Fatalf("update subscription: %v", err)
+	}
+	if updated.Secret != sub.Secret || updated.Active {
+		t.Errorf("expected the secret kept and the subscription deactivated, got %+v", updated)
+	}
+	_, err = webhooks.UpdateSubscription(f.ctx, sub.ID+100, "https://example.com/hook", nil, "", "", true)
+	checkKind(t, err, KindNotFound)
+}
+
+// lastOutboxEvent returns the latest event recorded in the fixture's outbox
+func (f *testFixture) lastOutboxEvent(t *testing.T) appt_booking.OutboxEvent {
+	t.Helper()
+	events, err := f.store.Outbox().GetAll(f.ctx)
+	if err != nil || len(events) == 0 {
+		t.Fatalf("outbox: %v, %d events", err, len(events))
+	}
+	return events[len(events)-1]
+}
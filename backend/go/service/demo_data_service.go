@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"k8s-fullstack-blueprint-backend/db"
@@ -17,26 +18,26 @@ func NewDemoDataService(repo *db.DemoDataRepository) *DemoDataService {
 }
 
 // UpsertDemoData creates or updates a demo record
-func (ds *DemoDataService) UpsertDemoData(id int, content string) (*db.DemoData, error) {
+func (ds *DemoDataService) UpsertDemoData(ctx context.Context, id int, content string) (*db.DemoData, error) {
 	// Basic validation
 	if content == "" {
 		return nil, fmt.Errorf("content cannot be empty")
 	}
 
-	return ds.repo.Upsert(id, content)
+	return ds.repo.Upsert(ctx, id, content)
 }
 
 // GetAllDemoData returns all demo records
-func (ds *DemoDataService) GetAllDemoData() ([]db.DemoData, error) {
-	return ds.repo.GetAll()
+func (ds *DemoDataService) GetAllDemoData(ctx context.Context) ([]db.DemoData, error) {
+	return ds.repo.GetAll(ctx)
 }
 
 // GetDemoDataByID returns a specific record
-func (ds *DemoDataService) GetDemoDataByID(id int) (*db.DemoData, error) {
-	return ds.repo.GetByID(id)
+func (ds *DemoDataService) GetDemoDataByID(ctx context.Context, id int) (*db.DemoData, error) {
+	return ds.repo.GetByID(ctx, id)
 }
 
 // DeleteDemoData removes a record
-func (ds *DemoDataService) DeleteDemoData(id int) error {
-	return ds.repo.Delete(id)
+func (ds *DemoDataService) DeleteDemoData(ctx context.Context, id int) error {
+	return ds.repo.Delete(ctx, id)
 }
@@ -93,3 +93,17 @@ Dictionary:
 {{ end -}}
 {{- end -}}
 {{- end }}
+
+{{/*
+Name of the Secret holding the backend's token signing key: backend.jwtSecretRef.name, or
+the chart's backend Secret when it is enabled and holds secrets.backend.jwtSecret
+*/}}
+{{- define "fullstack.jwtSecretName" -}}
+{{- if .Values.backend.jwtSecretRef.name -}}
+{{- .Values.backend.jwtSecretRef.name -}}
+{{- else if and .Values.secrets.backend.enabled .Values.secrets.backend.jwtSecret -}}
+{{- include "fullstack.fullname" . }}-backend-secret
+{{- else -}}
+{{- fail "backend.jwtSecretRef.name or secrets.backend.jwtSecret (with secrets.backend.enabled) is required" -}}
+{{- end -}}
+{{- end }}
@@ -3,6 +3,10 @@ import { ApptBookingHomeComponent } from './features/home/home.component';
 import { BookingComponent } from './features/booking/booking.component';
 import { MyAppointmentsComponent } from './features/my-appointments/my-appointments.component';
 import { DashboardComponent } from './features/dashboard/dashboard.component';
+import { LoginComponent } from './features/login/login.component';
+import { VerifyEmailComponent } from './features/verify-email/verify-email.component';
+import { WaitlistOfferComponent } from './features/waitlist-offer/waitlist-offer.component';
+import { authGuard } from './core/guards/auth.guard';
 
 export const apptBookingRoutes: Routes = [
   {
@@ -14,16 +18,33 @@ export const apptBookingRoutes: Routes = [
     path: 'home',
     component: ApptBookingHomeComponent
   },
+  {
+    path: 'login',
+    component: LoginComponent
+  },
+  {
+    path: 'verify-email',
+    component: VerifyEmailComponent
+  },
+  // The API requires signing in for booking and for seeing appointments
   {
     path: 'booking',
-    component: BookingComponent
+    component: BookingComponent,
+    canActivate: [authGuard]
   },
   {
     path: 'my-appointments',
-    component: MyAppointmentsComponent
+    component: MyAppointmentsComponent,
+    canActivate: [authGuard]
   },
   {
     path: 'dashboard',
-    component: DashboardComponent
+    component: DashboardComponent,
+    canActivate: [authGuard]
+  },
+  {
+    path: 'waitlist-offer',
+    component: WaitlistOfferComponent,
+    canActivate: [authGuard]
   }
 ];
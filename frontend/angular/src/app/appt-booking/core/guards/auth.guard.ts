@@ -0,0 +1,12 @@
+import { inject } from '@angular/core';
+import { CanActivateFn, Router } from '@angular/router';
+import { AuthService } from '../services/auth.service';
+
+// Sends signed-out visitors to the login page, returning them here afterwards
+export const authGuard: CanActivateFn = (route, state) => {
+  if (inject(AuthService).isLoggedIn()) {
+    return true;
+  }
+  return inject(Router).createUrlTree(['/appt-booking/login'], { queryParams: { returnUrl: state.url } });
+};
+
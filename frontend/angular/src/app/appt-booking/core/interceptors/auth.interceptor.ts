@@ -0,0 +1,30 @@
+import { inject } from '@angular/core';
+import { HttpErrorResponse, HttpInterceptorFn, HttpRequest } from '@angular/common/http';
+import { throwError } from 'rxjs';
+import { catchError, switchMap } from 'rxjs/operators';
+import { AuthService } from '../services/auth.service';
+
+// Sends the access token with API requests. A request rejected with 401 because the
+// token expired is retried once after refreshing it.
+export const authInterceptor: HttpInterceptorFn = (req, next) => {
+  const auth = inject(AuthService);
+  if (!auth.isApiUrl(req.url)) {
+    return next(req);
+  }
+
+  const token = auth.accessToken;
+  return next(withToken(req, token)).pipe(
+    catchError((err: HttpErrorResponse) => {
+      if (err.status !== 401 || !token || auth.isTokenUrl(req.url)) {
+        return throwError(() => err);
+      }
+      return auth.refresh().pipe(
+        switchMap(newToken => newToken ? next(withToken(req, newToken)) : throwError(() => err))
+      );
+    })
+  );
+};
+
+function withToken(req: HttpRequest<unknown>, token: string | null): HttpRequest<unknown> {
+  return token ? req.clone({ setHeaders: { Authorization: `Bearer ${token}` } }) : req;
+}
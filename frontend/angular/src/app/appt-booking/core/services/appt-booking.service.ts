@@ -286,6 +286,13 @@ export class ApptBookingService {
     );
   }
 
+  // ========== WAITLIST ==========
+  // Books the slot held for a waitlist entry with the token from the offer email. Errors are
+  // passed on so the caller can tell an expired offer from one already taken.
+  acceptWaitlistOffer(entryId: number, token: string): Observable<AppointmentResponse> {
+    return this.http.post<AppointmentResponse>(`${this.baseUrl}/api/appt_booking/waitlist/${entryId}/accept`, { token });
+  }
+
   // ========== HELPER METHODS ==========
   private handleError<T>(operation = 'operation', result?: T) {
     return (error: any): Observable<T> => {
@@ -0,0 +1,138 @@
+import { Injectable } from '@angular/core';
+import { HttpClient } from '@angular/common/http';
+import { BehaviorSubject, Observable, of } from 'rxjs';
+import { catchError, finalize, map, shareReplay, switchMap, tap } from 'rxjs/operators';
+
+// Auth interfaces
+export interface TokenResponse {
+  access_token: string;
+  refresh_token: string;
+  token_type: string;
+  expires_in: number;
+  refresh_expires_in: number;
+}
+
+export interface User {
+  id: number;
+  email: string;
+  role: 'customer' | 'provider' | 'admin';
+  staff_id: number | null;
+  email_verified: boolean; // customers see their appointments only once verified
+  created_at?: string;
+}
+
+const ACCESS_TOKEN_KEY = 'appt_booking.access_token';
+const REFRESH_TOKEN_KEY = 'appt_booking.refresh_token';
+
+@Injectable({
+  providedIn: 'root'
+})
+export class AuthService {
+  private baseUrl = 'http://localhost:8080';
+
+  private userSubject = new BehaviorSubject<User | null>(null);
+  // The signed-in user, or null
+  readonly user$ = this.userSubject.asObservable();
+
+  // Shared by concurrent requests that find the access token expired
+  private refreshing$: Observable<string | null> | null = null;
+
+  constructor(private http: HttpClient) {}
+
+  // Loads the user of a stored session; run once at startup
+  restoreSession(): Observable<User | null> {
+    return this.isLoggedIn() ? this.loadUser() : of(null);
+  }
+
+  get accessToken(): string | null {
+    return localStorage.getItem(ACCESS_TOKEN_KEY);
+  }
+
+  get user(): User | null {
+    return this.userSubject.value;
+  }
+
+  isLoggedIn(): boolean {
+    return this.accessToken !== null;
+  }
+
+  // Whether url is one of this API's endpoints, the only ones the access token is sent to
+  isApiUrl(url: string): boolean {
+    return url.startsWith(this.baseUrl);
+  }
+
+  // Whether url is an endpoint for getting tokens, whose failures must not trigger a refresh
+  isTokenUrl(url: string): boolean {
+    return ['login', 'register', 'refresh'].some(path => url === `${this.baseUrl}/api/auth/${path}`);
+  }
+
+  login(email: string, password: string): Observable<User | null> {
+    return this.http.post<TokenResponse>(`${this.baseUrl}/api/auth/login`, { email, password }).pipe(
+      tap(tokens => this.storeTokens(tokens)),
+      switchMap(() => this.loadUser())
+    );
+  }
+
+  // Creates a customer account and signs in; a confirmation link is emailed to the address
+  register(email: string, password: string): Observable<User | null> {
+    return this.http.post<User>(`${this.baseUrl}/api/auth/register`, { email, password }).pipe(
+      switchMap(() => this.login(email, password))
+    );
+  }
+
+  // Confirms the address with the token from the confirmation email. When signed in, the
+  // tokens are refreshed so they carry the verified address.
+  verifyEmail(token: string): Observable<User> {
+    return this.http.post<User>(`${this.baseUrl}/api/auth/verify-email`, { token }).pipe(
+      switchMap(user => this.isLoggedIn() ? this.refresh().pipe(map(() => user)) : of(user))
+    );
+  }
+
+  resendVerification(): Observable<any> {
+    return this.http.post(`${this.baseUrl}/api/auth/verify-email/resend`, {});
+  }
+
+  // Exchanges the refresh token for new tokens; emits the new access token, or null and
+  // signs out if the session is over
+  refresh(): Observable<string | null> {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    if (!refreshToken) {
+      return of(null);
+    }
+    if (!this.refreshing$) {
+      this.refreshing$ = this.http.post<TokenResponse>(`${this.baseUrl}/api/auth/refresh`, { refresh_token: refreshToken }).pipe(
+        tap(tokens => {
+          this.storeTokens(tokens);
+          // Not waited for: requests retried with the new token must not wait on each other
+          this.loadUser().subscribe();
+        }),
+        map(tokens => tokens.access_token),
+        catchError(() => {
+          this.logout();
+          return of(null);
+        }),
+        finalize(() => this.refreshing$ = null),
+        shareReplay(1)
+      );
+    }
+    return this.refreshing$;
+  }
+
+  logout(): void {
+    localStorage.removeItem(ACCESS_TOKEN_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
+    this.userSubject.next(null);
+  }
+
+  private loadUser(): Observable<User | null> {
+    return this.http.get<User>(`${this.baseUrl}/api/auth/me`).pipe(
+      tap(user => this.userSubject.next(user)),
+      catchError(() => of(null))
+    );
+  }
+
+  private storeTokens(tokens: TokenResponse): void {
+    localStorage.setItem(ACCESS_TOKEN_KEY, tokens.access_token);
+    localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+  }
+}
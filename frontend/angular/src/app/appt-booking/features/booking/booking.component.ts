@@ -3,6 +3,7 @@ import { CommonModule, ViewportScroller } from '@angular/common';
 import { FormsModule } from '@angular/forms';
 import { ApptBookingService, Service as ServiceModel, Staff, Schedule, Appointment } from '../../core/services/appt-booking.service';
 import { Observable } from 'rxjs';
+import { AuthService } from '../../core/services/auth.service';
 
 export type BookingStep = 'service' | 'staff' | 'schedule' | 'confirmation';
 
@@ -38,13 +39,25 @@ export class BookingComponent implements OnInit {
   errorMessage: string | null = null;
   successMessage: string | null = null;
   
-   constructor(public apptBookingService: ApptBookingService, private viewportScroller: ViewportScroller) {
+   constructor(public apptBookingService: ApptBookingService, private viewportScroller: ViewportScroller, private authService: AuthService) {
      this.loadServices();
      this.loadStaff();
    }
   
   ngOnInit(): void {
     // Services and staff are loaded in constructor
+    this.fillCustomerEmail();
+  }
+  
+  // Customers can only book for themselves; staff book for anyone
+  isCustomer(): boolean {
+    return this.authService.user?.role === 'customer';
+  }
+  
+  fillCustomerEmail(): void {
+    if (this.isCustomer()) {
+      this.customerEmail = this.authService.user!.email;
+    }
   }
   
   loadServices(): void {
@@ -217,7 +230,7 @@ export class BookingComponent implements OnInit {
        },
        error: (err) => {
          this.isLoading = false;
-         this.errorMessage = err.error?.message || 'Failed to book appointment. Please try again.';
+         this.errorMessage = err.error?.detail || 'Failed to book appointment. Please try again.';
          console.error(err);
        }
      });
@@ -237,6 +250,7 @@ export class BookingComponent implements OnInit {
     this.selectedTime = '';
     this.customerName = '';
     this.customerEmail = '';
+    this.fillCustomerEmail();
     this.customerPhone = '';
     this.errorMessage = null;
     this.successMessage = null;
@@ -0,0 +1,120 @@
+import { Component } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { ActivatedRoute, Router } from '@angular/router';
+import { AuthService } from '../../core/services/auth.service';
+
+@Component({
+  selector: 'app-login',
+  standalone: true,
+  imports: [CommonModule, FormsModule],
+  template: `
+    <div class="login-container">
+      <h1>{{ registering ? 'Create an Account' : 'Sign In' }}</h1>
+
+      <form (ngSubmit)="submit()">
+        <input type="email" name="email" [(ngModel)]="email" placeholder="Email address" autocomplete="email" required />
+        <input type="password" name="password" [(ngModel)]="password" placeholder="Password"
+               [attr.autocomplete]="registering ? 'new-password' : 'current-password'" required />
+        <p class="hint" *ngIf="registering">At least 8 characters. We will email you a link to confirm your address.</p>
+        <p class="error" *ngIf="error">{{ error }}</p>
+        <button type="submit" class="btn" [disabled]="loading || !email || !password">
+          {{ loading ? 'Please wait...' : (registering ? 'Create Account' : 'Sign In') }}
+        </button>
+      </form>
+
+      <button class="link" (click)="registering = !registering; error = null">
+        {{ registering ? 'Already have an account? Sign in' : 'New here? Create an account' }}
+      </button>
+    </div>
+  `,
+  styles: [`
+    .login-container {
+      max-width: 400px;
+      margin: 0 auto;
+      padding: 40px 20px;
+      text-align: center;
+    }
+
+    h1 {
+      color: #1976d2;
+      margin-bottom: 30px;
+    }
+
+    form {
+      display: flex;
+      flex-direction: column;
+      gap: 15px;
+    }
+
+    input {
+      padding: 12px;
+      border: 1px solid #ccc;
+      border-radius: 4px;
+      font-size: 16px;
+    }
+
+    .hint {
+      color: #666;
+      font-size: 14px;
+      margin: 0;
+    }
+
+    .error {
+      color: #d32f2f;
+      margin: 0;
+    }
+
+    .btn {
+      background-color: #1976d2;
+      color: white;
+      padding: 12px 24px;
+      border: none;
+      border-radius: 4px;
+      font-size: 16px;
+      cursor: pointer;
+    }
+
+    .btn:disabled {
+      background-color: #90caf9;
+      cursor: not-allowed;
+    }
+
+    .link {
+      margin-top: 20px;
+      background: none;
+      border: none;
+      color: #1976d2;
+      cursor: pointer;
+    }
+  `]
+})
+export class LoginComponent {
+  email: string = '';
+  password: string = '';
+  registering: boolean = false;
+  loading: boolean = false;
+  error: string | null = null;
+
+  constructor(private authService: AuthService, private route: ActivatedRoute, private router: Router) {}
+
+  submit(): void {
+    this.loading = true;
+    this.error = null;
+    const request = this.registering
+      ? this.authService.register(this.email.trim(), this.password)
+      : this.authService.login(this.email.trim(), this.password);
+
+    request.subscribe({
+      next: () => {
+        this.loading = false;
+        const returnUrl = this.route.snapshot.queryParamMap.get('returnUrl') || '/appt-booking/home';
+        this.router.navigateByUrl(returnUrl);
+      },
+      error: (err) => {
+        this.loading = false;
+        this.error = err.error?.detail || (this.registering ? 'Could not create the account.' : 'Invalid email or password.');
+      }
+    });
+  }
+}
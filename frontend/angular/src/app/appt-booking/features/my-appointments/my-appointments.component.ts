@@ -1,70 +1,46 @@
 import { Component, OnInit } from '@angular/core';
 import { CommonModule } from '@angular/common';
-import { FormsModule } from '@angular/forms';
 import { ApptBookingService } from '../../core/services/appt-booking.service';
 import { AppointmentWithDetails } from '../../core/services/appt-booking.service';
+import { AuthService } from '../../core/services/auth.service';
 
 @Component({
   selector: 'app-my-appointments',
   standalone: true,
-  imports: [CommonModule, FormsModule],
+  imports: [CommonModule],
   templateUrl: './my-appointments.component.html',
   styleUrls: ['./my-appointments.component.scss']
 })
 export class MyAppointmentsComponent implements OnInit {
-   // Form state
-   email: string = '';
-   searchPerformed: boolean = false;
-   
    // Data
    appointments: AppointmentWithDetails[] = [];
    loading: boolean = false;
    error: string | null = null;
    
+   // Customers see their appointments once they have confirmed their email
+   needsVerification: boolean = false;
+   verificationSent: boolean = false;
+   
    // Filtering
    upcomingOnly: boolean = true;
    
-   // Email suggestions
-   availableEmails: string[] = [];
-   
-   constructor(private apptBookingService: ApptBookingService) {}
+   constructor(private apptBookingService: ApptBookingService, private authService: AuthService) {}
    
    ngOnInit(): void {
-     // Load all appointments to extract unique emails for suggestions
-     this.loadEmailSuggestions();
-   }
-   
-   loadEmailSuggestions(): void {
-     this.apptBookingService.getAllAppointments()
-       .subscribe({
-         next: (appts: AppointmentWithDetails[]) => {
-           // Extract unique customer emails
-           const emailSet = new Set<string>();
-           appts.forEach(appt => {
-             if (appt.customer_email) {
-               emailSet.add(appt.customer_email);
-             }
-           });
-           this.availableEmails = Array.from(emailSet).sort();
-         },
-         error: (err) => {
-           console.error('Error loading email suggestions:', err);
-           // Non-critical - just won't show suggestions
-         }
-       });
+     const user = this.authService.user;
+     if (user && user.role === 'customer' && !user.email_verified) {
+       this.needsVerification = true;
+       return;
+     }
+     this.loadAppointments();
    }
   
-  searchAppointments(): void {
-    if (!this.email) {
-      this.error = 'Please enter your email address';
-      return;
-    }
-    
+  loadAppointments(): void {
     this.loading = true;
     this.error = null;
-    this.searchPerformed = true;
     
-    this.apptBookingService.getAllAppointments(undefined, this.email)
+    // The API returns only the signed-in user's own appointments
+    this.apptBookingService.getAllAppointments()
       .subscribe({
         next: (appts: AppointmentWithDetails[]) => {
           this.appointments = appts;
@@ -78,6 +54,17 @@ export class MyAppointmentsComponent implements OnInit {
       });
   }
   
+  resendVerification(): void {
+    this.authService.resendVerification()
+      .subscribe({
+        next: () => this.verificationSent = true,
+        error: (err) => {
+          this.error = 'Failed to send the confirmation email. Please try again.';
+          console.error('Error sending confirmation email:', err);
+        }
+      });
+  }
+  
   cancelAppointment(appointmentId: number): void {
     if (!confirm('Are you sure you want to cancel this appointment?')) {
       return;
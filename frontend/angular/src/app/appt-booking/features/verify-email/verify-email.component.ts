@@ -0,0 +1,85 @@
+import { Component, OnInit } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { ActivatedRoute, RouterLink } from '@angular/router';
+import { AuthService } from '../../core/services/auth.service';
+
+// Opened from the link in the confirmation email, with the token in the "token" parameter
+@Component({
+  selector: 'app-verify-email',
+  standalone: true,
+  imports: [CommonModule, RouterLink],
+  template: `
+    <div class="verify-container">
+      <h1>Confirm Your Email</h1>
+      <p *ngIf="status === 'verifying'">Confirming your email address...</p>
+      <ng-container *ngIf="status === 'verified'">
+        <p>Your email address is confirmed. You can now see your appointments.</p>
+        <a routerLink="/appt-booking/my-appointments" class="btn">View Appointments</a>
+      </ng-container>
+      <ng-container *ngIf="status === 'failed'">
+        <p class="error">This link is invalid or has expired.</p>
+        <button *ngIf="authService.isLoggedIn()" class="btn" (click)="resend()" [disabled]="resent">
+          {{ resent ? 'Link sent, check your email' : 'Send a new link' }}
+        </button>
+      </ng-container>
+    </div>
+  `,
+  styles: [`
+    .verify-container {
+      max-width: 500px;
+      margin: 0 auto;
+      padding: 40px 20px;
+      text-align: center;
+    }
+
+    h1 {
+      color: #1976d2;
+      margin-bottom: 20px;
+    }
+
+    p {
+      color: #666;
+      margin-bottom: 30px;
+    }
+
+    .error {
+      color: #d32f2f;
+    }
+
+    .btn {
+      display: inline-block;
+      background-color: #1976d2;
+      color: white;
+      padding: 12px 24px;
+      border: none;
+      border-radius: 4px;
+      text-decoration: none;
+      cursor: pointer;
+    }
+  `]
+})
+export class VerifyEmailComponent implements OnInit {
+  status: 'verifying' | 'verified' | 'failed' = 'verifying';
+  resent: boolean = false;
+
+  constructor(public authService: AuthService, private route: ActivatedRoute) {}
+
+  ngOnInit(): void {
+    const token = this.route.snapshot.queryParamMap.get('token');
+    if (!token) {
+      this.status = 'failed';
+      return;
+    }
+    this.authService.verifyEmail(token).subscribe({
+      next: () => this.status = 'verified',
+      error: () => this.status = 'failed'
+    });
+  }
+
+  resend(): void {
+    this.authService.resendVerification().subscribe({
+      next: () => this.resent = true,
+      error: (err) => console.error('Error sending a new link:', err)
+    });
+  }
+}
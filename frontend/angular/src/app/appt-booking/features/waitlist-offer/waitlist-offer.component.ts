@@ -0,0 +1,99 @@
+import { Component, OnInit } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { ActivatedRoute, RouterLink } from '@angular/router';
+import { ApptBookingService, AppointmentResponse } from '../../core/services/appt-booking.service';
+
+// Opened from the link in a waitlist offer email, with the entry and the offer's token in
+// the "entry" and "token" parameters
+@Component({
+  selector: 'app-waitlist-offer',
+  standalone: true,
+  imports: [CommonModule, RouterLink],
+  template: `
+    <div class="offer-container">
+      <h1>Your Waitlist Offer</h1>
+      <ng-container *ngIf="!appointment">
+        <p *ngIf="!invalid">A slot you were waiting for is held for you. Book it before the hold in your email runs out.</p>
+        <p class="error" *ngIf="invalid">This link is incomplete. Open the link from your offer email again.</p>
+        <p class="error" *ngIf="error">{{ error }}</p>
+        <button *ngIf="!invalid" class="btn" (click)="accept()" [disabled]="loading">
+          {{ loading ? 'Booking...' : 'Book This Slot' }}
+        </button>
+      </ng-container>
+      <ng-container *ngIf="appointment">
+        <p>You are booked for {{ apptBookingService.formatAppointmentDateTime(appointment.appointment_datetime) }}.</p>
+        <a routerLink="/appt-booking/my-appointments" class="btn">View Appointments</a>
+      </ng-container>
+    </div>
+  `,
+  styles: [`
+    .offer-container {
+      max-width: 500px;
+      margin: 0 auto;
+      padding: 40px 20px;
+      text-align: center;
+    }
+
+    h1 {
+      color: #1976d2;
+      margin-bottom: 20px;
+    }
+
+    p {
+      color: #666;
+      margin-bottom: 30px;
+    }
+
+    .error {
+      color: #d32f2f;
+    }
+
+    .btn {
+      display: inline-block;
+      background-color: #1976d2;
+      color: white;
+      padding: 12px 24px;
+      border: none;
+      border-radius: 4px;
+      text-decoration: none;
+      cursor: pointer;
+    }
+
+    .btn:disabled {
+      background-color: #90caf9;
+      cursor: not-allowed;
+    }
+  `]
+})
+export class WaitlistOfferComponent implements OnInit {
+  entryId: number = 0;
+  token: string = '';
+  invalid: boolean = false;
+  loading: boolean = false;
+  error: string | null = null;
+  appointment: AppointmentResponse | null = null;
+
+  constructor(public apptBookingService: ApptBookingService, private route: ActivatedRoute) {}
+
+  ngOnInit(): void {
+    const params = this.route.snapshot.queryParamMap;
+    this.entryId = Number(params.get('entry'));
+    this.token = params.get('token') || '';
+    this.invalid = !this.entryId || !this.token;
+  }
+
+  accept(): void {
+    this.loading = true;
+    this.error = null;
+    this.apptBookingService.acceptWaitlistOffer(this.entryId, this.token).subscribe({
+      next: (appointment) => {
+        this.loading = false;
+        this.appointment = appointment;
+      },
+      error: (err) => {
+        this.loading = false;
+        this.error = err.error?.detail || 'Could not book this slot.';
+      }
+    });
+  }
+}
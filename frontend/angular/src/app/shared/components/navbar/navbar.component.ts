@@ -1,6 +1,7 @@
 import { Component } from '@angular/core';
 import { CommonModule } from '@angular/common';
-import { RouterLink, RouterLinkActive } from '@angular/router';
+import { Router, RouterLink, RouterLinkActive } from '@angular/router';
+import { AuthService } from '../../../appt-booking/core/services/auth.service';
 
 @Component({
   selector: 'app-navbar',
@@ -21,6 +22,16 @@ import { RouterLink, RouterLinkActive } from '@angular/router';
         <a routerLink="/appt-booking/my-appointments" routerLinkActive="active">My Appointments</a>
         <a routerLink="/appt-booking/dashboard" routerLinkActive="active">Dashboard</a>
       </div>
+      <span class="divider"></span>
+      <div class="nav-section" *ngIf="authService.user$ | async as user; else signedOut">
+        <span class="section-label user-email">{{ user.email }}</span>
+        <a href="" (click)="logout($event)">Sign Out</a>
+      </div>
+      <ng-template #signedOut>
+        <div class="nav-section">
+          <a routerLink="/appt-booking/login" routerLinkActive="active">Sign In</a>
+        </div>
+      </ng-template>
     </nav>
   `,
   styles: [`
@@ -49,6 +60,11 @@ import { RouterLink, RouterLinkActive } from '@angular/router';
       padding-right: 8px;
     }
 
+    .user-email {
+      text-transform: none;
+      letter-spacing: normal;
+    }
+
     nav a {
       color: white;
       text-decoration: none;
@@ -74,4 +90,12 @@ import { RouterLink, RouterLinkActive } from '@angular/router';
     }
   `]
 })
-export class NavbarComponent {}
+export class NavbarComponent {
+  constructor(public authService: AuthService, private router: Router) {}
+
+  logout(event: Event): void {
+    event.preventDefault();
+    this.authService.logout();
+    this.router.navigateByUrl('/appt-booking/home');
+  }
+}
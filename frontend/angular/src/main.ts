@@ -1,12 +1,24 @@
+import { APP_INITIALIZER, inject } from '@angular/core';
 import { bootstrapApplication } from '@angular/platform-browser';
 import { AppComponent } from './app/app.component';
 import { provideRouter } from '@angular/router';
 import { routes } from './app/app.routes';
-import { provideHttpClient, withInterceptorsFromDi } from '@angular/common/http';
+import { provideHttpClient, withInterceptors, withInterceptorsFromDi } from '@angular/common/http';
+import { authInterceptor } from './app/appt-booking/core/interceptors/auth.interceptor';
+import { AuthService } from './app/appt-booking/core/services/auth.service';
 
 bootstrapApplication(AppComponent, {
   providers: [
     provideRouter(routes),
-    provideHttpClient(withInterceptorsFromDi())
+    provideHttpClient(withInterceptorsFromDi(), withInterceptors([authInterceptor])),
+    // Load the signed-in user of a stored session before the first route is shown
+    {
+      provide: APP_INITIALIZER,
+      useFactory: () => {
+        const auth = inject(AuthService);
+        return () => auth.restoreSession();
+      },
+      multi: true
+    }
   ]
 }).catch((err) => console.error(err));